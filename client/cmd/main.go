@@ -7,14 +7,21 @@ import (
 	"os"
 )
 
-const DefaultFileConfig = "config.json"
-
 func main() {
+	path := os.Getenv("GOPHKEEPER_CONFIG")
+	if path == "" {
+		path = config.FindPath()
+	}
+
 	cfg := config.NewConfig("")
-	err := cfg.ReadFile(DefaultFileConfig)
+	err := cfg.ReadFile(path)
 	if err != nil {
 		fmt.Fprintln(os.Stdout, err.Error())
 		os.Exit(1)
 	}
+	// Профиль из окружения действует только на текущий запуск и не сохраняется в файл.
+	if profile := os.Getenv("GOPHKEEPER_PROFILE"); profile != "" {
+		cfg.Current = profile
+	}
 	app.Run(cfg)
 }
@@ -2,6 +2,7 @@ package app
 
 import (
 	"bufio"
+	"client/internal/buildinfo"
 	"client/internal/config"
 	"client/internal/handlers"
 	"client/internal/service"
@@ -12,8 +13,15 @@ import (
 
 func Run(cnf *config.Config) {
 	gophKeeper := service.NewGophKeeperClient()
-	handlers := handlers.NewHandlers(gophKeeper, *cnf)
-	err := handlers.Run()
+	handlers, err := handlers.NewHandlers(gophKeeper, *cnf)
+	if err != nil {
+		fmt.Fprintln(os.Stdout, err.Error())
+		os.Exit(1)
+	}
+	fmt.Println("GophKeeper", buildinfo.String())
+	handlers.CheckServer()
+
+	err = handlers.Run()
 	if err != nil {
 		fmt.Fprintln(os.Stdout, err.Error())
 		os.Exit(1)
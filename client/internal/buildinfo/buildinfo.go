@@ -0,0 +1,24 @@
+// Package buildinfo содержит сведения о сборке клиента. Версия, коммит и дата
+// задаются при компоновке:
+//
+//	go build -ldflags "-X client/internal/buildinfo.Version=v1.2.0 \
+//	  -X client/internal/buildinfo.Commit=$(git rev-parse --short HEAD) \
+//	  -X client/internal/buildinfo.Date=$(date -u +%Y-%m-%dT%H:%M:%SZ)" ./cmd
+package buildinfo
+
+import "fmt"
+
+// Значения по умолчанию остаются у сборок без ldflags, например при go run.
+var (
+	Version = "dev"
+	Commit  = "none"
+	Date    = "unknown"
+)
+
+// APIVersion — версия HTTP API сервера, с которой работает клиент.
+const APIVersion = 1
+
+// String возвращает сведения о сборке одной строкой.
+func String() string {
+	return fmt.Sprintf("%s (commit %s, built %s, API v%d)", Version, Commit, Date, APIVersion)
+}
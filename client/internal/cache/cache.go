@@ -0,0 +1,357 @@
+// Package cache реализует зашифрованный локальный кэш записей хранилища на базе bbolt.
+// Кэш позволяет клиенту читать данные и накапливать изменения без подключения к серверу.
+package cache
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	bolt "go.etcd.io/bbolt"
+	"golang.org/x/crypto/argon2"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+var (
+	// ErrNotFound возвращается, если запись отсутствует в кэше.
+	ErrNotFound = errors.New("record not found in local cache")
+	// ErrWrongPassword возвращается, если пароль не подходит к кэшу.
+	ErrWrongPassword = errors.New("wrong password for local cache")
+)
+
+var (
+	bucketMeta    = []byte("meta")
+	bucketRecords = []byte("records")
+	bucketQueue   = []byte("queue")
+
+	metaSalt     = []byte("salt")
+	metaCheck    = []byte("check")
+	metaRevision = []byte("revision")
+	metaE2E      = []byte("e2e")
+
+	checkValue = []byte("gophkeeper")
+)
+
+// Действия, которые могут находиться в очереди отложенных операций.
+const (
+	ActionCreate = "create"
+	ActionUpdate = "update"
+	ActionDelete = "delete"
+)
+
+// Record описывает запись, сохранённую в локальном кэше.
+// Data содержит JSON в том виде, в котором его возвращает сервер.
+type Record struct {
+	Type      string          `json:"type"`
+	Key       string          `json:"key"`
+	Data      json.RawMessage `json:"data"`
+	Pending   bool            `json:"pending,omitempty"`
+	UpdatedAt time.Time       `json:"updated_at"`
+}
+
+// Operation описывает изменение, сделанное без подключения к серверу и ожидающее отправки.
+// Rejected содержит причину, по которой сервер отклонил операцию: такая операция остаётся
+// в очереди, пока пользователь не исправит запись, не повторит или не отменит её.
+type Operation struct {
+	ID        uint64          `json:"id"`
+	Action    string          `json:"action"`
+	Type      string          `json:"type"`
+	Key       string          `json:"key"`
+	Body      json.RawMessage `json:"body,omitempty"`
+	Rejected  string          `json:"rejected,omitempty"`
+	CreatedAt time.Time       `json:"created_at"`
+}
+
+// Vault представляет открытый зашифрованный кэш одного пользователя.
+type Vault struct {
+	db   *bolt.DB
+	aead cipher.AEAD
+}
+
+// Open открывает (или создаёт) файл кэша и проверяет пароль пользователя.
+// Ключ шифрования выводится из пароля через Argon2id с солью, хранящейся в самом кэше.
+func Open(path, password string) (*Vault, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return nil, err
+	}
+
+	db, err := bolt.Open(path, 0o600, &bolt.Options{Timeout: time.Second})
+	if err != nil {
+		return nil, err
+	}
+
+	v := &Vault{db: db}
+	err = db.Update(func(tx *bolt.Tx) error {
+		for _, name := range [][]byte{bucketMeta, bucketRecords, bucketQueue} {
+			if _, err := tx.CreateBucketIfNotExists(name); err != nil {
+				return err
+			}
+		}
+
+		meta := tx.Bucket(bucketMeta)
+		salt := meta.Get(metaSalt)
+		if salt == nil {
+			salt = make([]byte, 16)
+			if _, err := rand.Read(salt); err != nil {
+				return err
+			}
+			if err := meta.Put(metaSalt, salt); err != nil {
+				return err
+			}
+		}
+
+		aead, err := newAEAD(password, salt)
+		if err != nil {
+			return err
+		}
+		v.aead = aead
+
+		check := meta.Get(metaCheck)
+		if check == nil {
+			sealed, err := v.seal(checkValue)
+			if err != nil {
+				return err
+			}
+			return meta.Put(metaCheck, sealed)
+		}
+
+		if _, err := v.open(check); err != nil {
+			return ErrWrongPassword
+		}
+		return nil
+	})
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	return v, nil
+}
+
+// Close закрывает файл кэша.
+func (v *Vault) Close() error {
+	return v.db.Close()
+}
+
+// PutRecord сохраняет или заменяет запись в кэше.
+func (v *Vault) PutRecord(record Record) error {
+	if record.UpdatedAt.IsZero() {
+		record.UpdatedAt = time.Now()
+	}
+
+	return v.db.Update(func(tx *bolt.Tx) error {
+		return v.putJSON(tx.Bucket(bucketRecords), recordID(record.Type, record.Key), record)
+	})
+}
+
+// GetRecord возвращает запись из кэша или ErrNotFound.
+func (v *Vault) GetRecord(recordType, key string) (Record, error) {
+	var record Record
+	err := v.db.View(func(tx *bolt.Tx) error {
+		return v.getJSON(tx.Bucket(bucketRecords), recordID(recordType, key), &record)
+	})
+	return record, err
+}
+
+// DeleteRecord удаляет запись из кэша.
+func (v *Vault) DeleteRecord(recordType, key string) error {
+	return v.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(bucketRecords).Delete(recordID(recordType, key))
+	})
+}
+
+// Records возвращает все записи кэша.
+func (v *Vault) Records() ([]Record, error) {
+	var records []Record
+	err := v.db.View(func(tx *bolt.Tx) error {
+		return tx.Bucket(bucketRecords).ForEach(func(k, sealed []byte) error {
+			var record Record
+			if err := v.decode(sealed, &record); err != nil {
+				return err
+			}
+			records = append(records, record)
+			return nil
+		})
+	})
+	return records, err
+}
+
+// RemapKey переносит запись, созданную без сети под временным ключом, на ключ, выданный сервером.
+func (v *Vault) RemapKey(recordType, oldKey, newKey string) error {
+	return v.db.Update(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(bucketRecords)
+
+		var record Record
+		if err := v.getJSON(bucket, recordID(recordType, oldKey), &record); err != nil {
+			return err
+		}
+		if err := bucket.Delete(recordID(recordType, oldKey)); err != nil {
+			return err
+		}
+
+		record.Key = newKey
+		record.Pending = false
+		record.UpdatedAt = time.Now()
+		return v.putJSON(bucket, recordID(recordType, newKey), record)
+	})
+}
+
+// Enqueue добавляет операцию в конец очереди и возвращает её номер.
+func (v *Vault) Enqueue(op Operation) (uint64, error) {
+	err := v.db.Update(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(bucketQueue)
+		id, err := bucket.NextSequence()
+		if err != nil {
+			return err
+		}
+
+		op.ID = id
+		if op.CreatedAt.IsZero() {
+			op.CreatedAt = time.Now()
+		}
+		return v.putJSON(bucket, queueID(id), op)
+	})
+	return op.ID, err
+}
+
+// Pending возвращает операции очереди в порядке их создания.
+func (v *Vault) Pending() ([]Operation, error) {
+	var ops []Operation
+	err := v.db.View(func(tx *bolt.Tx) error {
+		return tx.Bucket(bucketQueue).ForEach(func(k, sealed []byte) error {
+			var op Operation
+			if err := v.decode(sealed, &op); err != nil {
+				return err
+			}
+			ops = append(ops, op)
+			return nil
+		})
+	})
+	return ops, err
+}
+
+// UpdateOperation заменяет операцию в очереди, сохраняя её место.
+func (v *Vault) UpdateOperation(op Operation) error {
+	return v.db.Update(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(bucketQueue)
+		if bucket.Get(queueID(op.ID)) == nil {
+			return ErrNotFound
+		}
+		return v.putJSON(bucket, queueID(op.ID), op)
+	})
+}
+
+// Dequeue удаляет операцию из очереди.
+func (v *Vault) Dequeue(id uint64) error {
+	return v.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(bucketQueue).Delete(queueID(id))
+	})
+}
+
+// Revision возвращает ревизию сервера, до которой кэш синхронизирован.
+func (v *Vault) Revision() (int64, error) {
+	var revision int64
+	err := v.db.View(func(tx *bolt.Tx) error {
+		err := v.getJSON(tx.Bucket(bucketMeta), metaRevision, &revision)
+		if errors.Is(err, ErrNotFound) {
+			return nil
+		}
+		return err
+	})
+	return revision, err
+}
+
+// SetRevision запоминает ревизию сервера после успешной синхронизации.
+func (v *Vault) SetRevision(revision int64) error {
+	return v.db.Update(func(tx *bolt.Tx) error {
+		return v.putJSON(tx.Bucket(bucketMeta), metaRevision, revision)
+	})
+}
+
+// E2EParams возвращает параметры сквозного шифрования, сохранённые для входа без сервера,
+// или ErrNotFound.
+func (v *Vault) E2EParams() (json.RawMessage, error) {
+	var params json.RawMessage
+	err := v.db.View(func(tx *bolt.Tx) error {
+		return v.getJSON(tx.Bucket(bucketMeta), metaE2E, &params)
+	})
+	return params, err
+}
+
+// SetE2EParams запоминает параметры сквозного шифрования, полученные от сервера.
+func (v *Vault) SetE2EParams(params json.RawMessage) error {
+	return v.db.Update(func(tx *bolt.Tx) error {
+		return v.putJSON(tx.Bucket(bucketMeta), metaE2E, params)
+	})
+}
+
+func (v *Vault) putJSON(bucket *bolt.Bucket, key []byte, value any) error {
+	plain, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+
+	sealed, err := v.seal(plain)
+	if err != nil {
+		return err
+	}
+	return bucket.Put(key, sealed)
+}
+
+func (v *Vault) getJSON(bucket *bolt.Bucket, key []byte, value any) error {
+	sealed := bucket.Get(key)
+	if sealed == nil {
+		return ErrNotFound
+	}
+	return v.decode(sealed, value)
+}
+
+func (v *Vault) decode(sealed []byte, value any) error {
+	plain, err := v.open(sealed)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(plain, value)
+}
+
+// seal шифрует данные AES-GCM, добавляя случайный nonce в начало результата.
+func (v *Vault) seal(plain []byte) ([]byte, error) {
+	nonce := make([]byte, v.aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	return v.aead.Seal(nonce, nonce, plain, nil), nil
+}
+
+// open расшифровывает данные, зашифрованные seal.
+func (v *Vault) open(sealed []byte) ([]byte, error) {
+	size := v.aead.NonceSize()
+	if len(sealed) < size {
+		return nil, fmt.Errorf("sealed value is too short")
+	}
+	return v.aead.Open(nil, sealed[:size], sealed[size:], nil)
+}
+
+func newAEAD(password string, salt []byte) (cipher.AEAD, error) {
+	key := argon2.IDKey([]byte(password), salt, 1, 64*1024, 4, 32)
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+func recordID(recordType, key string) []byte {
+	return []byte(recordType + "/" + key)
+}
+
+func queueID(id uint64) []byte {
+	b := make([]byte, 8)
+	binary.BigEndian.PutUint64(b, id)
+	return b
+}
@@ -0,0 +1,121 @@
+package cache
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestOpenPassword(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cache.db")
+	vault, err := Open(path, "correct horse")
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	record := Record{Type: "text", Key: "k1", Data: []byte(`{"data":"plain secret"}`)}
+	if err := vault.PutRecord(record); err != nil {
+		t.Fatalf("PutRecord: %v", err)
+	}
+	if err := vault.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+
+	// Содержимое записей хранится в файле только в зашифрованном виде.
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("ReadFile: %v", err)
+	}
+	if bytes.Contains(raw, []byte("plain secret")) {
+		t.Fatal("cache file contains plaintext record data")
+	}
+
+	tests := []struct {
+		name     string
+		password string
+		wantErr  error
+	}{
+		{name: "wrong password", password: "wrong horse", wantErr: ErrWrongPassword},
+		{name: "empty password", password: "", wantErr: ErrWrongPassword},
+		{name: "password prefix", password: "correct", wantErr: ErrWrongPassword},
+		{name: "different case", password: "Correct horse", wantErr: ErrWrongPassword},
+		{name: "correct password", password: "correct horse"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			vault, err := Open(path, tt.password)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("Open error = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Open: %v", err)
+			}
+			defer vault.Close()
+
+			got, err := vault.GetRecord(record.Type, record.Key)
+			if err != nil {
+				t.Fatalf("GetRecord: %v", err)
+			}
+			if !bytes.Equal(got.Data, record.Data) {
+				t.Errorf("Data = %s, want %s", got.Data, record.Data)
+			}
+		})
+	}
+}
+
+func TestSealOpen(t *testing.T) {
+	vault, err := Open(filepath.Join(t.TempDir(), "cache.db"), "password")
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	defer vault.Close()
+
+	other, err := Open(filepath.Join(t.TempDir(), "other.db"), "password")
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	defer other.Close()
+
+	sealed, err := vault.seal([]byte("value"))
+	if err != nil {
+		t.Fatalf("seal: %v", err)
+	}
+	tampered := bytes.Clone(sealed)
+	tampered[len(tampered)-1] ^= 1
+
+	tests := []struct {
+		name    string
+		vault   *Vault
+		sealed  []byte
+		wantErr bool
+	}{
+		{name: "same vault", vault: vault, sealed: sealed},
+		// У другого кэша своя соль, поэтому тот же пароль даёт другой ключ.
+		{name: "other salt", vault: other, sealed: sealed, wantErr: true},
+		{name: "tampered", vault: vault, sealed: tampered, wantErr: true},
+		{name: "truncated", vault: vault, sealed: sealed[:4], wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			plain, err := tt.vault.open(tt.sealed)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("open = %q, want error", plain)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("open: %v", err)
+			}
+			if string(plain) != "value" {
+				t.Errorf("open = %q, want %q", plain, "value")
+			}
+		})
+	}
+}
@@ -1,11 +1,54 @@
 package config
 
-import "github.com/spf13/viper"
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"github.com/spf13/viper"
+	"io/fs"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
 
-const DefaultListen = "localhost:8080"
+const DefaultListen = "http://localhost:8080"
 
+// LegacyFileConfig — файл настроек в текущем каталоге, который читался до появления профилей.
+const LegacyFileConfig = "config.json"
+
+// Config содержит настройки клиента. Адрес сервера, TLS, хранилище и логин задаются
+// либо на верхнем уровне файла, либо в именованных профилях; Current выбирает профиль.
 type Config struct {
-	Listen string `mapstructure:"listen"`
+	Listen   string      `mapstructure:"listen"`
+	CacheDir string      `mapstructure:"cache_dir"`
+	TLS      TLSSettings `mapstructure:"tls"`
+	Vault    string      `mapstructure:"vault"`
+	Login    string      `mapstructure:"login"`
+	E2E      bool        `mapstructure:"e2e"`
+
+	Current  string             `mapstructure:"profile"`
+	Profiles map[string]Profile `mapstructure:"profiles"`
+
+	path string // файл, из которого прочитаны настройки; в него сохраняется выбор профиля
+}
+
+// Profile описывает подключение к одному серверу GophKeeper.
+type Profile struct {
+	Server string      `mapstructure:"server"` // например "https://gophkeeper.example.com"
+	TLS    TLSSettings `mapstructure:"tls"`
+	Vault  string      `mapstructure:"vault"` // хранилище организации по умолчанию; пустое — личное
+	Login  string      `mapstructure:"login"` // логин, предлагаемый при входе
+	E2E    bool        `mapstructure:"e2e"`   // шифровать секреты на клиенте ключом из отдельной ключевой фразы
+}
+
+// TLSSettings задаёт проверку сертификата сервера.
+type TLSSettings struct {
+	CAFile             string `mapstructure:"ca_file"`              // дополнительный корневой сертификат в PEM
+	ServerName         string `mapstructure:"server_name"`          // имя в сертификате, если оно отличается от адреса
+	InsecureSkipVerify bool   `mapstructure:"insecure_skip_verify"` // не проверять сертификат; только для отладки
 }
 
 func NewConfig(listen string) *Config {
@@ -13,18 +56,159 @@ func NewConfig(listen string) *Config {
 		listen = DefaultListen
 	}
 	return &Config{
-		Listen: listen,
+		Listen:   listen,
+		CacheDir: DefaultCacheDir(),
+	}
+}
+
+// DefaultCacheDir возвращает каталог локального кэша по умолчанию.
+func DefaultCacheDir() string {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		dir = os.TempDir()
+	}
+	return filepath.Join(dir, "gophkeeper")
+}
+
+// DefaultPath возвращает файл настроек в каталоге конфигурации пользователя,
+// например ~/.config/gophkeeper/config.yaml с учётом XDG_CONFIG_HOME.
+func DefaultPath() string {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return LegacyFileConfig
 	}
+	return filepath.Join(dir, "gophkeeper", "config.yaml")
 }
 
+// FindPath выбирает файл настроек: файл из каталога конфигурации пользователя,
+// а если его нет — config.json из текущего каталога. Если нет ни одного, возвращает DefaultPath.
+func FindPath() string {
+	for _, path := range []string{DefaultPath(), LegacyFileConfig} {
+		if _, err := os.Stat(path); err == nil {
+			return path
+		}
+	}
+	return DefaultPath()
+}
+
+// ReadFile применяет настройки из файла; отсутствующий файл оставляет умолчания.
+// Формат определяется по расширению: .yaml, .yml, .json или .toml.
 func (c *Config) ReadFile(path string) error {
+	c.path = path
 	viper.SetConfigFile(path)
 	if err := viper.ReadInConfig(); err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil
+		}
 		return err
 	}
 	if err := viper.Unmarshal(c); err != nil {
 		return err
 	}
 
+	return c.Validate()
+}
+
+// Validate проверяет адреса серверов и настройки TLS всех профилей.
+func (c *Config) Validate() error {
+	var errs []error
+	if c.Current != "" {
+		if _, ok := c.Profiles[c.Current]; !ok {
+			errs = append(errs, fmt.Errorf("profile: unknown profile %q", c.Current))
+		}
+	}
+	if c.Listen != "" {
+		if err := (Profile{Server: c.Listen, TLS: c.TLS}).validate(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	for _, name := range c.ProfileNames() {
+		if err := c.Profiles[name].validate(); err != nil {
+			errs = append(errs, fmt.Errorf("profiles.%s: %w", name, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (p Profile) validate() error {
+	u, err := url.Parse(p.Server)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("server: invalid URL %q, expected http(s)://host:port", p.Server)
+	}
+	if _, err := p.TLS.Config(); err != nil {
+		return fmt.Errorf("tls: %w", err)
+	}
 	return nil
 }
+
+// ProfileNames возвращает имена профилей по алфавиту.
+func (c *Config) ProfileNames() []string {
+	names := make([]string, 0, len(c.Profiles))
+	for name := range c.Profiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Lookup возвращает профиль по имени. Пустое имя означает настройки верхнего уровня файла.
+func (c *Config) Lookup(name string) (Profile, error) {
+	if name == "" {
+		return Profile{Server: c.Listen, TLS: c.TLS, Vault: c.Vault, Login: c.Login, E2E: c.E2E}, nil
+	}
+	profile, ok := c.Profiles[name]
+	if !ok {
+		return Profile{}, fmt.Errorf("профиль %q не найден, доступны: %s", name, strings.Join(c.ProfileNames(), ", "))
+	}
+	return profile, nil
+}
+
+// SaveCurrent делает профиль name активным и записывает выбор в файл настроек.
+func (c *Config) SaveCurrent(name string) error {
+	if _, err := c.Lookup(name); err != nil {
+		return err
+	}
+	c.Current = name
+
+	path := c.path
+	if path == "" {
+		path = DefaultPath()
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return err
+	}
+
+	// Файл перечитывается, чтобы сохранить параметры, которые не попали в Config.
+	v := viper.New()
+	v.SetConfigFile(path)
+	if err := v.ReadInConfig(); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	v.Set("profile", name)
+	return v.WriteConfigAs(path)
+}
+
+// Config возвращает настройки TLS для HTTP-клиента.
+func (t TLSSettings) Config() (*tls.Config, error) {
+	cfg := &tls.Config{
+		ServerName:         t.ServerName,
+		InsecureSkipVerify: t.InsecureSkipVerify,
+	}
+	if t.CAFile == "" {
+		return cfg, nil
+	}
+
+	pem, err := os.ReadFile(t.CAFile)
+	if err != nil {
+		return nil, err
+	}
+	pool, err := x509.SystemCertPool()
+	if err != nil {
+		pool = x509.NewCertPool()
+	}
+	if !pool.AppendCertsFromPEM(pem) {
+		return nil, fmt.Errorf("%s: no PEM certificates found", t.CAFile)
+	}
+	cfg.RootCAs = pool
+	return cfg, nil
+}
@@ -0,0 +1,48 @@
+package handlers
+
+import (
+	"client/internal/model"
+	"fmt"
+	"github.com/spf13/cobra"
+	"log"
+	"net/http"
+	"strconv"
+)
+
+// Audit выводит журнал обращений к записям пользователя или активного хранилища.
+func (h *Handlers) Audit() *cobra.Command {
+	var limit int
+	cmd := &cobra.Command{
+		Use:   "audit",
+		Short: "Журнал обращений к записям",
+		Run: func(cmd *cobra.Command, args []string) {
+			var entries []model.AuditEntry
+			path := "/api/audit?limit=" + strconv.Itoa(limit)
+			if err := h.call(http.MethodGet, path, nil, http.StatusOK, &entries); err != nil {
+				log.Printf("%v", err)
+				return
+			}
+			if len(entries) == 0 {
+				fmt.Println("Журнал пуст")
+				return
+			}
+
+			for _, entry := range entries {
+				record := ""
+				if entry.RecordKey != nil {
+					record = entry.RecordType + " " + entry.RecordKey.String()
+				}
+				fmt.Printf("%s  %-12s %-8s %-44s %s\n",
+					entry.CreatedAt.Local().Format("2006-01-02 15:04:05"),
+					entry.Actor,
+					entry.Action,
+					record,
+					entry.IP,
+				)
+			}
+		},
+	}
+
+	cmd.Flags().IntVar(&limit, "limit", 20, "Сколько последних записей вывести")
+	return cmd
+}
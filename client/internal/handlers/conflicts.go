@@ -0,0 +1,161 @@
+package handlers
+
+import (
+	"client/internal/model"
+	"encoding/json"
+	"fmt"
+	"github.com/google/uuid"
+	"github.com/spf13/cobra"
+	"log"
+	"net/http"
+	"strings"
+)
+
+// ListConflicts выводит неразрешённые конфликты с различиями по полям.
+func (h *Handlers) ListConflicts() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "conflicts",
+		Short: "Список конфликтующих изменений",
+		Run: func(cmd *cobra.Command, args []string) {
+			conflicts, err := h.conflicts()
+			if err != nil {
+				log.Printf("%v", err)
+				return
+			}
+			if len(conflicts) == 0 {
+				fmt.Println("Конфликтов нет")
+				return
+			}
+
+			for _, conflict := range conflicts {
+				fmt.Printf("Конфликт %s: %s %s, основан на ревизии %d, создан %s\n",
+					conflict.DataConflictKey,
+					conflict.Type,
+					conflict.RecordKey,
+					conflict.BaseRevision,
+					conflict.CreatedAt.Format("2006-01-02 15:04:05"),
+				)
+				if len(conflict.Current) == 0 {
+					fmt.Println("  запись на сервере удалена")
+				}
+
+				diffs, err := h.gophKeeper.DiffFields(conflict.Current, conflict.Conflict)
+				if err != nil {
+					log.Printf("%v", err)
+					continue
+				}
+				for _, diff := range diffs {
+					fmt.Printf("  %s: сервер %q | копия %q\n", diff.Field, diff.Current, diff.Conflict)
+				}
+			}
+		},
+	}
+
+	return cmd
+}
+
+// ResolveConflict сохраняет выбранную версию записи.
+func (h *Handlers) ResolveConflict() *cobra.Command {
+	var (
+		id     string
+		choice string
+		take   string
+	)
+	cmd := &cobra.Command{
+		Use:   "resolve",
+		Short: "Разрешение конфликта",
+		Long: "Разрешение конфликта: --use current оставляет версию сервера, --use conflict принимает копию,\n" +
+			"--use merge --take поле1,поле2 берёт версию сервера и заменяет указанные поля значениями из копии.",
+		Run: func(cmd *cobra.Command, args []string) {
+			if _, err := uuid.Parse(id); err != nil {
+				log.Printf("UUID Parser: %v", err)
+				return
+			}
+
+			conflicts, err := h.conflicts()
+			if err != nil {
+				log.Printf("%v", err)
+				return
+			}
+
+			var conflict *model.Conflict
+			for i := range conflicts {
+				if conflicts[i].DataConflictKey.String() == id {
+					conflict = &conflicts[i]
+				}
+			}
+			if conflict == nil {
+				fmt.Println("Конфликт не найден")
+				return
+			}
+
+			resolution := model.ConflictResolution{}
+			switch choice {
+			case "current":
+				resolution.Choice = model.ResolveCurrent
+			case "conflict":
+				resolution.Choice = model.ResolveConflict
+			case "merge":
+				if take == "" {
+					fmt.Println("Укажите поля из копии с помощью флага --take.")
+					return
+				}
+				resolution.Choice = model.ResolveMerged
+				resolution.Data, err = h.gophKeeper.MergeFields(conflict.Current, conflict.Conflict, strings.Split(take, ","))
+				if err != nil {
+					log.Printf("%v", err)
+					return
+				}
+			default:
+				fmt.Println("Флаг --use принимает значения current, conflict или merge.")
+				return
+			}
+
+			body, err := json.Marshal(resolution)
+			if err != nil {
+				log.Printf("%v", err)
+				return
+			}
+
+			status, _, err := h.request(http.MethodPost, "/api/conflicts/"+id+"/resolve", body)
+			if err != nil {
+				log.Printf("%v", err)
+				return
+			}
+			if status != http.StatusOK {
+				log.Printf("Ошибка: сервер вернул ошибочный статус: %d %s", status, http.StatusText(status))
+				return
+			}
+
+			// Обновляем кэш выбранной версией.
+			if _, err := h.fetch(conflict.Type, conflict.RecordKey.String()); err != nil {
+				log.Printf("%v", err)
+			}
+			fmt.Println("Конфликт разрешён")
+		},
+	}
+
+	cmd.Flags().StringVar(&id, "key", "", "UUID конфликта")
+	cmd.Flags().StringVar(&choice, "use", "", "Выбранная версия: current, conflict или merge")
+	cmd.Flags().StringVar(&take, "take", "", "Поля через запятую, которые берутся из конфликтной копии при merge")
+	cmd.MarkFlagRequired("key")
+	cmd.MarkFlagRequired("use")
+	return cmd
+}
+
+// conflicts запрашивает у сервера неразрешённые конфликты.
+func (h *Handlers) conflicts() ([]model.Conflict, error) {
+	status, body, err := h.request(http.MethodGet, "/api/conflicts", nil)
+	if err != nil {
+		return nil, err
+	}
+	if status != http.StatusOK {
+		return nil, fmt.Errorf("сервер вернул ошибочный статус: %d %s", status, http.StatusText(status))
+	}
+
+	var conflicts []model.Conflict
+	if err := json.Unmarshal(body, &conflicts); err != nil {
+		return nil, err
+	}
+	return conflicts, nil
+}
@@ -1,13 +1,13 @@
 package handlers
 
 import (
-	"bytes"
+	"client/internal/model"
 	"fmt"
 	"github.com/google/uuid"
 	"github.com/spf13/cobra"
 	"log"
-	"net/http"
-	"net/url"
+	"os"
+	"path/filepath"
 )
 
 func (h *Handlers) CreateDataBinary() *cobra.Command {
@@ -17,11 +17,29 @@ func (h *Handlers) CreateDataBinary() *cobra.Command {
 		Short: "Добавление бинарных данных",
 		Run: func(cmd *cobra.Command, args []string) {
 			if filename == "" {
-				fmt.Println("Укажите текст для отправки с помощью флага --text.")
+				fmt.Println("Укажите файл для отправки с помощью флага --filename.")
 				return
 			}
 
-			fmt.Println(filename)
+			content, err := os.ReadFile(filename)
+			if err != nil {
+				log.Printf("%v", err)
+				return
+			}
+
+			body, err := h.gophKeeper.CreateBinary(filepath.Base(filename), content)
+			if err != nil {
+				log.Printf("%v", err)
+				return
+			}
+
+			key, err := h.create(model.RecordBinary, body)
+			if err != nil {
+				log.Printf("Ошибка добавления: %v", err)
+				return
+			}
+
+			fmt.Println("Данные добавлены, ключ:", key)
 		},
 	}
 
@@ -35,55 +53,103 @@ func (h *Handlers) CreateDataBinary() *cobra.Command {
 }
 
 func (h *Handlers) GetDataBinary() *cobra.Command {
-	var id string
+	var id, output string
 	cmd := &cobra.Command{
 		Use:   "getBinary",
 		Short: "Запрос бинарных данных",
 		Run: func(cmd *cobra.Command, args []string) {
-			if id == "" {
-				fmt.Println("Укажите параметр --uuid для выполнения запроса.")
+			if _, err := uuid.Parse(id); err != nil {
+				log.Printf("UUID Parser: %v", err)
+				return
+			}
+
+			body, err := h.fetch(model.RecordBinary, id)
+			if err != nil {
+				log.Printf("%v", err)
+				return
+			}
+
+			filename, content, err := h.gophKeeper.GetBinary(body)
+			if err != nil {
+				log.Printf("%v", err)
+				return
+			}
+
+			path := output
+			if path == "" {
+				path = filepath.Base(filename)
+			}
+			if err := os.WriteFile(path, content, 0o600); err != nil {
+				log.Printf("%v", err)
 				return
 			}
-			fmt.Println("Goood.")
+
+			fmt.Printf("Файл сохранён: %s (%d байт)\n", path, len(content))
 		},
 	}
 
 	cmd.Flags().StringVar(&id, "key", "", "UUID данных")
+	cmd.Flags().StringVarP(&output, "output", "o", "", "Путь для сохранения файла")
 	cmd.MarkFlagRequired("key")
 	return cmd
 }
 
-func (h *Handlers) DeleteDataBinary() *cobra.Command {
-	var id string
+func (h *Handlers) UpdateDataBinary() *cobra.Command {
+	var id, filename string
 	cmd := &cobra.Command{
-		Use:   "delBinary",
-		Short: "Удаление бинарных данных",
+		Use:   "editBinary",
+		Short: "Замена бинарных данных",
 		Run: func(cmd *cobra.Command, args []string) {
 			if _, err := uuid.Parse(id); err != nil {
 				log.Printf("UUID Parser: %v", err)
 				return
 			}
 
-			reqURL := fmt.Sprintf("%s/api/data/binary/%s", h.cnf.Listen, url.PathEscape(id))
-			req, err := http.NewRequest(http.MethodDelete, reqURL, bytes.NewBuffer(nil))
+			content, err := os.ReadFile(filename)
 			if err != nil {
 				log.Printf("%v", err)
 				return
 			}
-			req.AddCookie(h.gophKeeper.GetCookie())
 
-			resp, err := h.client.Do(req)
+			body, err := h.gophKeeper.CreateBinary(filepath.Base(filename), content)
 			if err != nil {
 				log.Printf("%v", err)
 				return
 			}
-			defer resp.Body.Close()
 
-			if resp.StatusCode == http.StatusOK {
-				fmt.Println("Данные удалены")
-			} else {
-				log.Printf("Ошибка удаления: HTTP %d - %s\n", resp.StatusCode, resp.Status)
+			if err := h.update(model.RecordBinary, id, body); err != nil {
+				log.Printf("Ошибка изменения: %v", err)
+				return
 			}
+
+			fmt.Println("Данные изменены")
+		},
+	}
+
+	cmd.Flags().StringVar(&id, "key", "", "UUID данных")
+	cmd.Flags().StringVarP(&filename, "filename", "f", "", "Новый файл")
+	cmd.MarkFlagRequired("key")
+	cmd.MarkFlagRequired("filename")
+	return cmd
+}
+
+func (h *Handlers) DeleteDataBinary() *cobra.Command {
+	var id string
+	cmd := &cobra.Command{
+		Use:   "delBinary",
+		Short: "Удаление бинарных данных",
+		Run: func(cmd *cobra.Command, args []string) {
+			if _, err := uuid.Parse(id); err != nil {
+				log.Printf("UUID Parser: %v", err)
+				return
+			}
+
+			if err := h.remove(model.RecordBinary, id); err != nil {
+				log.Printf("Ошибка удаления: %v", err)
+				return
+			}
+
+			fmt.Println("Данные удалены")
 		},
 	}
 
@@ -1,40 +1,50 @@
 package handlers
 
 import (
-	"bytes"
+	"client/internal/model"
 	"fmt"
 	"github.com/google/uuid"
 	"github.com/spf13/cobra"
 	"log"
-	"net/http"
-	"net/url"
 )
 
 func (h *Handlers) CreateDataCard() *cobra.Command {
-	var (
-		cardNumber     string
-		cardholderName string
-		expirationDate string
-		cvvHash        string
-	)
+	var card model.DataCreditCard
 	cmd := &cobra.Command{
 		Use:   "addCard",
 		Short: "Добавление карты",
 		Run: func(cmd *cobra.Command, args []string) {
-			if cardNumber == "" {
-				fmt.Println("Укажите текст для отправки с помощью флага --text.")
+			if card.CardNumber == "" {
+				fmt.Println("Укажите номер карты с помощью флага --number.")
+				return
+			}
+
+			body, err := h.gophKeeper.CreateCreditCard(card)
+			if err != nil {
+				log.Printf("%v", err)
+				return
+			}
+
+			key, err := h.create(model.RecordCard, body)
+			if err != nil {
+				log.Printf("Ошибка добавления: %v", err)
 				return
 			}
 
-			fmt.Println(cardNumber, cardholderName, expirationDate, cvvHash)
+			fmt.Println("Карта добавлена, ключ:", key)
 		},
 	}
 
 	// Добавляем флаги для команды
-	cmd.Flags().StringVar(&cardNumber, "number", "", "Номер кредитной карты")
-	cmd.Flags().StringVar(&cardholderName, "name", "", "Имя держателя карты")
-	cmd.Flags().StringVar(&expirationDate, "date", "", "Дата истечения карты (MM/YY)")
-	cmd.Flags().StringVar(&cvvHash, "cvv", "", "CVV")
+	cmd.Flags().StringVar(&card.CardNumber, "number", "", "Номер кредитной карты")
+	cmd.Flags().StringVar(&card.CardholderName, "name", "", "Имя держателя карты")
+	cmd.Flags().StringVar(&card.ExpirationDate, "date", "", "Дата истечения карты (MM/YY)")
+	cmd.Flags().StringVar(&card.CVV, "cvv", "", "CVV")
+	cmd.Flags().StringVar(&card.PIN, "pin", "", "PIN-код")
+	cmd.Flags().StringVar(&card.CardType, "type", "", "Тип карты: debit или credit")
+	cmd.Flags().StringVar(&card.IssuingBank, "bank", "", "Банк-эмитент")
+	cmd.Flags().StringVar(&card.BillingAddress, "address", "", "Адрес для выставления счетов")
+	cmd.Flags().StringVar(&card.Notes, "notes", "", "Заметки")
 
 	// Устанавливаем флаги как обязательные
 	cmd.MarkFlagRequired("number")
@@ -51,7 +61,24 @@ func (h *Handlers) GetDataCard() *cobra.Command {
 		Use:   "getCard",
 		Short: "Запрос карты",
 		Run: func(cmd *cobra.Command, args []string) {
+			if _, err := uuid.Parse(id); err != nil {
+				log.Printf("UUID Parser: %v", err)
+				return
+			}
 
+			body, err := h.fetch(model.RecordCard, id)
+			if err != nil {
+				log.Printf("%v", err)
+				return
+			}
+
+			result, err := h.gophKeeper.GetCreditCard(body)
+			if err != nil {
+				log.Printf("%v", err)
+				return
+			}
+
+			fmt.Println(result)
 		},
 	}
 
@@ -60,36 +87,72 @@ func (h *Handlers) GetDataCard() *cobra.Command {
 	return cmd
 }
 
-func (h *Handlers) DeleteDataCard() *cobra.Command {
-	var id string
+func (h *Handlers) UpdateDataCard() *cobra.Command {
+	var (
+		id   string
+		card model.DataCreditCard
+	)
 	cmd := &cobra.Command{
-		Use:   "delCard",
-		Short: "Удаление карты",
+		Use:   "editCard",
+		Short: "Изменение карты",
 		Run: func(cmd *cobra.Command, args []string) {
 			if _, err := uuid.Parse(id); err != nil {
 				log.Printf("UUID Parser: %v", err)
 				return
 			}
 
-			reqURL := fmt.Sprintf("%s/api/data/card/%s", h.cnf.Listen, url.PathEscape(id))
-			req, err := http.NewRequest(http.MethodDelete, reqURL, bytes.NewBuffer(nil))
+			body, err := h.gophKeeper.CreateCreditCard(card)
 			if err != nil {
 				log.Printf("%v", err)
 				return
 			}
-			req.AddCookie(h.gophKeeper.GetCookie())
-			resp, err := h.client.Do(req)
-			if err != nil {
-				log.Printf("%v", err)
+
+			if err := h.update(model.RecordCard, id, body); err != nil {
+				log.Printf("Ошибка изменения: %v", err)
+				return
+			}
+
+			fmt.Println("Карта изменена")
+		},
+	}
+
+	cmd.Flags().StringVar(&id, "key", "", "UUID данных")
+	cmd.Flags().StringVar(&card.CardNumber, "number", "", "Номер кредитной карты")
+	cmd.Flags().StringVar(&card.CardholderName, "name", "", "Имя держателя карты")
+	cmd.Flags().StringVar(&card.ExpirationDate, "date", "", "Дата истечения карты (MM/YY)")
+	cmd.Flags().StringVar(&card.CVV, "cvv", "", "CVV")
+	cmd.Flags().StringVar(&card.PIN, "pin", "", "PIN-код")
+	cmd.Flags().StringVar(&card.CardType, "type", "", "Тип карты: debit или credit")
+	cmd.Flags().StringVar(&card.IssuingBank, "bank", "", "Банк-эмитент")
+	cmd.Flags().StringVar(&card.BillingAddress, "address", "", "Адрес для выставления счетов")
+	cmd.Flags().StringVar(&card.Notes, "notes", "", "Заметки")
+
+	cmd.MarkFlagRequired("key")
+	cmd.MarkFlagRequired("number")
+	cmd.MarkFlagRequired("name")
+	cmd.MarkFlagRequired("date")
+	cmd.MarkFlagRequired("cvv")
+
+	return cmd
+}
+
+func (h *Handlers) DeleteDataCard() *cobra.Command {
+	var id string
+	cmd := &cobra.Command{
+		Use:   "delCard",
+		Short: "Удаление карты",
+		Run: func(cmd *cobra.Command, args []string) {
+			if _, err := uuid.Parse(id); err != nil {
+				log.Printf("UUID Parser: %v", err)
 				return
 			}
-			defer resp.Body.Close()
 
-			if resp.StatusCode == http.StatusOK {
-				fmt.Println("Данные удалены")
-			} else {
-				log.Printf("Ошибка удаления: HTTP %d - %s\n", resp.StatusCode, resp.Status)
+			if err := h.remove(model.RecordCard, id); err != nil {
+				log.Printf("Ошибка удаления: %v", err)
+				return
 			}
+
+			fmt.Println("Данные удалены")
 		},
 	}
 
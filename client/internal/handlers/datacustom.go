@@ -0,0 +1,196 @@
+package handlers
+
+import (
+	"client/internal/model"
+	"encoding/json"
+	"fmt"
+	"github.com/google/uuid"
+	"github.com/spf13/cobra"
+	"log"
+)
+
+func (h *Handlers) CreateDataCustom() *cobra.Command {
+	var (
+		templateRef string
+		sets        []string
+	)
+	cmd := &cobra.Command{
+		Use:   "addCustom",
+		Short: "Добавление записи пользовательского типа",
+		Long: "Добавляет запись по шаблону. Значения полей задаются флагом --set name=value,\n" +
+			"имена и типы полей выводит команда templates.",
+		Run: func(cmd *cobra.Command, args []string) {
+			values, err := parseValues(sets)
+			if err != nil {
+				log.Printf("%v", err)
+				return
+			}
+			template, err := h.saveTemplate(templateRef)
+			if err != nil {
+				log.Printf("%v", err)
+				return
+			}
+
+			body, err := h.gophKeeper.CreateCustom(template, values)
+			if err != nil {
+				log.Printf("%v", err)
+				return
+			}
+
+			key, err := h.create(model.RecordCustom, body)
+			if err != nil {
+				log.Printf("Ошибка добавления: %v", err)
+				return
+			}
+
+			fmt.Println("Запись добавлена, ключ:", key)
+		},
+	}
+
+	cmd.Flags().StringVar(&templateRef, "template", "", "UUID или название шаблона")
+	cmd.Flags().StringArrayVar(&sets, "set", nil, "Значение поля name=value; флаг повторяется")
+	cmd.MarkFlagRequired("template")
+	return cmd
+}
+
+func (h *Handlers) GetDataCustom() *cobra.Command {
+	var (
+		id     string
+		reveal bool
+	)
+	cmd := &cobra.Command{
+		Use:   "getCustom",
+		Short: "Запрос записи пользовательского типа",
+		Run: func(cmd *cobra.Command, args []string) {
+			if _, err := uuid.Parse(id); err != nil {
+				log.Printf("UUID Parser: %v", err)
+				return
+			}
+
+			body, err := h.fetch(model.RecordCustom, id)
+			if err != nil {
+				log.Printf("%v", err)
+				return
+			}
+
+			// Без сервера шаблон недоступен, и поля выводятся по именам.
+			var template *model.RecordTemplate
+			if found, err := h.customTemplate(body); err == nil {
+				template = &found
+			}
+
+			result, err := h.gophKeeper.GetCustom(body, template, reveal)
+			if err != nil {
+				log.Printf("%v", err)
+				return
+			}
+
+			fmt.Println(result)
+		},
+	}
+
+	cmd.Flags().StringVar(&id, "key", "", "UUID данных")
+	cmd.Flags().BoolVar(&reveal, "reveal", false, "Показать значения секретных полей")
+	cmd.MarkFlagRequired("key")
+	return cmd
+}
+
+func (h *Handlers) UpdateDataCustom() *cobra.Command {
+	var (
+		id   string
+		sets []string
+	)
+	cmd := &cobra.Command{
+		Use:   "editCustom",
+		Short: "Изменение записи пользовательского типа",
+		Long: "Меняет значения полей, заданных флагом --set name=value; остальные поля\n" +
+			"сохраняются. Пустое значение (name=) очищает поле.",
+		Run: func(cmd *cobra.Command, args []string) {
+			if _, err := uuid.Parse(id); err != nil {
+				log.Printf("UUID Parser: %v", err)
+				return
+			}
+			values, err := parseValues(sets)
+			if err != nil {
+				log.Printf("%v", err)
+				return
+			}
+
+			body, err := h.fetch(model.RecordCustom, id)
+			if err != nil {
+				log.Printf("%v", err)
+				return
+			}
+			current, err := h.gophKeeper.CustomValues(body)
+			if err != nil {
+				log.Printf("%v", err)
+				return
+			}
+			template, err := h.saveTemplate(current.TemplateKey.String())
+			if err != nil {
+				log.Printf("%v", err)
+				return
+			}
+
+			if current.Fields == nil {
+				current.Fields = make(map[string]string, len(values))
+			}
+			for name, value := range values {
+				current.Fields[name] = value
+			}
+			body, err = h.gophKeeper.CreateCustom(template, current.Fields)
+			if err != nil {
+				log.Printf("%v", err)
+				return
+			}
+
+			if err := h.update(model.RecordCustom, id, body); err != nil {
+				log.Printf("Ошибка изменения: %v", err)
+				return
+			}
+
+			fmt.Println("Запись изменена")
+		},
+	}
+
+	cmd.Flags().StringVar(&id, "key", "", "UUID данных")
+	cmd.Flags().StringArrayVar(&sets, "set", nil, "Новое значение поля name=value; флаг повторяется")
+	cmd.MarkFlagRequired("key")
+	cmd.MarkFlagRequired("set")
+	return cmd
+}
+
+func (h *Handlers) DeleteDataCustom() *cobra.Command {
+	var id string
+	cmd := &cobra.Command{
+		Use:   "delCustom",
+		Short: "Удаление записи пользовательского типа",
+		Run: func(cmd *cobra.Command, args []string) {
+			if _, err := uuid.Parse(id); err != nil {
+				log.Printf("UUID Parser: %v", err)
+				return
+			}
+
+			if err := h.remove(model.RecordCustom, id); err != nil {
+				log.Printf("Ошибка удаления: %v", err)
+				return
+			}
+
+			fmt.Println("Данные удалены")
+		},
+	}
+
+	cmd.Flags().StringVar(&id, "key", "", "UUID данных")
+	cmd.MarkFlagRequired("key")
+	return cmd
+}
+
+// customTemplate запрашивает шаблон, по которому создана запись.
+func (h *Handlers) customTemplate(body []byte) (model.RecordTemplate, error) {
+	var data model.DataCustomResponse
+	if err := json.Unmarshal(body, &data); err != nil {
+		return model.RecordTemplate{}, err
+	}
+
+	return h.findTemplate(data.TemplateKey.String())
+}
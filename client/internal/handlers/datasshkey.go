@@ -0,0 +1,145 @@
+package handlers
+
+import (
+	"client/internal/model"
+	"fmt"
+	"github.com/google/uuid"
+	"github.com/spf13/cobra"
+	"log"
+	"os"
+)
+
+func (h *Handlers) CreateDataSSHKey() *cobra.Command {
+	var file, passphrase, comment string
+	cmd := &cobra.Command{
+		Use:   "addSSH",
+		Short: "Добавление ключа SSH",
+		Run: func(cmd *cobra.Command, args []string) {
+			body, err := h.sshKeyBody(file, passphrase, comment)
+			if err != nil {
+				log.Printf("%v", err)
+				return
+			}
+
+			key, err := h.create(model.RecordSSH, body)
+			if err != nil {
+				log.Printf("Ошибка добавления: %v", err)
+				return
+			}
+
+			fmt.Println("Ключ SSH добавлен, ключ:", key)
+		},
+	}
+
+	cmd.Flags().StringVar(&file, "file", "", "Файл закрытого ключа, например ~/.ssh/id_ed25519")
+	cmd.Flags().StringVar(&passphrase, "passphrase", "", "Пароль закрытого ключа")
+	cmd.Flags().StringVar(&comment, "comment", "", "Комментарий, например user@host")
+	cmd.MarkFlagRequired("file")
+	return cmd
+}
+
+func (h *Handlers) GetDataSSHKey() *cobra.Command {
+	var (
+		id      string
+		private bool
+	)
+	cmd := &cobra.Command{
+		Use:   "getSSH",
+		Short: "Запрос ключа SSH",
+		Run: func(cmd *cobra.Command, args []string) {
+			if _, err := uuid.Parse(id); err != nil {
+				log.Printf("UUID Parser: %v", err)
+				return
+			}
+
+			body, err := h.fetch(model.RecordSSH, id)
+			if err != nil {
+				log.Printf("%v", err)
+				return
+			}
+
+			result, err := h.gophKeeper.GetSSHKey(body, private)
+			if err != nil {
+				log.Printf("%v", err)
+				return
+			}
+
+			fmt.Println(result)
+		},
+	}
+
+	cmd.Flags().StringVar(&id, "key", "", "UUID данных")
+	cmd.Flags().BoolVar(&private, "private", false, "Вывести закрытый ключ и пароль")
+	cmd.MarkFlagRequired("key")
+	return cmd
+}
+
+func (h *Handlers) UpdateDataSSHKey() *cobra.Command {
+	var id, file, passphrase, comment string
+	cmd := &cobra.Command{
+		Use:   "editSSH",
+		Short: "Изменение ключа SSH",
+		Run: func(cmd *cobra.Command, args []string) {
+			if _, err := uuid.Parse(id); err != nil {
+				log.Printf("UUID Parser: %v", err)
+				return
+			}
+
+			body, err := h.sshKeyBody(file, passphrase, comment)
+			if err != nil {
+				log.Printf("%v", err)
+				return
+			}
+
+			if err := h.update(model.RecordSSH, id, body); err != nil {
+				log.Printf("Ошибка изменения: %v", err)
+				return
+			}
+
+			fmt.Println("Ключ SSH изменён")
+		},
+	}
+
+	cmd.Flags().StringVar(&id, "key", "", "UUID данных")
+	cmd.Flags().StringVar(&file, "file", "", "Файл закрытого ключа, например ~/.ssh/id_ed25519")
+	cmd.Flags().StringVar(&passphrase, "passphrase", "", "Пароль закрытого ключа")
+	cmd.Flags().StringVar(&comment, "comment", "", "Комментарий, например user@host")
+	cmd.MarkFlagRequired("key")
+	cmd.MarkFlagRequired("file")
+	return cmd
+}
+
+func (h *Handlers) DeleteDataSSHKey() *cobra.Command {
+	var id string
+	cmd := &cobra.Command{
+		Use:   "delSSH",
+		Short: "Удаление ключа SSH",
+		Run: func(cmd *cobra.Command, args []string) {
+			if _, err := uuid.Parse(id); err != nil {
+				log.Printf("UUID Parser: %v", err)
+				return
+			}
+
+			if err := h.remove(model.RecordSSH, id); err != nil {
+				log.Printf("Ошибка удаления: %v", err)
+				return
+			}
+
+			fmt.Println("Данные удалены")
+		},
+	}
+
+	cmd.Flags().StringVar(&id, "key", "", "UUID данных")
+	cmd.MarkFlagRequired("key")
+	return cmd
+}
+
+// sshKeyBody читает закрытый ключ из файла и формирует тело запроса.
+func (h *Handlers) sshKeyBody(file, passphrase, comment string) ([]byte, error) {
+	privateKey, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+
+	return h.gophKeeper.CreateSSHKey(privateKey, passphrase, comment)
+}
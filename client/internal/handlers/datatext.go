@@ -1,14 +1,11 @@
 package handlers
 
 import (
-	"bytes"
+	"client/internal/model"
 	"fmt"
 	"github.com/google/uuid"
 	"github.com/spf13/cobra"
-	"io"
 	"log"
-	"net/http"
-	"net/url"
 )
 
 func (h *Handlers) CreateDataText() *cobra.Command {
@@ -17,7 +14,19 @@ func (h *Handlers) CreateDataText() *cobra.Command {
 		Use:   "addText",
 		Short: "Добавление текстовых данных",
 		Run: func(cmd *cobra.Command, args []string) {
-			fmt.Println("Goood.")
+			body, err := h.gophKeeper.CreateText(text)
+			if err != nil {
+				log.Printf("%v", err)
+				return
+			}
+
+			key, err := h.create(model.RecordText, body)
+			if err != nil {
+				log.Printf("Ошибка добавления: %v", err)
+				return
+			}
+
+			fmt.Println("Данные добавлены, ключ:", key)
 		},
 	}
 
@@ -37,38 +46,57 @@ func (h *Handlers) GetDataText() *cobra.Command {
 				return
 			}
 
-			req, err := http.NewRequest(http.MethodGet, h.cnf.Listen+"/api/data/text/"+id, bytes.NewBuffer(nil))
+			body, err := h.fetch(model.RecordText, id)
 			if err != nil {
 				log.Printf("%v", err)
 				return
 			}
-			req.AddCookie(h.gophKeeper.GetCookie())
 
-			resp, err := h.client.Do(req)
+			result, err := h.gophKeeper.GetText(body)
 			if err != nil {
 				log.Printf("%v", err)
 				return
 			}
-			body, err := io.ReadAll(resp.Body)
-			defer resp.Body.Close()
 
-			if resp.StatusCode != http.StatusOK {
-				log.Printf("Ошибка: сервер вернул ошибочный статус: %d %s", resp.StatusCode, resp.Status)
+			fmt.Println(result)
+		},
+	}
+
+	cmd.Flags().StringVar(&id, "key", "", "UUID данных")
+	cmd.MarkFlagRequired("key")
+	return cmd
+}
+
+func (h *Handlers) UpdateDataText() *cobra.Command {
+	var id, text string
+	cmd := &cobra.Command{
+		Use:   "editText",
+		Short: "Изменение текстовых данных",
+		Run: func(cmd *cobra.Command, args []string) {
+			if _, err := uuid.Parse(id); err != nil {
+				log.Printf("UUID Parser: %v", err)
 				return
 			}
 
-			result, err := h.gophKeeper.GetText(body)
+			body, err := h.gophKeeper.CreateText(text)
 			if err != nil {
 				log.Printf("%v", err)
 				return
 			}
 
-			fmt.Println(result)
+			if err := h.update(model.RecordText, id, body); err != nil {
+				log.Printf("Ошибка изменения: %v", err)
+				return
+			}
+
+			fmt.Println("Данные изменены")
 		},
 	}
 
 	cmd.Flags().StringVar(&id, "key", "", "UUID данных")
+	cmd.Flags().StringVarP(&text, "text", "t", "", "Новый текст")
 	cmd.MarkFlagRequired("key")
+	cmd.MarkFlagRequired("text")
 	return cmd
 }
 
@@ -83,26 +111,12 @@ func (h *Handlers) DeleteDataText() *cobra.Command {
 				return
 			}
 
-			reqURL := fmt.Sprintf("%s/api/data/text/%s", h.cnf.Listen, url.PathEscape(id))
-			req, err := http.NewRequest(http.MethodDelete, reqURL, bytes.NewBuffer(nil))
-			if err != nil {
-				log.Printf("%v", err)
+			if err := h.remove(model.RecordText, id); err != nil {
+				log.Printf("Ошибка удаления: %v", err)
 				return
 			}
-			req.AddCookie(h.gophKeeper.GetCookie())
 
-			resp, err := h.client.Do(req)
-			if err != nil {
-				log.Printf("%v", err)
-				return
-			}
-			defer resp.Body.Close()
-
-			if resp.StatusCode == http.StatusOK {
-				fmt.Println("Данные удалены")
-			} else {
-				log.Printf("Ошибка удаления: HTTP %d - %s\n", resp.StatusCode, resp.Status)
-			}
+			fmt.Println("Данные удалены")
 		},
 	}
 
@@ -0,0 +1,193 @@
+package handlers
+
+import (
+	"client/internal/model"
+	"client/internal/service"
+	"fmt"
+	"github.com/google/uuid"
+	"github.com/spf13/cobra"
+	"log"
+	"time"
+)
+
+func (h *Handlers) CreateDataTOTP() *cobra.Command {
+	var (
+		uri  string
+		totp model.DataTOTP
+	)
+	cmd := &cobra.Command{
+		Use:   "addOTP",
+		Short: "Добавление секрета двухфакторной аутентификации (TOTP)",
+		Run: func(cmd *cobra.Command, args []string) {
+			body, err := h.totpBody(uri, totp)
+			if err != nil {
+				log.Printf("%v", err)
+				return
+			}
+
+			key, err := h.create(model.RecordTOTP, body)
+			if err != nil {
+				log.Printf("Ошибка добавления: %v", err)
+				return
+			}
+
+			fmt.Println("Секрет TOTP добавлен, ключ:", key)
+		},
+	}
+
+	totpFlags(cmd, &uri, &totp)
+	return cmd
+}
+
+func (h *Handlers) GetDataTOTP() *cobra.Command {
+	var id string
+	cmd := &cobra.Command{
+		Use:   "getOTP",
+		Short: "Запрос секрета TOTP",
+		Run: func(cmd *cobra.Command, args []string) {
+			if _, err := uuid.Parse(id); err != nil {
+				log.Printf("UUID Parser: %v", err)
+				return
+			}
+
+			body, err := h.fetch(model.RecordTOTP, id)
+			if err != nil {
+				log.Printf("%v", err)
+				return
+			}
+
+			result, err := h.gophKeeper.GetTOTP(body)
+			if err != nil {
+				log.Printf("%v", err)
+				return
+			}
+
+			fmt.Println(result)
+		},
+	}
+
+	cmd.Flags().StringVar(&id, "key", "", "UUID данных")
+	cmd.MarkFlagRequired("key")
+	return cmd
+}
+
+func (h *Handlers) UpdateDataTOTP() *cobra.Command {
+	var (
+		id   string
+		uri  string
+		totp model.DataTOTP
+	)
+	cmd := &cobra.Command{
+		Use:   "editOTP",
+		Short: "Изменение секрета TOTP",
+		Run: func(cmd *cobra.Command, args []string) {
+			if _, err := uuid.Parse(id); err != nil {
+				log.Printf("UUID Parser: %v", err)
+				return
+			}
+
+			body, err := h.totpBody(uri, totp)
+			if err != nil {
+				log.Printf("%v", err)
+				return
+			}
+
+			if err := h.update(model.RecordTOTP, id, body); err != nil {
+				log.Printf("Ошибка изменения: %v", err)
+				return
+			}
+
+			fmt.Println("Секрет TOTP изменён")
+		},
+	}
+
+	cmd.Flags().StringVar(&id, "key", "", "UUID данных")
+	totpFlags(cmd, &uri, &totp)
+	cmd.MarkFlagRequired("key")
+	return cmd
+}
+
+func (h *Handlers) DeleteDataTOTP() *cobra.Command {
+	var id string
+	cmd := &cobra.Command{
+		Use:   "delOTP",
+		Short: "Удаление секрета TOTP",
+		Run: func(cmd *cobra.Command, args []string) {
+			if _, err := uuid.Parse(id); err != nil {
+				log.Printf("UUID Parser: %v", err)
+				return
+			}
+
+			if err := h.remove(model.RecordTOTP, id); err != nil {
+				log.Printf("Ошибка удаления: %v", err)
+				return
+			}
+
+			fmt.Println("Данные удалены")
+		},
+	}
+
+	cmd.Flags().StringVar(&id, "key", "", "UUID данных")
+	cmd.MarkFlagRequired("key")
+	return cmd
+}
+
+// OTP выводит текущий одноразовый код и время, которое он остаётся действительным.
+// Код вычисляется на клиенте, поэтому команда работает и без подключения к серверу.
+func (h *Handlers) OTP() *cobra.Command {
+	var id string
+	cmd := &cobra.Command{
+		Use:   "otp",
+		Short: "Текущий код двухфакторной аутентификации",
+		Run: func(cmd *cobra.Command, args []string) {
+			if _, err := uuid.Parse(id); err != nil {
+				log.Printf("UUID Parser: %v", err)
+				return
+			}
+
+			body, err := h.fetch(model.RecordTOTP, id)
+			if err != nil {
+				log.Printf("%v", err)
+				return
+			}
+
+			code, left, err := h.gophKeeper.TOTPCode(body, time.Now())
+			if err != nil {
+				log.Printf("%v", err)
+				return
+			}
+
+			fmt.Printf("%s (действителен ещё %d с)\n", code, int(left.Round(time.Second)/time.Second))
+		},
+	}
+
+	cmd.Flags().StringVar(&id, "key", "", "UUID данных")
+	cmd.MarkFlagRequired("key")
+	return cmd
+}
+
+// totpFlags добавляет флаги параметров TOTP, общие для добавления и изменения.
+func totpFlags(cmd *cobra.Command, uri *string, totp *model.DataTOTP) {
+	cmd.Flags().StringVar(uri, "uri", "", "Ссылка otpauth://totp/... из QR-кода")
+	cmd.Flags().StringVar(&totp.Secret, "secret", "", "Секрет в кодировке base32")
+	cmd.Flags().StringVar(&totp.Issuer, "issuer", "", "Сервис")
+	cmd.Flags().StringVar(&totp.Account, "account", "", "Учётная запись")
+	cmd.Flags().StringVar(&totp.Algorithm, "algorithm", "", "Алгоритм: SHA1, SHA256 или SHA512 (по умолчанию SHA1)")
+	cmd.Flags().IntVar(&totp.Digits, "digits", 0, "Число цифр кода: от 6 до 8 (по умолчанию 6)")
+	cmd.Flags().IntVar(&totp.Period, "period", 0, "Срок действия кода в секундах (по умолчанию 30)")
+}
+
+// totpBody формирует тело запроса из флагов: параметры ссылки otpauth:// заменяют
+// значения отдельных флагов.
+func (h *Handlers) totpBody(uri string, totp model.DataTOTP) ([]byte, error) {
+	if uri != "" {
+		if err := service.ParseOTPAuthURI(uri, &totp); err != nil {
+			return nil, err
+		}
+	}
+	if totp.Secret == "" {
+		return nil, fmt.Errorf("укажите ссылку флагом --uri или секрет флагом --secret")
+	}
+
+	return h.gophKeeper.CreateTOTP(totp)
+}
@@ -3,9 +3,11 @@ package handlers
 import (
 	"client/internal/config"
 	"client/internal/service"
+	"context"
 	"github.com/spf13/cobra"
+	"log"
 	"net/http"
-	"time"
+	"sync"
 )
 
 // Handlers представляет собой структуру, содержащую сервисы для обработки URL и авторизации.
@@ -13,36 +15,100 @@ type Handlers struct {
 	gophKeeper *service.GophKeeperClient // Сервис сокращения URL
 	cobra      *cobra.Command
 	cnf        config.Config
+	profile    config.Profile // настройки активного профиля
 	client     *http.Client
+	offline    bool               // работа только с локальным кэшем
+	syncMu     sync.Mutex         // синхронизация из команды sync и из фоновой подписки
+	watchStop  context.CancelFunc // останавливает фоновую подписку watch
+	agentStop  func()             // останавливает агент SSH
+	vault      string             // активное хранилище организации; пустое — личное хранилище
 }
 
-func NewHandlers(srv *service.GophKeeperClient, cnf config.Config) *Handlers {
-	return &Handlers{
+func NewHandlers(srv *service.GophKeeperClient, cnf config.Config) (*Handlers, error) {
+	h := &Handlers{
 		gophKeeper: srv,
 		cobra: &cobra.Command{
 			Use:   "app",
 			Short: "GophKeeper приложение",
 		},
 		cnf: cnf,
-		client: &http.Client{
-			Timeout: time.Second * 10,
-		},
 	}
+	if err := h.applyProfile(cnf.Current); err != nil {
+		return nil, err
+	}
+
+	var profile string
+	h.cobra.PersistentFlags().BoolVar(&h.offline, "offline", false, "Работать только с локальным кэшем")
+	h.cobra.PersistentFlags().StringVar(&profile, "profile", "", "Профиль сервера для этой и следующих команд")
+	h.cobra.PersistentPreRun = func(cmd *cobra.Command, args []string) {
+		// Флаг сбрасывается, иначе cobra сохранит значение для следующих команд интерактивного режима.
+		if profile != "" && profile != h.cnf.Current {
+			if err := h.switchProfile(profile); err != nil {
+				log.Printf("%v", err)
+			}
+		}
+		profile = ""
+		h.replay()
+	}
+
+	return h, nil
 }
 
 func (h *Handlers) Run() error {
 	h.cobra.AddCommand(
 		h.RegisterUser(),
 		h.AuthorizationUser(),
+		h.LogoutUser(),
 		h.CreateDataText(),
 		h.GetDataText(),
+		h.UpdateDataText(),
 		h.DeleteDataText(),
 		h.CreateDataCard(),
 		h.GetDataCard(),
+		h.UpdateDataCard(),
 		h.DeleteDataCard(),
 		h.CreateDataBinary(),
 		h.GetDataBinary(),
+		h.UpdateDataBinary(),
 		h.DeleteDataBinary(),
+		h.CreateDataTOTP(),
+		h.GetDataTOTP(),
+		h.UpdateDataTOTP(),
+		h.DeleteDataTOTP(),
+		h.OTP(),
+		h.CreateDataSSHKey(),
+		h.GetDataSSHKey(),
+		h.UpdateDataSSHKey(),
+		h.DeleteDataSSHKey(),
+		h.SSHAgent(),
+		h.CreateTemplate(),
+		h.Templates(),
+		h.DeleteTemplate(),
+		h.CreateDataCustom(),
+		h.GetDataCustom(),
+		h.UpdateDataCustom(),
+		h.DeleteDataCustom(),
+		h.ListRecords(),
+		h.Status(),
+		h.Queue(),
+		h.Sync(),
+		h.ListConflicts(),
+		h.ResolveConflict(),
+		h.History(),
+		h.Restore(),
+		h.Trash(),
+		h.Grant(),
+		h.Grants(),
+		h.Revoke(),
+		h.Share(),
+		h.Open(),
+		h.Audit(),
+		h.Expiring(),
+		h.Org(),
+		h.Vault(),
+		h.Watch(),
+		h.Version(),
+		h.Profile(),
 	)
 
 	if err := h.cobra.Execute(); err != nil {
@@ -0,0 +1,110 @@
+package handlers
+
+import (
+	"client/internal/cache"
+	"client/internal/model"
+	"encoding/json"
+	"fmt"
+	"github.com/google/uuid"
+	"github.com/spf13/cobra"
+	"log"
+	"net/http"
+)
+
+// History выводит версии записи, сохранённые на сервере.
+func (h *Handlers) History() *cobra.Command {
+	var (
+		recordType string
+		id         string
+	)
+	cmd := &cobra.Command{
+		Use:   "history",
+		Short: "История версий записи",
+		Run: func(cmd *cobra.Command, args []string) {
+			if _, err := uuid.Parse(id); err != nil {
+				log.Printf("UUID Parser: %v", err)
+				return
+			}
+
+			status, body, err := h.request(http.MethodGet, dataPath(recordType, id)+"/history", nil)
+			if err != nil {
+				log.Printf("%v", err)
+				return
+			}
+			if status != http.StatusOK {
+				log.Printf("Ошибка: сервер вернул ошибочный статус: %d %s", status, http.StatusText(status))
+				return
+			}
+
+			var history []model.HistoryEntry
+			if err := json.Unmarshal(body, &history); err != nil {
+				log.Printf("%v", err)
+				return
+			}
+
+			for _, entry := range history {
+				line := fmt.Sprintf("ревизия %-6d %s  %-8s", entry.Revision, entry.CreatedAt.Format("2006-01-02 15:04:05"), entry.Action)
+				if len(entry.Data) > 0 {
+					line += "  " + h.gophKeeper.Describe(recordType, entry.Data)
+				}
+				fmt.Println(line)
+			}
+		},
+	}
+
+	cmd.Flags().StringVar(&recordType, "type", "", "Тип записи: text, binary, card, totp, ssh или custom")
+	cmd.Flags().StringVar(&id, "key", "", "UUID данных")
+	cmd.MarkFlagRequired("type")
+	cmd.MarkFlagRequired("key")
+	return cmd
+}
+
+// Restore возвращает запись к версии из истории, в том числе после удаления.
+func (h *Handlers) Restore() *cobra.Command {
+	var (
+		recordType string
+		id         string
+		revision   int64
+	)
+	cmd := &cobra.Command{
+		Use:   "restore",
+		Short: "Восстановление версии записи",
+		Run: func(cmd *cobra.Command, args []string) {
+			if _, err := uuid.Parse(id); err != nil {
+				log.Printf("UUID Parser: %v", err)
+				return
+			}
+
+			body, err := json.Marshal(model.HistoryRestore{Revision: revision})
+			if err != nil {
+				log.Printf("%v", err)
+				return
+			}
+
+			status, respBody, err := h.request(http.MethodPost, dataPath(recordType, id)+"/restore", body)
+			if err != nil {
+				log.Printf("%v", err)
+				return
+			}
+			if status != http.StatusOK {
+				log.Printf("Ошибка: сервер вернул ошибочный статус: %d %s", status, http.StatusText(status))
+				return
+			}
+
+			if vault := h.gophKeeper.GetCache(); vault != nil {
+				if err := vault.PutRecord(cache.Record{Type: recordType, Key: id, Data: respBody}); err != nil {
+					log.Printf("Ошибка записи в локальный кэш: %v", err)
+				}
+			}
+			fmt.Printf("Запись восстановлена: %s\n", h.gophKeeper.Describe(recordType, respBody))
+		},
+	}
+
+	cmd.Flags().StringVar(&recordType, "type", "", "Тип записи: text, binary, card, totp, ssh или custom")
+	cmd.Flags().StringVar(&id, "key", "", "UUID данных")
+	cmd.Flags().Int64Var(&revision, "revision", 0, "Ревизия версии из истории")
+	cmd.MarkFlagRequired("type")
+	cmd.MarkFlagRequired("key")
+	cmd.MarkFlagRequired("revision")
+	return cmd
+}
@@ -0,0 +1,217 @@
+package handlers
+
+import (
+	"client/internal/model"
+	"encoding/json"
+	"fmt"
+	"github.com/google/uuid"
+	"github.com/spf13/cobra"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// linkPath — путь одноразовых ссылок на сервере.
+const linkPath = "/api/links/"
+
+// Share создаёт одноразовую ссылку на запись или произвольный текст.
+// Ключ шифрования передаётся только во фрагменте ссылки и на сервер не отправляется.
+func (h *Handlers) Share() *cobra.Command {
+	var (
+		recordType string
+		id         string
+		text       string
+		views      int
+		ttl        time.Duration
+	)
+	cmd := &cobra.Command{
+		Use:   "share",
+		Short: "Одноразовая ссылка на секрет",
+		Long: "Одноразовая ссылка на запись (--type и --key) или произвольный текст (--text).\n" +
+			"Ссылка открывается командой open без учётной записи и удаляется после последнего просмотра.",
+		Run: func(cmd *cobra.Command, args []string) {
+			payload := model.LinkPayload{Type: recordType}
+			var err error
+			switch {
+			case text != "":
+				payload.Type = model.RecordText
+				payload.Data, err = h.gophKeeper.CreateText(text)
+			case recordType != "" && id != "":
+				if _, err := uuid.Parse(id); err != nil {
+					log.Printf("UUID Parser: %v", err)
+					return
+				}
+				payload.Data, err = h.fetch(recordType, id)
+				if err == nil {
+					payload.Data, err = h.gophKeeper.OpenRecord(recordType, payload.Data)
+				}
+			default:
+				fmt.Println("Укажите запись флагами --type и --key или текст флагом --text.")
+				return
+			}
+			if err != nil {
+				log.Printf("%v", err)
+				return
+			}
+
+			plain, err := json.Marshal(payload)
+			if err != nil {
+				log.Printf("%v", err)
+				return
+			}
+			sealed, fragment, err := h.gophKeeper.SealLink(plain)
+			if err != nil {
+				log.Printf("%v", err)
+				return
+			}
+
+			link := model.Link{Data: sealed, MaxViews: views, ExpiresAt: time.Now().Add(ttl)}
+			if err := h.call(http.MethodPost, "/api/links", link, http.StatusCreated, &link); err != nil {
+				log.Printf("%v", err)
+				return
+			}
+
+			fmt.Println(h.profile.Server + linkPath + link.LinkKey.String() + "#" + fragment)
+			fmt.Printf("Просмотров: %d, действует до %s\n", link.MaxViews, link.ExpiresAt.Local().Format("2006-01-02 15:04:05"))
+		},
+	}
+
+	cmd.Flags().StringVar(&recordType, "type", "", "Тип записи: text, binary, card, totp, ssh или custom")
+	cmd.Flags().StringVar(&id, "key", "", "UUID данных")
+	cmd.Flags().StringVar(&text, "text", "", "Произвольный текст вместо записи")
+	cmd.Flags().IntVar(&views, "views", 1, "Сколько раз ссылку можно открыть")
+	cmd.Flags().DurationVar(&ttl, "ttl", 24*time.Hour, "Срок действия ссылки")
+	return cmd
+}
+
+// Open открывает одноразовую ссылку. Вход не требуется.
+func (h *Handlers) Open() *cobra.Command {
+	var output string
+	cmd := &cobra.Command{
+		Use:   "open [ссылка]",
+		Short: "Открытие одноразовой ссылки",
+		Args:  cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			link, err := url.Parse(args[0])
+			if err != nil {
+				log.Printf("%v", err)
+				return
+			}
+			fragment := link.Fragment
+			if fragment == "" {
+				fmt.Println("В ссылке нет ключа: проверьте, что она скопирована вместе с частью после #")
+				return
+			}
+			link.Fragment = ""
+
+			// Ссылка может вести на другой сервер, поэтому запрос отправляется без cookie пользователя.
+			resp, err := h.client.Get(link.String())
+			if err != nil {
+				log.Printf("%v", err)
+				return
+			}
+			defer resp.Body.Close()
+
+			if resp.StatusCode == http.StatusNotFound {
+				fmt.Println("Ссылка не найдена: срок действия истёк или она уже открыта")
+				return
+			}
+			if resp.StatusCode != http.StatusOK {
+				log.Printf("Ошибка: сервер вернул ошибочный статус: %d %s", resp.StatusCode, http.StatusText(resp.StatusCode))
+				return
+			}
+
+			body, err := io.ReadAll(resp.Body)
+			if err != nil {
+				log.Printf("%v", err)
+				return
+			}
+			var opened model.Link
+			if err := json.Unmarshal(body, &opened); err != nil {
+				log.Printf("%v", err)
+				return
+			}
+
+			plain, err := h.gophKeeper.OpenLink(opened.Data, fragment)
+			if err != nil {
+				log.Printf("Не удалось расшифровать: %v", err)
+				return
+			}
+			var payload model.LinkPayload
+			if err := json.Unmarshal(plain, &payload); err != nil {
+				log.Printf("%v", err)
+				return
+			}
+
+			if err := h.printPayload(payload, output); err != nil {
+				log.Printf("%v", err)
+				return
+			}
+			if left := opened.MaxViews - opened.Views; left > 0 {
+				fmt.Printf("Осталось просмотров: %d\n", left)
+			} else {
+				fmt.Println("Это был последний просмотр, ссылка удалена")
+			}
+		},
+	}
+
+	cmd.Flags().StringVarP(&output, "output", "o", "", "Путь для сохранения файла")
+	return cmd
+}
+
+// printPayload выводит содержимое ссылки; файлы сохраняются в output или под исходным именем.
+func (h *Handlers) printPayload(payload model.LinkPayload, output string) error {
+	switch payload.Type {
+	case model.RecordText:
+		text, err := h.gophKeeper.GetText(payload.Data)
+		if err != nil {
+			return err
+		}
+		fmt.Println(text)
+	case model.RecordCard:
+		card, err := h.gophKeeper.GetCreditCard(payload.Data)
+		if err != nil {
+			return err
+		}
+		fmt.Println(card)
+	case model.RecordTOTP:
+		totp, err := h.gophKeeper.GetTOTP(payload.Data)
+		if err != nil {
+			return err
+		}
+		fmt.Println(totp)
+	case model.RecordSSH:
+		key, err := h.gophKeeper.GetSSHKey(payload.Data, true)
+		if err != nil {
+			return err
+		}
+		fmt.Println(key)
+	case model.RecordCustom:
+		custom, err := h.gophKeeper.GetCustom(payload.Data, nil, true)
+		if err != nil {
+			return err
+		}
+		fmt.Println(custom)
+	case model.RecordBinary:
+		filename, content, err := h.gophKeeper.GetBinary(payload.Data)
+		if err != nil {
+			return err
+		}
+		path := output
+		if path == "" {
+			path = filepath.Base(filename)
+		}
+		if err := os.WriteFile(path, content, 0o600); err != nil {
+			return err
+		}
+		fmt.Printf("Файл сохранён: %s (%d байт)\n", path, len(content))
+	default:
+		return fmt.Errorf("unknown record type: %s", payload.Type)
+	}
+
+	return nil
+}
@@ -0,0 +1,782 @@
+package handlers
+
+import (
+	"bytes"
+	"client/internal/cache"
+	"client/internal/model"
+	"client/internal/service"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/google/uuid"
+	"github.com/spf13/cobra"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// errOffline означает, что сервер недоступен или включён режим --offline.
+var errOffline = errors.New("server is unreachable")
+
+// errConflict означает, что запись на сервере изменилась и изменение сохранено как конфликтная копия.
+var errConflict = errors.New("конфликт версий: изменение сохранено как конфликтная копия, выполните conflicts")
+
+// statusError описывает ошибочный ответ сервера. Для ответа 422 перечисляются поля, не прошедшие проверку.
+func statusError(status int, respBody []byte) error {
+	var validation model.ValidationError
+	if status == http.StatusUnprocessableEntity && json.Unmarshal(respBody, &validation) == nil && len(validation.Errors) > 0 {
+		fields := make([]string, len(validation.Errors))
+		for i, field := range validation.Errors {
+			fields[i] = field.Field + ": " + field.Message
+		}
+		return fmt.Errorf("сервер отклонил данные: %s", strings.Join(fields, "; "))
+	}
+	return fmt.Errorf("сервер вернул ошибочный статус: %d %s", status, http.StatusText(status))
+}
+
+// request отправляет запрос к серверу с cookie текущего пользователя в активном хранилище
+// и возвращает код и тело ответа. Если сервер недоступен, возвращается ошибка, оборачивающая errOffline.
+func (h *Handlers) request(method, path string, body []byte) (int, []byte, error) {
+	return h.send(method, path, body, h.vault)
+}
+
+// send выполняет request в хранилище vault; пустое значение означает личное хранилище.
+func (h *Handlers) send(method, path string, body []byte, vault string) (int, []byte, error) {
+	if h.offline {
+		return 0, nil, errOffline
+	}
+
+	req, err := http.NewRequest(method, h.profile.Server+path, bytes.NewBuffer(body))
+	if err != nil {
+		return 0, nil, err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if vault != "" {
+		req.Header.Set(vaultHeader, vault)
+	}
+	if cookie := h.gophKeeper.GetCookie(); cookie != nil {
+		req.AddCookie(cookie)
+	}
+
+	resp, err := h.client.Do(req)
+	if err != nil {
+		return 0, nil, fmt.Errorf("%w: %v", errOffline, err)
+	}
+	defer resp.Body.Close()
+
+	// Сервер выдаёт новый токен при регистрации и авторизации.
+	for _, cookie := range resp.Cookies() {
+		if cookie.Name == "user" {
+			h.gophKeeper.SetCookie(cookie)
+		}
+	}
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return 0, nil, err
+	}
+	return resp.StatusCode, respBody, nil
+}
+
+// openCache открывает локальный кэш пользователя, проверяя пароль.
+func (h *Handlers) openCache(login, password string) error {
+	// У профилей свои кэши: на разных серверах могут быть одинаковые логины.
+	path := filepath.Join(h.cnf.CacheDir, url.PathEscape(h.cnf.Current), url.PathEscape(login)+".db")
+	vault, err := cache.Open(path, password)
+	if err != nil {
+		return err
+	}
+
+	h.gophKeeper.SetLogin(login)
+	h.gophKeeper.SetCache(vault)
+
+	if !h.profile.E2E {
+		h.gophKeeper.DisableE2E()
+		return nil
+	}
+	return h.unlockE2E(login, password)
+}
+
+// unlockE2E запрашивает ключевую фразу сквозного шифрования и выводит из неё ключ.
+// Фраза отличается от пароля и не покидает клиент: пароль получает сервер при входе.
+// Соль и контрольное значение хранятся на сервере и копируются в кэш для входа без сервера;
+// при первом включении они создаются клиентом.
+func (h *Handlers) unlockE2E(login, password string) error {
+	passphrase := readPassphrase()
+	if passphrase == "" {
+		return errors.New("ключевая фраза сквозного шифрования не введена")
+	}
+	if passphrase == password {
+		return errors.New("ключевая фраза должна отличаться от пароля: пароль передаётся серверу")
+	}
+
+	params, err := h.e2eParams(passphrase)
+	if err != nil {
+		return err
+	}
+	if err := h.gophKeeper.EnableE2E(passphrase, params); err != nil {
+		return err
+	}
+	// Значения, зашифрованные до появления ключевой фразы, читаются прежним ключом из пароля.
+	return h.gophKeeper.EnableLegacyE2E(login, password)
+}
+
+// e2eParams получает параметры сквозного шифрования с сервера, а без сервера — из кэша.
+// Если параметров ещё нет, они создаются из ключевой фразы и сохраняются на сервере;
+// если их одновременно сохранило другое устройство, используются его параметры.
+func (h *Handlers) e2eParams(passphrase string) (model.E2EParams, error) {
+	vault := h.gophKeeper.GetCache()
+
+	var params model.E2EParams
+	status, body, err := h.send(http.MethodGet, "/api/user/e2e", nil, "")
+	if errors.Is(err, errOffline) {
+		cached, err := vault.E2EParams()
+		if errors.Is(err, cache.ErrNotFound) {
+			return model.E2EParams{}, errors.New("параметры сквозного шифрования не получены: войдите при доступном сервере")
+		}
+		if err != nil {
+			return model.E2EParams{}, err
+		}
+		err = json.Unmarshal(cached, &params)
+		return params, err
+	}
+	if err != nil {
+		return model.E2EParams{}, err
+	}
+
+	if status == http.StatusNotFound {
+		if params, err = service.NewE2EParams(passphrase); err != nil {
+			return model.E2EParams{}, err
+		}
+		reqBody, err := json.Marshal(params)
+		if err != nil {
+			return model.E2EParams{}, err
+		}
+		status, respBody, err := h.send(http.MethodPost, "/api/user/e2e", reqBody, "")
+		if err != nil {
+			return model.E2EParams{}, err
+		}
+		switch status {
+		case http.StatusCreated:
+			body, _ = json.Marshal(params)
+			fmt.Println("Сквозное шифрование включено: запомните ключевую фразу, без неё секреты не восстановить")
+		case http.StatusConflict:
+			if status, body, err = h.send(http.MethodGet, "/api/user/e2e", nil, ""); err != nil {
+				return model.E2EParams{}, err
+			}
+		default:
+			return model.E2EParams{}, statusError(status, respBody)
+		}
+	}
+	if status != http.StatusOK && status != http.StatusCreated {
+		return model.E2EParams{}, statusError(status, body)
+	}
+
+	if err := json.Unmarshal(body, &params); err != nil {
+		return model.E2EParams{}, err
+	}
+	if err := vault.SetE2EParams(body); err != nil {
+		log.Printf("Ошибка записи в локальный кэш: %v", err)
+	}
+	return params, nil
+}
+
+// fetch запрашивает запись у сервера и сохраняет её в кэш.
+// Если сервер недоступен, запись читается из локального кэша.
+func (h *Handlers) fetch(recordType, key string) ([]byte, error) {
+	status, body, err := h.request(http.MethodGet, dataPath(recordType, key), nil)
+	if errors.Is(err, errOffline) && h.vault == "" {
+		vault := h.gophKeeper.GetCache()
+		if vault == nil {
+			return nil, fmt.Errorf("локальный кэш не открыт, выполните вход командой aut")
+		}
+
+		record, err := vault.GetRecord(recordType, key)
+		if err != nil {
+			return nil, err
+		}
+		fmt.Println("Сервер недоступен, данные получены из локального кэша")
+		return record.Data, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if status != http.StatusOK {
+		return nil, fmt.Errorf("сервер вернул ошибочный статус: %d %s", status, http.StatusText(status))
+	}
+
+	// Кэш повторяет ленту синхронизации пользователя, поэтому здесь только обновляются
+	// уже известные записи: чужие общие записи в кэш не попадают.
+	if h.cached(recordType, key) {
+		if err := h.gophKeeper.GetCache().PutRecord(cache.Record{Type: recordType, Key: key, Data: body}); err != nil {
+			log.Printf("Ошибка записи в локальный кэш: %v", err)
+		}
+	}
+	return body, nil
+}
+
+// cached сообщает, есть ли запись в локальном кэше.
+func (h *Handlers) cached(recordType, key string) bool {
+	vault := h.gophKeeper.GetCache()
+	if vault == nil {
+		return false
+	}
+	_, err := vault.GetRecord(recordType, key)
+	return err == nil
+}
+
+// create отправляет новую запись на сервер и возвращает её ключ.
+// Если сервер недоступен, запись сохраняется в кэше под временным ключом и ставится в очередь.
+func (h *Handlers) create(recordType string, body []byte) (string, error) {
+	status, respBody, err := h.request(http.MethodPost, "/api/data/"+recordType, body)
+	if errors.Is(err, errOffline) && h.vault == "" {
+		vault := h.gophKeeper.GetCache()
+		if vault == nil {
+			return "", fmt.Errorf("локальный кэш не открыт, выполните вход командой aut")
+		}
+
+		key := uuid.NewString()
+		if err := vault.PutRecord(cache.Record{Type: recordType, Key: key, Data: body, Pending: true}); err != nil {
+			return "", err
+		}
+		if _, err := vault.Enqueue(cache.Operation{Action: cache.ActionCreate, Type: recordType, Key: key, Body: body}); err != nil {
+			return "", err
+		}
+		fmt.Println("Сервер недоступен, запись сохранена локально и будет отправлена позже")
+		return key, nil
+	}
+	if err != nil {
+		return "", err
+	}
+	if status != http.StatusCreated {
+		return "", statusError(status, respBody)
+	}
+
+	key, err := h.gophKeeper.ParseKey(recordType, respBody)
+	if err != nil {
+		return "", err
+	}
+	h.cacheSent(recordType, key, body, respBody)
+	return key, nil
+}
+
+// update отправляет новое содержимое записи на сервер вместе с ревизией, на которой основано изменение.
+// Если сервер недоступен, изменение сохраняется в кэше и ставится в очередь.
+// Если сервер отклонил отложенное создание или изменение записи, новое содержимое заменяет его.
+func (h *Handlers) update(recordType, key string, body []byte) error {
+	body, err := h.gophKeeper.SetRevision(body, h.baseRevision(recordType, key))
+	if err != nil {
+		return err
+	}
+
+	if vault := h.gophKeeper.GetCache(); vault != nil && h.vault == "" {
+		rejected, err := h.findRejected(vault, recordType, key)
+		if err != nil {
+			return err
+		}
+		if rejected != nil && rejected.Action != cache.ActionDelete {
+			return h.resubmit(vault, *rejected, body)
+		}
+	}
+
+	status, respBody, err := h.request(http.MethodPut, dataPath(recordType, key), body)
+	if errors.Is(err, errOffline) && h.vault == "" {
+		vault := h.gophKeeper.GetCache()
+		if vault == nil {
+			return fmt.Errorf("локальный кэш не открыт, выполните вход командой aut")
+		}
+
+		// Если запись ещё не создана на сервере, достаточно заменить тело отложенного создания.
+		queued, err := h.findCreate(vault, recordType, key)
+		if err != nil {
+			return err
+		}
+		if queued != nil {
+			queued.Body = body
+			err = vault.UpdateOperation(*queued)
+		} else {
+			_, err = vault.Enqueue(cache.Operation{Action: cache.ActionUpdate, Type: recordType, Key: key, Body: body})
+		}
+		if err != nil {
+			return err
+		}
+
+		fmt.Println("Сервер недоступен, изменение будет отправлено позже")
+		return vault.PutRecord(cache.Record{Type: recordType, Key: key, Data: body, Pending: true})
+	}
+	if err != nil {
+		return err
+	}
+	if status == http.StatusConflict {
+		return errConflict
+	}
+	if status != http.StatusOK {
+		return statusError(status, respBody)
+	}
+
+	if h.cached(recordType, key) {
+		h.cacheSent(recordType, key, body, respBody)
+	}
+	return nil
+}
+
+// baseRevision возвращает ревизию записи, известную клиенту.
+// Если кэш не открыт или записи в нём нет, она предварительно запрашивается у сервера.
+func (h *Handlers) baseRevision(recordType, key string) int64 {
+	if vault := h.gophKeeper.GetCache(); vault != nil {
+		record, err := vault.GetRecord(recordType, key)
+		if err == nil {
+			return h.gophKeeper.GetRevision(record.Data)
+		}
+		if !errors.Is(err, cache.ErrNotFound) {
+			return 0
+		}
+	}
+
+	data, err := h.fetch(recordType, key)
+	if err != nil {
+		return 0
+	}
+	return h.gophKeeper.GetRevision(data)
+}
+
+// cacheSent сохраняет в кэш отправленную на сервер запись, дополненную полями из ответа сервера.
+// Записи хранилищ организаций не кэшируются.
+func (h *Handlers) cacheSent(recordType, key string, body, respBody []byte) {
+	vault := h.gophKeeper.GetCache()
+	if vault == nil || h.vault != "" {
+		return
+	}
+
+	data, err := h.gophKeeper.MergeResponse(body, respBody)
+	if err == nil {
+		err = vault.PutRecord(cache.Record{Type: recordType, Key: key, Data: data})
+	}
+	if err != nil {
+		log.Printf("Ошибка записи в локальный кэш: %v", err)
+	}
+}
+
+// remove удаляет запись на сервере и в кэше.
+// Если сервер недоступен, удаление ставится в очередь.
+func (h *Handlers) remove(recordType, key string) error {
+	// Создание, отклонённое сервером, отменяется локально: на сервере записи нет.
+	if vault := h.gophKeeper.GetCache(); vault != nil && h.vault == "" {
+		rejected, err := h.findRejected(vault, recordType, key)
+		if err != nil {
+			return err
+		}
+		if rejected != nil && rejected.Action == cache.ActionCreate {
+			return h.dropQueued(vault, recordType, key)
+		}
+	}
+
+	status, _, err := h.request(http.MethodDelete, dataPath(recordType, key), nil)
+	if errors.Is(err, errOffline) && h.vault == "" {
+		vault := h.gophKeeper.GetCache()
+		if vault == nil {
+			return fmt.Errorf("локальный кэш не открыт, выполните вход командой aut")
+		}
+
+		// Запись ещё не попала на сервер: достаточно отменить её создание.
+		queued, err := h.findCreate(vault, recordType, key)
+		if err != nil {
+			return err
+		}
+		if queued != nil {
+			if err := vault.Dequeue(queued.ID); err != nil {
+				return err
+			}
+			return vault.DeleteRecord(recordType, key)
+		}
+
+		if _, err := vault.Enqueue(cache.Operation{Action: cache.ActionDelete, Type: recordType, Key: key}); err != nil {
+			return err
+		}
+		fmt.Println("Сервер недоступен, удаление будет выполнено позже")
+		return vault.DeleteRecord(recordType, key)
+	}
+	if err != nil {
+		return err
+	}
+	if status != http.StatusOK {
+		return fmt.Errorf("сервер вернул ошибочный статус: %d %s", status, http.StatusText(status))
+	}
+
+	if vault := h.gophKeeper.GetCache(); vault != nil {
+		// Отклонённые изменения удалённой записи больше не нужны.
+		return h.dropQueued(vault, recordType, key)
+	}
+	return nil
+}
+
+// findCreate ищет в очереди отложенное создание записи. Возвращает nil, если его нет.
+func (h *Handlers) findCreate(vault *cache.Vault, recordType, key string) (*cache.Operation, error) {
+	ops, err := vault.Pending()
+	if err != nil {
+		return nil, err
+	}
+
+	for _, op := range ops {
+		if op.Action == cache.ActionCreate && op.Type == recordType && op.Key == key {
+			return &op, nil
+		}
+	}
+	return nil, nil
+}
+
+// findRejected ищет в очереди операцию над записью, отклонённую сервером. Возвращает nil, если её нет.
+func (h *Handlers) findRejected(vault *cache.Vault, recordType, key string) (*cache.Operation, error) {
+	ops, err := vault.Pending()
+	if err != nil {
+		return nil, err
+	}
+
+	for _, op := range ops {
+		if op.Rejected != "" && op.Type == recordType && op.Key == key {
+			return &op, nil
+		}
+	}
+	return nil, nil
+}
+
+// resubmit заменяет тело отклонённой сервером операции исправленным и повторяет отправку очереди.
+// Если сервер снова отклоняет операцию, возвращается причина отказа.
+func (h *Handlers) resubmit(vault *cache.Vault, op cache.Operation, body []byte) error {
+	op.Body, op.Rejected = body, ""
+	if err := vault.UpdateOperation(op); err != nil {
+		return err
+	}
+	if err := vault.PutRecord(cache.Record{Type: op.Type, Key: op.Key, Data: body, Pending: true}); err != nil {
+		return err
+	}
+
+	h.replay()
+
+	ops, err := vault.Pending()
+	if err != nil {
+		return err
+	}
+	for _, queued := range ops {
+		if queued.ID != op.ID {
+			continue
+		}
+		if queued.Rejected != "" {
+			return errors.New(queued.Rejected)
+		}
+		fmt.Println("Сервер недоступен, изменение будет отправлено позже")
+	}
+	return nil
+}
+
+// dropQueued удаляет запись из кэша вместе со всеми операциями над ней в очереди.
+func (h *Handlers) dropQueued(vault *cache.Vault, recordType, key string) error {
+	ops, err := vault.Pending()
+	if err != nil {
+		return err
+	}
+
+	for _, op := range ops {
+		if op.Type != recordType || op.Key != key {
+			continue
+		}
+		if err := vault.Dequeue(op.ID); err != nil {
+			return err
+		}
+	}
+	return vault.DeleteRecord(recordType, key)
+}
+
+// replay отправляет на сервер изменения, накопленные без подключения.
+// Выполняется перед каждой командой; при недоступности сервера очередь остаётся нетронутой.
+// Операция, которую сервер отклонил, остаётся в очереди с причиной отказа, а запись в кэше —
+// с отметкой о неотправленных изменениях: их можно исправить командой изменения записи,
+// отправить повторно (queue retry) или отменить (queue discard). Следующие операции над той же
+// записью не отправляются, пока отклонённая операция в очереди.
+func (h *Handlers) replay() {
+	vault := h.gophKeeper.GetCache()
+	if h.offline || vault == nil || h.gophKeeper.GetCookie() == nil {
+		return
+	}
+
+	ops, err := vault.Pending()
+	if err != nil {
+		log.Printf("Ошибка чтения очереди: %v", err)
+		return
+	}
+
+	blocked := make(map[string]bool)
+	for _, op := range ops {
+		record := op.Type + "/" + op.Key
+		if op.Rejected != "" || blocked[record] {
+			blocked[record] = true
+			continue
+		}
+
+		var (
+			status int
+			body   []byte
+		)
+		switch op.Action {
+		case cache.ActionCreate:
+			status, body, err = h.send(http.MethodPost, "/api/data/"+op.Type, op.Body, "")
+		case cache.ActionUpdate:
+			status, body, err = h.send(http.MethodPut, dataPath(op.Type, op.Key), op.Body, "")
+		case cache.ActionDelete:
+			status, body, err = h.send(http.MethodDelete, dataPath(op.Type, op.Key), nil, "")
+		}
+		if errors.Is(err, errOffline) {
+			return
+		}
+		if err != nil {
+			log.Printf("Ошибка отправки отложенной операции: %v", err)
+			return
+		}
+
+		switch {
+		case op.Action == cache.ActionCreate && status == http.StatusCreated:
+			key, err := h.gophKeeper.ParseKey(op.Type, body)
+			if err != nil {
+				log.Printf("%v", err)
+				return
+			}
+			if err := vault.RemapKey(op.Type, op.Key, key); err != nil && !errors.Is(err, cache.ErrNotFound) {
+				log.Printf("Ошибка записи в локальный кэш: %v", err)
+			}
+			h.cacheSent(op.Type, key, op.Body, body)
+			fmt.Printf("Запись %s отправлена на сервер, новый ключ: %s\n", op.Key, key)
+		case op.Action == cache.ActionUpdate && status == http.StatusOK:
+			h.cacheSent(op.Type, op.Key, op.Body, body)
+			fmt.Printf("Изменение записи %s отправлено на сервер\n", op.Key)
+		case op.Action == cache.ActionUpdate && status == http.StatusConflict:
+			// Копия сохранена на сервере; локальная запись снова следует версии сервера.
+			if record, err := vault.GetRecord(op.Type, op.Key); err == nil {
+				record.Pending = false
+				if err := vault.PutRecord(record); err != nil {
+					log.Printf("Ошибка записи в локальный кэш: %v", err)
+				}
+			}
+			fmt.Printf("Запись %s изменена на другом устройстве: %v\n", op.Key, errConflict)
+		case op.Action == cache.ActionDelete && status == http.StatusOK:
+			fmt.Printf("Запись %s удалена на сервере\n", op.Key)
+		default:
+			op.Rejected = statusError(status, body).Error()
+			if err := vault.UpdateOperation(op); err != nil {
+				log.Printf("Ошибка записи в локальный кэш: %v", err)
+				return
+			}
+			blocked[record] = true
+			fmt.Printf("Сервер отклонил отложенную операцию #%d %s %s %s: %s\n", op.ID, op.Action, op.Type, op.Key, op.Rejected)
+			fmt.Println("Исправьте запись командой изменения, повторите операцию (queue retry) или отмените её (queue discard)")
+			continue
+		}
+
+		if err := vault.Dequeue(op.ID); err != nil {
+			log.Printf("Ошибка чтения очереди: %v", err)
+			return
+		}
+	}
+}
+
+// ListRecords выводит записи, сохранённые в локальном кэше.
+func (h *Handlers) ListRecords() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "list",
+		Short: "Список записей из локального кэша",
+		Run: func(cmd *cobra.Command, args []string) {
+			if h.vault != "" {
+				h.listVault()
+				return
+			}
+
+			vault := h.gophKeeper.GetCache()
+			if vault == nil {
+				fmt.Println("Локальный кэш не открыт, выполните вход командой aut")
+				return
+			}
+
+			records, err := vault.Records()
+			if err != nil {
+				log.Printf("%v", err)
+				return
+			}
+			sort.Slice(records, func(i, j int) bool {
+				if records[i].Type != records[j].Type {
+					return records[i].Type < records[j].Type
+				}
+				return records[i].Key < records[j].Key
+			})
+
+			for _, record := range records {
+				line := fmt.Sprintf("%-7s %s  %s", record.Type, record.Key, h.gophKeeper.Describe(record.Type, record.Data))
+				if record.Pending {
+					line += "  [не отправлено]"
+				}
+				fmt.Println(line)
+			}
+
+			h.listShared()
+		},
+	}
+
+	return cmd
+}
+
+// Status выводит режим работы клиента и список операций, ожидающих отправки.
+func (h *Handlers) Status() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "status",
+		Short: "Состояние подключения и очереди отложенных операций",
+		Run: func(cmd *cobra.Command, args []string) {
+			mode := "online"
+			if h.offline {
+				mode = "offline"
+			}
+			fmt.Println("Режим:", mode)
+
+			vault := h.gophKeeper.GetCache()
+			if vault == nil {
+				fmt.Println("Локальный кэш не открыт, выполните вход командой aut")
+				return
+			}
+			fmt.Println("Пользователь:", h.gophKeeper.GetLogin())
+
+			ops, err := vault.Pending()
+			if err != nil {
+				log.Printf("%v", err)
+				return
+			}
+			if len(ops) == 0 {
+				fmt.Println("Нет операций, ожидающих отправки")
+				return
+			}
+
+			fmt.Printf("Операций, ожидающих отправки: %d\n", len(ops))
+			for _, op := range ops {
+				fmt.Printf("  #%d %s %s %s (%s)\n", op.ID, op.Action, op.Type, op.Key, op.CreatedAt.Format("2006-01-02 15:04:05"))
+				if op.Rejected != "" {
+					fmt.Printf("    отклонена сервером: %s\n", op.Rejected)
+				}
+			}
+		},
+	}
+
+	return cmd
+}
+
+// Queue объединяет команды работы с операциями, которые сервер отклонил при отправке очереди.
+func (h *Handlers) Queue() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "queue",
+		Short: "Отклонённые сервером отложенные операции",
+	}
+
+	cmd.AddCommand(
+		h.queueRetry(),
+		h.queueDiscard(),
+	)
+	return cmd
+}
+
+func (h *Handlers) queueRetry() *cobra.Command {
+	var id uint64
+	cmd := &cobra.Command{
+		Use:   "retry",
+		Short: "Повторная отправка отклонённой операции",
+		Run: func(cmd *cobra.Command, args []string) {
+			vault, op, err := h.rejectedOperation(id)
+			if err != nil {
+				log.Printf("%v", err)
+				return
+			}
+
+			op.Rejected = ""
+			if err := vault.UpdateOperation(op); err != nil {
+				log.Printf("%v", err)
+				return
+			}
+			h.replay()
+		},
+	}
+
+	cmd.Flags().Uint64Var(&id, "id", 0, "Номер операции из вывода status")
+	return cmd
+}
+
+func (h *Handlers) queueDiscard() *cobra.Command {
+	var id uint64
+	cmd := &cobra.Command{
+		Use:   "discard",
+		Short: "Отмена отклонённой операции",
+		Long: "Отмена отклонённой операции: неотправленное создание удаляет запись из кэша,\n" +
+			"а после отмены изменения или удаления в кэш загружается версия сервера.",
+		Run: func(cmd *cobra.Command, args []string) {
+			vault, op, err := h.rejectedOperation(id)
+			if err != nil {
+				log.Printf("%v", err)
+				return
+			}
+
+			if op.Action == cache.ActionCreate {
+				if err := h.dropQueued(vault, op.Type, op.Key); err != nil {
+					log.Printf("%v", err)
+					return
+				}
+				fmt.Println("Создание записи отменено")
+				return
+			}
+
+			if err := vault.Dequeue(op.ID); err != nil {
+				log.Printf("%v", err)
+				return
+			}
+			fmt.Println("Операция отменена")
+
+			status, body, err := h.send(http.MethodGet, dataPath(op.Type, op.Key), nil, "")
+			switch {
+			case err != nil:
+				fmt.Println("Версия сервера будет загружена при следующей синхронизации (sync)")
+			case status == http.StatusOK:
+				err = vault.PutRecord(cache.Record{Type: op.Type, Key: op.Key, Data: body})
+			case status == http.StatusNotFound:
+				err = vault.DeleteRecord(op.Type, op.Key)
+			}
+			if err != nil && !errors.Is(err, errOffline) {
+				log.Printf("Ошибка записи в локальный кэш: %v", err)
+			}
+		},
+	}
+
+	cmd.Flags().Uint64Var(&id, "id", 0, "Номер операции из вывода status")
+	return cmd
+}
+
+// rejectedOperation возвращает открытый кэш и отклонённую сервером операцию с номером id.
+func (h *Handlers) rejectedOperation(id uint64) (*cache.Vault, cache.Operation, error) {
+	vault := h.gophKeeper.GetCache()
+	if vault == nil {
+		return nil, cache.Operation{}, errors.New("локальный кэш не открыт, выполните вход командой aut")
+	}
+
+	ops, err := vault.Pending()
+	if err != nil {
+		return nil, cache.Operation{}, err
+	}
+	for _, op := range ops {
+		if op.ID == id && op.Rejected != "" {
+			return vault, op, nil
+		}
+	}
+	return nil, cache.Operation{}, fmt.Errorf("отклонённая операция #%d не найдена, список операций выводит status", id)
+}
+
+// dataPath возвращает путь к записи заданного типа на сервере.
+func dataPath(recordType, key string) string {
+	return fmt.Sprintf("/api/data/%s/%s", recordType, url.PathEscape(key))
+}
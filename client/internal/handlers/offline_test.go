@@ -0,0 +1,211 @@
+package handlers
+
+import (
+	"client/internal/cache"
+	"client/internal/config"
+	"client/internal/service"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// testServer имитирует сервер: отклоняет тела со словами invalid (422) и forbidden (403),
+// отвечает конфликтом на тела со словом stale и принимает остальные.
+type testServer struct {
+	requests []string // метод и путь полученных запросов
+	created  int
+}
+
+func (s *testServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, _ := io.ReadAll(r.Body)
+	s.requests = append(s.requests, r.Method+" "+r.URL.Path)
+
+	switch {
+	case strings.Contains(string(body), "invalid"):
+		w.WriteHeader(http.StatusUnprocessableEntity)
+		w.Write([]byte(`{"errors": [{"field": "data", "message": "must not be invalid"}]}`))
+	case strings.Contains(string(body), "forbidden"):
+		w.WriteHeader(http.StatusForbidden)
+	case strings.Contains(string(body), "stale"):
+		w.WriteHeader(http.StatusConflict)
+	case r.Method == http.MethodPost:
+		s.created++
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte(`{"data_text_key": "00000000-0000-0000-0000-00000000000` + strconv.Itoa(s.created) + `", "revision": 1}`))
+	default:
+		w.Write([]byte(`{"revision": 2}`))
+	}
+}
+
+// newOfflineHandlers возвращает обработчики, подключённые к srv, с открытым локальным кэшем.
+func newOfflineHandlers(t *testing.T, srv *testServer) (*Handlers, *cache.Vault) {
+	t.Helper()
+	server := httptest.NewServer(srv)
+	t.Cleanup(server.Close)
+
+	gk := service.NewGophKeeperClient()
+	h, err := NewHandlers(gk, config.Config{Listen: server.URL})
+	if err != nil {
+		t.Fatalf("NewHandlers: %v", err)
+	}
+
+	vault, err := cache.Open(filepath.Join(t.TempDir(), "cache.db"), "password")
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	t.Cleanup(func() { vault.Close() })
+	gk.SetCache(vault)
+	gk.SetCookie(&http.Cookie{Name: "user", Value: "token"})
+	return h, vault
+}
+
+// enqueue сохраняет запись в кэше и ставит операции над ней в очередь, как это делает клиент без сети.
+func enqueue(t *testing.T, vault *cache.Vault, key string, ops ...cache.Operation) {
+	t.Helper()
+	for _, op := range ops {
+		op.Type, op.Key = "text", key
+		if _, err := vault.Enqueue(op); err != nil {
+			t.Fatalf("Enqueue: %v", err)
+		}
+		if err := vault.PutRecord(cache.Record{Type: "text", Key: key, Data: op.Body, Pending: true}); err != nil {
+			t.Fatalf("PutRecord: %v", err)
+		}
+	}
+}
+
+func TestReplayRejected(t *testing.T) {
+	const key = "11111111-1111-1111-1111-111111111111"
+
+	tests := []struct {
+		name         string
+		ops          []cache.Operation
+		wantRequests []string
+		wantQueue    []string // причины отказа оставшихся в очереди операций; пустая — не отправлена
+		wantPending  bool
+	}{
+		{
+			name:         "rejected create keeps record under temporary key",
+			ops:          []cache.Operation{{Action: cache.ActionCreate, Body: json.RawMessage(`{"data": "invalid"}`)}},
+			wantRequests: []string{"POST /api/data/text"},
+			wantQueue:    []string{"сервер отклонил данные: data: must not be invalid"},
+			wantPending:  true,
+		},
+		{
+			name: "rejected update holds back later updates of the record",
+			ops: []cache.Operation{
+				{Action: cache.ActionUpdate, Body: json.RawMessage(`{"data": "forbidden", "revision": 1}`)},
+				{Action: cache.ActionUpdate, Body: json.RawMessage(`{"data": "later", "revision": 1}`)},
+			},
+			wantRequests: []string{"PUT /api/data/text/" + key},
+			wantQueue:    []string{"сервер вернул ошибочный статус: 403 Forbidden", ""},
+			wantPending:  true,
+		},
+		{
+			name:         "conflict is kept on the server",
+			ops:          []cache.Operation{{Action: cache.ActionUpdate, Body: json.RawMessage(`{"data": "stale", "revision": 1}`)}},
+			wantRequests: []string{"PUT /api/data/text/" + key},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := &testServer{}
+			h, vault := newOfflineHandlers(t, srv)
+			enqueue(t, vault, key, tt.ops...)
+
+			h.replay()
+			// Отклонённые операции не отправляются повторно при каждой команде.
+			h.replay()
+
+			if strings.Join(srv.requests, ", ") != strings.Join(tt.wantRequests, ", ") {
+				t.Errorf("requests = %v, want %v", srv.requests, tt.wantRequests)
+			}
+
+			ops, err := vault.Pending()
+			if err != nil {
+				t.Fatalf("Pending: %v", err)
+			}
+			if len(ops) != len(tt.wantQueue) {
+				t.Fatalf("queue has %d operations, want %d", len(ops), len(tt.wantQueue))
+			}
+			for i, op := range ops {
+				if op.Rejected != tt.wantQueue[i] {
+					t.Errorf("operation #%d rejected = %q, want %q", op.ID, op.Rejected, tt.wantQueue[i])
+				}
+			}
+
+			record, err := vault.GetRecord("text", key)
+			if err != nil {
+				t.Fatalf("GetRecord: %v", err)
+			}
+			if record.Pending != tt.wantPending {
+				t.Errorf("record pending = %v, want %v", record.Pending, tt.wantPending)
+			}
+		})
+	}
+}
+
+func TestRejectedCreateFixed(t *testing.T) {
+	const key = "11111111-1111-1111-1111-111111111111"
+
+	tests := []struct {
+		name   string
+		fix    func(h *Handlers) error
+		wantOK bool // true — запись создана на сервере, false — создание отменено
+	}{
+		{name: "edit record", fix: func(h *Handlers) error {
+			return h.update("text", key, []byte(`{"data": "fixed"}`))
+		}, wantOK: true},
+		{name: "discard", fix: func(h *Handlers) error {
+			cmd := h.queueDiscard()
+			cmd.SetArgs([]string{"--id", "1"})
+			return cmd.Execute()
+		}},
+		{name: "delete record", fix: func(h *Handlers) error {
+			return h.remove("text", key)
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := &testServer{}
+			h, vault := newOfflineHandlers(t, srv)
+			enqueue(t, vault, key, cache.Operation{Action: cache.ActionCreate, Body: json.RawMessage(`{"data": "invalid"}`)})
+			h.replay()
+
+			if err := tt.fix(h); err != nil {
+				t.Fatalf("fix: %v", err)
+			}
+
+			ops, err := vault.Pending()
+			if err != nil {
+				t.Fatalf("Pending: %v", err)
+			}
+			if len(ops) != 0 {
+				t.Errorf("queue = %+v, want empty", ops)
+			}
+			if _, err := vault.GetRecord("text", key); err == nil {
+				t.Error("record is still cached under the temporary key")
+			}
+
+			records, err := vault.Records()
+			if err != nil {
+				t.Fatalf("Records: %v", err)
+			}
+			if !tt.wantOK {
+				if len(records) != 0 || srv.created != 0 {
+					t.Errorf("records = %+v, created = %d, want none", records, srv.created)
+				}
+				return
+			}
+			if len(records) != 1 || records[0].Key != "00000000-0000-0000-0000-000000000001" || records[0].Pending {
+				t.Errorf("records = %+v, want one sent record under the server key", records)
+			}
+		})
+	}
+}
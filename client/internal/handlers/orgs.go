@@ -0,0 +1,314 @@
+package handlers
+
+import (
+	"client/internal/model"
+	"encoding/json"
+	"fmt"
+	"github.com/google/uuid"
+	"github.com/spf13/cobra"
+	"log"
+	"net/http"
+	"net/url"
+)
+
+// vaultHeader задаёт хранилище организации, в котором выполняется запрос к записям.
+const vaultHeader = "X-Vault-Key"
+
+// Org объединяет команды управления организациями.
+func (h *Handlers) Org() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "org",
+		Short: "Организации и их участники",
+	}
+
+	cmd.AddCommand(
+		h.orgCreate(),
+		h.orgList(),
+		h.orgInvite(),
+		h.orgMembers(),
+		h.orgRemove(),
+	)
+	return cmd
+}
+
+// Vault объединяет команды работы с хранилищами организаций.
+func (h *Handlers) Vault() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "vault",
+		Short: "Хранилища организаций",
+	}
+
+	cmd.AddCommand(
+		h.vaultCreate(),
+		h.vaultList(),
+		h.vaultUse(),
+	)
+	return cmd
+}
+
+func (h *Handlers) orgCreate() *cobra.Command {
+	var name string
+	cmd := &cobra.Command{
+		Use:   "create",
+		Short: "Создание организации",
+		Run: func(cmd *cobra.Command, args []string) {
+			var org model.Org
+			if err := h.call(http.MethodPost, "/api/orgs", model.Org{Name: name}, http.StatusCreated, &org); err != nil {
+				log.Printf("%v", err)
+				return
+			}
+
+			fmt.Println("Организация создана, ключ:", org.OrgKey)
+		},
+	}
+
+	cmd.Flags().StringVar(&name, "name", "", "Название организации")
+	cmd.MarkFlagRequired("name")
+	return cmd
+}
+
+func (h *Handlers) orgList() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "list",
+		Short: "Организации пользователя",
+		Run: func(cmd *cobra.Command, args []string) {
+			var orgs []model.Org
+			if err := h.call(http.MethodGet, "/api/orgs", nil, http.StatusOK, &orgs); err != nil {
+				log.Printf("%v", err)
+				return
+			}
+			if len(orgs) == 0 {
+				fmt.Println("Вы не состоите в организациях")
+				return
+			}
+
+			for _, org := range orgs {
+				fmt.Printf("%s  %-20s %s\n", org.OrgKey, org.Name, org.Role)
+			}
+		},
+	}
+
+	return cmd
+}
+
+func (h *Handlers) orgInvite() *cobra.Command {
+	var (
+		id     string
+		member model.OrgMember
+	)
+	cmd := &cobra.Command{
+		Use:   "invite",
+		Short: "Приглашение участника или смена его роли",
+		Run: func(cmd *cobra.Command, args []string) {
+			if _, err := uuid.Parse(id); err != nil {
+				log.Printf("UUID Parser: %v", err)
+				return
+			}
+
+			if err := h.call(http.MethodPost, "/api/orgs/"+id+"/members", member, http.StatusCreated, nil); err != nil {
+				log.Printf("%v", err)
+				return
+			}
+
+			fmt.Printf("Пользователь %s добавлен с ролью %s\n", member.Login, member.Role)
+		},
+	}
+
+	cmd.Flags().StringVar(&id, "org", "", "UUID организации")
+	cmd.Flags().StringVar(&member.Login, "login", "", "Логин пользователя")
+	cmd.Flags().StringVar(&member.Role, "role", model.RoleViewer, "Роль: owner, admin, editor или viewer")
+	cmd.MarkFlagRequired("org")
+	cmd.MarkFlagRequired("login")
+	return cmd
+}
+
+func (h *Handlers) orgMembers() *cobra.Command {
+	var id string
+	cmd := &cobra.Command{
+		Use:   "members",
+		Short: "Участники организации",
+		Run: func(cmd *cobra.Command, args []string) {
+			if _, err := uuid.Parse(id); err != nil {
+				log.Printf("UUID Parser: %v", err)
+				return
+			}
+
+			var members []model.OrgMember
+			if err := h.call(http.MethodGet, "/api/orgs/"+id+"/members", nil, http.StatusOK, &members); err != nil {
+				log.Printf("%v", err)
+				return
+			}
+
+			for _, member := range members {
+				fmt.Printf("%-20s %s\n", member.Login, member.Role)
+			}
+		},
+	}
+
+	cmd.Flags().StringVar(&id, "org", "", "UUID организации")
+	cmd.MarkFlagRequired("org")
+	return cmd
+}
+
+func (h *Handlers) orgRemove() *cobra.Command {
+	var (
+		id    string
+		login string
+	)
+	cmd := &cobra.Command{
+		Use:   "remove",
+		Short: "Исключение участника",
+		Run: func(cmd *cobra.Command, args []string) {
+			if _, err := uuid.Parse(id); err != nil {
+				log.Printf("UUID Parser: %v", err)
+				return
+			}
+
+			path := "/api/orgs/" + id + "/members/" + url.PathEscape(login)
+			if err := h.call(http.MethodDelete, path, nil, http.StatusOK, nil); err != nil {
+				log.Printf("%v", err)
+				return
+			}
+
+			fmt.Printf("Пользователь %s исключён\n", login)
+		},
+	}
+
+	cmd.Flags().StringVar(&id, "org", "", "UUID организации")
+	cmd.Flags().StringVar(&login, "login", "", "Логин участника")
+	cmd.MarkFlagRequired("org")
+	cmd.MarkFlagRequired("login")
+	return cmd
+}
+
+func (h *Handlers) vaultCreate() *cobra.Command {
+	var (
+		id   string
+		name string
+	)
+	cmd := &cobra.Command{
+		Use:   "create",
+		Short: "Создание хранилища организации",
+		Run: func(cmd *cobra.Command, args []string) {
+			if _, err := uuid.Parse(id); err != nil {
+				log.Printf("UUID Parser: %v", err)
+				return
+			}
+
+			var vault model.Vault
+			if err := h.call(http.MethodPost, "/api/orgs/"+id+"/vaults", model.Vault{Name: name}, http.StatusCreated, &vault); err != nil {
+				log.Printf("%v", err)
+				return
+			}
+
+			fmt.Println("Хранилище создано, ключ:", vault.VaultKey)
+		},
+	}
+
+	cmd.Flags().StringVar(&id, "org", "", "UUID организации")
+	cmd.Flags().StringVar(&name, "name", "", "Название хранилища")
+	cmd.MarkFlagRequired("org")
+	cmd.MarkFlagRequired("name")
+	return cmd
+}
+
+func (h *Handlers) vaultList() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "list",
+		Short: "Доступные хранилища",
+		Run: func(cmd *cobra.Command, args []string) {
+			var vaults []model.Vault
+			if err := h.call(http.MethodGet, "/api/vaults", nil, http.StatusOK, &vaults); err != nil {
+				log.Printf("%v", err)
+				return
+			}
+
+			for _, vault := range vaults {
+				mark := " "
+				if vault.VaultKey.String() == h.vault {
+					mark = "*"
+				}
+				fmt.Printf("%s %s  %s / %s  %s\n", mark, vault.VaultKey, vault.OrgName, vault.Name, vault.Role)
+			}
+		},
+	}
+
+	return cmd
+}
+
+func (h *Handlers) vaultUse() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "use [UUID хранилища | personal]",
+		Short: "Выбор активного хранилища",
+		Long:  "Команды работы с записями выполняются в выбранном хранилище. personal возвращает к личным записям.",
+		Args:  cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			if args[0] == "personal" {
+				h.vault = ""
+				fmt.Println("Активно личное хранилище")
+				return
+			}
+
+			if _, err := uuid.Parse(args[0]); err != nil {
+				log.Printf("UUID Parser: %v", err)
+				return
+			}
+
+			var vaults []model.Vault
+			if err := h.call(http.MethodGet, "/api/vaults", nil, http.StatusOK, &vaults); err != nil {
+				log.Printf("%v", err)
+				return
+			}
+			for _, vault := range vaults {
+				if vault.VaultKey.String() == args[0] {
+					h.vault = args[0]
+					fmt.Printf("Активно хранилище %s / %s (%s)\n", vault.OrgName, vault.Name, vault.Role)
+					return
+				}
+			}
+			fmt.Println("Хранилище не найдено")
+		},
+	}
+
+	return cmd
+}
+
+// listVault выводит записи активного хранилища организации. Они не кэшируются и запрашиваются у сервера.
+func (h *Handlers) listVault() {
+	var response model.SyncResponse
+	if err := h.call(http.MethodGet, "/api/sync?since=0", nil, http.StatusOK, &response); err != nil {
+		log.Printf("%v", err)
+		return
+	}
+
+	for _, change := range response.Changes {
+		if change.Action == model.ChangeDeleted {
+			continue
+		}
+		fmt.Printf("%-7s %s  %s\n", change.Type, change.Key, h.gophKeeper.Describe(change.Type, change.Data))
+	}
+}
+
+// call отправляет запрос с телом in в формате JSON, проверяет код ответа и разбирает ответ в out.
+func (h *Handlers) call(method, path string, in any, want int, out any) error {
+	var body []byte
+	if in != nil {
+		var err error
+		body, err = json.Marshal(in)
+		if err != nil {
+			return err
+		}
+	}
+
+	status, respBody, err := h.request(method, path, body)
+	if err != nil {
+		return err
+	}
+	if status != want {
+		return statusError(status, respBody)
+	}
+	if out == nil {
+		return nil
+	}
+	return json.Unmarshal(respBody, out)
+}
@@ -0,0 +1,99 @@
+package handlers
+
+import (
+	"fmt"
+	"github.com/spf13/cobra"
+	"log"
+	"net/http"
+	"time"
+)
+
+// Profile управляет профилями подключения к серверам.
+func (h *Handlers) Profile() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "profile",
+		Short: "Профили серверов",
+	}
+
+	cmd.AddCommand(
+		&cobra.Command{
+			Use:   "list",
+			Short: "Список профилей",
+			Run: func(cmd *cobra.Command, args []string) {
+				names := h.cnf.ProfileNames()
+				if len(names) == 0 {
+					fmt.Println("Профили не настроены, используется сервер", h.profile.Server)
+					return
+				}
+				for _, name := range names {
+					mark := " "
+					if name == h.cnf.Current {
+						mark = "*"
+					}
+					profile := h.cnf.Profiles[name]
+					fmt.Printf("%s %-16s %-40s %s\n", mark, name, profile.Server, profile.Login)
+				}
+			},
+		},
+		&cobra.Command{
+			Use:   "use [имя профиля]",
+			Short: "Выбор активного профиля",
+			Long:  "Переключает клиент на сервер профиля и сохраняет выбор в файле настроек. Сессия и кэш текущего профиля закрываются.",
+			Args:  cobra.ExactArgs(1),
+			Run: func(cmd *cobra.Command, args []string) {
+				if err := h.cnf.SaveCurrent(args[0]); err != nil {
+					log.Printf("%v", err)
+					return
+				}
+				if err := h.switchProfile(args[0]); err != nil {
+					log.Printf("%v", err)
+				}
+			},
+		},
+	)
+
+	return cmd
+}
+
+// switchProfile завершает работу с текущим сервером и подключает профиль name.
+// Сессия, кэш и фоновая подписка принадлежат прежнему серверу, поэтому закрываются.
+func (h *Handlers) switchProfile(name string) error {
+	if err := h.applyProfile(name); err != nil {
+		return err
+	}
+
+	h.stopWatch()
+	h.stopAgent()
+	h.gophKeeper.SetCookie(nil)
+	h.gophKeeper.SetCache(nil)
+	h.gophKeeper.SetLogin("")
+	h.gophKeeper.DisableE2E()
+
+	fmt.Printf("Активен профиль %s: %s\n", name, h.profile.Server)
+	h.CheckServer()
+	return nil
+}
+
+// applyProfile настраивает адрес сервера, TLS и хранилище по умолчанию из профиля name.
+func (h *Handlers) applyProfile(name string) error {
+	profile, err := h.cnf.Lookup(name)
+	if err != nil {
+		return err
+	}
+	tlsConfig, err := profile.TLS.Config()
+	if err != nil {
+		return fmt.Errorf("профиль %q: %w", name, err)
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.TLSClientConfig = tlsConfig
+
+	h.cnf.Current = name
+	h.profile = profile
+	h.vault = profile.Vault
+	h.client = &http.Client{
+		Timeout:   time.Second * 10,
+		Transport: transport,
+	}
+	return nil
+}
@@ -0,0 +1,181 @@
+package handlers
+
+import (
+	"client/internal/config"
+	"client/internal/service"
+	"encoding/pem"
+	"github.com/spf13/cobra"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// versionServer отвечает на проверку версии и запоминает число обращений.
+type versionServer struct {
+	mu    sync.Mutex
+	calls int
+}
+
+func (s *versionServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	s.calls++
+	s.mu.Unlock()
+	w.Header().Set("Content-Type", "application/json")
+	w.Write([]byte(`{"version": "test", "api_version": 1}`))
+}
+
+func (s *versionServer) count() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.calls
+}
+
+// writeCA сохраняет сертификат тестового TLS-сервера в PEM-файл.
+func writeCA(t *testing.T, server *httptest.Server) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "ca.pem")
+	block := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw})
+	if err := os.WriteFile(path, block, 0o600); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+	return path
+}
+
+func TestProfileSelection(t *testing.T) {
+	home := &versionServer{}
+	plain := httptest.NewServer(home)
+	defer plain.Close()
+	work := &versionServer{}
+	secure := httptest.NewTLSServer(work)
+	defer secure.Close()
+	ca := writeCA(t, secure)
+
+	cnf := config.Config{
+		Listen: plain.URL,
+		Login:  "default-user",
+		Profiles: map[string]config.Profile{
+			"work": {Server: secure.URL, TLS: config.TLSSettings{CAFile: ca, ServerName: "example.com"}, Vault: "org-vault", Login: "worker"},
+			"lab":  {Server: secure.URL, TLS: config.TLSSettings{InsecureSkipVerify: true}},
+			"bare": {Server: secure.URL},
+		},
+	}
+
+	tests := []struct {
+		name       string
+		current    string
+		wantErr    bool
+		wantServer string
+		wantVault  string
+		wantLogin  string
+		wantOnline bool // проверка версии дошла до сервера профиля
+	}{
+		{name: "default profile uses top level settings", wantServer: plain.URL, wantLogin: "default-user", wantOnline: true},
+		{name: "profile with own CA and server name", current: "work", wantServer: secure.URL, wantVault: "org-vault", wantLogin: "worker", wantOnline: true},
+		{name: "profile without certificate check", current: "lab", wantServer: secure.URL, wantOnline: true},
+		{name: "profile without CA rejects server certificate", current: "bare", wantServer: secure.URL},
+		{name: "unknown profile", current: "missing", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cnf := cnf
+			cnf.Current = tt.current
+			h, err := NewHandlers(service.NewGophKeeperClient(), cnf)
+			if tt.wantErr {
+				if err == nil || !strings.Contains(err.Error(), tt.current) {
+					t.Fatalf("NewHandlers error = %v, want unknown profile %q", err, tt.current)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("NewHandlers: %v", err)
+			}
+
+			if h.profile.Server != tt.wantServer || h.vault != tt.wantVault || h.profile.Login != tt.wantLogin {
+				t.Errorf("profile = %+v, vault %q; want server %q, vault %q, login %q",
+					h.profile, h.vault, tt.wantServer, tt.wantVault, tt.wantLogin)
+			}
+
+			status, _, err := h.request(http.MethodGet, "/version", nil)
+			if online := err == nil && status == http.StatusOK; online != tt.wantOnline {
+				t.Errorf("request to profile server: status %d, error %v; want online %v", status, err, tt.wantOnline)
+			}
+		})
+	}
+}
+
+func TestProfileSwitch(t *testing.T) {
+	first := &versionServer{}
+	firstServer := httptest.NewServer(first)
+	defer firstServer.Close()
+	second := &versionServer{}
+	secondServer := httptest.NewServer(second)
+	defer secondServer.Close()
+
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	content := "listen: " + firstServer.URL + "\n" +
+		"profiles:\n" +
+		"  first:\n    server: " + firstServer.URL + "\n" +
+		"  second:\n    server: " + secondServer.URL + "\n    vault: second-vault\n"
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+
+	cnf := config.NewConfig("")
+	if err := cnf.ReadFile(path); err != nil {
+		t.Fatalf("ReadFile: %v", err)
+	}
+	if cnf.Current != "" || cnf.Listen != firstServer.URL {
+		t.Fatalf("config = %+v, want default profile with listen from file", cnf)
+	}
+
+	gk := service.NewGophKeeperClient()
+	h, err := NewHandlers(gk, *cnf)
+	if err != nil {
+		t.Fatalf("NewHandlers: %v", err)
+	}
+	gk.SetCookie(&http.Cookie{Name: "user", Value: "first-session"})
+
+	var servers []string
+	h.cobra.AddCommand(h.Profile(), &cobra.Command{
+		Use: "where",
+		Run: func(cmd *cobra.Command, args []string) { servers = append(servers, h.profile.Server) },
+	})
+	run := func(args ...string) {
+		t.Helper()
+		h.cobra.SetArgs(args)
+		if err := h.cobra.Execute(); err != nil {
+			t.Fatalf("%v: %v", args, err)
+		}
+	}
+
+	// Флаг переключает профиль для этой и следующих команд, но не меняет файл настроек.
+	run("--profile", "second", "where")
+	run("where")
+	if want := []string{secondServer.URL, secondServer.URL}; strings.Join(servers, " ") != strings.Join(want, " ") {
+		t.Errorf("servers = %v, want %v", servers, want)
+	}
+	if h.vault != "second-vault" || gk.GetCookie() != nil {
+		t.Errorf("after switch vault = %q, cookie = %v; want profile vault and no session", h.vault, gk.GetCookie())
+	}
+	if first.count() != 0 || second.count() != 1 {
+		t.Errorf("version checks: first %d, second %d; want only the new server checked", first.count(), second.count())
+	}
+
+	// Команда use сохраняет выбор, и следующий запуск начинается с выбранного профиля.
+	run("profile", "use", "first")
+	saved := config.NewConfig("")
+	if err := saved.ReadFile(path); err != nil {
+		t.Fatalf("ReadFile: %v", err)
+	}
+	if saved.Current != "first" || saved.Profiles["second"].Vault != "second-vault" {
+		t.Errorf("saved config = %+v, want profile first and other settings kept", saved)
+	}
+	if h.profile.Server != firstServer.URL || h.vault != "" {
+		t.Errorf("profile = %+v, vault %q; want first profile", h.profile, h.vault)
+	}
+}
@@ -0,0 +1,42 @@
+package handlers
+
+import (
+	"client/internal/model"
+	"fmt"
+	"github.com/spf13/cobra"
+	"log"
+	"net/http"
+	"strconv"
+)
+
+// Expiring выводит записи пользователя или активного хранилища, срок действия которых скоро истекает.
+func (h *Handlers) Expiring() *cobra.Command {
+	var days int
+	cmd := &cobra.Command{
+		Use:   "expiring",
+		Short: "Записи, срок действия которых скоро истекает",
+		Run: func(cmd *cobra.Command, args []string) {
+			var records []model.ExpiringRecord
+			path := "/api/reports/expiring?days=" + strconv.Itoa(days)
+			if err := h.call(http.MethodGet, path, nil, http.StatusOK, &records); err != nil {
+				log.Printf("%v", err)
+				return
+			}
+			if len(records) == 0 {
+				fmt.Printf("В ближайшие %d дн. сроки не истекают\n", days)
+				return
+			}
+
+			for _, record := range records {
+				left := "сегодня"
+				if record.DaysLeft > 0 {
+					left = "через " + strconv.Itoa(record.DaysLeft) + " дн."
+				}
+				fmt.Printf("%s  %-12s %-7s %s  %s\n", record.ExpiresOn, left, record.Type, record.Key, record.Title)
+			}
+		},
+	}
+
+	cmd.Flags().IntVar(&days, "days", 30, "За сколько дней вперёд показывать истекающие записи")
+	return cmd
+}
@@ -0,0 +1,189 @@
+package handlers
+
+import (
+	"client/internal/model"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/google/uuid"
+	"github.com/spf13/cobra"
+	"log"
+	"net/http"
+	"net/url"
+)
+
+// Grant выдаёт другому пользователю доступ к записи.
+func (h *Handlers) Grant() *cobra.Command {
+	var (
+		recordType string
+		id         string
+		share      model.Share
+	)
+	cmd := &cobra.Command{
+		Use:   "grant",
+		Short: "Доступ к записи для другого пользователя",
+		Long: "Выдаёт доступ к записи. Записи с секретами, зашифрованными на клиенте (e2e),\n" +
+			"сервер не передаёт: у получателя нет ключа. Для них используйте одноразовую ссылку: команда share.",
+		Run: func(cmd *cobra.Command, args []string) {
+			if _, err := uuid.Parse(id); err != nil {
+				log.Printf("UUID Parser: %v", err)
+				return
+			}
+			if share.Permission != model.PermissionRead && share.Permission != model.PermissionWrite {
+				fmt.Println("Флаг --perm принимает значения read или write.")
+				return
+			}
+
+			body, err := json.Marshal(share)
+			if err != nil {
+				log.Printf("%v", err)
+				return
+			}
+
+			status, _, err := h.request(http.MethodPost, dataPath(recordType, id)+"/shares", body)
+			if err != nil {
+				log.Printf("%v", err)
+				return
+			}
+			if status != http.StatusCreated {
+				log.Printf("Ошибка: сервер вернул ошибочный статус: %d %s", status, http.StatusText(status))
+				return
+			}
+
+			fmt.Printf("Пользователю %s выдан доступ %s\n", share.Login, share.Permission)
+		},
+	}
+
+	cmd.Flags().StringVar(&recordType, "type", "", "Тип записи: text, binary, card, totp, ssh или custom")
+	cmd.Flags().StringVar(&id, "key", "", "UUID данных")
+	cmd.Flags().StringVar(&share.Login, "login", "", "Логин получателя")
+	cmd.Flags().StringVar(&share.Permission, "perm", model.PermissionRead, "Права получателя: read или write")
+	cmd.MarkFlagRequired("type")
+	cmd.MarkFlagRequired("key")
+	cmd.MarkFlagRequired("login")
+	return cmd
+}
+
+// Grants выводит получателей записи.
+func (h *Handlers) Grants() *cobra.Command {
+	var (
+		recordType string
+		id         string
+	)
+	cmd := &cobra.Command{
+		Use:   "grants",
+		Short: "Получатели записи",
+		Run: func(cmd *cobra.Command, args []string) {
+			if _, err := uuid.Parse(id); err != nil {
+				log.Printf("UUID Parser: %v", err)
+				return
+			}
+
+			status, body, err := h.request(http.MethodGet, dataPath(recordType, id)+"/shares", nil)
+			if err != nil {
+				log.Printf("%v", err)
+				return
+			}
+			if status != http.StatusOK {
+				log.Printf("Ошибка: сервер вернул ошибочный статус: %d %s", status, http.StatusText(status))
+				return
+			}
+
+			var shares []model.Share
+			if err := json.Unmarshal(body, &shares); err != nil {
+				log.Printf("%v", err)
+				return
+			}
+			if len(shares) == 0 {
+				fmt.Println("Доступ никому не выдан")
+				return
+			}
+
+			for _, share := range shares {
+				fmt.Printf("%-20s %-5s  с %s\n", share.Login, share.Permission, share.CreatedAt.Format("2006-01-02 15:04:05"))
+			}
+		},
+	}
+
+	cmd.Flags().StringVar(&recordType, "type", "", "Тип записи: text, binary, card, totp, ssh или custom")
+	cmd.Flags().StringVar(&id, "key", "", "UUID данных")
+	cmd.MarkFlagRequired("type")
+	cmd.MarkFlagRequired("key")
+	return cmd
+}
+
+// Revoke отзывает доступ получателя к записи.
+func (h *Handlers) Revoke() *cobra.Command {
+	var (
+		recordType string
+		id         string
+		login      string
+	)
+	cmd := &cobra.Command{
+		Use:   "revoke",
+		Short: "Отзыв доступа к записи",
+		Run: func(cmd *cobra.Command, args []string) {
+			if _, err := uuid.Parse(id); err != nil {
+				log.Printf("UUID Parser: %v", err)
+				return
+			}
+
+			status, _, err := h.request(http.MethodDelete, dataPath(recordType, id)+"/shares/"+url.PathEscape(login), nil)
+			if err != nil {
+				log.Printf("%v", err)
+				return
+			}
+			if status != http.StatusOK {
+				log.Printf("Ошибка: сервер вернул ошибочный статус: %d %s", status, http.StatusText(status))
+				return
+			}
+
+			fmt.Printf("Доступ пользователя %s отозван\n", login)
+		},
+	}
+
+	cmd.Flags().StringVar(&recordType, "type", "", "Тип записи: text, binary, card, totp, ssh или custom")
+	cmd.Flags().StringVar(&id, "key", "", "UUID данных")
+	cmd.Flags().StringVar(&login, "login", "", "Логин получателя")
+	cmd.MarkFlagRequired("type")
+	cmd.MarkFlagRequired("key")
+	cmd.MarkFlagRequired("login")
+	return cmd
+}
+
+// listShared выводит записи других пользователей, доступные текущему пользователю.
+// Общие записи не кэшируются, поэтому без сервера список не выводится.
+func (h *Handlers) listShared() {
+	status, body, err := h.request(http.MethodGet, "/api/shares", nil)
+	if errors.Is(err, errOffline) {
+		return
+	}
+	if err != nil {
+		log.Printf("%v", err)
+		return
+	}
+	if status != http.StatusOK {
+		log.Printf("Ошибка: сервер вернул ошибочный статус: %d %s", status, http.StatusText(status))
+		return
+	}
+
+	var records []model.SharedRecord
+	if err := json.Unmarshal(body, &records); err != nil {
+		log.Printf("%v", err)
+		return
+	}
+	if len(records) == 0 {
+		return
+	}
+
+	fmt.Println("Доступные мне записи:")
+	for _, record := range records {
+		fmt.Printf("%-7s %s  %s  [%s, %s]\n",
+			record.Type,
+			record.Key,
+			h.gophKeeper.Describe(record.Type, record.Data),
+			record.Owner,
+			record.Permission,
+		)
+	}
+}
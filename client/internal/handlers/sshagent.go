@@ -0,0 +1,190 @@
+package handlers
+
+import (
+	"client/internal/model"
+	"errors"
+	"fmt"
+	"github.com/spf13/cobra"
+	"golang.org/x/crypto/ssh/agent"
+	"log"
+	"net"
+	"os"
+	"path/filepath"
+)
+
+// SSHAgent запускает агент SSH на Unix-сокете. Ключи расшифровываются в память процесса
+// и не записываются на диск; ssh и git подключаются к агенту через SSH_AUTH_SOCK.
+func (h *Handlers) SSHAgent() *cobra.Command {
+	var (
+		socket string
+		keys   []string
+		stop   bool
+	)
+	cmd := &cobra.Command{
+		Use:   "ssh-agent",
+		Short: "Агент SSH с ключами из хранилища",
+		Long: "Запускает в фоне агент SSH с ключами из хранилища: выбранными флагом --key\n" +
+			"или всеми ключами из локального кэша. --stop останавливает агент.",
+		Run: func(cmd *cobra.Command, args []string) {
+			if stop {
+				if !h.stopAgent() {
+					fmt.Println("Агент не запущен")
+					return
+				}
+				fmt.Println("Агент остановлен")
+				return
+			}
+
+			keyring, count, err := h.agentKeyring(keys)
+			if err != nil {
+				log.Printf("%v", err)
+				return
+			}
+			if count == 0 {
+				fmt.Println("Нет ключей SSH: добавьте ключ командой addSSH или выполните sync")
+				return
+			}
+
+			path := socket
+			if path == "" {
+				path = filepath.Join(h.cnf.CacheDir, "agent.sock")
+			}
+			h.stopAgent()
+			listener, err := listenAgent(path)
+			if err != nil {
+				log.Printf("Ошибка запуска агента: %v", err)
+				return
+			}
+			h.agentStop = func() { listener.Close() }
+			go serveAgent(listener, keyring)
+
+			fmt.Printf("Агент запущен, ключей: %d\n", count)
+			fmt.Printf("export SSH_AUTH_SOCK=%s\n", path)
+		},
+	}
+
+	cmd.Flags().StringVar(&socket, "socket", "", "Путь к сокету агента (по умолчанию agent.sock в каталоге кэша)")
+	cmd.Flags().StringSliceVar(&keys, "key", nil, "UUID ключей SSH; по умолчанию все ключи из локального кэша")
+	cmd.Flags().BoolVar(&stop, "stop", false, "Остановить агент")
+	return cmd
+}
+
+// stopAgent останавливает агент SSH. Возвращает false, если агент не был запущен.
+func (h *Handlers) stopAgent() bool {
+	if h.agentStop == nil {
+		return false
+	}
+	h.agentStop()
+	h.agentStop = nil
+	return true
+}
+
+// agentKeyring загружает в связку агента ключи с указанными UUID или все ключи SSH из кэша.
+func (h *Handlers) agentKeyring(keys []string) (agent.Agent, int, error) {
+	var bodies [][]byte
+	if len(keys) > 0 {
+		for _, key := range keys {
+			body, err := h.fetch(model.RecordSSH, key)
+			if err != nil {
+				return nil, 0, fmt.Errorf("ключ %s: %w", key, err)
+			}
+			bodies = append(bodies, body)
+		}
+	} else {
+		vault := h.gophKeeper.GetCache()
+		if vault == nil {
+			return nil, 0, errors.New("локальный кэш не открыт, выполните вход командой aut")
+		}
+		records, err := vault.Records()
+		if err != nil {
+			return nil, 0, err
+		}
+		for _, record := range records {
+			if record.Type == model.RecordSSH {
+				bodies = append(bodies, record.Data)
+			}
+		}
+	}
+
+	keyring := agent.NewKeyring()
+	for _, body := range bodies {
+		key, err := h.gophKeeper.SSHAgentKey(body)
+		if err != nil {
+			return nil, 0, err
+		}
+		if err := keyring.Add(key); err != nil {
+			return nil, 0, err
+		}
+	}
+	return keyring, len(bodies), nil
+}
+
+// listenAgent открывает Unix-сокет, доступный только владельцу. Сокет создаётся
+// во временном каталоге с правами 0700, поэтому до смены прав к нему никто не подключится,
+// и затем переносится на место. Сокет, оставшийся от предыдущего запуска, удаляется;
+// другие файлы по этому пути не трогаются.
+func listenAgent(path string) (net.Listener, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return nil, err
+	}
+	if info, err := os.Lstat(path); err == nil {
+		if info.Mode()&os.ModeSocket == 0 {
+			return nil, fmt.Errorf("%s уже существует и не является сокетом", path)
+		}
+		if err := os.Remove(path); err != nil {
+			return nil, err
+		}
+	}
+
+	dir, err := os.MkdirTemp(filepath.Dir(path), ".agent-")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(dir)
+
+	private := filepath.Join(dir, "agent.sock")
+	listener, err := net.ListenUnix("unix", &net.UnixAddr{Name: private, Net: "unix"})
+	if err != nil {
+		return nil, err
+	}
+	// Файл сокета удаляет agentListener.Close: после переноса net его не найдёт.
+	listener.SetUnlinkOnClose(false)
+	if err := os.Chmod(private, 0o600); err != nil {
+		listener.Close()
+		return nil, err
+	}
+	if err := os.Rename(private, path); err != nil {
+		listener.Close()
+		return nil, err
+	}
+	return agentListener{UnixListener: listener, path: path}, nil
+}
+
+// agentListener удаляет файл сокета агента при закрытии.
+type agentListener struct {
+	*net.UnixListener
+	path string
+}
+
+func (l agentListener) Close() error {
+	err := l.UnixListener.Close()
+	os.Remove(l.path)
+	return err
+}
+
+// serveAgent обслуживает подключения по протоколу агента SSH до закрытия listener.
+func serveAgent(listener net.Listener, keyring agent.Agent) {
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			if !errors.Is(err, net.ErrClosed) {
+				log.Printf("Ошибка агента SSH: %v", err)
+			}
+			return
+		}
+		go func() {
+			defer conn.Close()
+			agent.ServeAgent(keyring, conn)
+		}()
+	}
+}
@@ -0,0 +1,98 @@
+package handlers
+
+import (
+	"client/internal/cache"
+	"client/internal/model"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/pem"
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/agent"
+	"net"
+	"os"
+	"path/filepath"
+	"regexp"
+	"testing"
+)
+
+// fingerprintFormat — отпечаток SHA256 в формате ssh-keygen -l.
+var fingerprintFormat = regexp.MustCompile(`^SHA256:[A-Za-z0-9+/]{43}$`)
+
+func TestSSHAgent(t *testing.T) {
+	h, vault := newOfflineHandlers(t, &testServer{})
+
+	_, private, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("GenerateKey: %v", err)
+	}
+	block, err := ssh.MarshalPrivateKey(private, "")
+	if err != nil {
+		t.Fatalf("MarshalPrivateKey: %v", err)
+	}
+	body, err := h.gophKeeper.CreateSSHKey(pem.EncodeToMemory(block), "", "")
+	if err != nil {
+		t.Fatalf("CreateSSHKey: %v", err)
+	}
+	if err := vault.PutRecord(cache.Record{Type: model.RecordSSH, Key: "11111111-1111-1111-1111-111111111111", Data: body}); err != nil {
+		t.Fatalf("PutRecord: %v", err)
+	}
+
+	keyring, count, err := h.agentKeyring(nil)
+	if err != nil || count != 1 {
+		t.Fatalf("agentKeyring: %d keys, %v", count, err)
+	}
+
+	// Каталог сокета доступен всем, как /tmp: права задаёт сам сокет.
+	dir := t.TempDir()
+	if err := os.Chmod(dir, 0o777); err != nil {
+		t.Fatalf("Chmod: %v", err)
+	}
+	path := filepath.Join(dir, "agent.sock")
+	listener, err := listenAgent(path)
+	if err != nil {
+		t.Fatalf("listenAgent: %v", err)
+	}
+	go serveAgent(listener, keyring)
+
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatalf("Stat: %v", err)
+	}
+	if info.Mode()&os.ModeSocket == 0 || info.Mode().Perm() != 0o600 {
+		t.Errorf("socket mode = %v, want socket with 0600", info.Mode())
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil || len(entries) != 1 {
+		t.Errorf("socket directory has %v, %v; want only the socket", entries, err)
+	}
+
+	conn, err := net.Dial("unix", path)
+	if err != nil {
+		t.Fatalf("Dial: %v", err)
+	}
+	keys, err := agent.NewClient(conn).List()
+	conn.Close()
+	if err != nil {
+		t.Fatalf("List: %v", err)
+	}
+	if len(keys) != 1 {
+		t.Fatalf("agent has %d keys, want 1", len(keys))
+	}
+
+	// Ключ без комментария подписан отпечатком, как его показывает ssh-add -l.
+	signer, err := ssh.NewSignerFromKey(private)
+	if err != nil {
+		t.Fatalf("NewSignerFromKey: %v", err)
+	}
+	fingerprint := ssh.FingerprintSHA256(keys[0])
+	if !fingerprintFormat.MatchString(keys[0].Comment) || keys[0].Comment != fingerprint || fingerprint != ssh.FingerprintSHA256(signer.PublicKey()) {
+		t.Errorf("listed key comment %q, fingerprint %q; want the SHA256 fingerprint of the stored key", keys[0].Comment, fingerprint)
+	}
+
+	if err := listener.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+	if _, err := os.Lstat(path); !os.IsNotExist(err) {
+		t.Errorf("socket file left after close: %v", err)
+	}
+}
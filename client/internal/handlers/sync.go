@@ -0,0 +1,91 @@
+package handlers
+
+import (
+	"client/internal/cache"
+	"client/internal/model"
+	"encoding/json"
+	"fmt"
+	"github.com/spf13/cobra"
+	"log"
+	"net/http"
+)
+
+// Sync загружает с сервера изменения, сделанные после последней синхронизации.
+func (h *Handlers) Sync() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "sync",
+		Short: "Синхронизация локального кэша с сервером",
+		Run: func(cmd *cobra.Command, args []string) {
+			count, err := h.syncLocked()
+			if err != nil {
+				log.Printf("Ошибка синхронизации: %v", err)
+				return
+			}
+
+			fmt.Printf("Получено изменений: %d\n", count)
+		},
+	}
+
+	return cmd
+}
+
+// syncLocked выполняет sync, не допуская одновременной синхронизации из фоновой подписки.
+func (h *Handlers) syncLocked() (int, error) {
+	h.syncMu.Lock()
+	defer h.syncMu.Unlock()
+	return h.sync()
+}
+
+// sync запрашивает ленту изменений после сохранённой ревизии и применяет её к кэшу.
+// Записи с неотправленными локальными изменениями не перезаписываются.
+func (h *Handlers) sync() (int, error) {
+	vault := h.gophKeeper.GetCache()
+	if vault == nil {
+		return 0, fmt.Errorf("локальный кэш не открыт, выполните вход командой aut")
+	}
+
+	since, err := vault.Revision()
+	if err != nil {
+		return 0, err
+	}
+
+	// Кэш хранит только личные записи, поэтому синхронизация не зависит от активного хранилища.
+	status, body, err := h.send(http.MethodGet, fmt.Sprintf("/api/sync?since=%d", since), nil, "")
+	if err != nil {
+		return 0, err
+	}
+	if status != http.StatusOK {
+		return 0, fmt.Errorf("сервер вернул ошибочный статус: %d %s", status, http.StatusText(status))
+	}
+
+	var response model.SyncResponse
+	if err := json.Unmarshal(body, &response); err != nil {
+		return 0, err
+	}
+
+	for _, change := range response.Changes {
+		key := change.Key.String()
+		if record, err := vault.GetRecord(change.Type, key); err == nil && record.Pending {
+			continue
+		}
+
+		if change.Action == model.ChangeDeleted {
+			err = vault.DeleteRecord(change.Type, key)
+		} else {
+			err = vault.PutRecord(cache.Record{
+				Type:      change.Type,
+				Key:       key,
+				Data:      change.Data,
+				UpdatedAt: change.UpdatedAt,
+			})
+		}
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	if err := vault.SetRevision(response.Revision); err != nil {
+		return 0, err
+	}
+	return len(response.Changes), nil
+}
@@ -0,0 +1,182 @@
+package handlers
+
+import (
+	"client/internal/model"
+	"client/internal/service"
+	"fmt"
+	"github.com/google/uuid"
+	"github.com/spf13/cobra"
+	"log"
+	"net/http"
+	"strings"
+)
+
+func (h *Handlers) CreateTemplate() *cobra.Command {
+	var (
+		name   string
+		fields []string
+		org    string
+	)
+	cmd := &cobra.Command{
+		Use:   "addTemplate",
+		Short: "Создание пользовательского типа записей",
+		Long: "Создаёт шаблон записей с типизированными полями. Поле задаётся флагом --field\n" +
+			"в виде name:type[:required][:Подпись], где type — string, secret, url, date,\n" +
+			"number, totp или file. Например: --field password:secret:required:Пароль",
+		Run: func(cmd *cobra.Command, args []string) {
+			template := model.RecordTemplate{Name: name}
+			for _, spec := range fields {
+				field, err := service.ParseTemplateField(spec)
+				if err != nil {
+					log.Printf("%v", err)
+					return
+				}
+				template.Fields = append(template.Fields, field)
+			}
+			if org != "" {
+				orgKey, err := uuid.Parse(org)
+				if err != nil {
+					log.Printf("UUID Parser: %v", err)
+					return
+				}
+				template.OrgKey = &orgKey
+			}
+
+			var created model.RecordTemplate
+			if err := h.call(http.MethodPost, "/api/templates", template, http.StatusCreated, &created); err != nil {
+				log.Printf("Ошибка создания шаблона: %v", err)
+				return
+			}
+
+			fmt.Println("Шаблон создан, ключ:", created.TemplateKey)
+		},
+	}
+
+	cmd.Flags().StringVar(&name, "name", "", "Название типа записей")
+	cmd.Flags().StringArrayVar(&fields, "field", nil, "Поле name:type[:required][:Подпись]; флаг повторяется")
+	cmd.Flags().StringVar(&org, "org", "", "UUID организации, если шаблон общий для её хранилищ")
+	cmd.MarkFlagRequired("name")
+	cmd.MarkFlagRequired("field")
+	return cmd
+}
+
+func (h *Handlers) Templates() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "templates",
+		Short: "Шаблоны пользователя и его организаций",
+		Run: func(cmd *cobra.Command, args []string) {
+			templates, err := h.templates()
+			if err != nil {
+				log.Printf("%v", err)
+				return
+			}
+			if len(templates) == 0 {
+				fmt.Println("Шаблонов нет: создайте шаблон командой addTemplate")
+				return
+			}
+
+			for _, template := range templates {
+				owner := "личный"
+				if template.OrgKey != nil {
+					owner = "организация " + template.OrgKey.String()
+				}
+				fmt.Printf("%s  %s (%s)\n", template.TemplateKey, template.Name, owner)
+				for _, field := range template.Fields {
+					line := "    " + field.Name + ": " + field.Type
+					if field.Required {
+						line += ", обязательное"
+					}
+					if field.Label != "" {
+						line += " — " + field.Label
+					}
+					fmt.Println(line)
+				}
+			}
+		},
+	}
+
+	return cmd
+}
+
+func (h *Handlers) DeleteTemplate() *cobra.Command {
+	var id string
+	cmd := &cobra.Command{
+		Use:   "delTemplate",
+		Short: "Удаление шаблона, по которому не осталось записей",
+		Run: func(cmd *cobra.Command, args []string) {
+			if _, err := uuid.Parse(id); err != nil {
+				log.Printf("UUID Parser: %v", err)
+				return
+			}
+
+			if err := h.call(http.MethodDelete, "/api/templates/"+id, nil, http.StatusOK, nil); err != nil {
+				log.Printf("Ошибка удаления шаблона: %v", err)
+				return
+			}
+
+			fmt.Println("Шаблон удалён")
+		},
+	}
+
+	cmd.Flags().StringVar(&id, "key", "", "UUID шаблона")
+	cmd.MarkFlagRequired("key")
+	return cmd
+}
+
+// templates запрашивает шаблоны, доступные пользователю.
+func (h *Handlers) templates() ([]model.RecordTemplate, error) {
+	var templates []model.RecordTemplate
+	err := h.call(http.MethodGet, "/api/templates", nil, http.StatusOK, &templates)
+	return templates, err
+}
+
+// findTemplate находит шаблон по UUID или названию. Одинаковые названия бывают
+// у личного шаблона и шаблона организации, тогда нужно указать UUID.
+func (h *Handlers) findTemplate(ref string) (model.RecordTemplate, error) {
+	templates, err := h.templates()
+	if err != nil {
+		return model.RecordTemplate{}, err
+	}
+
+	var found []model.RecordTemplate
+	for _, template := range templates {
+		if template.TemplateKey.String() == ref || template.Name == ref {
+			found = append(found, template)
+		}
+	}
+	switch len(found) {
+	case 0:
+		return model.RecordTemplate{}, fmt.Errorf("шаблон %q не найден, список шаблонов: templates", ref)
+	case 1:
+		return found[0], nil
+	}
+	return model.RecordTemplate{}, fmt.Errorf("шаблонов с названием %q несколько, укажите UUID", ref)
+}
+
+// saveTemplate возвращает шаблон для сохранения записи. Типы полей нужны клиенту только
+// при сквозном шифровании, поэтому без него, если шаблон не получен, например без сервера,
+// достаточно UUID шаблона: значения проверит сервер.
+func (h *Handlers) saveTemplate(ref string) (model.RecordTemplate, error) {
+	template, err := h.findTemplate(ref)
+	if err == nil || h.profile.E2E {
+		return template, err
+	}
+	templateKey, parseErr := uuid.Parse(ref)
+	if parseErr != nil {
+		return model.RecordTemplate{}, err
+	}
+	return model.RecordTemplate{TemplateKey: templateKey}, nil
+}
+
+// parseValues разбирает значения полей вида name=value.
+func parseValues(sets []string) (map[string]string, error) {
+	values := make(map[string]string, len(sets))
+	for _, set := range sets {
+		name, value, ok := strings.Cut(set, "=")
+		if !ok || name == "" {
+			return nil, fmt.Errorf("значение %q: ожидается name=value", set)
+		}
+		values[name] = value
+	}
+	return values, nil
+}
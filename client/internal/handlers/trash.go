@@ -0,0 +1,133 @@
+package handlers
+
+import (
+	"client/internal/cache"
+	"client/internal/model"
+	"encoding/json"
+	"fmt"
+	"github.com/google/uuid"
+	"github.com/spf13/cobra"
+	"log"
+	"net/http"
+)
+
+// Trash объединяет команды работы с корзиной удалённых записей.
+func (h *Handlers) Trash() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "trash",
+		Short: "Корзина удалённых записей",
+	}
+
+	cmd.AddCommand(
+		h.trashList(),
+		h.trashRestore(),
+		h.trashEmpty(),
+	)
+	return cmd
+}
+
+func (h *Handlers) trashList() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "list",
+		Short: "Список записей в корзине",
+		Run: func(cmd *cobra.Command, args []string) {
+			status, body, err := h.request(http.MethodGet, "/api/trash", nil)
+			if err != nil {
+				log.Printf("%v", err)
+				return
+			}
+			if status != http.StatusOK {
+				log.Printf("Ошибка: сервер вернул ошибочный статус: %d %s", status, http.StatusText(status))
+				return
+			}
+
+			var trash []model.TrashEntry
+			if err := json.Unmarshal(body, &trash); err != nil {
+				log.Printf("%v", err)
+				return
+			}
+			if len(trash) == 0 {
+				fmt.Println("Корзина пуста")
+				return
+			}
+
+			for _, entry := range trash {
+				fmt.Printf("%-7s %s  удалена %s  %s\n",
+					entry.Type,
+					entry.Key,
+					entry.DeletedAt.Format("2006-01-02 15:04:05"),
+					h.gophKeeper.Describe(entry.Type, entry.Data),
+				)
+			}
+		},
+	}
+
+	return cmd
+}
+
+func (h *Handlers) trashRestore() *cobra.Command {
+	var (
+		recordType string
+		id         string
+	)
+	cmd := &cobra.Command{
+		Use:   "restore",
+		Short: "Восстановление записи из корзины",
+		Run: func(cmd *cobra.Command, args []string) {
+			if _, err := uuid.Parse(id); err != nil {
+				log.Printf("UUID Parser: %v", err)
+				return
+			}
+
+			status, body, err := h.request(http.MethodPost, fmt.Sprintf("/api/trash/%s/%s/restore", recordType, id), nil)
+			if err != nil {
+				log.Printf("%v", err)
+				return
+			}
+			if status != http.StatusOK {
+				log.Printf("Ошибка: сервер вернул ошибочный статус: %d %s", status, http.StatusText(status))
+				return
+			}
+
+			if vault := h.gophKeeper.GetCache(); vault != nil {
+				if err := vault.PutRecord(cache.Record{Type: recordType, Key: id, Data: body}); err != nil {
+					log.Printf("Ошибка записи в локальный кэш: %v", err)
+				}
+			}
+			fmt.Println("Запись восстановлена")
+		},
+	}
+
+	cmd.Flags().StringVar(&recordType, "type", "", "Тип записи: text, binary, card, totp, ssh или custom")
+	cmd.Flags().StringVar(&id, "key", "", "UUID данных")
+	cmd.MarkFlagRequired("type")
+	cmd.MarkFlagRequired("key")
+	return cmd
+}
+
+func (h *Handlers) trashEmpty() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "empty",
+		Short: "Окончательная очистка корзины",
+		Run: func(cmd *cobra.Command, args []string) {
+			status, body, err := h.request(http.MethodDelete, "/api/trash", nil)
+			if err != nil {
+				log.Printf("%v", err)
+				return
+			}
+			if status != http.StatusOK {
+				log.Printf("Ошибка: сервер вернул ошибочный статус: %d %s", status, http.StatusText(status))
+				return
+			}
+
+			var result model.TrashPurged
+			if err := json.Unmarshal(body, &result); err != nil {
+				log.Printf("%v", err)
+				return
+			}
+			fmt.Printf("Очищено записей: %d\n", result.Purged)
+		},
+	}
+
+	return cmd
+}
@@ -1,13 +1,16 @@
 package handlers
 
 import (
-	"bytes"
+	"bufio"
 	"client/internal/model"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"github.com/spf13/cobra"
 	"log"
 	"net/http"
+	"os"
+	"strings"
 )
 
 func (h *Handlers) RegisterUser() *cobra.Command {
@@ -15,42 +18,13 @@ func (h *Handlers) RegisterUser() *cobra.Command {
 		Use:   "reg",
 		Short: "Регистрация пользователя",
 		Run: func(cmd *cobra.Command, args []string) {
-			var username, password string
-			fmt.Print("Введите логи: ")
-			fmt.Scanln(&username)
-			fmt.Print("Введите пароль: ")
-			fmt.Scanln(&password)
-
-			data := model.User{
-				Login:        username,
-				PasswordHash: password,
-			}
-
-			jsonData, err := json.Marshal(data)
-			if err != nil {
-				log.Printf("%v", err)
-				return
-			}
-
-			req, err := http.NewRequest(http.MethodPost, h.cnf.Listen+"/api/register", bytes.NewBuffer(jsonData))
-			if err != nil {
-				log.Printf("Ошибка при создании запроса: %v", err)
-				return
-			}
-			req.Header.Set("Content-Type", "application/json")
-			resp, err := h.client.Do(req)
-			if err != nil {
-				log.Printf("Ошибка при отправке запроса: %v", err)
-				return
-			}
-			defer resp.Body.Close()
-
-			if resp.StatusCode != http.StatusCreated {
-				log.Printf("Ошибка: сервер вернул ошибочный статус: %d %s", resp.StatusCode, resp.Status)
+			username, password := readCredentials(h.profile.Login)
+			if err := h.signIn("/api/register", username, password); err != nil {
+				log.Printf("Ошибка регистрации: %v", err)
 				return
 			}
 
-			h.gophKeeper.SetCookie(resp.Cookies()[0])
+			fmt.Println("Пользователь зарегистрирован")
 		},
 	}
 
@@ -62,45 +36,101 @@ func (h *Handlers) AuthorizationUser() *cobra.Command {
 		Use:   "aut",
 		Short: "Авторизация пользователя",
 		Run: func(cmd *cobra.Command, args []string) {
-			var username, password string
-			fmt.Print("Введите логи: ")
-			fmt.Scanln(&username)
-			fmt.Print("Введите пароль: ")
-			fmt.Scanln(&password)
-
-			data := model.User{
-				Login:        username,
-				PasswordHash: password,
-			}
-
-			jsonData, err := json.Marshal(data)
-			if err != nil {
-				log.Printf("%v", err)
+			username, password := readCredentials(h.profile.Login)
+			err := h.signIn("/api/authorization", username, password)
+			if errors.Is(err, errOffline) {
+				// Без сервера пароль проверяется по локальному кэшу.
+				if err := h.openCache(username, password); err != nil {
+					log.Printf("Ошибка входа в автономном режиме: %v", err)
+					return
+				}
+				fmt.Println("Сервер недоступен, вход выполнен в автономном режиме")
 				return
 			}
-
-			req, err := http.NewRequest(http.MethodPost, h.cnf.Listen+"/api/authorization", bytes.NewBuffer(jsonData))
 			if err != nil {
-				log.Printf("Ошибка при создании запроса: %v", err)
+				log.Printf("Ошибка авторизации: %v", err)
 				return
 			}
-			req.Header.Set("Content-Type", "application/json")
-			resp, err := h.client.Do(req)
+
+			fmt.Println("Вход выполнен")
+			h.replay()
+		},
+	}
+
+	return cmd
+}
+
+// LogoutUser завершает сессию на сервере, останавливает фоновую подписку и агент SSH.
+func (h *Handlers) LogoutUser() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "logout",
+		Short: "Выход из сессии",
+		Run: func(cmd *cobra.Command, args []string) {
+			h.stopWatch()
+			h.stopAgent()
+
+			status, _, err := h.request(http.MethodPost, "/api/logout", nil)
 			if err != nil {
-				log.Printf("Ошибка при отправке запроса: %v", err)
+				log.Printf("Ошибка выхода: %v", err)
 				return
 			}
-			defer resp.Body.Close()
-
-			if resp.StatusCode != http.StatusCreated {
-				log.Printf("Ошибка: сервер вернул ошибочный статус: %d %s", resp.StatusCode, resp.Status)
+			if status != http.StatusOK {
+				log.Printf("Ошибка выхода: сервер вернул ошибочный статус: %d %s", status, http.StatusText(status))
 				return
 			}
 
-			h.gophKeeper.SetCookie(resp.Cookies()[0])
-
+			h.gophKeeper.SetCookie(nil)
+			fmt.Println("Сессия завершена")
 		},
 	}
 
 	return cmd
 }
+
+// readCredentials запрашивает логин и пароль у пользователя.
+// Если логин задан в профиле, пустой ввод принимает его.
+func readCredentials(defaultLogin string) (string, string) {
+	var username, password string
+	if defaultLogin != "" {
+		fmt.Printf("Введите логи [%s]: ", defaultLogin)
+	} else {
+		fmt.Print("Введите логи: ")
+	}
+	fmt.Scanln(&username)
+	if username == "" {
+		username = defaultLogin
+	}
+	fmt.Print("Введите пароль: ")
+	fmt.Scanln(&password)
+	return username, password
+}
+
+// readPassphrase запрашивает ключевую фразу сквозного шифрования; фраза может содержать пробелы.
+func readPassphrase() string {
+	fmt.Print("Введите ключевую фразу сквозного шифрования: ")
+	line, _ := bufio.NewReader(os.Stdin).ReadString('\n')
+	return strings.TrimRight(line, "\r\n")
+}
+
+// signIn отправляет учётные данные на сервер, сохраняет cookie сессии и открывает локальный кэш.
+func (h *Handlers) signIn(path, username, password string) error {
+	data := model.User{
+		Login:        username,
+		PasswordHash: password,
+	}
+
+	jsonData, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+
+	status, _, err := h.request(http.MethodPost, path, jsonData)
+	if err != nil {
+		return err
+	}
+	if status != http.StatusCreated {
+		return fmt.Errorf("сервер вернул ошибочный статус: %d %s", status, http.StatusText(status))
+	}
+
+	return h.openCache(username, password)
+}
@@ -0,0 +1,54 @@
+package handlers
+
+import (
+	"client/internal/buildinfo"
+	"client/internal/model"
+	"errors"
+	"fmt"
+	"github.com/spf13/cobra"
+	"log"
+	"net/http"
+)
+
+// Version выводит версии клиента и сервера.
+func (h *Handlers) Version() *cobra.Command {
+	return &cobra.Command{
+		Use:   "version",
+		Short: "Версии клиента и сервера",
+		Run: func(cmd *cobra.Command, args []string) {
+			fmt.Println("Клиент:", buildinfo.String())
+
+			var info model.BuildInfo
+			if err := h.call(http.MethodGet, "/version", nil, http.StatusOK, &info); err != nil {
+				log.Printf("%v", err)
+				return
+			}
+			fmt.Printf("Сервер: %s (commit %s, built %s, API v%d)\n", info.Version, info.Commit, info.Date, info.APIVersion)
+			if info.APIVersion != buildinfo.APIVersion {
+				fmt.Println(incompatibleWarning(info.APIVersion))
+			}
+		},
+	}
+}
+
+// CheckServer сверяет версию API сервера с версией клиента и предупреждает о несовместимости.
+// Если сервер недоступен, проверка пропускается: клиент может работать с локальным кэшем.
+func (h *Handlers) CheckServer() {
+	var info model.BuildInfo
+	err := h.call(http.MethodGet, "/version", nil, http.StatusOK, &info)
+	if errors.Is(err, errOffline) {
+		return
+	}
+	if err != nil {
+		log.Printf("не удалось проверить версию сервера: %v", err)
+		return
+	}
+	if info.APIVersion != buildinfo.APIVersion {
+		fmt.Println(incompatibleWarning(info.APIVersion))
+	}
+}
+
+func incompatibleWarning(serverAPIVersion int) string {
+	return fmt.Sprintf("Внимание: сервер использует API v%d, клиент рассчитан на API v%d; часть команд может работать неправильно",
+		serverAPIVersion, buildinfo.APIVersion)
+}
@@ -0,0 +1,111 @@
+package handlers
+
+import (
+	"client/internal/model"
+	"context"
+	"errors"
+	"fmt"
+	"github.com/spf13/cobra"
+	"log"
+	"net/http"
+)
+
+// Watch подписывается на события сервера и синхронизирует кэш в фоне сразу после изменений
+// на других устройствах, без опроса сервера.
+func (h *Handlers) Watch() *cobra.Command {
+	var stop bool
+	cmd := &cobra.Command{
+		Use:   "watch",
+		Short: "Получение изменений в реальном времени",
+		Long:  "Запускает фоновую подписку на изменения записей. --stop останавливает подписку.",
+		Run: func(cmd *cobra.Command, args []string) {
+			if stop {
+				if !h.stopWatch() {
+					fmt.Println("Подписка не запущена")
+					return
+				}
+				fmt.Println("Подписка остановлена")
+				return
+			}
+
+			if h.offline {
+				fmt.Println("Подписка недоступна в автономном режиме")
+				return
+			}
+			if h.gophKeeper.GetCache() == nil {
+				fmt.Println("Выполните вход командой aut")
+				return
+			}
+
+			h.stopWatch()
+			ctx, cancel := context.WithCancel(context.Background())
+			h.watchStop = cancel
+			go h.watch(ctx)
+
+			// Изменения, сделанные до подписки, забираем сразу.
+			if _, err := h.syncLocked(); err != nil {
+				log.Printf("Ошибка синхронизации: %v", err)
+			}
+			fmt.Println("Подписка запущена")
+		},
+	}
+
+	cmd.Flags().BoolVar(&stop, "stop", false, "Остановить подписку")
+	return cmd
+}
+
+// stopWatch останавливает фоновую подписку. Возвращает false, если подписка не была запущена.
+func (h *Handlers) stopWatch() bool {
+	if h.watchStop == nil {
+		return false
+	}
+	h.watchStop()
+	h.watchStop = nil
+	return true
+}
+
+// watch читает поток /api/events до отмены ctx, закрытия потока сервером или выхода из сессии.
+// На каждое изменение записи выполняется синхронизация кэша.
+func (h *Handlers) watch(ctx context.Context) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, h.profile.Server+"/api/events", nil)
+	if err != nil {
+		log.Printf("Ошибка подписки: %v", err)
+		return
+	}
+	req.Header.Set("Accept", "text/event-stream")
+	if cookie := h.gophKeeper.GetCookie(); cookie != nil {
+		req.AddCookie(cookie)
+	}
+
+	// Поток открыт долго, поэтому общий клиент с тайм-аутом не подходит.
+	// Поток бессрочный, поэтому тайм-аут h.client не подходит; TLS берётся из профиля.
+	resp, err := (&http.Client{Transport: h.client.Transport}).Do(req)
+	if err != nil {
+		if !errors.Is(err, context.Canceled) {
+			log.Printf("Ошибка подписки: %v", err)
+		}
+		return
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		log.Printf("Ошибка подписки: сервер вернул ошибочный статус: %d %s", resp.StatusCode, http.StatusText(resp.StatusCode))
+		return
+	}
+
+	err = h.gophKeeper.ReadEvents(resp.Body, func(event model.Event) bool {
+		switch event.Type {
+		case model.EventRecordChanged:
+			if _, err := h.syncLocked(); err != nil {
+				log.Printf("Ошибка синхронизации: %v", err)
+			}
+		case model.EventSessionRevoked:
+			log.Println("Сессия завершена, подписка остановлена")
+			return false
+		}
+		return true
+	})
+	if err != nil && ctx.Err() == nil {
+		log.Printf("Подписка прервана: %v", err)
+	}
+}
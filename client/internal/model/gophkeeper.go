@@ -1,10 +1,28 @@
 package model
 
 import (
+	"encoding/json"
 	"github.com/google/uuid"
 	"time"
 )
 
+// Типы записей хранилища, совпадающие с сегментом пути /api/data/{type}.
+const (
+	RecordText   = "text"
+	RecordBinary = "binary"
+	RecordCard   = "card"
+	RecordTOTP   = "totp"
+	RecordSSH    = "ssh"
+	RecordCustom = "custom"
+)
+
+// Виды изменений в ленте синхронизации.
+const (
+	ChangeCreated = "created"
+	ChangeUpdated = "updated"
+	ChangeDeleted = "deleted"
+)
+
 type User struct {
 	Login         string `json:"login,omitempty"`
 	PasswordHash  string `json:"password_hash"`
@@ -20,11 +38,13 @@ type DataText struct {
 	DataTextKey    uuid.UUID `json:"data_text_key,omitempty"`
 	PrivateUserKey uuid.UUID `json:"private_user_key,omitempty"`
 	Data           string    `json:"data,omitempty"`
+	Revision       int64     `json:"revision,omitempty"`
 }
 
 type DataTextResponse struct {
 	DataTextKey uuid.UUID `json:"data_text_key,omitempty"`
 	Data        string    `json:"data,omitempty"`
+	Revision    int64     `json:"revision,omitempty"`
 }
 
 type DataBinary struct {
@@ -32,12 +52,28 @@ type DataBinary struct {
 	PrivateUserKey uuid.UUID `json:"private_user_key,omitempty"`
 	FileName       string    `json:"filename,omitempty"`
 	Data           string    `json:"data,omitempty"`
+	Revision       int64     `json:"revision,omitempty"`
 }
 
 type DataBinaryResponse struct {
 	DataBinaryKey uuid.UUID `json:"data_binary_key,omitempty"`
 	FileName      string    `json:"filename,omitempty"`
 	Data          string    `json:"data,omitempty"`
+	Revision      int64     `json:"revision,omitempty"`
+}
+
+// DataCreditCard — тело запроса на сохранение карты. CardType принимает значения debit или credit.
+type DataCreditCard struct {
+	CardNumber     string `json:"card_number,omitempty"`
+	CardholderName string `json:"cardholder_name,omitempty"`
+	ExpirationDate string `json:"expiration_date,omitempty"`
+	CVV            string `json:"cvv,omitempty"`
+	PIN            string `json:"pin,omitempty"`
+	CardType       string `json:"card_type,omitempty"`
+	IssuingBank    string `json:"issuing_bank,omitempty"`
+	BillingAddress string `json:"billing_address,omitempty"`
+	Notes          string `json:"notes,omitempty"`
+	Revision       int64  `json:"revision,omitempty"`
 }
 
 type DataCreditCardResponse struct {
@@ -45,6 +81,309 @@ type DataCreditCardResponse struct {
 	CardNumber        string    `json:"card_number,omitempty"`
 	CardholderName    string    `json:"cardholder_name,omitempty"`
 	ExpirationDate    string    `json:"expiration_date,omitempty"`
-	CVVHash           string    `json:"cvv_hash,omitempty"`
+	CVV               string    `json:"cvv,omitempty"`
+	PIN               string    `json:"pin,omitempty"`
+	Brand             string    `json:"brand,omitempty"`      // платёжная система, определённая сервером по номеру
+	ExpiresOn         string    `json:"expires_on,omitempty"` // последний день срока действия, YYYY-MM-DD
+	CardType          string    `json:"card_type,omitempty"`
+	IssuingBank       string    `json:"issuing_bank,omitempty"`
+	BillingAddress    string    `json:"billing_address,omitempty"`
+	Notes             string    `json:"notes,omitempty"`
 	CreatedAt         time.Time `json:"created_at,omitempty"`
+	Revision          int64     `json:"revision,omitempty"`
+}
+
+// DataTOTP — тело запроса на сохранение секрета двухфакторной аутентификации.
+// Algorithm принимает значения SHA1, SHA256 или SHA512; Period задаётся в секундах.
+type DataTOTP struct {
+	Secret    string `json:"secret,omitempty"`
+	Issuer    string `json:"issuer,omitempty"`
+	Account   string `json:"account,omitempty"`
+	Algorithm string `json:"algorithm,omitempty"`
+	Digits    int    `json:"digits,omitempty"`
+	Period    int    `json:"period,omitempty"`
+	Revision  int64  `json:"revision,omitempty"`
+}
+
+type DataTOTPResponse struct {
+	DataTOTPKey uuid.UUID `json:"data_totp_key,omitempty"`
+	Secret      string    `json:"secret,omitempty"` // base32 или шифротекст с префиксом "e2e:"
+	Issuer      string    `json:"issuer,omitempty"`
+	Account     string    `json:"account,omitempty"`
+	Algorithm   string    `json:"algorithm,omitempty"`
+	Digits      int       `json:"digits,omitempty"`
+	Period      int       `json:"period,omitempty"`
+	CreatedAt   time.Time `json:"created_at,omitempty"`
+	Revision    int64     `json:"revision,omitempty"`
+}
+
+// DataSSHKey — тело запроса на сохранение ключа SSH. Открытый ключ и отпечаток вычисляет клиент.
+type DataSSHKey struct {
+	PrivateKey  string `json:"private_key,omitempty"`
+	PublicKey   string `json:"public_key,omitempty"`
+	Comment     string `json:"comment,omitempty"`
+	Passphrase  string `json:"passphrase,omitempty"`
+	Fingerprint string `json:"fingerprint,omitempty"`
+	Revision    int64  `json:"revision,omitempty"`
+}
+
+type DataSSHKeyResponse struct {
+	DataSSHKeyKey uuid.UUID `json:"data_ssh_key_key,omitempty"`
+	PrivateKey    string    `json:"private_key,omitempty"` // PEM или шифротекст с префиксом "e2e:"
+	PublicKey     string    `json:"public_key,omitempty"`
+	Comment       string    `json:"comment,omitempty"`
+	Passphrase    string    `json:"passphrase,omitempty"`
+	Fingerprint   string    `json:"fingerprint,omitempty"`
+	CreatedAt     time.Time `json:"created_at,omitempty"`
+	Revision      int64     `json:"revision,omitempty"`
+}
+
+// Типы полей шаблонов записей.
+const (
+	FieldString = "string"
+	FieldSecret = "secret"
+	FieldURL    = "url"
+	FieldDate   = "date" // YYYY-MM-DD
+	FieldNumber = "number"
+	FieldTOTP   = "totp" // секрет TOTP в base32
+	FieldFile   = "file" // ключ бинарной записи
+)
+
+// TemplateField описывает поле шаблона записи.
+type TemplateField struct {
+	Name     string `json:"name"`
+	Label    string `json:"label,omitempty"`
+	Type     string `json:"type"`
+	Required bool   `json:"required,omitempty"`
+}
+
+// RecordTemplate описывает пользовательский тип записи: личный или организации (OrgKey).
+type RecordTemplate struct {
+	TemplateKey uuid.UUID       `json:"template_key,omitempty"`
+	OrgKey      *uuid.UUID      `json:"org_key,omitempty"`
+	Name        string          `json:"name"`
+	Fields      []TemplateField `json:"fields"`
+	CreatedAt   time.Time       `json:"created_at,omitempty"`
+}
+
+// DataCustom — тело запроса на сохранение записи пользовательского типа.
+type DataCustom struct {
+	TemplateKey uuid.UUID         `json:"template_key,omitempty"`
+	Fields      map[string]string `json:"fields,omitempty"`
+	Revision    int64             `json:"revision,omitempty"`
+}
+
+type DataCustomResponse struct {
+	DataCustomKey uuid.UUID         `json:"data_custom_key,omitempty"`
+	TemplateKey   uuid.UUID         `json:"template_key,omitempty"`
+	TemplateName  string            `json:"template_name,omitempty"`
+	Fields        map[string]string `json:"fields,omitempty"` // значения secret и totp могут быть шифротекстом "e2e:"
+	CreatedAt     time.Time         `json:"created_at,omitempty"`
+	Revision      int64             `json:"revision,omitempty"`
+}
+
+// SyncChange описывает изменение одной записи в ленте синхронизации.
+type SyncChange struct {
+	Type      string          `json:"type"`
+	Key       uuid.UUID       `json:"key"`
+	Action    string          `json:"action"`
+	Revision  int64           `json:"revision"`
+	UpdatedAt time.Time       `json:"updated_at"`
+	Data      json.RawMessage `json:"data,omitempty"`
+}
+
+// SyncResponse содержит изменения пользователя после запрошенной ревизии.
+type SyncResponse struct {
+	Revision int64        `json:"revision"`
+	Changes  []SyncChange `json:"changes"`
+}
+
+// HistoryEntry описывает одну версию записи в истории. У удалений Data не заполняется.
+type HistoryEntry struct {
+	Revision  int64           `json:"revision"`
+	Action    string          `json:"action"`
+	CreatedAt time.Time       `json:"created_at"`
+	Data      json.RawMessage `json:"data,omitempty"`
+}
+
+// HistoryRestore задаёт ревизию, к которой возвращается запись.
+type HistoryRestore struct {
+	Revision int64 `json:"revision"`
+}
+
+// TrashEntry описывает удалённую запись в корзине на сервере.
+type TrashEntry struct {
+	Type      string          `json:"type"`
+	Key       uuid.UUID       `json:"key"`
+	DeletedAt time.Time       `json:"deleted_at"`
+	Data      json.RawMessage `json:"data"`
+}
+
+// TrashPurged содержит количество окончательно очищенных записей.
+type TrashPurged struct {
+	Purged int64 `json:"purged"`
+}
+
+// Права получателя на общую запись.
+const (
+	PermissionRead  = "read"  // только чтение
+	PermissionWrite = "write" // чтение и изменение
+)
+
+// Share описывает доступ получателя к записи.
+type Share struct {
+	Type       string    `json:"type,omitempty"`
+	RecordKey  uuid.UUID `json:"record_key,omitempty"`
+	Login      string    `json:"login"`
+	Permission string    `json:"permission"`
+	CreatedAt  time.Time `json:"created_at,omitempty"`
+}
+
+// SharedRecord описывает запись другого пользователя, доступную текущему пользователю.
+type SharedRecord struct {
+	Type       string          `json:"type"`
+	Key        uuid.UUID       `json:"key"`
+	Owner      string          `json:"owner"`
+	Permission string          `json:"permission"`
+	Data       json.RawMessage `json:"data"`
+}
+
+// E2EParams содержит параметры сквозного шифрования пользователя, общие для всех его устройств:
+// случайную соль Argon2id и контрольное значение, зашифрованное ключом, для проверки ключевой фразы.
+type E2EParams struct {
+	Salt  string `json:"salt"`
+	Check string `json:"check"`
+}
+
+// Link описывает одноразовую ссылку на секрет. Data содержит шифротекст LinkPayload.
+type Link struct {
+	LinkKey   uuid.UUID `json:"link_key,omitempty"`
+	Data      string    `json:"data,omitempty"`
+	MaxViews  int       `json:"max_views,omitempty"`
+	Views     int       `json:"views,omitempty"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// LinkPayload — содержимое ссылки до шифрования: тип записи и её данные.
+type LinkPayload struct {
+	Type string          `json:"type"`
+	Data json.RawMessage `json:"data"`
+}
+
+// AuditEntry описывает запись журнала аудита.
+type AuditEntry struct {
+	AuditID    int64      `json:"audit_id"`
+	Actor      string     `json:"actor,omitempty"`
+	Action     string     `json:"action"`
+	RecordType string     `json:"record_type,omitempty"`
+	RecordKey  *uuid.UUID `json:"record_key,omitempty"`
+	IP         string     `json:"ip"`
+	UserAgent  string     `json:"user_agent"`
+	CreatedAt  time.Time  `json:"created_at"`
+	Hash       string     `json:"hash"`
+}
+
+// ExpiringRecord описывает запись, срок действия которой скоро истекает.
+type ExpiringRecord struct {
+	Type      string    `json:"type"`
+	Key       uuid.UUID `json:"key"`
+	Title     string    `json:"title"`
+	ExpiresOn string    `json:"expires_on"`
+	DaysLeft  int       `json:"days_left"`
+}
+
+// Роли участников организации.
+const (
+	RoleOwner  = "owner"
+	RoleAdmin  = "admin"
+	RoleEditor = "editor"
+	RoleViewer = "viewer"
+)
+
+// Org описывает организацию и роль текущего пользователя в ней.
+type Org struct {
+	OrgKey    uuid.UUID `json:"org_key,omitempty"`
+	Name      string    `json:"name"`
+	Role      string    `json:"role,omitempty"`
+	CreatedAt time.Time `json:"created_at,omitempty"`
+}
+
+// OrgMember описывает участника организации.
+type OrgMember struct {
+	Login     string    `json:"login"`
+	Role      string    `json:"role"`
+	CreatedAt time.Time `json:"created_at,omitempty"`
+}
+
+// Vault описывает хранилище организации и роль текущего пользователя в нём.
+type Vault struct {
+	VaultKey  uuid.UUID `json:"vault_key,omitempty"`
+	OrgKey    uuid.UUID `json:"org_key,omitempty"`
+	OrgName   string    `json:"org_name,omitempty"`
+	Name      string    `json:"name"`
+	Role      string    `json:"role,omitempty"`
+	CreatedAt time.Time `json:"created_at,omitempty"`
+}
+
+// Варианты разрешения конфликта.
+const (
+	ResolveCurrent  = "current"  // оставить версию сервера
+	ResolveConflict = "conflict" // принять конфликтную копию
+	ResolveMerged   = "merged"   // сохранить объединённую версию
+)
+
+// Conflict описывает конфликтную копию записи вместе с текущей версией на сервере.
+type Conflict struct {
+	DataConflictKey uuid.UUID       `json:"data_conflict_key"`
+	Type            string          `json:"type"`
+	RecordKey       uuid.UUID       `json:"record_key"`
+	BaseRevision    int64           `json:"base_revision"`
+	CreatedAt       time.Time       `json:"created_at"`
+	Current         json.RawMessage `json:"current,omitempty"`
+	Conflict        json.RawMessage `json:"conflict"`
+}
+
+// ConflictResolution описывает выбор пользователя при разрешении конфликта.
+type ConflictResolution struct {
+	Choice string          `json:"choice"`
+	Data   json.RawMessage `json:"data,omitempty"`
+}
+
+// ValidationError — ответ сервера со статусом 422 со списком ошибок полей.
+type ValidationError struct {
+	Errors []struct {
+		Field   string `json:"field"`
+		Message string `json:"message"`
+	} `json:"errors"`
+}
+
+// FieldDiff описывает различие одного поля между версией сервера и конфликтной копией.
+type FieldDiff struct {
+	Field    string
+	Current  string
+	Conflict string
+}
+
+// Типы событий потока /api/events.
+const (
+	EventRecordChanged  = "record-changed"
+	EventSessionRevoked = "session-revoked"
+)
+
+// Event описывает событие, полученное от сервера в реальном времени.
+type Event struct {
+	Type       string    `json:"type"`
+	RecordType string    `json:"record_type,omitempty"`
+	RecordKey  uuid.UUID `json:"record_key,omitempty"`
+	Action     string    `json:"action,omitempty"`
+	Revision   int64     `json:"revision,omitempty"`
+	Session    string    `json:"session,omitempty"`
+}
+
+// BuildInfo описывает сборку сервера из ответа /version.
+type BuildInfo struct {
+	Version    string `json:"version"`
+	Commit     string `json:"commit"`
+	Date       string `json:"date"`
+	APIVersion int    `json:"api_version"`
 }
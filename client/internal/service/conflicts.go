@@ -0,0 +1,136 @@
+package service
+
+import (
+	"client/internal/model"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// SetRevision добавляет в тело запроса ревизию, на которой основано изменение.
+// Нулевая ревизия не добавляется: сервер отклонит такое изменение со статусом 428.
+func (gk *GophKeeperClient) SetRevision(body []byte, revision int64) ([]byte, error) {
+	var fields map[string]any
+	if err := json.Unmarshal(body, &fields); err != nil {
+		return nil, err
+	}
+
+	if revision > 0 {
+		fields["revision"] = revision
+	} else {
+		delete(fields, "revision")
+	}
+	return json.Marshal(fields)
+}
+
+// MergeResponse дополняет тело отправленной записи полями из ответа сервера,
+// например ревизией и значениями, которые сервер вычислил или привёл к каноническому виду.
+func (gk *GophKeeperClient) MergeResponse(body, respBody []byte) ([]byte, error) {
+	var fields map[string]any
+	if err := json.Unmarshal(body, &fields); err != nil {
+		return nil, err
+	}
+
+	var response map[string]any
+	if err := json.Unmarshal(respBody, &response); err != nil {
+		return nil, err
+	}
+	for name, value := range response {
+		fields[name] = value
+	}
+	return json.Marshal(fields)
+}
+
+// GetRevision извлекает ревизию записи из JSON-представления записи.
+func (gk *GophKeeperClient) GetRevision(body []byte) int64 {
+	var data struct {
+		Revision int64 `json:"revision"`
+	}
+	if err := json.Unmarshal(body, &data); err != nil {
+		return 0
+	}
+	return data.Revision
+}
+
+// DiffFields сравнивает версию сервера и конфликтную копию поле за полем.
+// Служебные поля (ключи, ревизии, даты) в сравнении не участвуют.
+func (gk *GophKeeperClient) DiffFields(current, conflict []byte) ([]model.FieldDiff, error) {
+	currentFields, err := userFields(current)
+	if err != nil {
+		return nil, err
+	}
+	conflictFields, err := userFields(conflict)
+	if err != nil {
+		return nil, err
+	}
+
+	names := map[string]struct{}{}
+	for name := range currentFields {
+		names[name] = struct{}{}
+	}
+	for name := range conflictFields {
+		names[name] = struct{}{}
+	}
+
+	var diffs []model.FieldDiff
+	for name := range names {
+		if currentFields[name] != conflictFields[name] {
+			diffs = append(diffs, model.FieldDiff{
+				Field:    name,
+				Current:  currentFields[name],
+				Conflict: conflictFields[name],
+			})
+		}
+	}
+	sort.Slice(diffs, func(i, j int) bool { return diffs[i].Field < diffs[j].Field })
+
+	return diffs, nil
+}
+
+// MergeFields собирает объединённую версию: за основу берётся версия сервера,
+// а перечисленные поля заменяются значениями из конфликтной копии.
+func (gk *GophKeeperClient) MergeFields(current, conflict []byte, take []string) ([]byte, error) {
+	var currentFields, conflictFields map[string]any
+	if len(current) > 0 {
+		if err := json.Unmarshal(current, &currentFields); err != nil {
+			return nil, err
+		}
+	}
+	if err := json.Unmarshal(conflict, &conflictFields); err != nil {
+		return nil, err
+	}
+	if currentFields == nil {
+		currentFields = map[string]any{}
+	}
+
+	for _, name := range take {
+		value, ok := conflictFields[name]
+		if !ok {
+			return nil, fmt.Errorf("поле %q отсутствует в конфликтной копии", name)
+		}
+		currentFields[name] = value
+	}
+	return json.Marshal(currentFields)
+}
+
+// userFields возвращает значимые для пользователя поля записи в виде строк.
+func userFields(body []byte) (map[string]string, error) {
+	fields := map[string]string{}
+	if len(body) == 0 {
+		return fields, nil
+	}
+
+	var raw map[string]any
+	if err := json.Unmarshal(body, &raw); err != nil {
+		return nil, err
+	}
+
+	for name, value := range raw {
+		if name == "revision" || name == "created_at" || strings.HasSuffix(name, "_key") {
+			continue
+		}
+		fields[name] = fmt.Sprint(value)
+	}
+	return fields, nil
+}
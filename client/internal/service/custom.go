@@ -0,0 +1,147 @@
+package service
+
+import (
+	"client/internal/model"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// ParseTemplateField разбирает описание поля вида name:type[:required][:Подпись],
+// например password:secret:required:Пароль или portal:url::Портал.
+func ParseTemplateField(spec string) (model.TemplateField, error) {
+	parts := strings.SplitN(spec, ":", 4)
+	if len(parts) < 2 || parts[0] == "" || parts[1] == "" {
+		return model.TemplateField{}, fmt.Errorf("поле %q: ожидается name:type[:required][:Подпись]", spec)
+	}
+
+	field := model.TemplateField{Name: parts[0], Type: parts[1]}
+	if len(parts) > 2 {
+		switch parts[2] {
+		case "required":
+			field.Required = true
+		case "", "optional":
+		default:
+			return model.TemplateField{}, fmt.Errorf("поле %q: третья часть может быть только required", spec)
+		}
+	}
+	if len(parts) > 3 {
+		field.Label = parts[3]
+	}
+	return field, nil
+}
+
+// CreateCustom формирует тело запроса на сохранение записи по шаблону. При сквозном шифровании
+// значения полей secret и totp шифруются на клиенте, а секреты TOTP перед этим проверяются:
+// сервер получает только шифротекст и проверить их не может.
+func (gk *GophKeeperClient) CreateCustom(template model.RecordTemplate, values map[string]string) ([]byte, error) {
+	fields := make(map[string]string, len(values))
+	for name, value := range values {
+		fields[name] = value
+	}
+
+	if gk.e2e != nil {
+		for _, field := range template.Fields {
+			value := fields[field.Name]
+			if value == "" {
+				continue
+			}
+			switch field.Type {
+			case model.FieldTOTP:
+				value = normalizeSecret(value)
+				if _, err := totpEncoding.DecodeString(value); err != nil {
+					return nil, fmt.Errorf("поле %s: секрет должен быть в кодировке base32", field.Name)
+				}
+			case model.FieldSecret:
+			default:
+				continue
+			}
+
+			var err error
+			if fields[field.Name], err = gk.sealE2E(value); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	return json.Marshal(model.DataCustom{TemplateKey: template.TemplateKey, Fields: fields})
+}
+
+// CustomValues разбирает запись по шаблону и расшифровывает значения, зашифрованные на клиенте.
+func (gk *GophKeeperClient) CustomValues(body []byte) (model.DataCustomResponse, error) {
+	var dataJson model.DataCustomResponse
+	if err := json.Unmarshal(body, &dataJson); err != nil {
+		return model.DataCustomResponse{}, err
+	}
+
+	for name, value := range dataJson.Fields {
+		plain, err := gk.openE2E(value)
+		if err != nil {
+			return model.DataCustomResponse{}, err
+		}
+		dataJson.Fields[name] = plain
+	}
+	return dataJson, nil
+}
+
+// GetCustom возвращает запись по шаблону в виде «подпись: значение» в порядке полей шаблона.
+// Секреты скрываются, если не задан reveal; для полей totp выводится текущий код.
+// Без шаблона, например в автономном режиме, поля выводятся по именам как есть.
+func (gk *GophKeeperClient) GetCustom(body []byte, template *model.RecordTemplate, reveal bool) (string, error) {
+	dataJson, err := gk.CustomValues(body)
+	if err != nil {
+		return "", err
+	}
+
+	name := dataJson.TemplateName
+	if name == "" && template != nil {
+		name = template.Name
+	}
+	lines := []string{"Тип: " + name}
+
+	if template == nil {
+		names := make([]string, 0, len(dataJson.Fields))
+		for name := range dataJson.Fields {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			lines = append(lines, name+": "+dataJson.Fields[name])
+		}
+		return strings.Join(lines, "\n"), nil
+	}
+
+	for _, field := range template.Fields {
+		value, ok := dataJson.Fields[field.Name]
+		if !ok {
+			continue
+		}
+		label := field.Label
+		if label == "" {
+			label = field.Name
+		}
+
+		switch field.Type {
+		case model.FieldSecret:
+			if !reveal {
+				value = "********"
+			}
+		case model.FieldTOTP:
+			code, left, err := totpCode(value, "", 0, 0, time.Now())
+			if err != nil {
+				return "", fmt.Errorf("поле %s: %w", field.Name, err)
+			}
+			secret := value
+			value = fmt.Sprintf("%s (ещё %d с)", code, int(left.Round(time.Second)/time.Second))
+			if reveal {
+				value += ", секрет " + secret
+			}
+		case model.FieldFile:
+			value = "файл, getBinary --key " + value
+		}
+		lines = append(lines, label+": "+value)
+	}
+	return strings.Join(lines, "\n"), nil
+}
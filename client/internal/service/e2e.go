@@ -0,0 +1,208 @@
+package service
+
+import (
+	"client/internal/model"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"golang.org/x/crypto/argon2"
+	"strings"
+)
+
+// e2ePrefix отмечает значение, зашифрованное на клиенте: "e2e:v2:<base64(nonce||шифротекст)>".
+// Сервер сохраняет такие значения без проверки и не может их расшифровать.
+const e2ePrefix = "e2e:v2:"
+
+// legacyE2EPrefix отмечает значения, зашифрованные ключом из пароля входа. Пароль получает сервер,
+// поэтому такие значения только расшифровываются и при изменении записи шифруются заново.
+const legacyE2EPrefix = "e2e:v1:"
+
+// e2eCheckValue шифруется ключом при задании параметров; по нему проверяется ключевая фраза.
+const e2eCheckValue = "gophkeeper-e2e-check"
+
+// e2eSaltSize — длина случайной соли ключа сквозного шифрования в байтах.
+const e2eSaltSize = 16
+
+// ErrE2ELocked возвращается, если запись зашифрована на клиенте, а ключ не получен.
+var ErrE2ELocked = errors.New("секрет зашифрован на клиенте: включите e2e в профиле и выполните вход командой aut")
+
+// ErrE2EPassphrase возвращается, если ключевая фраза не подходит к параметрам пользователя.
+var ErrE2EPassphrase = errors.New("неверная ключевая фраза сквозного шифрования")
+
+// NewE2EParams создаёт параметры сквозного шифрования: случайную соль и контрольное значение,
+// зашифрованное ключом из ключевой фразы. Параметры сохраняются на сервере, чтобы
+// на всех устройствах пользователя из той же фразы получался тот же ключ.
+func NewE2EParams(passphrase string) (model.E2EParams, error) {
+	salt := make([]byte, e2eSaltSize)
+	if _, err := rand.Read(salt); err != nil {
+		return model.E2EParams{}, err
+	}
+	aead, err := e2eCipher(passphrase, salt)
+	if err != nil {
+		return model.E2EParams{}, err
+	}
+
+	check, err := sealWith(aead, e2eCheckValue)
+	if err != nil {
+		return model.E2EParams{}, err
+	}
+	return model.E2EParams{Salt: base64.StdEncoding.EncodeToString(salt), Check: check}, nil
+}
+
+// EnableE2E выводит ключ сквозного шифрования из ключевой фразы и соли пользователя через Argon2id.
+// Ключевая фраза не отправляется на сервер, поэтому сервер не может вывести ключ.
+// Неподходящая фраза возвращает ErrE2EPassphrase.
+func (gk *GophKeeperClient) EnableE2E(passphrase string, params model.E2EParams) error {
+	salt, err := base64.StdEncoding.DecodeString(params.Salt)
+	if err != nil {
+		return fmt.Errorf("соль сквозного шифрования: %w", err)
+	}
+	aead, err := e2eCipher(passphrase, salt)
+	if err != nil {
+		return err
+	}
+
+	check, err := openWith(aead, params.Check)
+	if err != nil || check != e2eCheckValue {
+		return ErrE2EPassphrase
+	}
+	gk.e2e = aead
+	return nil
+}
+
+// EnableLegacyE2E выводит прежний ключ из логина и пароля, чтобы читать значения "e2e:v1:".
+func (gk *GophKeeperClient) EnableLegacyE2E(login, password string) error {
+	salt := sha256.Sum256([]byte("gophkeeper-e2e:" + login))
+	var err error
+	gk.legacy, err = e2eCipher(password, salt[:])
+	return err
+}
+
+// DisableE2E забывает ключи сквозного шифрования, например при смене профиля.
+func (gk *GophKeeperClient) DisableE2E() {
+	gk.e2e = nil
+	gk.legacy = nil
+}
+
+// e2eCipher выводит ключ AES-256-GCM из секрета и соли через Argon2id.
+func e2eCipher(secret string, salt []byte) (cipher.AEAD, error) {
+	key := argon2.IDKey([]byte(secret), salt, 1, 64*1024, 4, 32)
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// sealE2E шифрует значение ключом сквозного шифрования. Без ключа значение возвращается как есть.
+// Значения, зашифрованные прежним ключом из пароля, шифруются заново.
+func (gk *GophKeeperClient) sealE2E(value string) (string, error) {
+	if gk.e2e == nil || value == "" || strings.HasPrefix(value, e2ePrefix) {
+		return value, nil
+	}
+	if strings.HasPrefix(value, legacyE2EPrefix) {
+		plain, err := gk.openE2E(value)
+		if err != nil {
+			return "", err
+		}
+		value = plain
+	}
+
+	return sealWith(gk.e2e, value)
+}
+
+// openE2E расшифровывает значение, зашифрованное на клиенте. Остальные значения возвращаются как есть.
+func (gk *GophKeeperClient) openE2E(value string) (string, error) {
+	aead := gk.e2e
+	if strings.HasPrefix(value, legacyE2EPrefix) {
+		aead = gk.legacy
+	} else if !strings.HasPrefix(value, e2ePrefix) {
+		return value, nil
+	}
+	if aead == nil {
+		return "", ErrE2ELocked
+	}
+
+	plain, err := openWith(aead, value)
+	if err != nil {
+		return "", errors.New("не удалось расшифровать секрет: запись зашифрована другим ключом")
+	}
+	return plain, nil
+}
+
+// sealWith шифрует значение ключом aead и добавляет отметку e2ePrefix.
+func sealWith(aead cipher.AEAD, value string) (string, error) {
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+	sealed := aead.Seal(nonce, nonce, []byte(value), nil)
+	return e2ePrefix + base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+// openWith расшифровывает значение с отметкой e2ePrefix или legacyE2EPrefix ключом aead.
+func openWith(aead cipher.AEAD, value string) (string, error) {
+	payload, ok := strings.CutPrefix(value, e2ePrefix)
+	if !ok {
+		payload, ok = strings.CutPrefix(value, legacyE2EPrefix)
+	}
+	if !ok {
+		return "", errors.New("value is not sealed")
+	}
+
+	sealed, err := base64.StdEncoding.DecodeString(payload)
+	if err != nil {
+		return "", err
+	}
+	size := aead.NonceSize()
+	if len(sealed) < size {
+		return "", errors.New("sealed value is too short")
+	}
+	plain, err := aead.Open(nil, sealed[:size], sealed[size:], nil)
+	if err != nil {
+		return "", err
+	}
+	return string(plain), nil
+}
+
+// OpenRecord расшифровывает поля записи, зашифрованные на клиенте, например перед отправкой
+// по одноразовой ссылке: у получателя ссылки нет ключа сквозного шифрования.
+func (gk *GophKeeperClient) OpenRecord(recordType string, body []byte) ([]byte, error) {
+	var err error
+	switch recordType {
+	case model.RecordTOTP:
+		var data model.DataTOTPResponse
+		if err := json.Unmarshal(body, &data); err != nil {
+			return nil, err
+		}
+		if data.Secret, err = gk.openE2E(data.Secret); err != nil {
+			return nil, err
+		}
+		return json.Marshal(data)
+	case model.RecordSSH:
+		var data model.DataSSHKeyResponse
+		if err := json.Unmarshal(body, &data); err != nil {
+			return nil, err
+		}
+		if data.PrivateKey, err = gk.openE2E(data.PrivateKey); err != nil {
+			return nil, err
+		}
+		if data.Passphrase, err = gk.openE2E(data.Passphrase); err != nil {
+			return nil, err
+		}
+		return json.Marshal(data)
+	case model.RecordCustom:
+		data, err := gk.CustomValues(body)
+		if err != nil {
+			return nil, err
+		}
+		return json.Marshal(data)
+	}
+
+	return body, nil
+}
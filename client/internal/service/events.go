@@ -0,0 +1,43 @@
+package service
+
+import (
+	"bufio"
+	"client/internal/model"
+	"encoding/json"
+	"io"
+	"strings"
+)
+
+// ReadEvents разбирает поток Server-Sent Events и передаёт каждое событие в handle.
+// Чтение прекращается, когда handle возвращает false или поток заканчивается.
+func (gk *GophKeeperClient) ReadEvents(stream io.Reader, handle func(model.Event) bool) error {
+	scanner := bufio.NewScanner(stream)
+	var data strings.Builder
+	for scanner.Scan() {
+		line := scanner.Text()
+		switch {
+		case line == "":
+			// Пустая строка завершает событие.
+			if data.Len() == 0 {
+				continue
+			}
+			var event model.Event
+			err := json.Unmarshal([]byte(data.String()), &event)
+			data.Reset()
+			if err != nil {
+				return err
+			}
+			if !handle(event) {
+				return nil
+			}
+		case strings.HasPrefix(line, "data:"):
+			if data.Len() > 0 {
+				data.WriteByte('\n')
+			}
+			data.WriteString(strings.TrimPrefix(strings.TrimPrefix(line, "data:"), " "))
+		}
+		// Строки event: и комментарии-пинги не несут данных: тип события продублирован в JSON.
+	}
+
+	return scanner.Err()
+}
@@ -1,14 +1,23 @@
 package service
 
 import (
+	"client/internal/cache"
 	"client/internal/model"
+	"crypto/cipher"
+	"encoding/base64"
 	"encoding/json"
+	"fmt"
 	"net/http"
+	"strings"
 )
 
 type GophKeeperClient struct {
 	token  Token
 	cookie *http.Cookie
+	login  string
+	vault  *cache.Vault
+	e2e    cipher.AEAD // ключ сквозного шифрования; nil, если оно выключено
+	legacy cipher.AEAD // ключ из пароля для значений "e2e:v1:", сохранённых до ключевой фразы
 }
 
 func NewGophKeeperClient() *GophKeeperClient {
@@ -31,8 +40,31 @@ func (gk *GophKeeperClient) GetCookie() *http.Cookie {
 	return gk.cookie
 }
 
-func (gk *GophKeeperClient) CreateText(text string) (string, error) {
-	return "", nil
+// SetLogin запоминает логин текущего пользователя.
+func (gk *GophKeeperClient) SetLogin(login string) {
+	gk.login = login
+}
+
+// GetLogin возвращает логин текущего пользователя.
+func (gk *GophKeeperClient) GetLogin() string {
+	return gk.login
+}
+
+// SetCache подключает открытый локальный кэш, закрывая предыдущий.
+func (gk *GophKeeperClient) SetCache(vault *cache.Vault) {
+	if gk.vault != nil {
+		gk.vault.Close()
+	}
+	gk.vault = vault
+}
+
+// GetCache возвращает локальный кэш или nil, если пользователь ещё не вошёл.
+func (gk *GophKeeperClient) GetCache() *cache.Vault {
+	return gk.vault
+}
+
+func (gk *GophKeeperClient) CreateText(text string) ([]byte, error) {
+	return json.Marshal(model.DataText{Data: text})
 }
 
 func (gk *GophKeeperClient) GetText(body []byte) (string, error) {
@@ -45,18 +77,179 @@ func (gk *GophKeeperClient) GetText(body []byte) (string, error) {
 	return dataJson.Data, nil
 }
 
-func (gk *GophKeeperClient) CreateCreditCard(text string) (string, error) {
-	return "", nil
+func (gk *GophKeeperClient) CreateCreditCard(card model.DataCreditCard) ([]byte, error) {
+	return json.Marshal(card)
 }
 
 func (gk *GophKeeperClient) GetCreditCard(body []byte) (string, error) {
-	return "", nil
+	var dataJson model.DataCreditCardResponse
+	err := json.Unmarshal(body, &dataJson)
+	if err != nil {
+		return "", err
+	}
+
+	brand := dataJson.Brand
+	if brand == "" {
+		brand = "не определена"
+	}
+	result := fmt.Sprintf("Номер: %s\nПлатёжная система: %s\nДержатель: %s\nСрок действия: %s\nCVV: %s",
+		dataJson.CardNumber,
+		brand,
+		dataJson.CardholderName,
+		dataJson.ExpirationDate,
+		dataJson.CVV,
+	)
+
+	// Необязательные поля выводятся, только если заполнены.
+	optional := []struct{ name, value string }{
+		{"PIN", dataJson.PIN},
+		{"Тип карты", cardTypeNames[dataJson.CardType]},
+		{"Банк-эмитент", dataJson.IssuingBank},
+		{"Адрес для выставления счетов", dataJson.BillingAddress},
+		{"Заметки", dataJson.Notes},
+	}
+	for _, field := range optional {
+		if field.value != "" {
+			result += "\n" + field.name + ": " + field.value
+		}
+	}
+	return result, nil
 }
 
-func (gk *GophKeeperClient) CreateBinary(text string) (string, error) {
-	return "", nil
+// CreateBinary кодирует содержимое файла в base64 и формирует тело запроса.
+func (gk *GophKeeperClient) CreateBinary(filename string, content []byte) ([]byte, error) {
+	return json.Marshal(model.DataBinary{
+		FileName: filename,
+		Data:     base64.StdEncoding.EncodeToString(content),
+	})
 }
 
-func (gk *GophKeeperClient) GetBinary(body []byte) (string, error) {
-	return "", nil
+// GetBinary возвращает имя файла и декодированное содержимое.
+func (gk *GophKeeperClient) GetBinary(body []byte) (string, []byte, error) {
+	var dataJson model.DataBinaryResponse
+	err := json.Unmarshal(body, &dataJson)
+	if err != nil {
+		return "", nil, err
+	}
+
+	content, err := base64.StdEncoding.DecodeString(dataJson.Data)
+	if err != nil {
+		return "", nil, err
+	}
+	return dataJson.FileName, content, nil
+}
+
+// ParseKey извлекает ключ созданной записи из ответа сервера.
+func (gk *GophKeeperClient) ParseKey(recordType string, body []byte) (string, error) {
+	switch recordType {
+	case model.RecordText:
+		var data model.DataTextResponse
+		if err := json.Unmarshal(body, &data); err != nil {
+			return "", err
+		}
+		return data.DataTextKey.String(), nil
+	case model.RecordBinary:
+		var data model.DataBinaryResponse
+		if err := json.Unmarshal(body, &data); err != nil {
+			return "", err
+		}
+		return data.DataBinaryKey.String(), nil
+	case model.RecordCard:
+		var data model.DataCreditCardResponse
+		if err := json.Unmarshal(body, &data); err != nil {
+			return "", err
+		}
+		return data.DataCreditCardKey.String(), nil
+	case model.RecordTOTP:
+		var data model.DataTOTPResponse
+		if err := json.Unmarshal(body, &data); err != nil {
+			return "", err
+		}
+		return data.DataTOTPKey.String(), nil
+	case model.RecordSSH:
+		var data model.DataSSHKeyResponse
+		if err := json.Unmarshal(body, &data); err != nil {
+			return "", err
+		}
+		return data.DataSSHKeyKey.String(), nil
+	case model.RecordCustom:
+		var data model.DataCustomResponse
+		if err := json.Unmarshal(body, &data); err != nil {
+			return "", err
+		}
+		return data.DataCustomKey.String(), nil
+	}
+
+	return "", fmt.Errorf("unknown record type: %s", recordType)
+}
+
+// Describe возвращает краткое описание записи для вывода в списке.
+func (gk *GophKeeperClient) Describe(recordType string, body []byte) string {
+	switch recordType {
+	case model.RecordText:
+		text, err := gk.GetText(body)
+		if err != nil {
+			return ""
+		}
+		if len([]rune(text)) > 40 {
+			text = string([]rune(text)[:40]) + "…"
+		}
+		return text
+	case model.RecordBinary:
+		var data model.DataBinaryResponse
+		if err := json.Unmarshal(body, &data); err != nil {
+			return ""
+		}
+		return data.FileName
+	case model.RecordCard:
+		var data model.DataCreditCardResponse
+		if err := json.Unmarshal(body, &data); err != nil {
+			return ""
+		}
+		line := maskCardNumber(data.CardNumber)
+		if data.Brand != "" {
+			line = data.Brand + " " + line
+		}
+		if data.ExpirationDate != "" {
+			line += " до " + data.ExpirationDate
+		}
+		return line + " " + data.CardholderName
+	case model.RecordTOTP:
+		var data model.DataTOTPResponse
+		if err := json.Unmarshal(body, &data); err != nil {
+			return ""
+		}
+		if data.Issuer == "" {
+			return data.Account
+		}
+		return strings.TrimSpace(data.Issuer + " " + data.Account)
+	case model.RecordSSH:
+		var data model.DataSSHKeyResponse
+		if err := json.Unmarshal(body, &data); err != nil {
+			return ""
+		}
+		return strings.TrimSpace(data.Fingerprint + " " + data.Comment)
+	case model.RecordCustom:
+		var data model.DataCustomResponse
+		if err := json.Unmarshal(body, &data); err != nil {
+			return ""
+		}
+		return data.TemplateName
+	}
+
+	return ""
+}
+
+// cardTypeNames — названия типов карт для вывода.
+var cardTypeNames = map[string]string{
+	"debit":  "дебетовая",
+	"credit": "кредитная",
+}
+
+// maskCardNumber скрывает все цифры номера карты, кроме последних четырёх.
+func maskCardNumber(number string) string {
+	if len(number) <= 4 {
+		return number
+	}
+	return "**** " + number[len(number)-4:]
 }
@@ -0,0 +1,62 @@
+package service
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"fmt"
+)
+
+// SealLink шифрует содержимое ссылки AES-256-GCM случайным ключом.
+// Возвращает шифротекст для сервера и ключ для фрагмента URL, оба в base64url.
+func (gk *GophKeeperClient) SealLink(plain []byte) (string, string, error) {
+	key := make([]byte, 32)
+	if _, err := rand.Read(key); err != nil {
+		return "", "", err
+	}
+
+	aead, err := linkAEAD(key)
+	if err != nil {
+		return "", "", err
+	}
+
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", "", err
+	}
+	sealed := aead.Seal(nonce, nonce, plain, nil)
+
+	return base64.RawURLEncoding.EncodeToString(sealed), base64.RawURLEncoding.EncodeToString(key), nil
+}
+
+// OpenLink расшифровывает содержимое ссылки ключом из фрагмента URL.
+func (gk *GophKeeperClient) OpenLink(data, fragment string) ([]byte, error) {
+	key, err := base64.RawURLEncoding.DecodeString(fragment)
+	if err != nil {
+		return nil, fmt.Errorf("invalid link key: %w", err)
+	}
+	sealed, err := base64.RawURLEncoding.DecodeString(data)
+	if err != nil {
+		return nil, err
+	}
+
+	aead, err := linkAEAD(key)
+	if err != nil {
+		return nil, err
+	}
+
+	size := aead.NonceSize()
+	if len(sealed) < size {
+		return nil, fmt.Errorf("sealed value is too short")
+	}
+	return aead.Open(nil, sealed[:size], sealed[size:], nil)
+}
+
+func linkAEAD(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
@@ -0,0 +1,125 @@
+package service
+
+import (
+	"client/internal/model"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/agent"
+	"strings"
+)
+
+// CreateSSHKey проверяет закрытый ключ SSH, вычисляет открытый ключ и отпечаток SHA256
+// и формирует тело запроса. При сквозном шифровании закрытый ключ и пароль шифруются на клиенте.
+func (gk *GophKeeperClient) CreateSSHKey(privateKey []byte, passphrase, comment string) ([]byte, error) {
+	signer, err := parseSSHSigner(privateKey, passphrase)
+	if err != nil {
+		return nil, err
+	}
+
+	publicKey := strings.TrimSpace(string(ssh.MarshalAuthorizedKey(signer.PublicKey())))
+	if comment != "" {
+		publicKey += " " + comment
+	}
+	data := model.DataSSHKey{
+		PrivateKey:  string(privateKey),
+		PublicKey:   publicKey,
+		Comment:     comment,
+		Passphrase:  passphrase,
+		Fingerprint: ssh.FingerprintSHA256(signer.PublicKey()),
+	}
+
+	if data.PrivateKey, err = gk.sealE2E(data.PrivateKey); err != nil {
+		return nil, err
+	}
+	if data.Passphrase, err = gk.sealE2E(data.Passphrase); err != nil {
+		return nil, err
+	}
+	return json.Marshal(data)
+}
+
+// GetSSHKey возвращает описание ключа SSH. Закрытый ключ выводится, только если withPrivate.
+func (gk *GophKeeperClient) GetSSHKey(body []byte, withPrivate bool) (string, error) {
+	var dataJson model.DataSSHKeyResponse
+	err := json.Unmarshal(body, &dataJson)
+	if err != nil {
+		return "", err
+	}
+
+	result := fmt.Sprintf("Комментарий: %s\nОтпечаток: %s\nОткрытый ключ: %s",
+		dataJson.Comment,
+		dataJson.Fingerprint,
+		dataJson.PublicKey,
+	)
+	if !withPrivate {
+		return result, nil
+	}
+
+	privateKey, err := gk.openE2E(dataJson.PrivateKey)
+	if err != nil {
+		return "", err
+	}
+	passphrase, err := gk.openE2E(dataJson.Passphrase)
+	if err != nil {
+		return "", err
+	}
+	if passphrase != "" {
+		result += "\nПароль ключа: " + passphrase
+	}
+	return result + "\n" + strings.TrimRight(privateKey, "\n"), nil
+}
+
+// SSHAgentKey расшифровывает ключ SSH для загрузки в агент. Ключ остаётся только в памяти процесса.
+func (gk *GophKeeperClient) SSHAgentKey(body []byte) (agent.AddedKey, error) {
+	var dataJson model.DataSSHKeyResponse
+	if err := json.Unmarshal(body, &dataJson); err != nil {
+		return agent.AddedKey{}, err
+	}
+
+	privateKey, err := gk.openE2E(dataJson.PrivateKey)
+	if err != nil {
+		return agent.AddedKey{}, err
+	}
+	passphrase, err := gk.openE2E(dataJson.Passphrase)
+	if err != nil {
+		return agent.AddedKey{}, err
+	}
+
+	var raw any
+	if passphrase != "" {
+		raw, err = ssh.ParseRawPrivateKeyWithPassphrase([]byte(privateKey), []byte(passphrase))
+	} else {
+		raw, err = ssh.ParseRawPrivateKey([]byte(privateKey))
+	}
+	if err != nil {
+		return agent.AddedKey{}, err
+	}
+
+	comment := dataJson.Comment
+	if comment == "" {
+		comment = dataJson.Fingerprint
+	}
+	return agent.AddedKey{PrivateKey: raw, Comment: comment}, nil
+}
+
+// parseSSHSigner разбирает закрытый ключ в формате PEM или OpenSSH.
+func parseSSHSigner(privateKey []byte, passphrase string) (ssh.Signer, error) {
+	if passphrase != "" {
+		signer, err := ssh.ParsePrivateKeyWithPassphrase(privateKey, []byte(passphrase))
+		if err != nil {
+			return nil, fmt.Errorf("не удалось разобрать ключ SSH: %w", err)
+		}
+		return signer, nil
+	}
+
+	signer, err := ssh.ParsePrivateKey(privateKey)
+	var missing *ssh.PassphraseMissingError
+	if errors.As(err, &missing) {
+		return nil, errors.New("ключ SSH защищён паролем, укажите его флагом --passphrase")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("не удалось разобрать ключ SSH: %w", err)
+	}
+	return signer, nil
+}
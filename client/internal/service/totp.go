@@ -0,0 +1,182 @@
+package service
+
+import (
+	"client/internal/model"
+	"crypto/hmac"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base32"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// totpEncoding декодирует секрет base32 без выравнивания "=", как его записывают в otpauth://.
+var totpEncoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// ParseOTPAuthURI переносит в запись параметры ссылки вида
+// otpauth://totp/Issuer:account?secret=...&issuer=...&algorithm=...&digits=...&period=...
+// Ссылка разбирается на клиенте, чтобы при сквозном шифровании секрет не уходил на сервер.
+func ParseOTPAuthURI(raw string, data *model.DataTOTP) error {
+	u, err := url.Parse(raw)
+	if err != nil || u.Scheme != "otpauth" {
+		return errors.New("ожидается ссылка otpauth://")
+	}
+	if !strings.EqualFold(u.Host, "totp") {
+		return errors.New("поддерживаются только ссылки otpauth://totp/")
+	}
+
+	label := strings.TrimPrefix(u.Path, "/")
+	if issuer, account, ok := strings.Cut(label, ":"); ok {
+		data.Issuer, data.Account = issuer, strings.TrimSpace(account)
+	} else if label != "" {
+		data.Account = label
+	}
+
+	query := u.Query()
+	if secret := query.Get("secret"); secret != "" {
+		data.Secret = secret
+	}
+	// Параметр issuer главнее префикса метки.
+	if issuer := query.Get("issuer"); issuer != "" {
+		data.Issuer = issuer
+	}
+	if algorithm := query.Get("algorithm"); algorithm != "" {
+		data.Algorithm = strings.ToUpper(algorithm)
+	}
+	if digits := query.Get("digits"); digits != "" {
+		if data.Digits, err = strconv.Atoi(digits); err != nil {
+			return fmt.Errorf("digits: %w", err)
+		}
+	}
+	if period := query.Get("period"); period != "" {
+		if data.Period, err = strconv.Atoi(period); err != nil {
+			return fmt.Errorf("period: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// CreateTOTP формирует тело запроса на сохранение секрета TOTP. При сквозном шифровании
+// секрет проверяется на клиенте, потому что сервер получает только шифротекст.
+func (gk *GophKeeperClient) CreateTOTP(data model.DataTOTP) ([]byte, error) {
+	if gk.e2e != nil {
+		data.Secret = normalizeSecret(data.Secret)
+		if _, err := totpEncoding.DecodeString(data.Secret); err != nil || data.Secret == "" {
+			return nil, errors.New("секрет должен быть в кодировке base32")
+		}
+
+		var err error
+		if data.Secret, err = gk.sealE2E(data.Secret); err != nil {
+			return nil, err
+		}
+	}
+	return json.Marshal(data)
+}
+
+// GetTOTP возвращает параметры записи TOTP и текущий код.
+func (gk *GophKeeperClient) GetTOTP(body []byte) (string, error) {
+	var dataJson model.DataTOTPResponse
+	err := json.Unmarshal(body, &dataJson)
+	if err != nil {
+		return "", err
+	}
+
+	code, left, err := gk.TOTPCode(body, time.Now())
+	if err != nil {
+		return "", err
+	}
+
+	return fmt.Sprintf("Сервис: %s\nУчётная запись: %s\nАлгоритм: %s, цифр: %d, период: %d с\nКод: %s (ещё %d с)",
+		dataJson.Issuer,
+		dataJson.Account,
+		dataJson.Algorithm,
+		dataJson.Digits,
+		dataJson.Period,
+		code,
+		int(left.Round(time.Second)/time.Second),
+	), nil
+}
+
+// TOTPCode вычисляет код TOTP (RFC 6238) на момент now и время, которое он остаётся действительным.
+func (gk *GophKeeperClient) TOTPCode(body []byte, now time.Time) (string, time.Duration, error) {
+	var dataJson model.DataTOTPResponse
+	if err := json.Unmarshal(body, &dataJson); err != nil {
+		return "", 0, err
+	}
+
+	secret, err := gk.openE2E(dataJson.Secret)
+	if err != nil {
+		return "", 0, err
+	}
+	return totpCode(secret, dataJson.Algorithm, dataJson.Digits, dataJson.Period, now)
+}
+
+// totpCode вычисляет код TOTP по открытому секрету base32. Нулевые число цифр и период
+// заменяются значениями по умолчанию: 6 цифр, 30 секунд.
+func totpCode(secret, algorithm string, digits, periodSeconds int, now time.Time) (string, time.Duration, error) {
+	key, err := totpEncoding.DecodeString(normalizeSecret(secret))
+	if err != nil {
+		return "", 0, fmt.Errorf("секрет не в кодировке base32: %w", err)
+	}
+
+	period := int64(periodSeconds)
+	if period <= 0 {
+		period = 30
+	}
+	if digits <= 0 {
+		digits = 6
+	}
+
+	counter := now.Unix() / period
+	code, err := hotp(key, uint64(counter), digits, algorithm)
+	if err != nil {
+		return "", 0, err
+	}
+	expires := time.Unix((counter+1)*period, 0)
+	return code, expires.Sub(now), nil
+}
+
+// hotp вычисляет код HOTP (RFC 4226) для значения счётчика.
+func hotp(key []byte, counter uint64, digits int, algorithm string) (string, error) {
+	var newHash func() hash.Hash
+	switch strings.ToUpper(algorithm) {
+	case "", "SHA1":
+		newHash = sha1.New
+	case "SHA256":
+		newHash = sha256.New
+	case "SHA512":
+		newHash = sha512.New
+	default:
+		return "", fmt.Errorf("unknown TOTP algorithm: %s", algorithm)
+	}
+
+	var msg [8]byte
+	binary.BigEndian.PutUint64(msg[:], counter)
+	mac := hmac.New(newHash, key)
+	mac.Write(msg[:])
+	sum := mac.Sum(nil)
+
+	offset := sum[len(sum)-1] & 0x0f
+	value := binary.BigEndian.Uint32(sum[offset:offset+4]) & 0x7fffffff
+
+	modulo := uint32(1)
+	for i := 0; i < digits; i++ {
+		modulo *= 10
+	}
+	return fmt.Sprintf("%0*d", digits, value%modulo), nil
+}
+
+// normalizeSecret приводит секрет к виду, который принимает base32: заглавные буквы без пробелов и "=".
+func normalizeSecret(secret string) string {
+	secret = strings.ToUpper(strings.NewReplacer(" ", "", "-", "").Replace(secret))
+	return strings.TrimRight(secret, "=")
+}
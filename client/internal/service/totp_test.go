@@ -0,0 +1,97 @@
+package service
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+// Тестовые векторы RFC 6238, приложение B: восьмизначные коды с периодом 30 секунд.
+func TestTOTPCodeRFC6238(t *testing.T) {
+	secrets := map[string]string{
+		"SHA1":   "12345678901234567890",
+		"SHA256": "12345678901234567890123456789012",
+		"SHA512": "1234567890123456789012345678901234567890123456789012345678901234",
+	}
+
+	tests := []struct {
+		unix      int64
+		algorithm string
+		want      string
+	}{
+		{unix: 59, algorithm: "SHA1", want: "94287082"},
+		{unix: 59, algorithm: "SHA256", want: "46119246"},
+		{unix: 59, algorithm: "SHA512", want: "90693936"},
+		{unix: 1111111109, algorithm: "SHA1", want: "07081804"},
+		{unix: 1111111109, algorithm: "SHA256", want: "68084774"},
+		{unix: 1111111109, algorithm: "SHA512", want: "25091201"},
+		{unix: 1111111111, algorithm: "SHA1", want: "14050471"},
+		{unix: 1111111111, algorithm: "SHA256", want: "67062674"},
+		{unix: 1111111111, algorithm: "SHA512", want: "99943326"},
+		{unix: 1234567890, algorithm: "SHA1", want: "89005924"},
+		{unix: 1234567890, algorithm: "SHA256", want: "91819424"},
+		{unix: 1234567890, algorithm: "SHA512", want: "93441116"},
+		{unix: 2000000000, algorithm: "SHA1", want: "69279037"},
+		{unix: 2000000000, algorithm: "SHA256", want: "90698825"},
+		{unix: 2000000000, algorithm: "SHA512", want: "38618901"},
+		{unix: 20000000000, algorithm: "SHA1", want: "65353130"},
+		{unix: 20000000000, algorithm: "SHA256", want: "77737706"},
+		{unix: 20000000000, algorithm: "SHA512", want: "47863826"},
+	}
+
+	for _, tt := range tests {
+		now := time.Unix(tt.unix, 0)
+		t.Run(tt.algorithm+"/"+now.UTC().Format(time.RFC3339), func(t *testing.T) {
+			secret := totpEncoding.EncodeToString([]byte(secrets[tt.algorithm]))
+			code, left, err := totpCode(secret, tt.algorithm, 8, 30, now)
+			if err != nil {
+				t.Fatalf("totpCode: %v", err)
+			}
+			if code != tt.want {
+				t.Errorf("code = %s, want %s", code, tt.want)
+			}
+			if want := time.Duration(30-tt.unix%30) * time.Second; left != want {
+				t.Errorf("left = %s, want %s", left, want)
+			}
+		})
+	}
+}
+
+func TestTOTPCodeDefaults(t *testing.T) {
+	secret := totpEncoding.EncodeToString([]byte("12345678901234567890"))
+	now := time.Unix(59, 0)
+
+	tests := []struct {
+		name      string
+		secret    string
+		algorithm string
+		want      string
+		wantErr   bool
+	}{
+		// Без параметров используются SHA1, 6 цифр и период 30 секунд: последние цифры вектора RFC.
+		{name: "defaults", secret: secret, want: "287082"},
+		{name: "lower case algorithm", secret: secret, algorithm: "sha1", want: "287082"},
+		{name: "lower case secret with spaces", secret: strings.ToLower(secret[:4] + " " + secret[4:]), want: "287082"},
+		{name: "padded secret", secret: secret + "====", want: "287082"},
+		{name: "unknown algorithm", secret: secret, algorithm: "MD5", wantErr: true},
+		{name: "invalid base32", secret: "not base32!", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			code, _, err := totpCode(tt.secret, tt.algorithm, 0, 0, now)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("totpCode = %s, want error", code)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("totpCode: %v", err)
+			}
+			if code != tt.want {
+				t.Errorf("code = %s, want %s", code, tt.want)
+			}
+		})
+	}
+}
@@ -5,13 +5,57 @@ import (
 	"server/internal/config"
 )
 
-// ParseFlags
-func ParseFlags(cnf *config.Config) {
-	flag.StringVar(&cnf.Listen, "a", cnf.Listen, "address to run server")
-	flag.StringVar(&cnf.Postgres.Host, "pgh", cnf.Postgres.Host, "file storage path")
-	flag.StringVar(&cnf.Postgres.Port, "pgp", cnf.Postgres.Port, "file storage path")
-	flag.StringVar(&cnf.Postgres.User, "pgu", cnf.Postgres.User, "file storage path")
-	flag.StringVar(&cnf.Postgres.Password, "pgpass", cnf.Postgres.Password, "file storage path")
-	flag.StringVar(&cnf.Postgres.Database, "pgdb", cnf.Postgres.Database, "file storage path")
+var (
+	// configPath — файл настроек; если не задан, используется $CONFIG или config.json.
+	configPath string
+	// auditExport — файл для выгрузки журнала аудита; "-" означает стандартный вывод.
+	// Если флаг задан, сервер выгружает журнал, проверяет цепочку и завершается.
+	auditExport string
+	// auditAnchor — якорь "audit_id:entries" из лога сервера для проверки выгрузки журнала.
+	auditAnchor string
+	// printConfig — вывести итоговые настройки со скрытыми секретами и завершиться.
+	printConfig bool
+)
+
+// flagConfig принимает значения флагов настроек. В итоговую конфигурацию
+// переносятся только явно заданные флаги, см. applyFlags.
+var flagConfig config.Config
+
+// ParseFlags разбирает флаги командной строки.
+func ParseFlags() {
+	flag.StringVar(&configPath, "c", "", "config file: .json, .yaml, .yml or .toml (default $CONFIG or config.json)")
+	flag.StringVar(&flagConfig.Listen, "a", "", "address to run server, host:port")
+	flag.StringVar(&flagConfig.Postgres.DSN, "dsn", "", "PostgreSQL connection string; overrides the -pg* flags")
+	flag.StringVar(&flagConfig.Postgres.Host, "pgh", "", "PostgreSQL host")
+	flag.StringVar(&flagConfig.Postgres.Port, "pgp", "", "PostgreSQL port")
+	flag.StringVar(&flagConfig.Postgres.User, "pgu", "", "PostgreSQL user")
+	flag.StringVar(&flagConfig.Postgres.Password, "pgpass", "", "PostgreSQL password")
+	flag.StringVar(&flagConfig.Postgres.Database, "pgdb", "", "PostgreSQL database name")
+	flag.StringVar(&auditExport, "audit-export", "", "export audit log as JSONL to file (\"-\" for stdout), verify its hash chain and exit")
+	flag.StringVar(&auditAnchor, "audit-anchor", "", "with -audit-export: anchor audit_id:entries from the server log to detect deleted entries")
+	flag.BoolVar(&printConfig, "print-config", false, "print the effective configuration with secrets redacted and exit")
+	flag.Parse()
+}
 
+// applyFlags переносит в cnf флаги, явно заданные в командной строке,
+// поэтому пустое значение флага тоже перекрывает файл и окружение.
+func applyFlags(cnf *config.Config) {
+	flag.Visit(func(f *flag.Flag) {
+		switch f.Name {
+		case "a":
+			cnf.Listen = flagConfig.Listen
+		case "dsn":
+			cnf.Postgres.DSN = flagConfig.Postgres.DSN
+		case "pgh":
+			cnf.Postgres.Host = flagConfig.Postgres.Host
+		case "pgp":
+			cnf.Postgres.Port = flagConfig.Postgres.Port
+		case "pgu":
+			cnf.Postgres.User = flagConfig.Postgres.User
+		case "pgpass":
+			cnf.Postgres.Password = flagConfig.Postgres.Password
+		case "pgdb":
+			cnf.Postgres.Database = flagConfig.Postgres.Database
+		}
+	})
 }
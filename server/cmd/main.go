@@ -1,34 +1,77 @@
 package main
 
 import (
+	"errors"
 	"fmt"
+	"go.uber.org/zap"
+	"io/fs"
 	"os"
 	"server/internal/app"
 	"server/internal/config"
+	"server/internal/logger"
 )
 
 const DefaultFileConfig = "config.json"
 
 func main() {
-	cfg := config.NewConfig(
-		os.Getenv("LISTEN"),
-		os.Getenv("PG_HOST"),
-		os.Getenv("PG_PORT"),
-		os.Getenv("PG_USER"),
-		os.Getenv("PG_PASSWORD"),
-		os.Getenv("PG_DATABASE"),
-	)
-
-	fileConfig := os.Getenv("CONFIG")
-	if fileConfig == "" {
-		fileConfig = DefaultFileConfig
-	}
-	ParseFlags(cfg)
-	err := cfg.ReadFile(fileConfig)
+	ParseFlags()
+
+	cfg, err := loadConfig()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err.Error())
+		os.Exit(1)
+	}
+
+	if printConfig {
+		if err := cfg.Print(os.Stdout); err != nil {
+			fmt.Fprintln(os.Stderr, err.Error())
+			os.Exit(1)
+		}
+		return
+	}
+
+	log, level, err := logger.New(cfg.Log)
 	if err != nil {
-		fmt.Fprintln(os.Stdout, err.Error())
+		fmt.Fprintln(os.Stderr, err.Error())
 		os.Exit(1)
 	}
+	defer log.Sync()
 
-	app.Run(cfg)
+	if auditExport != "" {
+		if err := app.ExportAudit(cfg, auditExport, auditAnchor, log); err != nil {
+			log.Fatal("audit export", zap.Error(err))
+		}
+		return
+	}
+
+	app.Run(cfg, loadConfig, log, level)
+}
+
+// loadConfig собирает настройки по слоям: умолчания, файл, переменные окружения, флаги.
+// Отсутствие файла по умолчанию не ошибка, а явно указанный файл должен существовать.
+func loadConfig() (*config.Config, error) {
+	cfg := config.Default()
+
+	path, explicit := configPath, true
+	if path == "" {
+		path = os.Getenv("CONFIG")
+	}
+	if path == "" {
+		path, explicit = DefaultFileConfig, false
+	}
+
+	_, err := os.Stat(path)
+	if explicit || !errors.Is(err, fs.ErrNotExist) {
+		if err := cfg.ReadFile(path); err != nil {
+			return nil, err
+		}
+	}
+
+	cfg.ReadEnv()
+	applyFlags(cfg)
+
+	if err := cfg.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid configuration:\n%w", err)
+	}
+	return cfg, nil
 }
@@ -0,0 +1,61 @@
+package main
+
+import (
+	"flag"
+	"github.com/stretchr/testify/require"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// Флаги — верхний слой настроек: они перекрывают файл и переменные окружения,
+// а не заданные флаги оставляют значения нижних слоёв.
+func TestLoadConfigPrecedence(t *testing.T) {
+	key := "test-key-0123456789abcdef0123456789"
+	file := filepath.Join(t.TempDir(), "config.json")
+	content := `{
+  "listen": "file:8081",
+  "postgres": {"host": "file-host", "port": "6432", "user": "file-user", "password": "file-pass"},
+  "auth": {"jwt_keys": ["` + key + `"]},
+  "encryption": {"keys": ["` + key + `"]},
+  "audit": {"key": "` + key + `"}
+}`
+	require.NoError(t, os.WriteFile(file, []byte(content), 0o600))
+
+	for _, name := range []string{"CONFIG", "LISTEN", "DATABASE_DSN", "PG_PORT", "PG_DATABASE", "JWT_KEYS", "ENCRYPTION_KEYS", "AUDIT_KEY"} {
+		t.Setenv(name, "")
+	}
+	t.Setenv("PG_HOST", "env-host")
+	t.Setenv("PG_USER", "env-user")
+	t.Setenv("PG_PASSWORD", "env-pass")
+
+	commandLine, args, path, values := flag.CommandLine, os.Args, configPath, flagConfig
+	t.Cleanup(func() {
+		flag.CommandLine, os.Args, configPath, flagConfig = commandLine, args, path, values
+	})
+	flag.CommandLine = flag.NewFlagSet("server", flag.ContinueOnError)
+	os.Args = []string{"server", "-c", file, "-a", "flag:8083", "-pgu", "flag-user", "-pgpass", ""}
+	ParseFlags()
+
+	cfg, err := loadConfig()
+	require.NoError(t, err)
+
+	tests := []struct {
+		name string
+		got  string
+		want string
+	}{
+		{name: "default", got: cfg.Postgres.Database, want: "gophkeeper"},
+		{name: "file", got: cfg.Postgres.Port, want: "6432"},
+		{name: "env over file", got: cfg.Postgres.Host, want: "env-host"},
+		{name: "flag over file", got: cfg.Listen, want: "flag:8083"},
+		{name: "flag over env", got: cfg.Postgres.User, want: "flag-user"},
+		{name: "explicit empty flag over env", got: cfg.Postgres.Password, want: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.want, tt.got)
+		})
+	}
+}
@@ -2,52 +2,239 @@ package app
 
 import (
 	"context"
+	"errors"
 	"fmt"
-	"log"
+	"go.uber.org/zap"
+	"net/http"
 	"os"
 	"os/signal"
+	"server/internal/buildinfo"
 	"server/internal/config"
 	"server/internal/handlers"
+	"server/internal/metrics"
+	"server/internal/middleware"
+	"server/internal/model"
 	"server/internal/server"
 	"server/internal/service"
 	"server/internal/storage"
+	"server/internal/tracing"
 	"syscall"
+	"time"
 )
 
-func Run(cnf *config.Config) {
+// maintenanceInterval — период удаления устаревших версий истории, очистки корзины, истёкших ссылок и отзывов сессий.
+const maintenanceInterval = time.Hour
+
+// Run запускает сервер и блокируется до его остановки сигналом.
+// Все компоненты пишут в общий логгер log с уровнем level.
+// По SIGHUP настройки перечитываются через load, и изменения, не требующие перезапуска, применяются.
+func Run(cnf *config.Config, load Loader, log *zap.Logger, level zap.AtomicLevel) {
+	log.Info("starting GophKeeper server",
+		zap.String("version", buildinfo.Version),
+		zap.String("commit", buildinfo.Commit),
+		zap.String("date", buildinfo.Date),
+		zap.Int("api_version", buildinfo.APIVersion),
+	)
+
+	shutdownTracing, err := tracing.Setup(context.Background(), cnf.Tracing)
+	if err != nil {
+		log.Fatal("tracing setup", zap.Error(err))
+	}
+
+	service.SetSigningKeys(cnf.Auth.JWTKeys)
+	storage.SetEncryptionKeys(cnf.Encryption.Keys)
+	storage.SetAuditKey(cnf.Audit.Key)
+
 	objStorage := storage.NewPostgresql(*cnf)
-	err := objStorage.Connect()
+	err = objStorage.Connect()
+	if err != nil {
+		log.Fatal("connect to PostgreSQL", zap.Error(err))
+	}
+	log.Info("connected to PostgreSQL")
+	sealed, err := objStorage.SealPlaintext(context.Background())
 	if err != nil {
-		fmt.Fprintln(os.Stdout, err.Error())
-		os.Exit(1)
+		log.Fatal("seal plaintext record fields", zap.Error(err))
+	}
+	if sealed > 0 {
+		log.Info("sealed plaintext record fields", zap.Int64("rows", sealed))
 	}
 	objService := service.NewGophKeeper(objStorage)
 	objHandler := handlers.NewHandlers(&objService)
-	objServer := server.NewServer(server.Router(objHandler), cnf.Listen)
+
+	objMetrics := metrics.New()
+	objMetrics.RegisterDB(objStorage.DB(), cnf.Postgres.Database)
+	objMetrics.RegisterSessions(func() float64 {
+		return float64(objService.GetServiceAuthorization().ActiveSessions())
+	})
+	objMetrics.RegisterAuditFailures(func() float64 {
+		return float64(objService.AuditFailures())
+	})
+
+	limiter := middleware.NewRateLimiter(cnf.RateLimit)
+	settings := newReloader(cnf, load, level, limiter, log)
+
+	objServer := server.NewServer(server.Router(objHandler, log, objMetrics, limiter), cnf.Listen)
+	objServer.RegisterOnShutdown(objService.GetServiceEvents().Close)
+
+	maintenanceCtx, stopMaintenance := context.WithCancel(context.Background())
+	go maintenance(maintenanceCtx, objStorage, settings.config, log)
+	go remindExpiring(maintenanceCtx, objStorage, settings.config, log)
+
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	go func() {
+		for range hup {
+			log.Info("reload signal received")
+			settings.reload()
+		}
+	}()
 
 	idleConnsClosed := make(chan struct{})
 	stop := make(chan os.Signal, 1)
 	signal.Notify(stop, syscall.SIGTERM, syscall.SIGINT, syscall.SIGQUIT)
 
 	go func() {
-		<-stop
-		if err := objServer.Stop(context.Background()); err != nil {
-			log.Printf("HTTP server Shutdown: %v", err)
-		}
+		sig := <-stop
+		log.Info("shutdown signal received", zap.String("signal", sig.String()))
+		signal.Stop(hup)
+		stopMaintenance()
+
+		drain(objServer, &objService, settings.config().Shutdown, log)
 
 		if err := objStorage.Close(); err != nil {
-			log.Printf("Object storage close: %v", err)
+			log.Error("storage close", zap.Error(err))
+		}
+
+		if err := shutdownTracing(context.Background()); err != nil {
+			log.Error("tracing shutdown", zap.Error(err))
 		}
 
-		log.Println("Signal shutdown")
 		close(idleConnsClosed)
 	}()
 
+	log.Info("server started", zap.String("listen", cnf.Listen))
 	err = objServer.Start()
-	if err != nil {
-		log.Printf("Starting server error: %s", err)
+	if err != nil && !errors.Is(err, http.ErrServerClosed) {
+		// Сервер не запустился, например адрес занят: ресурсы освобождаются так же, как по сигналу.
+		log.Error("HTTP server", zap.Error(err))
+		select {
+		case stop <- syscall.SIGTERM:
+		default:
+		}
+		<-idleConnsClosed
+		log.Fatal("server stopped", zap.Error(err))
 	}
 
 	<-idleConnsClosed
-	fmt.Println("Server Shutdown gracefully")
+	log.Info("server shutdown gracefully")
+}
+
+// drain останавливает HTTP-сервер, дожидаясь начатых запросов не дольше shutdown.DrainTimeout.
+// Сначала проверка готовности перестаёт проходить, чтобы балансировщик
+// успел исключить сервер, пока тот ещё принимает запросы.
+func drain(srv *server.Server, gk *service.GophKeeper, shutdown config.ShutdownSettings, log *zap.Logger) {
+	gk.SetDraining()
+	if shutdown.ReadinessDelay > 0 {
+		log.Info("draining, readiness check disabled", zap.Duration("delay", shutdown.ReadinessDelay))
+		time.Sleep(shutdown.ReadinessDelay)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), shutdown.DrainTimeout)
+	defer cancel()
+	if err := srv.Stop(ctx); err != nil {
+		log.Warn("drain timeout exceeded, in-flight requests cancelled", zap.Error(err))
+	}
+}
+
+// ExportAudit выгружает журнал аудита в файл path в формате JSONL и проверяет цепочки хешей.
+// Путь "-" означает стандартный вывод. Непустой anchor — якорь "audit_id:entries" из записи
+// "audit anchor" в логе сервера; по нему обнаруживается удаление записей журнала.
+func ExportAudit(cnf *config.Config, path, anchor string, log *zap.Logger) error {
+	var auditAnchor *model.AuditAnchor
+	if anchor != "" {
+		auditAnchor = &model.AuditAnchor{}
+		if _, err := fmt.Sscanf(anchor, "%d:%d", &auditAnchor.AuditID, &auditAnchor.Entries); err != nil {
+			return fmt.Errorf("audit anchor %q: expected audit_id:entries", anchor)
+		}
+	}
+
+	storage.SetAuditKey(cnf.Audit.Key)
+	objStorage := storage.NewPostgresql(*cnf)
+	if err := objStorage.Connect(); err != nil {
+		return err
+	}
+	defer objStorage.Close()
+
+	out := os.Stdout
+	if path != "-" {
+		file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0o600)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		out = file
+	}
+
+	count, err := objStorage.ExportAudit(context.Background(), out, auditAnchor)
+	if err != nil {
+		return fmt.Errorf("exported %d audit entries: %w", count, err)
+	}
+
+	log.Info("audit log exported, hash chain verified", zap.Int64("entries", count))
+	return nil
+}
+
+// maintenance периодически применяет настройки хранения истории, очищает корзину,
+// удаляет истёкшие ссылки и отзывы сессий и пишет в лог якорь журнала аудита, пока не отменён ctx. Отмена прерывает и выполняющийся запрос.
+// Настройки хранения берутся из settings на каждом проходе, поэтому применяются после перезагрузки.
+func maintenance(ctx context.Context, objStorage *storage.PostgreSQL, settings func() *config.Config, log *zap.Logger) {
+	ticker := time.NewTicker(maintenanceInterval)
+	defer ticker.Stop()
+
+	for {
+		cnf := settings()
+		count, err := objStorage.PruneHistory(ctx, cnf.History)
+		if err != nil {
+			log.Error("history prune", zap.Error(err))
+		} else if count > 0 {
+			log.Info("history pruned", zap.Int64("versions", count))
+		}
+
+		if cnf.Trash.RetentionDays > 0 {
+			count, err = objStorage.PurgeTrash(ctx, time.Duration(cnf.Trash.RetentionDays)*24*time.Hour)
+			if err != nil {
+				log.Error("trash purge", zap.Error(err))
+			} else if count > 0 {
+				log.Info("trash purged", zap.Int64("records", count))
+			}
+		}
+
+		count, err = objStorage.PurgeLinks(ctx)
+		if err != nil {
+			log.Error("links purge", zap.Error(err))
+		} else if count > 0 {
+			log.Info("expired links removed", zap.Int64("links", count))
+		}
+
+		count, err = objStorage.PurgeRevokedSessions(ctx)
+		if err != nil {
+			log.Error("revoked sessions purge", zap.Error(err))
+		} else if count > 0 {
+			log.Info("expired session revocations removed", zap.Int64("sessions", count))
+		}
+
+		// Лог хранится вне базы данных, поэтому якорь в нём позволяет обнаружить удаление записей журнала.
+		anchor, err := objStorage.AuditAnchor(ctx)
+		if err != nil {
+			log.Error("audit anchor", zap.Error(err))
+		} else {
+			log.Info("audit anchor", zap.String("anchor", fmt.Sprintf("%d:%d", anchor.AuditID, anchor.Entries)))
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
 }
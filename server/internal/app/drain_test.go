@@ -0,0 +1,145 @@
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+	"net"
+	"net/http"
+	"server/internal/config"
+	"server/internal/handlers"
+	"server/internal/model"
+	"server/internal/server"
+	"server/internal/service"
+	"testing"
+	"time"
+)
+
+// drainStorage отвечает только на вопросы проверки готовности во время остановки.
+type drainStorage struct {
+	service.Storage
+}
+
+func (drainStorage) ExpectedSchemaVersion() int64 {
+	return 1
+}
+
+// freeAddr возвращает свободный локальный адрес для сервера.
+func freeAddr(t *testing.T) string {
+	t.Helper()
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	addr := l.Addr().String()
+	require.NoError(t, l.Close())
+	return addr
+}
+
+func TestDrain(t *testing.T) {
+	tests := []struct {
+		name        string
+		work        time.Duration // время обработки начатого запроса без отмены
+		shutdown    config.ShutdownSettings
+		wantStatus  int // ответ на начатый запрос; 0 — соединение закрыто
+		wantAborted bool
+	}{
+		{
+			name:       "in-flight request finishes",
+			work:       300 * time.Millisecond,
+			shutdown:   config.ShutdownSettings{ReadinessDelay: 100 * time.Millisecond, DrainTimeout: 5 * time.Second},
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:        "request past the timeout is cancelled",
+			work:        time.Minute,
+			shutdown:    config.ShutdownSettings{ReadinessDelay: 100 * time.Millisecond, DrainTimeout: 200 * time.Millisecond},
+			wantAborted: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gk := service.NewGophKeeper(drainStorage{})
+			h := handlers.NewHandlers(&gk)
+
+			started := make(chan struct{})
+			requestErr := make(chan error, 1)
+			mux := http.NewServeMux()
+			mux.HandleFunc("/readyz", h.Readyz)
+			mux.HandleFunc("/slow", func(w http.ResponseWriter, r *http.Request) {
+				close(started)
+				select {
+				case <-time.After(tt.work):
+					requestErr <- nil
+				case <-r.Context().Done():
+					requestErr <- r.Context().Err()
+					return
+				}
+				w.WriteHeader(http.StatusOK)
+			})
+
+			addr := freeAddr(t)
+			srv := server.NewServer(mux, addr)
+			go srv.Start()
+			require.Eventually(t, func() bool {
+				conn, err := net.Dial("tcp", addr)
+				if err == nil {
+					conn.Close()
+				}
+				return err == nil
+			}, time.Second, 10*time.Millisecond)
+
+			slowStatus := make(chan int, 1)
+			go func() {
+				resp, err := http.Get("http://" + addr + "/slow")
+				if err != nil {
+					slowStatus <- 0
+					return
+				}
+				resp.Body.Close()
+				slowStatus <- resp.StatusCode
+			}()
+			<-started
+
+			core, logs := observer.New(zapcore.InfoLevel)
+			drained := make(chan struct{})
+			go func() {
+				drain(srv, &gk, tt.shutdown, zap.New(core))
+				close(drained)
+			}()
+
+			// Пока идёт задержка, сервер принимает запросы, но не готов.
+			require.Eventually(t, func() bool { return logs.FilterMessage("draining, readiness check disabled").Len() == 1 }, time.Second, time.Millisecond)
+			client := &http.Client{Transport: &http.Transport{DisableKeepAlives: true}}
+			resp, err := client.Get("http://" + addr + "/readyz")
+			require.NoError(t, err)
+			var readiness model.Readiness
+			require.NoError(t, json.NewDecoder(resp.Body).Decode(&readiness))
+			resp.Body.Close()
+			require.Equal(t, http.StatusServiceUnavailable, resp.StatusCode)
+			require.Equal(t, model.HealthDraining, readiness.Status)
+
+			select {
+			case <-drained:
+			case <-time.After(5 * time.Second):
+				t.Fatal("drain did not return")
+			}
+
+			require.Equal(t, tt.wantStatus, <-slowStatus)
+			timeouts := logs.FilterMessage("drain timeout exceeded, in-flight requests cancelled").Len()
+			if !tt.wantAborted {
+				require.NoError(t, <-requestErr)
+				require.Zero(t, timeouts)
+				return
+			}
+			require.ErrorIs(t, <-requestErr, context.Canceled)
+			require.Equal(t, 1, timeouts)
+
+			// После остановки новые соединения не принимаются.
+			_, err = client.Get("http://" + addr + "/readyz")
+			require.Error(t, err)
+		})
+	}
+}
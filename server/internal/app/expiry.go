@@ -0,0 +1,50 @@
+package app
+
+import (
+	"context"
+	"go.uber.org/zap"
+	"server/internal/config"
+	"server/internal/notify"
+	"server/internal/storage"
+	"time"
+)
+
+// remindExpiring раз в maintenanceInterval отправляет напоминания о записях, срок действия которых
+// скоро истекает, пока не отменён ctx. Уведомитель и горизонт берутся из settings на каждом проходе,
+// поэтому применяются после перезагрузки настроек.
+func remindExpiring(ctx context.Context, objStorage *storage.PostgreSQL, settings func() *config.Config, log *zap.Logger) {
+	ticker := time.NewTicker(maintenanceInterval)
+	defer ticker.Stop()
+
+	for {
+		expiry := settings().Expiry
+		if notifier := notify.New(expiry); notifier != nil && expiry.NotifyDays > 0 {
+			count, err := sendReminders(ctx, objStorage, notifier, expiry.NotifyDays)
+			if err != nil {
+				log.Error("expiry reminders", zap.Error(err))
+			} else if count > 0 {
+				log.Info("expiry reminders sent", zap.Int("records", count))
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// sendReminders доставляет напоминания о записях, о которых ещё не напоминали,
+// и отмечает их. Если доставка не удалась, напоминания повторяются на следующем проходе.
+func sendReminders(ctx context.Context, objStorage *storage.PostgreSQL, notifier notify.Notifier, days int) (int, error) {
+	records, err := objStorage.SelectDueReminders(ctx, days)
+	if err != nil || len(records) == 0 {
+		return 0, err
+	}
+
+	if err := notifier.NotifyExpiring(ctx, records); err != nil {
+		return 0, err
+	}
+	return len(records), objStorage.MarkReminded(ctx, records)
+}
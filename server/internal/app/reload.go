@@ -0,0 +1,113 @@
+package app
+
+import (
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"server/internal/config"
+	"server/internal/middleware"
+	"server/internal/service"
+	"server/internal/storage"
+	"strings"
+	"sync/atomic"
+)
+
+// Loader заново собирает настройки из тех же источников, что и при запуске.
+type Loader func() (*config.Config, error)
+
+// reloadable — параметры и группы параметров (с точкой на конце), которые применяются без перезапуска.
+// Остальные, например listen и postgres.*, требуют перезапуска сервера.
+var reloadable = []string{
+	"auth.",
+	"encryption.",
+	"rate_limit.",
+	"log.level",
+	"history.",
+	"trash.",
+	"shutdown.",
+	"expiry.",
+}
+
+// reloader хранит действующие настройки и применяет изменения к работающему серверу.
+type reloader struct {
+	load    Loader
+	current atomic.Pointer[config.Config]
+	level   zap.AtomicLevel
+	limiter *middleware.RateLimiter
+	log     *zap.Logger
+}
+
+func newReloader(cnf *config.Config, load Loader, level zap.AtomicLevel, limiter *middleware.RateLimiter, log *zap.Logger) *reloader {
+	rl := &reloader{
+		load:    load,
+		level:   level,
+		limiter: limiter,
+		log:     log,
+	}
+	rl.current.Store(cnf)
+	return rl
+}
+
+// config возвращает действующие настройки.
+func (rl *reloader) config() *config.Config {
+	return rl.current.Load()
+}
+
+// reload перечитывает настройки. Новые настройки проверяются целиком до применения:
+// при ошибке остаются прежние, и ни одно изменение не применяется частично.
+// Изменения параметров, требующих перезапуска, только журналируются.
+// Значения в журнал не пишутся, так как среди них есть секреты.
+func (rl *reloader) reload() {
+	next, err := rl.load()
+	if err != nil {
+		rl.log.Error("config reload failed, keeping current settings", zap.Error(err))
+		return
+	}
+
+	current := rl.config()
+	var applied, ignored []string
+	for _, key := range current.Diff(next) {
+		if isReloadable(key) {
+			applied = append(applied, key)
+		} else {
+			ignored = append(ignored, key)
+		}
+	}
+
+	// Параметры, требующие перезапуска, сохраняют значения, с которыми работает сервер.
+	next.Listen = current.Listen
+	next.Postgres = current.Postgres
+	next.Log.Encoding = current.Log.Encoding
+	next.Tracing = current.Tracing
+	// Ключ аудита задаётся только при запуске: цепочка журнала подписывается одним ключом.
+	next.Audit = current.Audit
+
+	var level zapcore.Level
+	if err := level.UnmarshalText([]byte(next.Log.Level)); err != nil {
+		rl.log.Error("config reload failed, keeping current settings", zap.Error(err))
+		return
+	}
+
+	rl.level.SetLevel(level)
+	service.SetSigningKeys(next.Auth.JWTKeys)
+	storage.SetEncryptionKeys(next.Encryption.Keys)
+	rl.limiter.Update(next.RateLimit)
+	rl.current.Store(next)
+
+	if len(ignored) > 0 {
+		rl.log.Warn("config reload: settings require restart and were ignored", zap.Strings("settings", ignored))
+	}
+	if len(applied) == 0 {
+		rl.log.Info("config reloaded, nothing to apply")
+		return
+	}
+	rl.log.Info("config reloaded", zap.Strings("applied", applied))
+}
+
+func isReloadable(key string) bool {
+	for _, prefix := range reloadable {
+		if key == prefix || strings.HasSuffix(prefix, ".") && strings.HasPrefix(key, prefix) {
+			return true
+		}
+	}
+	return false
+}
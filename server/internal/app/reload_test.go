@@ -0,0 +1,118 @@
+package app
+
+import (
+	"errors"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+	"server/internal/config"
+	"server/internal/middleware"
+	"testing"
+)
+
+// testKey — ключ подписи, шифрования и аудита, который проходит Validate.
+const testKey = "test-key-0123456789abcdef0123456789"
+
+func testConfig() *config.Config {
+	cfg := config.Default()
+	cfg.Auth.JWTKeys = []string{testKey}
+	cfg.Encryption.Keys = []string{testKey}
+	cfg.Audit.Key = testKey
+	return cfg
+}
+
+func TestReload(t *testing.T) {
+	tests := []struct {
+		name        string
+		modify      func(*config.Config)
+		loadErr     error
+		wantApplied bool // true — новые настройки приняты
+		wantLevel   zapcore.Level
+		wantIgnored []string // параметры, изменения которых требуют перезапуска
+	}{
+		{
+			name: "reloadable settings applied",
+			modify: func(c *config.Config) {
+				c.Log.Level = "debug"
+				c.Auth.JWTKeys = []string{testKey + "new", testKey}
+				c.RateLimit = config.RateLimitSettings{RequestsPerSecond: 5, Burst: 10}
+				c.History.Limit = 3
+			},
+			wantApplied: true,
+			wantLevel:   zapcore.DebugLevel,
+		},
+		{
+			name: "settings requiring restart keep current values",
+			modify: func(c *config.Config) {
+				c.Listen = "localhost:9090"
+				c.Postgres.Host = "other-host"
+				c.Log.Encoding = "console"
+				c.Audit.Key = testKey + "new"
+				c.History.Limit = 3
+			},
+			wantApplied: true,
+			wantLevel:   zapcore.InfoLevel,
+			wantIgnored: []string{"listen", "postgres.host", "log.encoding", "audit.key"},
+		},
+		{
+			name: "invalid level keeps everything",
+			modify: func(c *config.Config) {
+				c.Log.Level = "trace"
+				c.Auth.JWTKeys = []string{testKey + "new"}
+				c.History.Limit = 3
+			},
+			wantLevel: zapcore.InfoLevel,
+		},
+		{
+			name:      "load error keeps everything",
+			loadErr:   errors.New("invalid configuration"),
+			wantLevel: zapcore.InfoLevel,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			current := testConfig()
+			next := testConfig()
+			if tt.modify != nil {
+				tt.modify(next)
+			}
+			load := func() (*config.Config, error) {
+				if tt.loadErr != nil {
+					return nil, tt.loadErr
+				}
+				return next, nil
+			}
+
+			core, logs := observer.New(zapcore.DebugLevel)
+			level := zap.NewAtomicLevelAt(zapcore.InfoLevel)
+			rl := newReloader(current, load, level, middleware.NewRateLimiter(current.RateLimit), zap.New(core))
+			rl.reload()
+
+			require.Equal(t, tt.wantLevel, level.Level())
+			got := rl.config()
+			if !tt.wantApplied {
+				require.Same(t, current, got)
+				require.Equal(t, 1, logs.FilterMessage("config reload failed, keeping current settings").Len())
+				return
+			}
+
+			require.Equal(t, next.History, got.History)
+			require.Equal(t, next.Auth, got.Auth)
+			require.Equal(t, next.RateLimit, got.RateLimit)
+			require.Equal(t, current.Listen, got.Listen)
+			require.Equal(t, current.Postgres, got.Postgres)
+			require.Equal(t, current.Log.Encoding, got.Log.Encoding)
+			require.Equal(t, current.Audit, got.Audit)
+
+			warnings := logs.FilterMessage("config reload: settings require restart and were ignored").All()
+			if len(tt.wantIgnored) == 0 {
+				require.Empty(t, warnings)
+				return
+			}
+			require.Len(t, warnings, 1)
+			require.ElementsMatch(t, tt.wantIgnored, warnings[0].ContextMap()["settings"])
+		})
+	}
+}
@@ -0,0 +1,36 @@
+// Package buildinfo содержит сведения о сборке сервера. Версия, коммит и дата
+// задаются при компоновке:
+//
+//	go build -ldflags "-X server/internal/buildinfo.Version=v1.2.0 \
+//	  -X server/internal/buildinfo.Commit=$(git rev-parse --short HEAD) \
+//	  -X server/internal/buildinfo.Date=$(date -u +%Y-%m-%dT%H:%M:%SZ)" ./cmd
+package buildinfo
+
+// Значения по умолчанию остаются у сборок без ldflags, например при go run.
+var (
+	Version = "dev"
+	Commit  = "none"
+	Date    = "unknown"
+)
+
+// APIVersion — версия HTTP API. Увеличивается при несовместимых изменениях API,
+// клиент с другой версией предупреждает пользователя.
+const APIVersion = 1
+
+// Info описывает сборку в ответе /version.
+type Info struct {
+	Version    string `json:"version"`
+	Commit     string `json:"commit"`
+	Date       string `json:"date"`
+	APIVersion int    `json:"api_version"`
+}
+
+// Get возвращает сведения о текущей сборке.
+func Get() Info {
+	return Info{
+		Version:    Version,
+		Commit:     Commit,
+		Date:       Date,
+		APIVersion: APIVersion,
+	}
+}
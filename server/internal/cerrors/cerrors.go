@@ -0,0 +1,55 @@
+// Package cerrors содержит ошибки предметной области, по которым обработчики
+// выбирают HTTP-статус ответа.
+package cerrors
+
+import (
+	"errors"
+	"strings"
+)
+
+// ErrNotFound возвращается, если запись не существует, удалена или недоступна пользователю.
+var ErrNotFound = errors.New("record not found")
+
+// ErrConflict возвращается, если запись изменилась после версии, на которой основано изменение,
+// или по ней есть неразрешённый конфликт. Изменение сохраняется как конфликтная копия.
+var ErrConflict = errors.New("record version conflict")
+
+// ErrPreconditionRequired возвращается, если изменение записи не указывает ревизию, на которой оно основано:
+// без неё сервер не может обнаружить конфликт с изменениями других устройств.
+var ErrPreconditionRequired = errors.New("base revision required")
+
+// ErrForbidden возвращается, если у пользователя есть доступ к записи, но недостаточно прав на действие.
+var ErrForbidden = errors.New("access denied")
+
+// FieldError описывает ошибку значения одного поля запроса.
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// ValidationError возвращается, если поля запроса не прошли проверку.
+// Обработчики отвечают на неё статусом 422 и списком ошибок полей.
+type ValidationError struct {
+	Errors []FieldError `json:"errors"`
+}
+
+func (e *ValidationError) Error() string {
+	messages := make([]string, len(e.Errors))
+	for i, field := range e.Errors {
+		messages[i] = field.Field + ": " + field.Message
+	}
+	return "validation failed: " + strings.Join(messages, "; ")
+}
+
+// Add добавляет ошибку поля.
+func (e *ValidationError) Add(field, message string) {
+	e.Errors = append(e.Errors, FieldError{Field: field, Message: message})
+}
+
+// OrNil возвращает ошибку, только если найдена хотя бы одна ошибка поля.
+func (e *ValidationError) OrNil() error {
+	if len(e.Errors) == 0 {
+		return nil
+	}
+	return e
+}
@@ -0,0 +1,81 @@
+package config
+
+import (
+	"fmt"
+	"io"
+	"net/url"
+	"reflect"
+	"regexp"
+)
+
+// redacted заменяет значения секретных параметров при выводе настроек.
+const redacted = "[REDACTED]"
+
+// dsnPassword находит пароль в строке подключения вида "host=... password=...".
+var dsnPassword = regexp.MustCompile(`(?i)(password\s*=\s*)('[^']*'|\S+)`)
+
+// Print выводит итоговые настройки по одной на строку в виде "postgres.host = localhost".
+// Значения полей с тегом secret:"true" скрываются; в DSN скрывается только пароль.
+func (c *Config) Print(w io.Writer) error {
+	var err error
+	walk("", reflect.ValueOf(*c), func(key string, field reflect.StructField, value reflect.Value) {
+		if err != nil {
+			return
+		}
+
+		text := fmt.Sprint(value.Interface())
+		if field.Tag.Get("secret") == "true" && !value.IsZero() {
+			text = redacted
+			if key == "postgres.dsn" {
+				text = redactDSN(value.String())
+			}
+		}
+		_, err = fmt.Fprintf(w, "%s = %s\n", key, text)
+	})
+	return err
+}
+
+// Diff возвращает параметры, значения которых в next отличаются от c, например "log.level".
+func (c *Config) Diff(next *Config) []string {
+	previous := map[string]any{}
+	walk("", reflect.ValueOf(*c), func(key string, _ reflect.StructField, value reflect.Value) {
+		previous[key] = value.Interface()
+	})
+
+	var changed []string
+	walk("", reflect.ValueOf(*next), func(key string, _ reflect.StructField, value reflect.Value) {
+		if !reflect.DeepEqual(previous[key], value.Interface()) {
+			changed = append(changed, key)
+		}
+	})
+	return changed
+}
+
+// walk вызывает fn для каждого параметра; имена вложенных параметров соединяются точкой.
+func walk(prefix string, v reflect.Value, fn func(key string, field reflect.StructField, value reflect.Value)) {
+	for i := 0; i < v.NumField(); i++ {
+		field := v.Type().Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		key := prefix + field.Tag.Get("mapstructure")
+		if v.Field(i).Kind() == reflect.Struct {
+			walk(key+".", v.Field(i), fn)
+			continue
+		}
+		fn(key, field, v.Field(i))
+	}
+}
+
+// redactDSN скрывает пароль в строке подключения PostgreSQL.
+func redactDSN(dsn string) string {
+	if u, err := url.Parse(dsn); err == nil && u.Scheme != "" {
+		query := u.Query()
+		if query.Has("password") {
+			query.Set("password", "xxxxx")
+			u.RawQuery = query.Encode()
+		}
+		return u.Redacted()
+	}
+	return dsnPassword.ReplaceAllString(dsn, "${1}"+redacted)
+}
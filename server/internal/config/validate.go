@@ -0,0 +1,182 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// minSecretKey — наименьшая длина ключа подписи или шифрования; столько символов
+// даёт base64 от 24 случайных байт.
+const minSecretKey = 32
+
+// PlaceholderSecret стоит в примере настроек на месте ключей и не принимается Validate.
+const PlaceholderSecret = "change-me"
+
+// maxNotifyDays — наибольший горизонт напоминаний об истечении срока.
+const maxNotifyDays = 366
+
+// Validate проверяет итоговые настройки и возвращает все найденные ошибки сразу.
+func (c *Config) Validate() error {
+	var errs []error
+	check := func(field string, err error) {
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", field, err))
+		}
+	}
+
+	check("listen", validateAddress(c.Listen))
+
+	if c.Postgres.DSN != "" {
+		check("postgres.dsn", validateDSN(c.Postgres.DSN))
+	} else {
+		check("postgres.host", required(c.Postgres.Host))
+		check("postgres.port", validatePort(c.Postgres.Port))
+		check("postgres.user", required(c.Postgres.User))
+		check("postgres.database", required(c.Postgres.Database))
+	}
+	if c.Postgres.QueryTimeout < 0 {
+		check("postgres.query_timeout", errors.New("must not be negative"))
+	}
+
+	if c.History.Limit < 0 {
+		check("history.limit", errors.New("must not be negative"))
+	}
+	if c.History.MaxAge < 0 {
+		check("history.max_age", errors.New("must not be negative"))
+	}
+	if c.Trash.RetentionDays < 0 {
+		check("trash.retention_days", errors.New("must not be negative"))
+	}
+
+	check("log.level", oneOf(c.Log.Level, "debug", "info", "warn", "error"))
+	check("log.encoding", oneOf(c.Log.Encoding, "json", "console"))
+
+	check("tracing.exporter", oneOf(c.Tracing.Exporter, "none", "stdout", "otlp"))
+	if c.Tracing.Exporter == "otlp" {
+		check("tracing.endpoint", validateAddress(c.Tracing.Endpoint))
+	}
+	if c.Tracing.SampleRatio < 0 || c.Tracing.SampleRatio > 1 {
+		check("tracing.sample_ratio", fmt.Errorf("must be between 0 and 1, got %v", c.Tracing.SampleRatio))
+	}
+
+	if len(c.Auth.JWTKeys) == 0 {
+		check("auth.jwt_keys", errors.New("at least one key is required"))
+	}
+	for i, key := range c.Auth.JWTKeys {
+		check(fmt.Sprintf("auth.jwt_keys[%d]", i), validateSecretKey(key))
+	}
+
+	if len(c.Encryption.Keys) == 0 {
+		check("encryption.keys", errors.New("at least one key is required"))
+	}
+	for i, key := range c.Encryption.Keys {
+		check(fmt.Sprintf("encryption.keys[%d]", i), validateSecretKey(key))
+	}
+	check("audit.key", validateSecretKey(c.Audit.Key))
+
+	if c.RateLimit.RequestsPerSecond < 0 {
+		check("rate_limit.requests_per_second", errors.New("must not be negative"))
+	}
+	if c.RateLimit.RequestsPerSecond > 0 && c.RateLimit.Burst < 1 {
+		check("rate_limit.burst", errors.New("must be at least 1 when the limit is enabled"))
+	}
+
+	if c.Shutdown.ReadinessDelay < 0 {
+		check("shutdown.readiness_delay", errors.New("must not be negative"))
+	}
+	if c.Shutdown.DrainTimeout <= 0 {
+		check("shutdown.drain_timeout", errors.New("must be positive"))
+	}
+
+	if c.Expiry.NotifyDays < 0 || c.Expiry.NotifyDays > maxNotifyDays {
+		check("expiry.notify_days", fmt.Errorf("must be between 0 and %d", maxNotifyDays))
+	}
+	if c.Expiry.Webhook.URL != "" {
+		check("expiry.webhook.url", validateURL(c.Expiry.Webhook.URL))
+		if c.Expiry.Webhook.Timeout <= 0 {
+			check("expiry.webhook.timeout", errors.New("must be positive"))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// validateSecretKey проверяет ключ подписи или шифрования: он задан, не совпадает
+// с заглушкой из примера настроек и достаточно длинный, чтобы его нельзя было подобрать.
+func validateSecretKey(key string) error {
+	switch {
+	case key == "":
+		return errors.New("must not be empty")
+	case key == PlaceholderSecret:
+		return errors.New("replace the placeholder with a random secret, e.g. openssl rand -base64 32")
+	case len(key) < minSecretKey:
+		return fmt.Errorf("must be at least %d characters long", minSecretKey)
+	}
+	return nil
+}
+
+func required(value string) error {
+	if value == "" {
+		return errors.New("required")
+	}
+	return nil
+}
+
+// validateAddress проверяет адрес вида host:port; пустой host означает все интерфейсы.
+func validateAddress(addr string) error {
+	if addr == "" {
+		return errors.New("required")
+	}
+	_, port, err := net.SplitHostPort(addr)
+	if err != nil {
+		return fmt.Errorf("invalid address %q, expected host:port", addr)
+	}
+	return validatePort(port)
+}
+
+func validatePort(port string) error {
+	if port == "" {
+		return errors.New("required")
+	}
+	n, err := strconv.Atoi(port)
+	if err != nil || n < 1 || n > 65535 {
+		return fmt.Errorf("invalid port %q, expected a number from 1 to 65535", port)
+	}
+	return nil
+}
+
+// validateURL проверяет абсолютный адрес http:// или https://.
+func validateURL(value string) error {
+	u, err := url.Parse(value)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return errors.New("invalid URL, expected http:// or https:// address")
+	}
+	return nil
+}
+
+// validateDSN принимает URL postgres:// или postgresql:// и строку вида "host=... dbname=...".
+func validateDSN(dsn string) error {
+	if strings.HasPrefix(dsn, "postgres://") || strings.HasPrefix(dsn, "postgresql://") {
+		if _, err := url.Parse(dsn); err != nil {
+			return errors.New("invalid connection URL")
+		}
+		return nil
+	}
+	if !strings.Contains(dsn, "=") {
+		return errors.New("invalid connection string, expected a postgres:// URL or key=value pairs")
+	}
+	return nil
+}
+
+func oneOf(value string, allowed ...string) error {
+	for _, a := range allowed {
+		if value == a {
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown value %q, expected one of: %s", value, strings.Join(allowed, ", "))
+}
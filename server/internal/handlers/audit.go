@@ -0,0 +1,102 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+	"net"
+	"net/http"
+	"server/internal/logger"
+	"server/internal/model"
+	"server/internal/service"
+)
+
+// GetAudit возвращает журнал аудита записей текущего пользователя или хранилища.
+// Параметр limit ограничивает число записей.
+func (h *Handlers) GetAudit(w http.ResponseWriter, r *http.Request) {
+	handlerStatus := http.StatusOK
+	userID, ok := service.GetCurrentUserID(r.Context())
+	if !ok {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	resultBody, err := h.gophKeeper.SelectAudit(r.Context(), r.URL.Query().Get("limit"), userID)
+
+	if err != nil {
+		handlerStatus = h.handlerError(r, err)
+		if handlerStatus == http.StatusBadRequest {
+			w.WriteHeader(handlerStatus)
+			return
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(handlerStatus)
+	w.Write(resultBody)
+}
+
+// audit записывает успешное действие с записью в журнал аудита.
+func (h *Handlers) audit(r *http.Request, action, recordType, key string) {
+	owner, _ := service.GetCurrentUserID(r.Context())
+	actor, _ := service.GetCurrentActor(r.Context())
+
+	entry := model.AuditEntry{
+		OwnerKey:   owner,
+		ActorKey:   actor,
+		Action:     action,
+		RecordType: recordType,
+	}
+	if recordKey, err := uuid.Parse(key); err == nil {
+		entry.RecordKey = &recordKey
+	}
+	h.writeAudit(r, entry)
+}
+
+// auditLink записывает просмотр одноразовой ссылки. Ссылка открывается без учётной записи,
+// поэтому исполнитель не известен, а запись журнала видит владелец ссылки.
+func (h *Handlers) auditLink(r *http.Request, owner uuid.UUID, key string) {
+	entry := model.AuditEntry{
+		OwnerKey:   owner,
+		Action:     model.AuditOpen,
+		RecordType: model.AuditTargetLink,
+	}
+	if linkKey, err := uuid.Parse(key); err == nil {
+		entry.RecordKey = &linkKey
+	}
+	h.writeAudit(r, entry)
+}
+
+// auditLogin записывает вход пользователя по ответу сервиса авторизации.
+func (h *Handlers) auditLogin(r *http.Request, resultBody []byte) {
+	var user model.UserResponse
+	if err := json.Unmarshal(resultBody, &user); err != nil {
+		logger.FromContext(r.Context()).Error("audit", zap.Error(err))
+		return
+	}
+
+	h.writeAudit(r, model.AuditEntry{
+		OwnerKey: user.PrivateUserKey,
+		ActorKey: user.PrivateUserKey,
+		Action:   model.AuditLogin,
+	})
+}
+
+// writeAudit дополняет запись журнала адресом и клиентом запроса и сохраняет её.
+// Запрос при ошибке записи не отклоняется: действие к этому моменту уже выполнено, и ответ
+// с ошибкой заставил бы клиент повторить его. Ошибка логируется и учитывается в метрике
+// gophkeeper_audit_write_failures_total (service.GophKeeper.AuditFailures), по которой настраивается тревога.
+func (h *Handlers) writeAudit(r *http.Request, entry model.AuditEntry) {
+	entry.IP = r.RemoteAddr
+	if host, _, err := net.SplitHostPort(r.RemoteAddr); err == nil {
+		entry.IP = host
+	}
+	entry.UserAgent = r.UserAgent()
+
+	// Действие уже выполнено, поэтому запись в журнал не отменяется вместе с запросом,
+	// даже если клиент успел отключиться.
+	if err := h.gophKeeper.Audit(context.WithoutCancel(r.Context()), entry); err != nil {
+		logger.FromContext(r.Context()).Error("audit", zap.Error(err))
+	}
+}
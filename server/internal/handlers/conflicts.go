@@ -0,0 +1,78 @@
+package handlers
+
+import (
+	"github.com/go-chi/chi/v5"
+	"io"
+	"net/http"
+	"server/internal/model"
+	"server/internal/service"
+)
+
+// GetConflicts возвращает неразрешённые конфликты текущего пользователя.
+func (h *Handlers) GetConflicts(w http.ResponseWriter, r *http.Request) {
+	handlerStatus := http.StatusOK
+	userID, ok := service.GetCurrentUserID(r.Context())
+	if !ok {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	resultBody, err := h.gophKeeper.SelectConflicts(r.Context(), userID)
+
+	if err != nil {
+		handlerStatus = h.handlerError(r, err)
+		if handlerStatus == http.StatusBadRequest {
+			w.WriteHeader(handlerStatus)
+			return
+		}
+	}
+
+	// Конфликтные копии и текущие версии возвращаются с расшифрованными секретами
+	if err == nil {
+		h.audit(r, model.AuditConflicts, "", "")
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(handlerStatus)
+	w.Write(resultBody)
+}
+
+// ResolveConflict сохраняет выбранную пользователем версию записи.
+func (h *Handlers) ResolveConflict(w http.ResponseWriter, r *http.Request) {
+	handlerStatus := http.StatusOK
+	key := chi.URLParam(r, "uuid")
+	body, err := io.ReadAll(r.Body)
+	defer r.Body.Close()
+
+	if err != nil {
+		handlerStatus = h.handlerError(r, err)
+		if handlerStatus == http.StatusBadRequest {
+			w.WriteHeader(handlerStatus)
+			return
+		}
+	}
+
+	userID, ok := service.GetCurrentUserID(r.Context())
+	if !ok {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	resultBody, resolved, err := h.gophKeeper.ResolveConflict(r.Context(), key, body, userID)
+
+	if err != nil {
+		handlerStatus = h.handlerError(r, err)
+		if handlerStatus == http.StatusBadRequest {
+			w.WriteHeader(handlerStatus)
+			return
+		}
+	}
+
+	if err == nil {
+		h.audit(r, model.AuditResolve, resolved.Type, resolved.RecordKey.String())
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(handlerStatus)
+	w.Write(resultBody)
+}
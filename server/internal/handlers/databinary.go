@@ -4,6 +4,7 @@ import (
 	"github.com/go-chi/chi/v5"
 	"io"
 	"net/http"
+	"server/internal/model"
 	"server/internal/service"
 )
 
@@ -13,7 +14,7 @@ func (h *Handlers) CreateDataBinary(w http.ResponseWriter, r *http.Request) {
 	defer r.Body.Close()
 
 	if err != nil {
-		handlerStatus = h.handlerError(err)
+		handlerStatus = h.handlerError(r, err)
 		if handlerStatus == http.StatusBadRequest {
 			w.WriteHeader(handlerStatus)
 			return
@@ -26,39 +27,86 @@ func (h *Handlers) CreateDataBinary(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	resultBody, err := h.gophKeeper.InsertDataBinary(body, userID)
+	resultBody, createdKey, err := h.gophKeeper.InsertDataBinary(r.Context(), body, userID)
 
 	if err != nil {
-		handlerStatus = h.handlerError(err)
+		handlerStatus = h.handlerError(r, err)
 		if handlerStatus == http.StatusBadRequest {
 			w.WriteHeader(handlerStatus)
 			return
 		}
 	}
 
+	if err == nil {
+		h.audit(r, model.AuditCreate, model.RecordBinary, createdKey.String())
+	}
+
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(handlerStatus)
 	w.Write(resultBody)
 }
 
 func (h *Handlers) GetDataBinary(w http.ResponseWriter, r *http.Request) {
-	handlerStatus := http.StatusCreated
+	handlerStatus := http.StatusOK
 	key := chi.URLParam(r, "uuid")
 	userID, ok := service.GetCurrentUserID(r.Context())
 	if !ok {
 		w.WriteHeader(http.StatusUnauthorized)
 		return
 	}
-	resultBody, err := h.gophKeeper.SelectDataBinary(key, userID)
+	resultBody, err := h.gophKeeper.SelectDataBinary(r.Context(), key, userID)
 
 	if err != nil {
-		handlerStatus = h.handlerError(err)
+		handlerStatus = h.handlerError(r, err)
 		if handlerStatus == http.StatusBadRequest {
 			w.WriteHeader(handlerStatus)
 			return
 		}
 	}
 
+	if err == nil {
+		h.audit(r, model.AuditRead, model.RecordBinary, key)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(handlerStatus)
+	w.Write(resultBody)
+}
+
+func (h *Handlers) UpdateDataBinary(w http.ResponseWriter, r *http.Request) {
+	handlerStatus := http.StatusOK
+	key := chi.URLParam(r, "uuid")
+	body, err := io.ReadAll(r.Body)
+	defer r.Body.Close()
+
+	if err != nil {
+		handlerStatus = h.handlerError(r, err)
+		if handlerStatus == http.StatusBadRequest {
+			w.WriteHeader(handlerStatus)
+			return
+		}
+	}
+
+	userID, ok := service.GetCurrentUserID(r.Context())
+	if !ok {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	resultBody, err := h.gophKeeper.UpdateDataBinary(r.Context(), key, body, userID)
+
+	if err != nil {
+		handlerStatus = h.handlerError(r, err)
+		if handlerStatus == http.StatusBadRequest {
+			w.WriteHeader(handlerStatus)
+			return
+		}
+	}
+
+	if err == nil {
+		h.audit(r, model.AuditUpdate, model.RecordBinary, key)
+	}
+
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(handlerStatus)
 	w.Write(resultBody)
@@ -72,15 +120,19 @@ func (h *Handlers) DeleteDataBinary(w http.ResponseWriter, r *http.Request) {
 		w.WriteHeader(http.StatusUnauthorized)
 		return
 	}
-	err := h.gophKeeper.DeleteDataBinary(key, userID)
+	err := h.gophKeeper.DeleteDataBinary(r.Context(), key, userID)
 
 	if err != nil {
-		handlerStatus = h.handlerError(err)
+		handlerStatus = h.handlerError(r, err)
 		if handlerStatus == http.StatusBadRequest {
 			w.WriteHeader(handlerStatus)
 			return
 		}
 	}
 
+	if err == nil {
+		h.audit(r, model.AuditDelete, model.RecordBinary, key)
+	}
+
 	w.WriteHeader(handlerStatus)
 }
@@ -4,6 +4,7 @@ import (
 	"github.com/go-chi/chi/v5"
 	"io"
 	"net/http"
+	"server/internal/model"
 	"server/internal/service"
 )
 
@@ -12,7 +13,7 @@ func (h *Handlers) CreateDataCard(w http.ResponseWriter, r *http.Request) {
 	body, err := io.ReadAll(r.Body)
 	defer r.Body.Close()
 	if err != nil {
-		handlerStatus = h.handlerError(err)
+		handlerStatus = h.handlerError(r, err)
 		if handlerStatus == http.StatusBadRequest {
 			w.WriteHeader(handlerStatus)
 			return
@@ -23,14 +24,19 @@ func (h *Handlers) CreateDataCard(w http.ResponseWriter, r *http.Request) {
 		w.WriteHeader(http.StatusUnauthorized)
 		return
 	}
-	resultBody, err := h.gophKeeper.InsertDataCard(body, userID)
+	resultBody, createdKey, err := h.gophKeeper.InsertDataCard(r.Context(), body, userID)
 
 	if err != nil {
-		handlerStatus = h.handlerError(err)
+		handlerStatus = h.handlerError(r, err)
 		if handlerStatus == http.StatusBadRequest {
 			w.WriteHeader(handlerStatus)
 			return
 		}
+		resultBody = errorBody(err)
+	}
+
+	if err == nil {
+		h.audit(r, model.AuditCreate, model.RecordCard, createdKey.String())
 	}
 
 	w.Header().Set("Content-Type", "application/json")
@@ -39,23 +45,67 @@ func (h *Handlers) CreateDataCard(w http.ResponseWriter, r *http.Request) {
 }
 
 func (h *Handlers) GetDataCard(w http.ResponseWriter, r *http.Request) {
-	handlerStatus := http.StatusCreated
+	handlerStatus := http.StatusOK
 	key := chi.URLParam(r, "uuid")
 	userID, ok := service.GetCurrentUserID(r.Context())
 	if !ok {
 		w.WriteHeader(http.StatusUnauthorized)
 		return
 	}
-	resultBody, err := h.gophKeeper.SelectDataCard(key, userID)
+	resultBody, err := h.gophKeeper.SelectDataCard(r.Context(), key, userID)
 
 	if err != nil {
-		handlerStatus = h.handlerError(err)
+		handlerStatus = h.handlerError(r, err)
 		if handlerStatus == http.StatusBadRequest {
 			w.WriteHeader(handlerStatus)
 			return
 		}
 	}
 
+	if err == nil {
+		h.audit(r, model.AuditRead, model.RecordCard, key)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(handlerStatus)
+	w.Write(resultBody)
+}
+
+func (h *Handlers) UpdateDataCard(w http.ResponseWriter, r *http.Request) {
+	handlerStatus := http.StatusOK
+	key := chi.URLParam(r, "uuid")
+	body, err := io.ReadAll(r.Body)
+	defer r.Body.Close()
+
+	if err != nil {
+		handlerStatus = h.handlerError(r, err)
+		if handlerStatus == http.StatusBadRequest {
+			w.WriteHeader(handlerStatus)
+			return
+		}
+	}
+
+	userID, ok := service.GetCurrentUserID(r.Context())
+	if !ok {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	resultBody, err := h.gophKeeper.UpdateDataCard(r.Context(), key, body, userID)
+
+	if err != nil {
+		handlerStatus = h.handlerError(r, err)
+		if handlerStatus == http.StatusBadRequest {
+			w.WriteHeader(handlerStatus)
+			return
+		}
+		resultBody = errorBody(err)
+	}
+
+	if err == nil {
+		h.audit(r, model.AuditUpdate, model.RecordCard, key)
+	}
+
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(handlerStatus)
 	w.Write(resultBody)
@@ -69,15 +119,19 @@ func (h *Handlers) DeleteDataCard(w http.ResponseWriter, r *http.Request) {
 		w.WriteHeader(http.StatusUnauthorized)
 		return
 	}
-	err := h.gophKeeper.DeleteDataCard(key, userID)
+	err := h.gophKeeper.DeleteDataCard(r.Context(), key, userID)
 
 	if err != nil {
-		handlerStatus = h.handlerError(err)
+		handlerStatus = h.handlerError(r, err)
 		if handlerStatus == http.StatusBadRequest {
 			w.WriteHeader(handlerStatus)
 			return
 		}
 	}
 
+	if err == nil {
+		h.audit(r, model.AuditDelete, model.RecordCard, key)
+	}
+
 	w.WriteHeader(handlerStatus)
 }
@@ -0,0 +1,137 @@
+package handlers
+
+import (
+	"github.com/go-chi/chi/v5"
+	"io"
+	"net/http"
+	"server/internal/model"
+	"server/internal/service"
+)
+
+func (h *Handlers) CreateDataCustom(w http.ResponseWriter, r *http.Request) {
+	handlerStatus := http.StatusCreated
+	body, err := io.ReadAll(r.Body)
+	defer r.Body.Close()
+	if err != nil {
+		handlerStatus = h.handlerError(r, err)
+		if handlerStatus == http.StatusBadRequest {
+			w.WriteHeader(handlerStatus)
+			return
+		}
+	}
+	userID, ok := service.GetCurrentUserID(r.Context())
+	if !ok {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+	resultBody, createdKey, err := h.gophKeeper.InsertDataCustom(r.Context(), body, userID)
+
+	if err != nil {
+		handlerStatus = h.handlerError(r, err)
+		if handlerStatus == http.StatusBadRequest {
+			w.WriteHeader(handlerStatus)
+			return
+		}
+		resultBody = errorBody(err)
+	}
+
+	if err == nil {
+		h.audit(r, model.AuditCreate, model.RecordCustom, createdKey.String())
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(handlerStatus)
+	w.Write(resultBody)
+}
+
+func (h *Handlers) GetDataCustom(w http.ResponseWriter, r *http.Request) {
+	handlerStatus := http.StatusOK
+	key := chi.URLParam(r, "uuid")
+	userID, ok := service.GetCurrentUserID(r.Context())
+	if !ok {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+	resultBody, err := h.gophKeeper.SelectDataCustom(r.Context(), key, userID)
+
+	if err != nil {
+		handlerStatus = h.handlerError(r, err)
+		if handlerStatus == http.StatusBadRequest {
+			w.WriteHeader(handlerStatus)
+			return
+		}
+	}
+
+	if err == nil {
+		h.audit(r, model.AuditRead, model.RecordCustom, key)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(handlerStatus)
+	w.Write(resultBody)
+}
+
+func (h *Handlers) UpdateDataCustom(w http.ResponseWriter, r *http.Request) {
+	handlerStatus := http.StatusOK
+	key := chi.URLParam(r, "uuid")
+	body, err := io.ReadAll(r.Body)
+	defer r.Body.Close()
+
+	if err != nil {
+		handlerStatus = h.handlerError(r, err)
+		if handlerStatus == http.StatusBadRequest {
+			w.WriteHeader(handlerStatus)
+			return
+		}
+	}
+
+	userID, ok := service.GetCurrentUserID(r.Context())
+	if !ok {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	resultBody, err := h.gophKeeper.UpdateDataCustom(r.Context(), key, body, userID)
+
+	if err != nil {
+		handlerStatus = h.handlerError(r, err)
+		if handlerStatus == http.StatusBadRequest {
+			w.WriteHeader(handlerStatus)
+			return
+		}
+		resultBody = errorBody(err)
+	}
+
+	if err == nil {
+		h.audit(r, model.AuditUpdate, model.RecordCustom, key)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(handlerStatus)
+	w.Write(resultBody)
+}
+
+func (h *Handlers) DeleteDataCustom(w http.ResponseWriter, r *http.Request) {
+	handlerStatus := http.StatusOK
+	key := chi.URLParam(r, "uuid")
+	userID, ok := service.GetCurrentUserID(r.Context())
+	if !ok {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+	err := h.gophKeeper.DeleteDataCustom(r.Context(), key, userID)
+
+	if err != nil {
+		handlerStatus = h.handlerError(r, err)
+		if handlerStatus == http.StatusBadRequest {
+			w.WriteHeader(handlerStatus)
+			return
+		}
+	}
+
+	if err == nil {
+		h.audit(r, model.AuditDelete, model.RecordCustom, key)
+	}
+
+	w.WriteHeader(handlerStatus)
+}
@@ -0,0 +1,137 @@
+package handlers
+
+import (
+	"github.com/go-chi/chi/v5"
+	"io"
+	"net/http"
+	"server/internal/model"
+	"server/internal/service"
+)
+
+func (h *Handlers) CreateDataSSHKey(w http.ResponseWriter, r *http.Request) {
+	handlerStatus := http.StatusCreated
+	body, err := io.ReadAll(r.Body)
+	defer r.Body.Close()
+	if err != nil {
+		handlerStatus = h.handlerError(r, err)
+		if handlerStatus == http.StatusBadRequest {
+			w.WriteHeader(handlerStatus)
+			return
+		}
+	}
+	userID, ok := service.GetCurrentUserID(r.Context())
+	if !ok {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+	resultBody, createdKey, err := h.gophKeeper.InsertDataSSHKey(r.Context(), body, userID)
+
+	if err != nil {
+		handlerStatus = h.handlerError(r, err)
+		if handlerStatus == http.StatusBadRequest {
+			w.WriteHeader(handlerStatus)
+			return
+		}
+		resultBody = errorBody(err)
+	}
+
+	if err == nil {
+		h.audit(r, model.AuditCreate, model.RecordSSH, createdKey.String())
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(handlerStatus)
+	w.Write(resultBody)
+}
+
+func (h *Handlers) GetDataSSHKey(w http.ResponseWriter, r *http.Request) {
+	handlerStatus := http.StatusOK
+	key := chi.URLParam(r, "uuid")
+	userID, ok := service.GetCurrentUserID(r.Context())
+	if !ok {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+	resultBody, err := h.gophKeeper.SelectDataSSHKey(r.Context(), key, userID)
+
+	if err != nil {
+		handlerStatus = h.handlerError(r, err)
+		if handlerStatus == http.StatusBadRequest {
+			w.WriteHeader(handlerStatus)
+			return
+		}
+	}
+
+	if err == nil {
+		h.audit(r, model.AuditRead, model.RecordSSH, key)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(handlerStatus)
+	w.Write(resultBody)
+}
+
+func (h *Handlers) UpdateDataSSHKey(w http.ResponseWriter, r *http.Request) {
+	handlerStatus := http.StatusOK
+	key := chi.URLParam(r, "uuid")
+	body, err := io.ReadAll(r.Body)
+	defer r.Body.Close()
+
+	if err != nil {
+		handlerStatus = h.handlerError(r, err)
+		if handlerStatus == http.StatusBadRequest {
+			w.WriteHeader(handlerStatus)
+			return
+		}
+	}
+
+	userID, ok := service.GetCurrentUserID(r.Context())
+	if !ok {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	resultBody, err := h.gophKeeper.UpdateDataSSHKey(r.Context(), key, body, userID)
+
+	if err != nil {
+		handlerStatus = h.handlerError(r, err)
+		if handlerStatus == http.StatusBadRequest {
+			w.WriteHeader(handlerStatus)
+			return
+		}
+		resultBody = errorBody(err)
+	}
+
+	if err == nil {
+		h.audit(r, model.AuditUpdate, model.RecordSSH, key)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(handlerStatus)
+	w.Write(resultBody)
+}
+
+func (h *Handlers) DeleteDataSSHKey(w http.ResponseWriter, r *http.Request) {
+	handlerStatus := http.StatusOK
+	key := chi.URLParam(r, "uuid")
+	userID, ok := service.GetCurrentUserID(r.Context())
+	if !ok {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+	err := h.gophKeeper.DeleteDataSSHKey(r.Context(), key, userID)
+
+	if err != nil {
+		handlerStatus = h.handlerError(r, err)
+		if handlerStatus == http.StatusBadRequest {
+			w.WriteHeader(handlerStatus)
+			return
+		}
+	}
+
+	if err == nil {
+		h.audit(r, model.AuditDelete, model.RecordSSH, key)
+	}
+
+	w.WriteHeader(handlerStatus)
+}
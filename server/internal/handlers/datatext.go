@@ -4,6 +4,7 @@ import (
 	"github.com/go-chi/chi/v5"
 	"io"
 	"net/http"
+	"server/internal/model"
 	"server/internal/service"
 )
 
@@ -13,7 +14,7 @@ func (h *Handlers) CreateDataText(w http.ResponseWriter, r *http.Request) {
 	defer r.Body.Close()
 
 	if err != nil {
-		handlerStatus = h.handlerError(err)
+		handlerStatus = h.handlerError(r, err)
 		if handlerStatus == http.StatusBadRequest {
 			w.WriteHeader(handlerStatus)
 			return
@@ -26,16 +27,20 @@ func (h *Handlers) CreateDataText(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	resultBody, err := h.gophKeeper.InsertDataText(body, userID)
+	resultBody, createdKey, err := h.gophKeeper.InsertDataText(r.Context(), body, userID)
 
 	if err != nil {
-		handlerStatus = h.handlerError(err)
+		handlerStatus = h.handlerError(r, err)
 		if handlerStatus == http.StatusBadRequest {
 			w.WriteHeader(handlerStatus)
 			return
 		}
 	}
 
+	if err == nil {
+		h.audit(r, model.AuditCreate, model.RecordText, createdKey.String())
+	}
+
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(handlerStatus)
 	w.Write(resultBody)
@@ -49,16 +54,59 @@ func (h *Handlers) GetDataText(w http.ResponseWriter, r *http.Request) {
 		w.WriteHeader(http.StatusUnauthorized)
 		return
 	}
-	resultBody, err := h.gophKeeper.SelectDataText(key, userID)
+	resultBody, err := h.gophKeeper.SelectDataText(r.Context(), key, userID)
 
 	if err != nil {
-		handlerStatus = h.handlerError(err)
+		handlerStatus = h.handlerError(r, err)
 		if handlerStatus == http.StatusBadRequest {
 			w.WriteHeader(handlerStatus)
 			return
 		}
 	}
 
+	if err == nil {
+		h.audit(r, model.AuditRead, model.RecordText, key)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(handlerStatus)
+	w.Write(resultBody)
+}
+
+func (h *Handlers) UpdateDataText(w http.ResponseWriter, r *http.Request) {
+	handlerStatus := http.StatusOK
+	key := chi.URLParam(r, "uuid")
+	body, err := io.ReadAll(r.Body)
+	defer r.Body.Close()
+
+	if err != nil {
+		handlerStatus = h.handlerError(r, err)
+		if handlerStatus == http.StatusBadRequest {
+			w.WriteHeader(handlerStatus)
+			return
+		}
+	}
+
+	userID, ok := service.GetCurrentUserID(r.Context())
+	if !ok {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	resultBody, err := h.gophKeeper.UpdateDataText(r.Context(), key, body, userID)
+
+	if err != nil {
+		handlerStatus = h.handlerError(r, err)
+		if handlerStatus == http.StatusBadRequest {
+			w.WriteHeader(handlerStatus)
+			return
+		}
+	}
+
+	if err == nil {
+		h.audit(r, model.AuditUpdate, model.RecordText, key)
+	}
+
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(handlerStatus)
 	w.Write(resultBody)
@@ -72,15 +120,19 @@ func (h *Handlers) DeleteDataText(w http.ResponseWriter, r *http.Request) {
 		w.WriteHeader(http.StatusUnauthorized)
 		return
 	}
-	err := h.gophKeeper.DeleteDataText(key, userID)
+	err := h.gophKeeper.DeleteDataText(r.Context(), key, userID)
 
 	if err != nil {
-		handlerStatus = h.handlerError(err)
+		handlerStatus = h.handlerError(r, err)
 		if handlerStatus == http.StatusBadRequest {
 			w.WriteHeader(handlerStatus)
 			return
 		}
 	}
 
+	if err == nil {
+		h.audit(r, model.AuditDelete, model.RecordText, key)
+	}
+
 	w.WriteHeader(handlerStatus)
 }
@@ -0,0 +1,69 @@
+package handlers
+
+import (
+	"io"
+	"net/http"
+	"server/internal/service"
+)
+
+// GetE2EParams возвращает параметры сквозного шифрования текущего пользователя.
+func (h *Handlers) GetE2EParams(w http.ResponseWriter, r *http.Request) {
+	handlerStatus := http.StatusOK
+	userID, ok := service.GetCurrentUserID(r.Context())
+	if !ok {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	resultBody, err := h.gophKeeper.SelectE2EParams(r.Context(), userID)
+
+	if err != nil {
+		handlerStatus = h.handlerError(r, err)
+		if handlerStatus == http.StatusBadRequest {
+			w.WriteHeader(handlerStatus)
+			return
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(handlerStatus)
+	w.Write(resultBody)
+}
+
+// CreateE2EParams сохраняет параметры сквозного шифрования текущего пользователя.
+// Заданные параметры не заменяются, повторный запрос возвращает 409.
+func (h *Handlers) CreateE2EParams(w http.ResponseWriter, r *http.Request) {
+	handlerStatus := http.StatusCreated
+	body, err := io.ReadAll(r.Body)
+	defer r.Body.Close()
+
+	if err != nil {
+		handlerStatus = h.handlerError(r, err)
+		if handlerStatus == http.StatusBadRequest {
+			w.WriteHeader(handlerStatus)
+			return
+		}
+	}
+
+	userID, ok := service.GetCurrentUserID(r.Context())
+	if !ok {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	err = h.gophKeeper.InsertE2EParams(r.Context(), body, userID)
+
+	var resultBody []byte
+	if err != nil {
+		handlerStatus = h.handlerError(r, err)
+		if handlerStatus == http.StatusBadRequest {
+			w.WriteHeader(handlerStatus)
+			return
+		}
+		resultBody = errorBody(err)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(handlerStatus)
+	w.Write(resultBody)
+}
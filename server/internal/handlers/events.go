@@ -0,0 +1,76 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"server/internal/model"
+	"server/internal/service"
+	"time"
+)
+
+// eventsKeepAlive — период отправки комментария-пинга, чтобы прокси не закрывали простаивающее соединение.
+const eventsKeepAlive = 30 * time.Second
+
+// GetEvents открывает поток Server-Sent Events с изменениями записей пользователя.
+// Поток закрывается при отключении клиента, остановке сервера или выходе из текущей сессии.
+func (h *Handlers) GetEvents(w http.ResponseWriter, r *http.Request) {
+	userID, ok := service.GetCurrentUserID(r.Context())
+	if !ok {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+	sessionKey, _ := service.GetCurrentSession(r.Context())
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	events, unsubscribe, err := h.gophKeeper.SubscribeEvents(r.Context(), userID)
+	if err != nil {
+		w.WriteHeader(h.handlerError(r, err))
+		return
+	}
+	defer unsubscribe()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	ticker := time.NewTicker(eventsKeepAlive)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-ticker.C:
+			if _, err := fmt.Fprint(w, ": ping\n\n"); err != nil {
+				return
+			}
+			flusher.Flush()
+		case event, ok := <-events:
+			if !ok {
+				return
+			}
+
+			data, err := json.Marshal(event)
+			if err != nil {
+				h.handlerError(r, err)
+				return
+			}
+			if _, err := fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event.Type, data); err != nil {
+				return
+			}
+			flusher.Flush()
+
+			if event.Type == model.EventSessionRevoked && event.Session == sessionKey {
+				return
+			}
+		}
+	}
+}
@@ -1,8 +1,13 @@
 package handlers
 
 import (
-	"log"
+	"context"
+	"encoding/json"
+	"errors"
+	"go.uber.org/zap"
 	"net/http"
+	"server/internal/cerrors"
+	"server/internal/logger"
 	"server/internal/service"
 )
 
@@ -21,21 +26,60 @@ func NewHandlers(srv *service.GophKeeper) *Handlers {
 // handlerError обрабатывает ошибки и возвращает соответствующий код состояния HTTP.
 // Следующие коды могут вернуться:
 // - 400 Bad Request: для всех прочих ошибок.
-// - 409 Conflict: если пытаетесь добавить уже существующий оригинальный URL.
-// - 410 Gone: если URL был помечен как удаленный.
-func (h *Handlers) handlerError(err error) int {
+// - 403 Forbidden: если прав на запись недостаточно для действия.
+// - 404 Not Found: если запись не существует или удалена.
+// - 409 Conflict: если запись изменилась после версии, на которой основано изменение.
+// - 422 Unprocessable Entity: если поля запроса не прошли проверку.
+// - 504 Gateway Timeout: если запрос к базе данных прерван по тайм-ауту или отменой запроса.
+func (h *Handlers) handlerError(r *http.Request, err error) int {
 	statusCode := http.StatusBadRequest
-	//if errors.Is(err, cerrors.ErrNewShortURL) {
-	//	statusCode = http.StatusConflict
-	//	}
-	//if errors.Is(err, cerrors.ErrGetShortURLDelete) {
-	//	statusCode = http.StatusGone
-	//	}
-
-	log.Printf("error handling request: %v, status: %d", err, statusCode)
+	if errors.Is(err, cerrors.ErrForbidden) {
+		statusCode = http.StatusForbidden
+	}
+	if errors.Is(err, cerrors.ErrNotFound) {
+		statusCode = http.StatusNotFound
+	}
+	if errors.Is(err, cerrors.ErrConflict) {
+		statusCode = http.StatusConflict
+	}
+	if errors.Is(err, cerrors.ErrPreconditionRequired) {
+		statusCode = http.StatusPreconditionRequired
+	}
+	var validationErr *cerrors.ValidationError
+	if errors.As(err, &validationErr) {
+		statusCode = http.StatusUnprocessableEntity
+	}
+	if isTimeout(err) {
+		statusCode = http.StatusGatewayTimeout
+	}
+
+	logger.FromContext(r.Context()).Warn("error handling request", zap.Error(err), zap.Int("status", statusCode))
 	return statusCode
 }
 
+// errorBody возвращает тело ответа с ошибками полей, если err — ошибка проверки, иначе nil.
+func errorBody(err error) []byte {
+	var validationErr *cerrors.ValidationError
+	if !errors.As(err, &validationErr) {
+		return nil
+	}
+	body, _ := json.Marshal(validationErr)
+	return body
+}
+
+// queryCanceled — код ошибки PostgreSQL, с которым сервер прерывает запрос
+// по statement_timeout или по запросу отмены от драйвера.
+const queryCanceled = "57014"
+
+// isTimeout сообщает, прерван ли запрос к базе данных по тайм-ауту или отменой контекста.
+func isTimeout(err error) bool {
+	if errors.Is(err, context.DeadlineExceeded) || errors.Is(err, context.Canceled) {
+		return true
+	}
+	var sqlErr interface{ SQLState() string }
+	return errors.As(err, &sqlErr) && sqlErr.SQLState() == queryCanceled
+}
+
 func (h *Handlers) GetServiceGophKeeper() *service.GophKeeper {
 	return h.gophKeeper
 }
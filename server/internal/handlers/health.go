@@ -0,0 +1,51 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"server/internal/buildinfo"
+	"server/internal/model"
+)
+
+// Healthz сообщает, что процесс сервера жив. Внешние зависимости не проверяются.
+func (h *Handlers) Healthz(w http.ResponseWriter, r *http.Request) {
+	resultBody, _ := json.Marshal(map[string]string{"status": model.HealthOK})
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(resultBody)
+}
+
+// Readyz проверяет базу данных и миграции. Если сервер не готов, возвращает 503
+// с описанием проверок.
+func (h *Handlers) Readyz(w http.ResponseWriter, r *http.Request) {
+	handlerStatus := http.StatusOK
+	result, ready := h.gophKeeper.Readiness(r.Context())
+	if !ready {
+		handlerStatus = http.StatusServiceUnavailable
+	}
+
+	resultBody, err := json.Marshal(result)
+	if err != nil {
+		w.WriteHeader(h.handlerError(r, err))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(handlerStatus)
+	w.Write(resultBody)
+}
+
+// Version возвращает версию, коммит и дату сборки сервера и версию API.
+func (h *Handlers) Version(w http.ResponseWriter, r *http.Request) {
+	resultBody, err := json.Marshal(buildinfo.Get())
+	if err != nil {
+		w.WriteHeader(h.handlerError(r, err))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(resultBody)
+}
@@ -0,0 +1,80 @@
+package handlers
+
+import (
+	"github.com/go-chi/chi/v5"
+	"io"
+	"net/http"
+	"server/internal/model"
+	"server/internal/service"
+)
+
+// GetHistory возвращает историю версий записи.
+func (h *Handlers) GetHistory(w http.ResponseWriter, r *http.Request) {
+	handlerStatus := http.StatusOK
+	recordType := chi.URLParam(r, "type")
+	key := chi.URLParam(r, "uuid")
+	userID, ok := service.GetCurrentUserID(r.Context())
+	if !ok {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	resultBody, err := h.gophKeeper.SelectHistory(r.Context(), recordType, key, userID)
+
+	if err != nil {
+		handlerStatus = h.handlerError(r, err)
+		if handlerStatus == http.StatusBadRequest {
+			w.WriteHeader(handlerStatus)
+			return
+		}
+	}
+
+	if err == nil {
+		h.audit(r, model.AuditHistory, recordType, key)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(handlerStatus)
+	w.Write(resultBody)
+}
+
+// RestoreRecord возвращает запись к версии из истории, в том числе после удаления.
+func (h *Handlers) RestoreRecord(w http.ResponseWriter, r *http.Request) {
+	handlerStatus := http.StatusOK
+	recordType := chi.URLParam(r, "type")
+	key := chi.URLParam(r, "uuid")
+	body, err := io.ReadAll(r.Body)
+	defer r.Body.Close()
+
+	if err != nil {
+		handlerStatus = h.handlerError(r, err)
+		if handlerStatus == http.StatusBadRequest {
+			w.WriteHeader(handlerStatus)
+			return
+		}
+	}
+
+	userID, ok := service.GetCurrentUserID(r.Context())
+	if !ok {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	resultBody, err := h.gophKeeper.RestoreRecord(r.Context(), recordType, key, body, userID)
+
+	if err != nil {
+		handlerStatus = h.handlerError(r, err)
+		if handlerStatus == http.StatusBadRequest {
+			w.WriteHeader(handlerStatus)
+			return
+		}
+	}
+
+	if err == nil {
+		h.audit(r, model.AuditRestore, recordType, key)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(handlerStatus)
+	w.Write(resultBody)
+}
@@ -0,0 +1,31 @@
+package handlers
+
+import (
+	"net/http"
+	"server/internal/service"
+)
+
+// GetExpiring возвращает записи текущего пользователя или хранилища, срок действия которых скоро истекает.
+// Параметр days задаёт горизонт отчёта в днях.
+func (h *Handlers) GetExpiring(w http.ResponseWriter, r *http.Request) {
+	handlerStatus := http.StatusOK
+	userID, ok := service.GetCurrentUserID(r.Context())
+	if !ok {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	resultBody, err := h.gophKeeper.SelectExpiring(r.Context(), r.URL.Query().Get("days"), userID)
+
+	if err != nil {
+		handlerStatus = h.handlerError(r, err)
+		if handlerStatus == http.StatusBadRequest {
+			w.WriteHeader(handlerStatus)
+			return
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(handlerStatus)
+	w.Write(resultBody)
+}
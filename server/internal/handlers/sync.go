@@ -0,0 +1,35 @@
+package handlers
+
+import (
+	"net/http"
+	"server/internal/model"
+	"server/internal/service"
+)
+
+// GetChanges возвращает ленту изменений пользователя после ревизии из параметра since.
+func (h *Handlers) GetChanges(w http.ResponseWriter, r *http.Request) {
+	handlerStatus := http.StatusOK
+	userID, ok := service.GetCurrentUserID(r.Context())
+	if !ok {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	resultBody, err := h.gophKeeper.SelectChanges(r.Context(), r.URL.Query().Get("since"), userID)
+
+	if err != nil {
+		handlerStatus = h.handlerError(r, err)
+		if handlerStatus == http.StatusBadRequest {
+			w.WriteHeader(handlerStatus)
+			return
+		}
+	}
+
+	if err == nil {
+		h.audit(r, model.AuditSync, "", "")
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(handlerStatus)
+	w.Write(resultBody)
+}
@@ -3,6 +3,7 @@ package handlers
 import (
 	"io"
 	"net/http"
+	"server/internal/service"
 )
 
 func (h *Handlers) RegisterUser(w http.ResponseWriter, r *http.Request) {
@@ -11,17 +12,17 @@ func (h *Handlers) RegisterUser(w http.ResponseWriter, r *http.Request) {
 	defer r.Body.Close()
 
 	if err != nil {
-		handlerStatus = h.handlerError(err)
+		handlerStatus = h.handlerError(r, err)
 		if handlerStatus == http.StatusBadRequest {
 			w.WriteHeader(handlerStatus)
 			return
 		}
 	}
 
-	resultBody, token, err := h.gophKeeper.RegisterUser(string(body))
+	resultBody, token, err := h.gophKeeper.RegisterUser(r.Context(), string(body))
 
 	if err != nil {
-		handlerStatus = h.handlerError(err)
+		handlerStatus = h.handlerError(r, err)
 		if handlerStatus == http.StatusBadRequest {
 			w.WriteHeader(handlerStatus)
 			return
@@ -42,23 +43,27 @@ func (h *Handlers) AuthorizationUser(w http.ResponseWriter, r *http.Request) {
 	defer r.Body.Close()
 
 	if err != nil {
-		handlerStatus = h.handlerError(err)
+		handlerStatus = h.handlerError(r, err)
 		if handlerStatus == http.StatusBadRequest {
 			w.WriteHeader(handlerStatus)
 			return
 		}
 	}
 
-	resultBody, token, err := h.gophKeeper.AuthorizationUser(string(body))
+	resultBody, token, err := h.gophKeeper.AuthorizationUser(r.Context(), string(body))
 
 	if err != nil {
-		handlerStatus = h.handlerError(err)
+		handlerStatus = h.handlerError(r, err)
 		if handlerStatus == http.StatusBadRequest {
 			w.WriteHeader(handlerStatus)
 			return
 		}
 	}
 
+	if err == nil {
+		h.auditLogin(r, resultBody)
+	}
+
 	newCookie := http.Cookie{Name: "user", Value: token}
 	http.SetCookie(w, &newCookie)
 	w.Header().Set("Content-Type", "application/json")
@@ -66,6 +71,28 @@ func (h *Handlers) AuthorizationUser(w http.ResponseWriter, r *http.Request) {
 	w.Write(resultBody)
 }
 
+// LogoutUser завершает текущую сессию: токен отзывается, кука удаляется,
+// открытые потоки событий этой сессии закрываются.
 func (h *Handlers) LogoutUser(w http.ResponseWriter, r *http.Request) {
-	return
+	handlerStatus := http.StatusOK
+	userID, ok := service.GetCurrentUserID(r.Context())
+	if !ok {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+	sessionKey, _ := service.GetCurrentSession(r.Context())
+
+	err := h.gophKeeper.LogoutUser(r.Context(), sessionKey, userID)
+
+	if err != nil {
+		handlerStatus = h.handlerError(r, err)
+		if handlerStatus == http.StatusBadRequest {
+			w.WriteHeader(handlerStatus)
+			return
+		}
+	}
+
+	expiredCookie := http.Cookie{Name: "user", Value: "", MaxAge: -1}
+	http.SetCookie(w, &expiredCookie)
+	w.WriteHeader(handlerStatus)
 }
@@ -0,0 +1,103 @@
+// Package logger создаёт общий для сервера zap-логгер и передаёт его через контекст запроса.
+package logger
+
+import (
+	"context"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"server/internal/config"
+	"strings"
+)
+
+// redacted заменяет значения полей, которые могут содержать секреты.
+const redacted = "[REDACTED]"
+
+// secretFields — имена полей, значения которых никогда не попадают в журнал.
+var secretFields = []string{
+	"password",
+	"password_hash",
+	"encryption_key",
+	"cookie",
+	"token",
+	"authorization",
+	"data",
+}
+
+type ctxKey struct{}
+
+// New создаёт логгер с уровнем и кодированием (json или console) из настроек.
+// Возвращаемый уровень можно менять без пересоздания логгера.
+func New(settings config.LogSettings) (*zap.Logger, zap.AtomicLevel, error) {
+	level, err := zap.ParseAtomicLevel(settings.Level)
+	if err != nil {
+		return nil, level, err
+	}
+
+	cfg := zap.NewProductionConfig()
+	cfg.Level = level
+	cfg.Encoding = settings.Encoding
+	cfg.EncoderConfig.TimeKey = "time"
+	cfg.EncoderConfig.EncodeTime = zapcore.ISO8601TimeEncoder
+	if settings.Encoding == "console" {
+		cfg.EncoderConfig.EncodeLevel = zapcore.CapitalColorLevelEncoder
+	}
+
+	log, err := cfg.Build(zap.WrapCore(func(core zapcore.Core) zapcore.Core {
+		return redactCore{core}
+	}))
+	return log, level, err
+}
+
+// WithContext сохраняет логгер запроса в контексте.
+func WithContext(ctx context.Context, log *zap.Logger) context.Context {
+	return context.WithValue(ctx, ctxKey{}, log)
+}
+
+// FromContext возвращает логгер запроса или пустой логгер, если он не задан.
+func FromContext(ctx context.Context) *zap.Logger {
+	if log, ok := ctx.Value(ctxKey{}).(*zap.Logger); ok {
+		return log
+	}
+	return zap.NewNop()
+}
+
+// redactCore скрывает значения полей из secretFields, в том числе переданных через With.
+type redactCore struct {
+	zapcore.Core
+}
+
+func (c redactCore) With(fields []zapcore.Field) zapcore.Core {
+	return redactCore{c.Core.With(redact(fields))}
+}
+
+func (c redactCore) Check(entry zapcore.Entry, checked *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(entry.Level) {
+		return checked.AddCore(entry, c)
+	}
+	return checked
+}
+
+func (c redactCore) Write(entry zapcore.Entry, fields []zapcore.Field) error {
+	return c.Core.Write(entry, redact(fields))
+}
+
+func redact(fields []zapcore.Field) []zapcore.Field {
+	result := make([]zapcore.Field, len(fields))
+	for i, field := range fields {
+		result[i] = field
+		if isSecret(field.Key) {
+			result[i] = zap.String(field.Key, redacted)
+		}
+	}
+	return result
+}
+
+func isSecret(key string) bool {
+	key = strings.ToLower(key)
+	for _, secret := range secretFields {
+		if key == secret {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,98 @@
+// Package metrics собирает метрики сервера в формате Prometheus.
+package metrics
+
+import (
+	"database/sql"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// namespace — общий префикс имён метрик.
+const namespace = "gophkeeper"
+
+// Metrics хранит метрики сервера в собственном реестре, чтобы несколько серверов
+// в одном процессе (например, в тестах) не конфликтовали.
+type Metrics struct {
+	registry      *prometheus.Registry
+	requests      *prometheus.CounterVec
+	latency       *prometheus.HistogramVec
+	loginFailures *prometheus.CounterVec
+}
+
+// New создаёт реестр с метриками запросов, входов и среды выполнения Go.
+func New() *Metrics {
+	m := &Metrics{
+		registry: prometheus.NewRegistry(),
+		requests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "http_requests_total",
+			Help:      "Количество HTTP-запросов по маршруту, методу, статусу и типу записи.",
+		}, []string{"route", "method", "status", "record_type"}),
+		latency: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "http_request_duration_seconds",
+			Help:      "Длительность обработки HTTP-запросов по маршруту, методу, статусу и типу записи.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"route", "method", "status", "record_type"}),
+		loginFailures: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "login_failures_total",
+			Help:      "Количество неудачных попыток входа по коду ответа.",
+		}, []string{"status"}),
+	}
+
+	m.registry.MustRegister(
+		m.requests,
+		m.latency,
+		m.loginFailures,
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+	)
+	return m
+}
+
+// RegisterDB добавляет статистику пула соединений sql.DB.Stats().
+func (m *Metrics) RegisterDB(db *sql.DB, name string) {
+	m.registry.MustRegister(collectors.NewDBStatsCollector(db, name))
+}
+
+// RegisterSessions добавляет число активных сессий, которое возвращает count:
+// выданных этим сервером, не истёкших и не завершённых выходом.
+func (m *Metrics) RegisterSessions(count func() float64) {
+	m.registry.MustRegister(prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "active_sessions",
+		Help:      "Количество активных сессий пользователей, выданных этим сервером.",
+	}, count))
+}
+
+// RegisterAuditFailures добавляет число действий, не записанных в журнал аудита, которое возвращает count.
+// Такие действия уже выполнены и не отменяются, поэтому по росту метрики следует поднимать тревогу.
+func (m *Metrics) RegisterAuditFailures(count func() float64) {
+	m.registry.MustRegister(prometheus.NewCounterFunc(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "audit_write_failures_total",
+		Help:      "Количество действий, которые не удалось записать в журнал аудита.",
+	}, count))
+}
+
+// ObserveRequest учитывает обработанный запрос.
+func (m *Metrics) ObserveRequest(route, method string, status int, recordType string, duration time.Duration) {
+	code := strconv.Itoa(status)
+	m.requests.WithLabelValues(route, method, code, recordType).Inc()
+	m.latency.WithLabelValues(route, method, code, recordType).Observe(duration.Seconds())
+}
+
+// LoginFailed учитывает неудачную попытку входа.
+func (m *Metrics) LoginFailed(status int) {
+	m.loginFailures.WithLabelValues(strconv.Itoa(status)).Inc()
+}
+
+// Handler отдаёт метрики в текстовом формате Prometheus.
+func (m *Metrics) Handler() http.Handler {
+	return promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{})
+}
@@ -4,9 +4,10 @@ package middleware
 
 import (
 	"compress/gzip"
+	"go.uber.org/zap"
 	"io"
-	"log"
 	"net/http"
+	"server/internal/logger"
 	"strings"
 )
 
@@ -109,7 +110,7 @@ func СompressionResponseRequest(handler http.Handler) http.Handler {
 		if sendsGzip {
 			cr, err := newCompressReader(r.Body)
 			if err != nil {
-				log.Printf("CompressReader error: %s", err)
+				logger.FromContext(r.Context()).Warn("gzip request body", zap.Error(err))
 				w.WriteHeader(http.StatusInternalServerError)
 				return
 			}
@@ -1,10 +1,14 @@
 // Package middleware предоставляет middleware для обработки HTTP-запросов и ответов,
-// включая логирование через zap.NewDevelopment.
+// включая структурированное логирование через zap.
 package middleware
 
 import (
+	"context"
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel/trace"
 	"go.uber.org/zap"
 	"net/http"
+	"server/internal/logger"
 	"time"
 )
 
@@ -40,43 +44,96 @@ func (r *loggingResponseWriter) WriteHeader(statusCode int) {
 	r.responseData.status = statusCode // захватываем код статуса
 }
 
-// LoggingResponseRequest является middleware, которая логирует HTTP-запросы и ответы.
-// Принимает http.Handler и возвращает новый http.Handler с логированием.
-// Логируется время выполения запроса duration.
-func LoggingResponseRequest(handler http.Handler) http.Handler {
-	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		start := time.Now()
-		//response
-		responseData := &responseData{
-			status: 0,
-			size:   0,
-		}
-		lw := loggingResponseWriter{
-			ResponseWriter: w,
-			responseData:   responseData,
-		}
+// Flush передаёт буферизованные данные клиенту. Нужен потоковым ответам, например /api/events.
+func (r *loggingResponseWriter) Flush() {
+	if flusher, ok := r.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
 
-		handler.ServeHTTP(&lw, r)
+// requestIDHeader передаёт идентификатор запроса от клиента или прокси и возвращается в ответе.
+const requestIDHeader = "X-Request-ID"
 
-		logger, err := zap.NewDevelopment()
-		if err != nil {
-			panic(err)
-		}
+// maxRequestIDLength ограничивает длину принимаемого идентификатора запроса.
+const maxRequestIDLength = 128
+
+// requestInfo собирает сведения о запросе, которые становятся известны во внутренних middleware.
+type requestInfo struct {
+	userKey string
+}
+
+type requestInfoKey struct{}
+
+// LoggingResponseRequest возвращает middleware, которое присваивает запросу идентификатор,
+// передаёт логгер запроса через контекст и после ответа записывает метод, маршрут, статус,
+// размер ответа, длительность и ключ пользователя.
+// Тела запросов и ответов, заголовки и куки не логируются.
+func LoggingResponseRequest(log *zap.Logger) func(http.Handler) http.Handler {
+	return func(handler http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			start := time.Now()
+
+			requestID := r.Header.Get(requestIDHeader)
+			if !validRequestID(requestID) {
+				requestID = uuid.NewString()
+			}
+			w.Header().Set(requestIDHeader, requestID)
 
-		defer logger.Sync()
-		sugar := logger.Sugar()
+			requestLog := log.With(zap.String("request_id", requestID))
+			if spanContext := trace.SpanContextFromContext(r.Context()); spanContext.IsValid() {
+				requestLog = requestLog.With(zap.String("trace_id", spanContext.TraceID().String()))
+			}
+			info := &requestInfo{}
+			ctx := logger.WithContext(r.Context(), requestLog)
+			ctx = context.WithValue(ctx, requestInfoKey{}, info)
 
-		duration := time.Since(start)
-		sugar.Infoln(
-			"uri", r.RequestURI,
-			"method", r.Method,
-			"duration", duration,
-		)
+			//response
+			responseData := &responseData{
+				status: http.StatusOK,
+				size:   0,
+			}
+			lw := loggingResponseWriter{
+				ResponseWriter: w,
+				responseData:   responseData,
+			}
 
-		sugar.Infoln(
-			"status", responseData.status,
-			"size", responseData.size,
-		)
-	})
+			handler.ServeHTTP(&lw, r.WithContext(ctx))
 
+			fields := []zap.Field{
+				zap.String("method", r.Method),
+				zap.String("route", routePattern(r.WithContext(ctx))),
+				zap.Int("status", responseData.status),
+				zap.Int("size", responseData.size),
+				zap.Duration("latency", time.Since(start)),
+			}
+			if info.userKey != "" {
+				fields = append(fields, zap.String("user_key", info.userKey))
+			}
+			requestLog.Info("request", fields...)
+		})
+	}
+}
+
+// setRequestUser сообщает журналу запроса ключ авторизованного пользователя
+// и добавляет его к логгеру запроса.
+func setRequestUser(ctx context.Context, userKey string) context.Context {
+	if info, ok := ctx.Value(requestInfoKey{}).(*requestInfo); ok {
+		info.userKey = userKey
+	}
+	return logger.WithContext(ctx, logger.FromContext(ctx).With(zap.String("user_key", userKey)))
+}
+
+// validRequestID принимает непустые идентификаторы из букв, цифр, '-', '_' и '.'.
+func validRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLength {
+		return false
+	}
+	for _, c := range id {
+		switch {
+		case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z', c >= '0' && c <= '9', c == '-', c == '_', c == '.':
+		default:
+			return false
+		}
+	}
+	return true
 }
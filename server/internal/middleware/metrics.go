@@ -0,0 +1,98 @@
+package middleware
+
+import (
+	"github.com/go-chi/chi/v5"
+	"net/http"
+	"server/internal/metrics"
+	"server/internal/model"
+	"strings"
+	"time"
+)
+
+// loginPath — маршрут входа, неудачные ответы которого считаются отдельно.
+const loginPath = "/api/authorization"
+
+// dataPrefix — префикс маршрутов записей, за которым следует тип записи.
+const dataPrefix = "/api/data/"
+
+// MetricsResponseRequest возвращает middleware, которое учитывает количество и длительность
+// запросов по маршруту, методу, статусу и типу записи, а также неудачные попытки входа.
+func MetricsResponseRequest(m *metrics.Metrics) func(http.Handler) http.Handler {
+	return func(handler http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			start := time.Now()
+			responseData := &responseData{
+				status: http.StatusOK,
+				size:   0,
+			}
+			lw := loggingResponseWriter{
+				ResponseWriter: w,
+				responseData:   responseData,
+			}
+
+			handler.ServeHTTP(&lw, r)
+
+			route, recordType := unmatchedRoute, ""
+			if rctx := matchRoute(r); rctx != nil {
+				route = rctx.RoutePattern()
+				recordType = routeRecordType(route, rctx)
+			}
+
+			m.ObserveRequest(route, r.Method, responseData.status, recordType, time.Since(start))
+			if route == loginPath && responseData.status >= http.StatusBadRequest {
+				m.LoginFailed(responseData.status)
+			}
+		})
+	}
+}
+
+// unmatchedRoute заменяет маршрут запросов, не найденных в дереве маршрутов.
+const unmatchedRoute = "unmatched"
+
+// routePattern возвращает шаблон маршрута запроса, например /api/links/{uuid}, или unmatchedRoute.
+// В журнал и трассы попадает маршрут вместо пути: в пути могут быть ключи одноразовых ссылок.
+func routePattern(r *http.Request) string {
+	if rctx := matchRoute(r); rctx != nil {
+		return rctx.RoutePattern()
+	}
+	return unmatchedRoute
+}
+
+// matchRoute возвращает контекст маршрута запроса или nil, если маршрут не найден.
+// Запросы, отклонённые middleware до маршрутизации, сопоставляются с деревом маршрутов отдельно.
+func matchRoute(r *http.Request) *chi.Context {
+	rctx := chi.RouteContext(r.Context())
+	if rctx == nil {
+		return nil
+	}
+	if rctx.RoutePattern() != "" {
+		return rctx
+	}
+	if rctx.Routes == nil {
+		return nil
+	}
+
+	matched := chi.NewRouteContext()
+	if !rctx.Routes.Match(matched, r.Method, r.URL.Path) {
+		return nil
+	}
+	return matched
+}
+
+// routeRecordType возвращает тип записи из параметра {type} или из маршрута вида /api/data/text/...
+// Неизвестные типы сводятся к "other", чтобы значения из пути не порождали новые ряды метрик.
+func routeRecordType(route string, rctx *chi.Context) string {
+	recordType := rctx.URLParam("type")
+	if recordType == "" {
+		if !strings.HasPrefix(route, dataPrefix) {
+			return ""
+		}
+		recordType, _, _ = strings.Cut(strings.TrimPrefix(route, dataPrefix), "/")
+	}
+
+	switch recordType {
+	case model.RecordText, model.RecordBinary, model.RecordCard, model.RecordTOTP, model.RecordSSH, model.RecordCustom:
+		return recordType
+	}
+	return "other"
+}
@@ -0,0 +1,124 @@
+package middleware
+
+import (
+	"go.uber.org/zap"
+	"golang.org/x/time/rate"
+	"math"
+	"net"
+	"net/http"
+	"server/internal/config"
+	"server/internal/logger"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// rateLimitPath — префикс путей, к которым применяется ограничение частоты запросов.
+// Проверки готовности и метрики не ограничиваются.
+const rateLimitPath = "/api/"
+
+// rateLimitIdle — через сколько после последнего запроса забывается ограничитель адреса.
+const rateLimitIdle = 10 * time.Minute
+
+// RateLimiter ограничивает частоту запросов с одного IP-адреса.
+// Настройки можно менять на ходу методом Update.
+type RateLimiter struct {
+	mu        sync.Mutex
+	limit     rate.Limit
+	burst     int
+	clients   map[string]*rateClient
+	lastSweep time.Time
+}
+
+type rateClient struct {
+	limiter *rate.Limiter
+	seen    time.Time
+}
+
+// NewRateLimiter создаёт ограничитель с заданными настройками.
+func NewRateLimiter(settings config.RateLimitSettings) *RateLimiter {
+	l := &RateLimiter{
+		clients:   map[string]*rateClient{},
+		lastSweep: time.Now(),
+	}
+	l.Update(settings)
+	return l
+}
+
+// Update применяет новые настройки, в том числе к уже известным адресам.
+func (l *RateLimiter) Update(settings config.RateLimitSettings) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.limit = rate.Limit(settings.RequestsPerSecond)
+	l.burst = settings.Burst
+	for _, client := range l.clients {
+		client.limiter.SetLimit(l.limit)
+		client.limiter.SetBurst(l.burst)
+	}
+}
+
+// allow сообщает, можно ли выполнить запрос с адреса ip, и если нельзя — через сколько повторить.
+func (l *RateLimiter) allow(ip string) (bool, time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.limit <= 0 {
+		return true, 0
+	}
+
+	now := time.Now()
+	if now.Sub(l.lastSweep) > rateLimitIdle {
+		for key, client := range l.clients {
+			if now.Sub(client.seen) > rateLimitIdle {
+				delete(l.clients, key)
+			}
+		}
+		l.lastSweep = now
+	}
+
+	client, ok := l.clients[ip]
+	if !ok {
+		client = &rateClient{limiter: rate.NewLimiter(l.limit, l.burst)}
+		l.clients[ip] = client
+	}
+	client.seen = now
+
+	reservation := client.limiter.ReserveN(now, 1)
+	if delay := reservation.DelayFrom(now); delay > 0 {
+		reservation.CancelAt(now)
+		return false, delay
+	}
+	return true, 0
+}
+
+// RateLimitResponseRequest возвращает middleware, которое отвечает 429 Too Many Requests
+// с заголовком Retry-After, если адрес превысил ограничение.
+func RateLimitResponseRequest(l *RateLimiter) func(http.Handler) http.Handler {
+	return func(handler http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if !strings.HasPrefix(r.URL.Path, rateLimitPath) {
+				handler.ServeHTTP(w, r)
+				return
+			}
+
+			ip := r.RemoteAddr
+			if host, _, err := net.SplitHostPort(r.RemoteAddr); err == nil {
+				ip = host
+			}
+
+			if ok, retry := l.allow(ip); !ok {
+				logger.FromContext(r.Context()).Warn("request rejected",
+					zap.String("reason", "rate limit"),
+					zap.Int("status", http.StatusTooManyRequests),
+				)
+				w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(retry.Seconds()))))
+				w.WriteHeader(http.StatusTooManyRequests)
+				return
+			}
+
+			handler.ServeHTTP(w, r)
+		})
+	}
+}
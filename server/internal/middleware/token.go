@@ -2,11 +2,31 @@ package middleware
 
 import (
 	"github.com/google/uuid"
-	"log"
+	"go.uber.org/zap"
 	"net/http"
+	"server/internal/logger"
 	"server/internal/service"
+	"strings"
 )
 
+// vaultHeader задаёт хранилище организации, в котором выполняется запрос к записям.
+const vaultHeader = "X-Vault-Key"
+
+// linkPath — префикс одноразовых ссылок, которые открываются без авторизации.
+const linkPath = "/api/links/"
+
+// vaultPaths — префиксы путей, которые работают с записями и учитывают заголовок vaultHeader.
+var vaultPaths = []string{
+	"/api/data/",
+	"/api/sync",
+	"/api/events",
+	"/api/conflicts",
+	"/api/trash",
+	"/api/shares",
+	"/api/audit",
+	"/api/reports",
+}
+
 // TokenResponseRequest является middleware-обработчиком, который проверяет наличие куки с токеном "user".
 // Если куки не существует или токен недействителен, создает новый токен и устанавливает его в куки.
 // Если токен существует и действителен, проверяет пользователя и продолжает выполнение запроса.
@@ -16,6 +36,10 @@ func TokenResponseRequest(gophKeeper *service.GophKeeper, handler http.Handler)
 		authorizationPaths := []string{
 			"/api/authorization",
 			"/api/register",
+			"/metrics",
+			"/healthz",
+			"/readyz",
+			"/version",
 		}
 
 		// Пропускаем авторизацию
@@ -26,28 +50,78 @@ func TokenResponseRequest(gophKeeper *service.GophKeeper, handler http.Handler)
 			}
 		}
 
+		// Одноразовые ссылки открываются без учётной записи
+		if r.Method == http.MethodGet && strings.HasPrefix(r.URL.Path, linkPath) {
+			handler.ServeHTTP(w, r)
+			return
+		}
+
 		cookie, err := r.Cookie("user")
 		// не существует или она не проходит проверку подлинности
 		if err != nil {
-			log.Printf("error handling request: %v, status: %d", err, http.StatusInternalServerError)
+			logger.FromContext(r.Context()).Warn("request rejected", zap.Error(err), zap.Int("status", http.StatusUnauthorized))
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		token, err := service.ReadToken(cookie.Value)
+		if err != nil {
+			logger.FromContext(r.Context()).Warn("request rejected", zap.Error(err), zap.Int("status", http.StatusInternalServerError))
 			w.WriteHeader(http.StatusInternalServerError)
+			return
 		}
 
-		userKey, err := service.ReadToken(cookie.Value)
+		// Сессия завершена командой выхода
+		revoked, err := gophKeeper.IsSessionRevoked(r.Context(), token.ID)
 		if err != nil {
-			log.Printf("error handling request: %v, status: %d", err, http.StatusInternalServerError)
+			logger.FromContext(r.Context()).Warn("request rejected", zap.Error(err), zap.Int("status", http.StatusInternalServerError))
 			w.WriteHeader(http.StatusInternalServerError)
 			return
 		}
+		if revoked {
+			logger.FromContext(r.Context()).Warn("request rejected",
+				zap.String("reason", "session revoked"),
+				zap.Int("status", http.StatusUnauthorized),
+			)
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
 
-		userKeyUUID, err := uuid.Parse(userKey)
+		userKeyUUID, err := uuid.Parse(token.UserKey)
 		if err != nil {
-			log.Printf("error handling request: %v, status: %d", err, http.StatusInternalServerError)
+			logger.FromContext(r.Context()).Warn("request rejected", zap.Error(err), zap.Int("status", http.StatusInternalServerError))
+			w.WriteHeader(http.StatusInternalServerError)
 			return
 		}
 
-		ctx := service.SetCurrentUserID(r.Context(), userKeyUUID)
+		// Запросы к хранилищу организации выполняются от имени хранилища; роль участника проверяет сервис.
+		ownerKey := userKeyUUID
+		if header := r.Header.Get(vaultHeader); header != "" && isVaultPath(r.URL.Path) {
+			vaultKey, err := uuid.Parse(header)
+			if err != nil {
+				logger.FromContext(r.Context()).Warn("request rejected", zap.Error(err), zap.Int("status", http.StatusBadRequest))
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+
+			ownerKey = vaultKey
+		}
+
+		ctx := setRequestUser(r.Context(), userKeyUUID.String())
+		ctx = service.SetCurrentUserID(ctx, ownerKey)
+		ctx = service.SetCurrentActor(ctx, userKeyUUID)
+		ctx = service.SetCurrentSession(ctx, token.ID)
 
 		handler.ServeHTTP(w, r.WithContext(ctx))
 	})
 }
+
+// isVaultPath сообщает, относится ли путь к записям и может ли выполняться в хранилище организации.
+func isVaultPath(path string) bool {
+	for _, prefix := range vaultPaths {
+		if strings.HasPrefix(path, prefix) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,65 @@
+package middleware
+
+import (
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+	"net/http"
+)
+
+// tracer создаёт серверные спаны HTTP-запросов.
+var tracer = otel.Tracer("server/internal/middleware")
+
+// TracingResponseRequest возвращает middleware, которое продолжает трассировку из заголовка
+// traceparent или начинает новую и открывает спан на время обработки запроса.
+// Спан называется по методу и маршруту; ответы 5xx отмечаются как ошибки.
+func TracingResponseRequest() func(http.Handler) http.Handler {
+	return func(handler http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ctx := otel.GetTextMapPropagator().Extract(r.Context(), propagation.HeaderCarrier(r.Header))
+			ctx, span := tracer.Start(ctx, r.Method,
+				trace.WithSpanKind(trace.SpanKindServer),
+				trace.WithAttributes(
+					attribute.String("http.request.method", r.Method),
+					attribute.String("url.scheme", scheme(r)),
+					attribute.String("user_agent.original", r.UserAgent()),
+				),
+			)
+			defer span.End()
+
+			responseData := &responseData{
+				status: http.StatusOK,
+				size:   0,
+			}
+			lw := loggingResponseWriter{
+				ResponseWriter: w,
+				responseData:   responseData,
+			}
+
+			r = r.WithContext(ctx)
+			handler.ServeHTTP(&lw, r)
+
+			if route := routePattern(r); route != unmatchedRoute {
+				span.SetName(r.Method + " " + route)
+				span.SetAttributes(attribute.String("http.route", route))
+			}
+			span.SetAttributes(
+				attribute.Int("http.response.status_code", responseData.status),
+				attribute.Int("http.response.body.size", responseData.size),
+			)
+			if responseData.status >= http.StatusInternalServerError {
+				span.SetStatus(codes.Error, http.StatusText(responseData.status))
+			}
+		})
+	}
+}
+
+// scheme возвращает схему запроса с учётом TLS.
+func scheme(r *http.Request) string {
+	if r.TLS != nil {
+		return "https"
+	}
+	return "http"
+}
@@ -1,10 +1,31 @@
 package model
 
 import (
+	"encoding/json"
 	"github.com/google/uuid"
 	"time"
 )
 
+// Типы записей хранилища, совпадающие с сегментом пути /api/data/{type}.
+const (
+	RecordText   = "text"
+	RecordBinary = "binary"
+	RecordCard   = "card"
+	RecordTOTP   = "totp"
+	RecordSSH    = "ssh"
+	RecordCustom = "custom"
+)
+
+// Виды изменений в ленте синхронизации.
+const (
+	ChangeCreated = "created"
+	ChangeUpdated = "updated"
+	ChangeDeleted = "deleted"
+)
+
+// HistoryRestored отмечает в истории версию, восстановленную из более ранней.
+const HistoryRestored = "restored"
+
 type User struct {
 	Login         string `json:"login,omitempty"`
 	PasswordHash  string `json:"password_hash"`
@@ -20,11 +41,13 @@ type DataText struct {
 	DataTextKey    uuid.UUID `json:"data_text_key,omitempty"`
 	PrivateUserKey uuid.UUID `json:"private_user_key,omitempty"`
 	Data           string    `json:"data,omitempty"`
+	Revision       int64     `json:"revision,omitempty"`
 }
 
 type DataTextResponse struct {
 	DataTextKey uuid.UUID `json:"data_text_key,omitempty"`
 	Data        string    `json:"data,omitempty"`
+	Revision    int64     `json:"revision,omitempty"`
 }
 
 type DataBinary struct {
@@ -32,21 +55,53 @@ type DataBinary struct {
 	PrivateUserKey uuid.UUID `json:"private_user_key,omitempty"`
 	FileName       string    `json:"filename,omitempty"`
 	Data           string    `json:"data,omitempty"`
+	Revision       int64     `json:"revision,omitempty"`
 }
 
 type DataBinaryResponse struct {
 	DataBinaryKey uuid.UUID `json:"data_binary_key,omitempty"`
 	FileName      string    `json:"filename,omitempty"`
 	Data          string    `json:"data,omitempty"`
+	Revision      int64     `json:"revision,omitempty"`
 }
 
+// Платёжные системы карт, определяемые сервером по номеру.
+const (
+	BrandVisa       = "visa"
+	BrandMastercard = "mastercard"
+	BrandMir        = "mir"
+	BrandAmex       = "amex"
+	BrandDiscover   = "discover"
+	BrandDiners     = "diners"
+	BrandJCB        = "jcb"
+	BrandUnionPay   = "unionpay"
+	BrandMaestro    = "maestro"
+)
+
+// Типы карт.
+const (
+	CardTypeDebit  = "debit"
+	CardTypeCredit = "credit"
+)
+
+// DataCreditCard описывает банковскую карту. Brand и ExpiresOn (последний день срока действия
+// в формате YYYY-MM-DD) вычисляет сервер, значения из запроса не учитываются.
+// CVV и PIN хранятся зашифрованными и возвращаются владельцу в открытом виде.
 type DataCreditCard struct {
 	DataCreditCardKey uuid.UUID `json:"data_credit_card_key,omitempty"`
 	PrivateUserKey    uuid.UUID `json:"private_user_key,omitempty"`
 	CardNumber        string    `json:"card_number,omitempty"`
 	CardholderName    string    `json:"cardholder_name,omitempty"`
 	ExpirationDate    string    `json:"expiration_date,omitempty"`
-	CVVHash           string    `json:"cvv_hash,omitempty"`
+	CVV               string    `json:"cvv,omitempty"`
+	PIN               string    `json:"pin,omitempty"`
+	Brand             string    `json:"brand,omitempty"`
+	ExpiresOn         string    `json:"expires_on,omitempty"`
+	CardType          string    `json:"card_type,omitempty"` // CardTypeDebit или CardTypeCredit
+	IssuingBank       string    `json:"issuing_bank,omitempty"`
+	BillingAddress    string    `json:"billing_address,omitempty"`
+	Notes             string    `json:"notes,omitempty"`
+	Revision          int64     `json:"revision,omitempty"`
 }
 
 type DataCreditCardResponse struct {
@@ -54,6 +109,386 @@ type DataCreditCardResponse struct {
 	CardNumber        string    `json:"card_number,omitempty"`
 	CardholderName    string    `json:"cardholder_name,omitempty"`
 	ExpirationDate    string    `json:"expiration_date,omitempty"`
-	CVVHash           string    `json:"cvv_hash,omitempty"`
+	CVV               string    `json:"cvv,omitempty"`
+	PIN               string    `json:"pin,omitempty"`
+	Brand             string    `json:"brand,omitempty"`
+	ExpiresOn         string    `json:"expires_on,omitempty"`
+	CardType          string    `json:"card_type,omitempty"`
+	IssuingBank       string    `json:"issuing_bank,omitempty"`
+	BillingAddress    string    `json:"billing_address,omitempty"`
+	Notes             string    `json:"notes,omitempty"`
 	CreatedAt         time.Time `json:"created_at,omitempty"`
+	Revision          int64     `json:"revision,omitempty"`
+}
+
+// Алгоритмы HMAC для одноразовых кодов TOTP.
+const (
+	TOTPSHA1   = "SHA1"
+	TOTPSHA256 = "SHA256"
+	TOTPSHA512 = "SHA512"
+)
+
+// DataTOTP описывает секрет двухфакторной аутентификации по RFC 6238. Запись можно создать
+// из ссылки otpauth:// в поле URI: заданные в ней параметры заменяют поля запроса.
+// Secret в кодировке base32 хранится зашифрованным; значение с префиксом "e2e:" зашифровано
+// клиентом, и сервер сохраняет его без проверки.
+type DataTOTP struct {
+	DataTOTPKey    uuid.UUID `json:"data_totp_key,omitempty"`
+	PrivateUserKey uuid.UUID `json:"private_user_key,omitempty"`
+	URI            string    `json:"uri,omitempty"`
+	Secret         string    `json:"secret,omitempty"`
+	Issuer         string    `json:"issuer,omitempty"`
+	Account        string    `json:"account,omitempty"`
+	Algorithm      string    `json:"algorithm,omitempty"` // TOTPSHA1, TOTPSHA256 или TOTPSHA512
+	Digits         int       `json:"digits,omitempty"`
+	Period         int       `json:"period,omitempty"` // срок действия кода в секундах
+	Revision       int64     `json:"revision,omitempty"`
+}
+
+type DataTOTPResponse struct {
+	DataTOTPKey uuid.UUID `json:"data_totp_key,omitempty"`
+	Secret      string    `json:"secret,omitempty"`
+	Issuer      string    `json:"issuer,omitempty"`
+	Account     string    `json:"account,omitempty"`
+	Algorithm   string    `json:"algorithm,omitempty"`
+	Digits      int       `json:"digits,omitempty"`
+	Period      int       `json:"period,omitempty"`
+	CreatedAt   time.Time `json:"created_at,omitempty"`
+	Revision    int64     `json:"revision,omitempty"`
+}
+
+// DataSSHKey описывает ключ SSH. Ключ разбирает и проверяет клиент: он же вычисляет открытый ключ
+// и отпечаток SHA256, поэтому сервер хранит их как есть. PrivateKey и Passphrase хранятся зашифрованными;
+// значения с префиксом "e2e:" зашифрованы клиентом.
+type DataSSHKey struct {
+	DataSSHKeyKey  uuid.UUID `json:"data_ssh_key_key,omitempty"`
+	PrivateUserKey uuid.UUID `json:"private_user_key,omitempty"`
+	PrivateKey     string    `json:"private_key,omitempty"` // в формате PEM или OpenSSH
+	PublicKey      string    `json:"public_key,omitempty"`  // строка формата authorized_keys
+	Comment        string    `json:"comment,omitempty"`
+	Passphrase     string    `json:"passphrase,omitempty"`
+	Fingerprint    string    `json:"fingerprint,omitempty"` // например "SHA256:..."
+	Revision       int64     `json:"revision,omitempty"`
+}
+
+type DataSSHKeyResponse struct {
+	DataSSHKeyKey uuid.UUID `json:"data_ssh_key_key,omitempty"`
+	PrivateKey    string    `json:"private_key,omitempty"`
+	PublicKey     string    `json:"public_key,omitempty"`
+	Comment       string    `json:"comment,omitempty"`
+	Passphrase    string    `json:"passphrase,omitempty"`
+	Fingerprint   string    `json:"fingerprint,omitempty"`
+	CreatedAt     time.Time `json:"created_at,omitempty"`
+	Revision      int64     `json:"revision,omitempty"`
+}
+
+// Типы полей шаблонов записей.
+const (
+	FieldString = "string"
+	FieldSecret = "secret" // хранится зашифрованным
+	FieldURL    = "url"
+	FieldDate   = "date" // YYYY-MM-DD
+	FieldNumber = "number"
+	FieldTOTP   = "totp" // секрет TOTP в base32, хранится зашифрованным
+	FieldFile   = "file" // ключ бинарной записи
+)
+
+// TemplateField описывает поле шаблона записи.
+type TemplateField struct {
+	Name     string `json:"name"`
+	Label    string `json:"label,omitempty"`
+	Type     string `json:"type"`
+	Required bool   `json:"required,omitempty"`
+}
+
+// RecordTemplate описывает пользовательский тип записи. Шаблон принадлежит пользователю
+// или организации (OrgKey) и после создания не меняется, поэтому записи по нему всегда ему соответствуют.
+type RecordTemplate struct {
+	TemplateKey uuid.UUID       `json:"template_key"`
+	OrgKey      *uuid.UUID      `json:"org_key,omitempty"`
+	Name        string          `json:"name"`
+	Fields      []TemplateField `json:"fields"`
+	CreatedAt   time.Time       `json:"created_at"`
+}
+
+// DataCustom описывает запись пользовательского типа: значения полей шаблона по их именам.
+// Значения полей secret и totp хранятся зашифрованными; значения с префиксом "e2e:"
+// зашифрованы клиентом и сервером не проверяются.
+type DataCustom struct {
+	DataCustomKey  uuid.UUID         `json:"data_custom_key,omitempty"`
+	PrivateUserKey uuid.UUID         `json:"private_user_key,omitempty"`
+	TemplateKey    uuid.UUID         `json:"template_key,omitempty"`
+	Fields         map[string]string `json:"fields,omitempty"`
+	Revision       int64             `json:"revision,omitempty"`
+}
+
+type DataCustomResponse struct {
+	DataCustomKey uuid.UUID         `json:"data_custom_key,omitempty"`
+	TemplateKey   uuid.UUID         `json:"template_key,omitempty"`
+	TemplateName  string            `json:"template_name,omitempty"`
+	Fields        map[string]string `json:"fields,omitempty"`
+	CreatedAt     time.Time         `json:"created_at,omitempty"`
+	Revision      int64             `json:"revision,omitempty"`
+}
+
+// SyncChange описывает изменение одной записи в ленте синхронизации.
+// Для удалённых записей (tombstone) поле Data не заполняется.
+type SyncChange struct {
+	Type      string          `json:"type"`
+	Key       uuid.UUID       `json:"key"`
+	Action    string          `json:"action"`
+	Revision  int64           `json:"revision"`
+	UpdatedAt time.Time       `json:"updated_at"`
+	Data      json.RawMessage `json:"data,omitempty"`
+}
+
+// SyncResponse содержит изменения пользователя после запрошенной ревизии.
+type SyncResponse struct {
+	Revision int64        `json:"revision"`
+	Changes  []SyncChange `json:"changes"`
+}
+
+// HistoryEntry описывает одну версию записи в истории. У удалений Data не заполняется.
+type HistoryEntry struct {
+	Revision  int64           `json:"revision"`
+	Action    string          `json:"action"`
+	CreatedAt time.Time       `json:"created_at"`
+	Data      json.RawMessage `json:"data,omitempty"`
+}
+
+// HistoryRestore задаёт ревизию, к которой возвращается запись.
+type HistoryRestore struct {
+	Revision int64 `json:"revision"`
+}
+
+// ExpiringRecord описывает запись, срок действия которой скоро истекает.
+// DaysLeft — число дней до последнего дня срока; 0 означает, что срок истекает сегодня.
+// Owner и OwnerKey заполняются только в напоминаниях, которые сервер отправляет сам.
+type ExpiringRecord struct {
+	Type      string     `json:"type"`
+	Key       uuid.UUID  `json:"key"`
+	Title     string     `json:"title"`
+	ExpiresOn string     `json:"expires_on"`
+	DaysLeft  int        `json:"days_left"`
+	OwnerKey  *uuid.UUID `json:"owner_key,omitempty"`
+	Owner     string     `json:"owner,omitempty"`
+}
+
+// TrashEntry описывает удалённую запись в корзине.
+type TrashEntry struct {
+	Type      string          `json:"type"`
+	Key       uuid.UUID       `json:"key"`
+	DeletedAt time.Time       `json:"deleted_at"`
+	Data      json.RawMessage `json:"data"`
+}
+
+// TrashPurged содержит количество окончательно очищенных записей.
+type TrashPurged struct {
+	Purged int64 `json:"purged"`
+}
+
+// Права получателя на общую запись.
+const (
+	PermissionRead  = "read"  // только чтение
+	PermissionWrite = "write" // чтение и изменение
+)
+
+// Share описывает доступ получателя к записи владельца.
+type Share struct {
+	Type       string    `json:"type"`
+	RecordKey  uuid.UUID `json:"record_key"`
+	OwnerKey   uuid.UUID `json:"-"`
+	Login      string    `json:"login"`
+	Permission string    `json:"permission"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// SharedRecord описывает запись другого пользователя, к которой у текущего пользователя есть доступ.
+type SharedRecord struct {
+	Type       string          `json:"type"`
+	Key        uuid.UUID       `json:"key"`
+	Owner      string          `json:"owner"`
+	Permission string          `json:"permission"`
+	Data       json.RawMessage `json:"data"`
+}
+
+// E2EParams содержит параметры сквозного шифрования пользователя. Ключ выводится на клиенте
+// из ключевой фразы и соли Salt; Check — известное значение, зашифрованное этим ключом,
+// по которому клиент проверяет фразу. Сервер ключевую фразу не получает.
+type E2EParams struct {
+	Salt  string `json:"salt"`
+	Check string `json:"check"`
+}
+
+// Link описывает одноразовую ссылку на секрет.
+// Data содержит шифротекст; ключ передаётся получателю во фрагменте URL и серверу неизвестен.
+type Link struct {
+	LinkKey   uuid.UUID `json:"link_key,omitempty"`
+	OwnerKey  uuid.UUID `json:"-"`
+	Data      string    `json:"data,omitempty"`
+	MaxViews  int       `json:"max_views,omitempty"`
+	Views     int       `json:"views,omitempty"`
+	ExpiresAt time.Time `json:"expires_at"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// Действия, записываемые в журнал аудита.
+const (
+	AuditLogin     = "login"
+	AuditRead      = "read"
+	AuditCreate    = "create"
+	AuditUpdate    = "update"
+	AuditDelete    = "delete"
+	AuditRestore   = "restore"
+	AuditShare     = "share"
+	AuditUnshare   = "unshare"
+	AuditSync      = "sync"      // чтение ленты изменений со всеми записями
+	AuditHistory   = "history"   // чтение истории версий записи
+	AuditTrash     = "trash"     // чтение корзины
+	AuditPurge     = "purge"     // очистка корзины
+	AuditShared    = "shared"    // чтение записей, доступных по общему доступу
+	AuditResolve   = "resolve"   // разрешение конфликта версий
+	AuditConflicts = "conflicts" // чтение конфликтных копий вместе с текущими версиями записей
+	AuditLink      = "link"      // создание одноразовой ссылки
+	AuditOpen      = "open"      // просмотр одноразовой ссылки
+	AuditMember    = "member"    // добавление участника организации или смена его роли
+	AuditExpel     = "expel"     // исключение участника из организации
+)
+
+// Значения record_type в записях журнала о действиях не с записями.
+const (
+	AuditTargetLink = "link" // одноразовая ссылка
+	AuditTargetOrg  = "org"  // организация: изменения её состава записываются в журнал исполнителя
+)
+
+// AuditEntry описывает запись журнала аудита. Записи владельца связаны цепочкой:
+// Hash — HMAC-SHA256 с ключом сервера от PrevHash и остальных полей, кроме AuditID и Actor.
+type AuditEntry struct {
+	AuditID    int64      `json:"audit_id"`
+	OwnerKey   uuid.UUID  `json:"owner_key"`
+	ActorKey   uuid.UUID  `json:"actor_key"`
+	Actor      string     `json:"actor,omitempty"`
+	Action     string     `json:"action"`
+	RecordType string     `json:"record_type,omitempty"`
+	RecordKey  *uuid.UUID `json:"record_key,omitempty"`
+	IP         string     `json:"ip"`
+	UserAgent  string     `json:"user_agent"`
+	CreatedAt  time.Time  `json:"created_at"`
+	PrevHash   string     `json:"prev_hash"`
+	Hash       string     `json:"hash"`
+}
+
+// AuditAnchor — якорь журнала аудита: номер последней записи и число записей до него.
+// Сервер периодически пишет якорь в свой лог, а выгрузка журнала сверяется с ним.
+type AuditAnchor struct {
+	AuditID int64 `json:"audit_id"`
+	Entries int64 `json:"entries"`
+}
+
+// Роли участников организации в порядке убывания прав.
+const (
+	RoleOwner  = "owner"  // все действия, включая назначение владельцев и администраторов
+	RoleAdmin  = "admin"  // управление хранилищами и участниками-редакторами и читателями
+	RoleEditor = "editor" // чтение и изменение записей хранилищ
+	RoleViewer = "viewer" // только чтение записей хранилищ
+)
+
+// Org описывает организацию и роль текущего пользователя в ней.
+type Org struct {
+	OrgKey    uuid.UUID `json:"org_key"`
+	Name      string    `json:"name"`
+	Role      string    `json:"role,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// OrgMember описывает участника организации.
+type OrgMember struct {
+	Login     string    `json:"login"`
+	Role      string    `json:"role"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// Vault описывает хранилище организации и роль текущего пользователя в нём.
+type Vault struct {
+	VaultKey  uuid.UUID `json:"vault_key"`
+	OrgKey    uuid.UUID `json:"org_key"`
+	OrgName   string    `json:"org_name,omitempty"`
+	Name      string    `json:"name"`
+	Role      string    `json:"role,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// Варианты разрешения конфликта.
+const (
+	ResolveCurrent  = "current"  // оставить версию сервера
+	ResolveConflict = "conflict" // принять конфликтную копию
+	ResolveMerged   = "merged"   // сохранить объединённую версию из запроса
+)
+
+// Conflict описывает конфликтную копию записи вместе с текущей версией на сервере.
+type Conflict struct {
+	DataConflictKey uuid.UUID       `json:"data_conflict_key"`
+	Type            string          `json:"type"`
+	RecordKey       uuid.UUID       `json:"record_key"`
+	BaseRevision    int64           `json:"base_revision"`
+	CreatedAt       time.Time       `json:"created_at"`
+	Current         json.RawMessage `json:"current,omitempty"`
+	Conflict        json.RawMessage `json:"conflict"`
+}
+
+// ConflictResolution описывает выбор пользователя при разрешении конфликта.
+// Data заполняется только для варианта ResolveMerged.
+type ConflictResolution struct {
+	DataConflictKey uuid.UUID       `json:"-"`
+	PrivateUserKey  uuid.UUID       `json:"-"`
+	Choice          string          `json:"choice"`
+	Data            json.RawMessage `json:"data,omitempty"`
+}
+
+// ConflictResolutionResponse содержит ревизию записи после разрешения конфликта.
+type ConflictResolutionResponse struct {
+	Type      string    `json:"type"`
+	RecordKey uuid.UUID `json:"record_key"`
+	Revision  int64     `json:"revision"`
+}
+
+// Типы событий, передаваемых в поток /api/events.
+const (
+	EventRecordChanged  = "record-changed"
+	EventSessionRevoked = "session-revoked"
+)
+
+// Event описывает событие, отправляемое клиентам пользователя в реальном времени.
+type Event struct {
+	Type       string    `json:"type"`
+	RecordType string    `json:"record_type,omitempty"`
+	RecordKey  uuid.UUID `json:"record_key,omitempty"`
+	Action     string    `json:"action,omitempty"`
+	Revision   int64     `json:"revision,omitempty"`
+	Session    string    `json:"session,omitempty"`
+}
+
+// Состояния проверок готовности сервера.
+const (
+	HealthOK       = "ok"
+	HealthFailed   = "failed"
+	HealthOutdated = "outdated" // применены не все миграции
+	HealthDirty    = "dirty"    // миграция прервана и требует ручного исправления
+	HealthUnknown  = "unknown"  // версия схемы не записана в базе данных
+	HealthDraining = "draining" // сервер останавливается и завершает начатые запросы
+)
+
+// Readiness описывает готовность сервера обрабатывать запросы.
+type Readiness struct {
+	Status     string          `json:"status"`
+	Database   string          `json:"database"`
+	Migrations MigrationStatus `json:"migrations"`
+}
+
+// MigrationStatus сравнивает применённую миграцию с той, на которую рассчитан сервер.
+type MigrationStatus struct {
+	Status   string `json:"status"`
+	Version  int64  `json:"version,omitempty"`
+	Expected int64  `json:"expected"`
+	Dirty    bool   `json:"dirty,omitempty"`
 }
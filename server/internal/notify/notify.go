@@ -0,0 +1,33 @@
+// Package notify доставляет уведомления сервера внешним получателям.
+package notify
+
+import (
+	"context"
+	"server/internal/config"
+	"server/internal/model"
+	"time"
+)
+
+// EventExpiring — тип события с напоминанием об истекающих записях.
+const EventExpiring = "records.expiring"
+
+// Event — тело уведомления.
+type Event struct {
+	Type    string                 `json:"type"`
+	SentAt  time.Time              `json:"sent_at"`
+	Records []model.ExpiringRecord `json:"records"`
+}
+
+// Notifier доставляет напоминания об истекающих записях.
+// Ошибка означает, что напоминания не доставлены и будут отправлены повторно.
+type Notifier interface {
+	NotifyExpiring(ctx context.Context, records []model.ExpiringRecord) error
+}
+
+// New возвращает уведомитель по настройкам или nil, если доставка не настроена.
+func New(settings config.ExpirySettings) Notifier {
+	if settings.Webhook.URL == "" {
+		return nil
+	}
+	return NewWebhook(settings.Webhook)
+}
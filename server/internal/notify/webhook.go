@@ -0,0 +1,77 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"server/internal/buildinfo"
+	"server/internal/config"
+	"server/internal/model"
+	"time"
+)
+
+// SignatureHeader содержит подпись тела "sha256=<hex(HMAC-SHA256(secret, body))>".
+const SignatureHeader = "X-GophKeeper-Signature"
+
+// Webhook отправляет уведомления запросом POST с телом Event в формате JSON.
+// Доставленным считается уведомление, на которое получатель ответил статусом 2xx.
+type Webhook struct {
+	url    string
+	secret string
+	client *http.Client
+}
+
+// NewWebhook создаёт уведомитель, отправляющий запросы на адрес из настроек.
+func NewWebhook(settings config.WebhookSettings) *Webhook {
+	return &Webhook{
+		url:    settings.URL,
+		secret: settings.Secret,
+		client: &http.Client{Timeout: settings.Timeout},
+	}
+}
+
+// NotifyExpiring отправляет одно уведомление со всеми записями.
+func (w *Webhook) NotifyExpiring(ctx context.Context, records []model.ExpiringRecord) error {
+	body, err := json.Marshal(Event{
+		Type:    EventExpiring,
+		SentAt:  time.Now().UTC(),
+		Records: records,
+	})
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, w.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "gophkeeper-server/"+buildinfo.Version)
+	if w.secret != "" {
+		req.Header.Set(SignatureHeader, "sha256="+sign(w.secret, body))
+	}
+
+	resp, err := w.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, resp.Body)
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("webhook responded with status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+func sign(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
@@ -2,42 +2,125 @@ package server
 
 import (
 	"github.com/go-chi/chi/v5"
+	"go.uber.org/zap"
 	"net/http"
 	"server/internal/handlers"
+	"server/internal/metrics"
 	"server/internal/middleware"
 )
 
-func Router(h *handlers.Handlers) chi.Router {
+func Router(h *handlers.Handlers, log *zap.Logger, m *metrics.Metrics, limiter *middleware.RateLimiter) chi.Router {
 	router := chi.NewRouter()
 
 	// middleware
 	//router.Use(middleware.СompressionResponseRequest)
-	router.Use(middleware.LoggingResponseRequest)
+	router.Use(middleware.TracingResponseRequest())
+	router.Use(middleware.LoggingResponseRequest(log))
+	router.Use(middleware.MetricsResponseRequest(m))
+	router.Use(middleware.RateLimitResponseRequest(limiter))
 	router.Use(func(handlerF http.Handler) http.Handler {
 		return middleware.TokenResponseRequest(h.GetServiceGophKeeper(), handlerF)
 	})
 
 	// router
+	router.Handle("/metrics", m.Handler())
+	router.Get("/healthz", http.HandlerFunc(h.Healthz))
+	router.Get("/readyz", http.HandlerFunc(h.Readyz))
+	router.Get("/version", http.HandlerFunc(h.Version))
+
 	// user
 	router.Post("/api/register", http.HandlerFunc(h.RegisterUser))
 	router.Post("/api/authorization", http.HandlerFunc(h.AuthorizationUser))
 	router.Post("/api/logout", http.HandlerFunc(h.LogoutUser))
+	router.Get("/api/user/e2e", http.HandlerFunc(h.GetE2EParams))
+	router.Post("/api/user/e2e", http.HandlerFunc(h.CreateE2EParams))
 
 	// data
 	// data text
 	router.Post("/api/data/text", http.HandlerFunc(h.CreateDataText))
 	router.Get("/api/data/text/{uuid}", http.HandlerFunc(h.GetDataText))
+	router.Put("/api/data/text/{uuid}", http.HandlerFunc(h.UpdateDataText))
 	router.Delete("/api/data/text/{uuid}", http.HandlerFunc(h.DeleteDataText))
 
 	// data byte
 	router.Post("/api/data/binary", http.HandlerFunc(h.CreateDataBinary))
 	router.Get("/api/data/binary/{uuid}", http.HandlerFunc(h.GetDataBinary))
+	router.Put("/api/data/binary/{uuid}", http.HandlerFunc(h.UpdateDataBinary))
 	router.Delete("/api/data/binary/{uuid}", http.HandlerFunc(h.DeleteDataBinary))
 
 	// data card
 	router.Post("/api/data/card", http.HandlerFunc(h.CreateDataCard))
 	router.Get("/api/data/card/{uuid}", http.HandlerFunc(h.GetDataCard))
+	router.Put("/api/data/card/{uuid}", http.HandlerFunc(h.UpdateDataCard))
 	router.Delete("/api/data/card/{uuid}", http.HandlerFunc(h.DeleteDataCard))
 
+	// data totp
+	router.Post("/api/data/totp", http.HandlerFunc(h.CreateDataTOTP))
+	router.Get("/api/data/totp/{uuid}", http.HandlerFunc(h.GetDataTOTP))
+	router.Put("/api/data/totp/{uuid}", http.HandlerFunc(h.UpdateDataTOTP))
+	router.Delete("/api/data/totp/{uuid}", http.HandlerFunc(h.DeleteDataTOTP))
+
+	// data ssh
+	router.Post("/api/data/ssh", http.HandlerFunc(h.CreateDataSSHKey))
+	router.Get("/api/data/ssh/{uuid}", http.HandlerFunc(h.GetDataSSHKey))
+	router.Put("/api/data/ssh/{uuid}", http.HandlerFunc(h.UpdateDataSSHKey))
+	router.Delete("/api/data/ssh/{uuid}", http.HandlerFunc(h.DeleteDataSSHKey))
+
+	// data custom
+	router.Post("/api/data/custom", http.HandlerFunc(h.CreateDataCustom))
+	router.Get("/api/data/custom/{uuid}", http.HandlerFunc(h.GetDataCustom))
+	router.Put("/api/data/custom/{uuid}", http.HandlerFunc(h.UpdateDataCustom))
+	router.Delete("/api/data/custom/{uuid}", http.HandlerFunc(h.DeleteDataCustom))
+
+	// record templates
+	router.Post("/api/templates", http.HandlerFunc(h.CreateTemplate))
+	router.Get("/api/templates", http.HandlerFunc(h.GetTemplates))
+	router.Delete("/api/templates/{uuid}", http.HandlerFunc(h.DeleteTemplate))
+
+	// history
+	router.Get("/api/data/{type}/{uuid}/history", http.HandlerFunc(h.GetHistory))
+	router.Post("/api/data/{type}/{uuid}/restore", http.HandlerFunc(h.RestoreRecord))
+
+	// sharing
+	router.Post("/api/data/{type}/{uuid}/shares", http.HandlerFunc(h.ShareRecord))
+	router.Get("/api/data/{type}/{uuid}/shares", http.HandlerFunc(h.GetShares))
+	router.Delete("/api/data/{type}/{uuid}/shares/{login}", http.HandlerFunc(h.RevokeShare))
+	router.Get("/api/shares", http.HandlerFunc(h.GetSharedRecords))
+
+	// organizations
+	router.Post("/api/orgs", http.HandlerFunc(h.CreateOrg))
+	router.Get("/api/orgs", http.HandlerFunc(h.GetOrgs))
+	router.Get("/api/orgs/{uuid}/members", http.HandlerFunc(h.GetMembers))
+	router.Post("/api/orgs/{uuid}/members", http.HandlerFunc(h.AddMember))
+	router.Delete("/api/orgs/{uuid}/members/{login}", http.HandlerFunc(h.RemoveMember))
+	router.Post("/api/orgs/{uuid}/vaults", http.HandlerFunc(h.CreateVault))
+	router.Get("/api/vaults", http.HandlerFunc(h.GetVaults))
+
+	// one-time links
+	router.Post("/api/links", http.HandlerFunc(h.CreateLink))
+	router.Get("/api/links/{uuid}", http.HandlerFunc(h.OpenLink))
+	router.Delete("/api/links/{uuid}", http.HandlerFunc(h.DeleteLink))
+
+	// trash
+	router.Get("/api/trash", http.HandlerFunc(h.GetTrash))
+	router.Post("/api/trash/{type}/{uuid}/restore", http.HandlerFunc(h.RestoreTrash))
+	router.Delete("/api/trash", http.HandlerFunc(h.EmptyTrash))
+
+	// sync
+	router.Get("/api/sync", http.HandlerFunc(h.GetChanges))
+
+	// conflicts
+	router.Get("/api/conflicts", http.HandlerFunc(h.GetConflicts))
+	router.Post("/api/conflicts/{uuid}/resolve", http.HandlerFunc(h.ResolveConflict))
+
+	// audit
+	router.Get("/api/audit", http.HandlerFunc(h.GetAudit))
+
+	// reports
+	router.Get("/api/reports/expiring", http.HandlerFunc(h.GetExpiring))
+
+	// events
+	router.Get("/api/events", http.HandlerFunc(h.GetEvents))
+
 	return router
 }
@@ -2,22 +2,43 @@ package server
 
 import (
 	"context"
+	"net"
 	"net/http"
+	"sync"
+	"time"
 )
 
+// abortWait ограничивает ожидание обработчиков, запросы которых отменены после истечения срока остановки.
+const abortWait = 5 * time.Second
+
 // Server представляет структуру HTTP-сервера.
 type Server struct {
 	httpServer *http.Server
+	cancel     context.CancelFunc // отменяет контексты всех запросов
+	inFlight   sync.WaitGroup     // обработчики, которые ещё выполняются
 }
 
 // NewServer создаёт сервер и возвращает аддрес объекта.
 func NewServer(handler http.Handler, addr string) *Server {
-	return &Server{
-		httpServer: &http.Server{
-			Handler: handler,
-			Addr:    addr,
+	ctx, cancel := context.WithCancel(context.Background())
+	s := &Server{cancel: cancel}
+	s.httpServer = &http.Server{
+		Handler: s.track(handler),
+		Addr:    addr,
+		BaseContext: func(net.Listener) context.Context {
+			return ctx
 		},
 	}
+	return s
+}
+
+// track учитывает выполняющиеся обработчики, чтобы Stop мог дождаться их после отмены.
+func (s *Server) track(handler http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.inFlight.Add(1)
+		defer s.inFlight.Done()
+		handler.ServeHTTP(w, r)
+	})
 }
 
 // Start запускает HTTP-сервер. Сервер начинает слушать входящие запросы.
@@ -26,7 +47,33 @@ func (s *Server) Start() error {
 }
 
 // Stop останавливает HTTP-сервер с возможностью плавного завершения работы.
-// ctx - контекст, который может использоваться для задания тайм-аута на завершение работы сервера.
+// Сервер перестаёт принимать соединения и ждёт начатые запросы, пока не истечёт ctx.
+// После этого контексты оставшихся запросов отменяются, поэтому их транзакции откатываются,
+// соединения закрываются, и Stop ещё до abortWait ждёт выхода обработчиков.
+// Возвращает ошибку ctx, если запросы не успели завершиться сами.
 func (s *Server) Stop(ctx context.Context) error {
-	return s.httpServer.Shutdown(ctx)
+	err := s.httpServer.Shutdown(ctx)
+	if err == nil {
+		return nil
+	}
+
+	s.cancel()
+	s.httpServer.Close()
+
+	done := make(chan struct{})
+	go func() {
+		s.inFlight.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(abortWait):
+	}
+	return err
+}
+
+// RegisterOnShutdown регистрирует функцию, вызываемую в начале остановки сервера.
+// Используется для закрытия долгих соединений, которые Shutdown не прерывает сам.
+func (s *Server) RegisterOnShutdown(f func()) {
+	s.httpServer.RegisterOnShutdown(f)
 }
@@ -0,0 +1,67 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/google/uuid"
+	"server/internal/model"
+	"strconv"
+)
+
+const (
+	defaultAuditLimit = 100  // сколько записей журнала возвращается, если limit не указан
+	maxAuditLimit     = 1000 // наибольшее число записей журнала в одном ответе
+)
+
+// Audit добавляет действие пользователя в журнал аудита. Неудачные попытки
+// подсчитываются, их число возвращает AuditFailures.
+func (gk *GophKeeper) Audit(ctx context.Context, entry model.AuditEntry) error {
+	ctx, span := startSpan(ctx, "Audit")
+	defer span.End()
+
+	err := gk.str.InsertAudit(ctx, entry)
+	if err != nil {
+		gk.auditFailures.Add(1)
+	}
+	return err
+}
+
+// AuditFailures возвращает число действий, которые не удалось записать в журнал аудита
+// с момента запуска сервера.
+func (gk *GophKeeper) AuditFailures() int64 {
+	return gk.auditFailures.Load()
+}
+
+// SelectAudit возвращает последние записи журнала аудита владельца.
+func (gk *GophKeeper) SelectAudit(ctx context.Context, limit string, ownerKey uuid.UUID) ([]byte, error) {
+	ctx, span := startSpan(ctx, "SelectAudit")
+	defer span.End()
+
+	if err := gk.authorizeOwner(ctx, ownerKey, model.RoleViewer); err != nil {
+		return nil, err
+	}
+
+	count := defaultAuditLimit
+	if limit != "" {
+		var err error
+		count, err = strconv.Atoi(limit)
+		if err != nil {
+			return nil, err
+		}
+		if count < 1 || count > maxAuditLimit {
+			return nil, fmt.Errorf("limit must be between 1 and %d", maxAuditLimit)
+		}
+	}
+
+	result, err := gk.str.SelectAudit(ctx, ownerKey, count)
+	if err != nil {
+		return nil, err
+	}
+
+	resultBytes, err := json.Marshal(result)
+	if err != nil {
+		return nil, err
+	}
+	return resultBytes, nil
+}
@@ -0,0 +1,44 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"github.com/stretchr/testify/require"
+	"server/internal/model"
+	"testing"
+)
+
+// auditStorage отвечает на запись журнала ошибкой err; остальные методы хранилища не используются.
+type auditStorage struct {
+	Storage
+	err error
+}
+
+func (s auditStorage) InsertAudit(context.Context, model.AuditEntry) error {
+	return s.err
+}
+
+func TestAuditFailures(t *testing.T) {
+	failing := errors.New("database is unavailable")
+
+	tests := []struct {
+		name string
+		errs []error
+		want int64
+	}{
+		{name: "no failures", errs: []error{nil, nil}, want: 0},
+		{name: "every failure counted", errs: []error{failing, nil, failing}, want: 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			storage := &auditStorage{}
+			gk := NewGophKeeper(storage)
+			for _, err := range tt.errs {
+				storage.err = err
+				require.ErrorIs(t, gk.Audit(context.Background(), model.AuditEntry{}), err)
+			}
+			require.Equal(t, tt.want, gk.AuditFailures())
+		})
+	}
+}
@@ -7,13 +7,14 @@ import (
 	"context"
 	"github.com/google/uuid"
 	"sync"
-	"sync/atomic"
+	"time"
 )
 
 // Authorization хранит информацию о пользователях системы и их текущем состоянии авторизации.
 type Authorization struct {
-	Users      sync.Map // ключ — login, значение — UserInfo
-	CountUsers int64    // количество пользователей
+	Users    sync.Map // ключ — login, значение — UserInfo
+	sessions sync.Map // сессии, выданные этим сервером: ключ — идентификатор сессии, значение — время истечения её токена
+	revoked  sync.Map // кэш отзывов из базы: ключ — идентификатор сессии, значение — время истечения её токена
 }
 
 // UserInfo представляет данные о пользователе.
@@ -24,9 +25,7 @@ type UserInfo struct {
 
 // NewAuthorization создает новый объект Authorization и возвращает указатель на него.
 func NewAuthorization() *Authorization {
-	return &Authorization{
-		CountUsers: 0,
-	}
+	return &Authorization{}
 }
 
 // NewUserToken создает новый JWT-токен для нового пользователя.
@@ -36,14 +35,59 @@ func (ath *Authorization) NewUserToken(login, userKey, encrKey string) (string,
 		EncryptionKey: encrKey,
 	})
 
-	atomic.AddInt64(&ath.CountUsers, 1)
-	token, err := NewToken(userKey, encrKey)
+	sessionKey := uuid.NewString()
+	token, err := NewToken(userKey, sessionKey, encrKey)
 	if err != nil {
 		return "", err
 	}
+	ath.sessions.Store(sessionKey, time.Now().Add(tokenEXP))
 	return token, err
 }
 
+// ActiveSessions возвращает число сессий, выданных этим сервером, токены которых ещё не истекли
+// и которые не завершены выходом. Истёкшие сессии при этом забываются.
+func (ath *Authorization) ActiveSessions() int {
+	now := time.Now()
+	count := 0
+	ath.sessions.Range(func(key, value any) bool {
+		if value.(time.Time).Before(now) {
+			ath.sessions.Delete(key)
+		} else {
+			count++
+		}
+		return true
+	})
+	return count
+}
+
+// RevokeSession запоминает отзыв сессии, завершённой на этом сервере, до expiresAt.
+// Сам отзыв хранится в базе данных, см. GophKeeper.LogoutUser.
+func (ath *Authorization) RevokeSession(sessionKey string, expiresAt time.Time) {
+	ath.markRevoked(sessionKey, expiresAt)
+}
+
+// markRevoked запоминает отзыв сессии до expiresAt, удаляя из кэша истёкшие отзывы.
+// Отозванная сессия больше не считается активной.
+func (ath *Authorization) markRevoked(sessionKey string, expiresAt time.Time) {
+	now := time.Now()
+	ath.revoked.Range(func(key, value any) bool {
+		if value.(time.Time).Before(now) {
+			ath.revoked.Delete(key)
+		}
+		return true
+	})
+
+	ath.sessions.Delete(sessionKey)
+	ath.revoked.Store(sessionKey, expiresAt)
+}
+
+// IsRevoked сообщает, известен ли этому серверу отзыв сессии. Отзывы, сделанные на других
+// серверах или до перезапуска, проверяет GophKeeper.IsSessionRevoked.
+func (ath *Authorization) IsRevoked(sessionKey string) bool {
+	_, ok := ath.revoked.Load(sessionKey)
+	return ok
+}
+
 // SetCurrentUserID в контексте запроса сохраняет текущего авторизованного пользователя.
 func SetCurrentUserID(ctx context.Context, userKey uuid.UUID) context.Context {
 	return context.WithValue(ctx, "currentUserKey", userKey)
@@ -54,3 +98,26 @@ func GetCurrentUserID(ctx context.Context) (uuid.UUID, bool) {
 	userID, ok := ctx.Value("currentUserKey").(uuid.UUID)
 	return userID, ok
 }
+
+// SetCurrentActor в контексте запроса сохраняет пользователя, выполняющего запрос.
+// В отличие от SetCurrentUserID, он не заменяется хранилищем организации.
+func SetCurrentActor(ctx context.Context, userKey uuid.UUID) context.Context {
+	return context.WithValue(ctx, "currentActorKey", userKey)
+}
+
+// GetCurrentActor извлекает пользователя, выполняющего запрос, из контекста запроса.
+func GetCurrentActor(ctx context.Context) (uuid.UUID, bool) {
+	actor, ok := ctx.Value("currentActorKey").(uuid.UUID)
+	return actor, ok
+}
+
+// SetCurrentSession в контексте запроса сохраняет идентификатор сессии текущего пользователя.
+func SetCurrentSession(ctx context.Context, sessionKey string) context.Context {
+	return context.WithValue(ctx, "currentSessionKey", sessionKey)
+}
+
+// GetCurrentSession извлекает идентификатор сессии текущего пользователя из контекста запроса.
+func GetCurrentSession(ctx context.Context) (string, bool) {
+	sessionKey, ok := ctx.Value("currentSessionKey").(string)
+	return sessionKey, ok
+}
@@ -0,0 +1,34 @@
+package service
+
+import (
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+func TestActiveSessions(t *testing.T) {
+	SetSigningKeys([]string{"test-key-0123456789abcdef0123456789"})
+
+	ath := NewAuthorization()
+	var sessions []string
+	for i := 0; i < 3; i++ {
+		cookie, err := ath.NewUserToken("user", "user-key", "encryption-key")
+		require.NoError(t, err)
+		token, err := ReadToken(cookie)
+		require.NoError(t, err)
+		sessions = append(sessions, token.ID)
+	}
+	require.Equal(t, 3, ath.ActiveSessions())
+
+	// Выход на этом сервере и отзыв, найденный в базе, завершают сессию.
+	ath.RevokeSession(sessions[0], time.Now().Add(tokenEXP))
+	ath.markRevoked(sessions[1], time.Now().Add(tokenEXP))
+	require.Equal(t, 1, ath.ActiveSessions())
+
+	// Сессия с истёкшим токеном забывается, а повторный вход добавляет новую.
+	ath.sessions.Store(sessions[2], time.Now().Add(-time.Second))
+	require.Equal(t, 0, ath.ActiveSessions())
+	_, err := ath.NewUserToken("user", "user-key", "encryption-key")
+	require.NoError(t, err)
+	require.Equal(t, 1, ath.ActiveSessions())
+}
@@ -0,0 +1,200 @@
+package service
+
+import (
+	"errors"
+	"server/internal/cerrors"
+	"server/internal/model"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// maxCardYears ограничивает срок действия карты в будущем; более поздний срок считается ошибкой ввода.
+const maxCardYears = 20
+
+// maxCardholderName — наибольшая длина имени держателя карты.
+const maxCardholderName = 100
+
+// maxIssuingBank — наибольшая длина названия банка-эмитента.
+const maxIssuingBank = 100
+
+// minPIN и maxPIN ограничивают длину PIN-кода по ISO 9564.
+const (
+	minPIN = 4
+	maxPIN = 12
+)
+
+// Ошибки разбора срока действия карты.
+var (
+	errRequired     = errors.New("required")
+	errExpiryFormat = errors.New("must be in MM/YY format")
+	errExpiryMonth  = errors.New("month must be between 01 and 12")
+)
+
+// cardBrand описывает платёжную систему: диапазоны IIN и допустимые длины номера.
+type cardBrand struct {
+	name    string
+	ranges  [][2]int // диапазоны префиксов включительно; длина префикса задаётся числом цифр
+	lengths []int
+	cvv     int // длина CVV
+}
+
+// cardBrands проверяются по порядку: более узкие диапазоны стоят раньше широких.
+var cardBrands = []cardBrand{
+	{name: model.BrandMir, ranges: [][2]int{{2200, 2204}}, lengths: []int{16, 17, 18, 19}, cvv: 3},
+	{name: model.BrandAmex, ranges: [][2]int{{34, 34}, {37, 37}}, lengths: []int{15}, cvv: 4},
+	{name: model.BrandVisa, ranges: [][2]int{{4, 4}}, lengths: []int{13, 16, 19}, cvv: 3},
+	{name: model.BrandMastercard, ranges: [][2]int{{51, 55}, {2221, 2720}}, lengths: []int{16}, cvv: 3},
+	{name: model.BrandDiners, ranges: [][2]int{{300, 305}, {36, 36}, {38, 39}}, lengths: []int{14, 15, 16, 17, 18, 19}, cvv: 3},
+	{name: model.BrandDiscover, ranges: [][2]int{{6011, 6011}, {644, 649}, {65, 65}}, lengths: []int{16, 17, 18, 19}, cvv: 3},
+	{name: model.BrandJCB, ranges: [][2]int{{3528, 3589}}, lengths: []int{16, 17, 18, 19}, cvv: 3},
+	{name: model.BrandUnionPay, ranges: [][2]int{{62, 62}}, lengths: []int{16, 17, 18, 19}, cvv: 3},
+	{name: model.BrandMaestro, ranges: [][2]int{{50, 50}, {56, 58}, {6, 6}}, lengths: []int{12, 13, 14, 15, 16, 17, 18, 19}, cvv: 3},
+}
+
+// validateCard проверяет карту и приводит её к каноническому виду: номер без пробелов и дефисов,
+// срок в формате MM/YY. Заполняет платёжную систему и последний день срока действия.
+// Все ошибки полей возвращаются вместе в cerrors.ValidationError.
+func validateCard(data *model.DataCreditCard, now time.Time) error {
+	errs := &cerrors.ValidationError{}
+
+	number := strings.NewReplacer(" ", "", "-", "").Replace(data.CardNumber)
+	var brand *cardBrand
+	switch {
+	case number == "":
+		errs.Add("card_number", "required")
+	case !isDigits(number):
+		errs.Add("card_number", "must contain only digits, spaces and dashes")
+	case len(number) < 12 || len(number) > 19:
+		errs.Add("card_number", "must be 12 to 19 digits long")
+	case !luhnValid(number):
+		errs.Add("card_number", "checksum is invalid")
+	default:
+		brand = detectBrand(number)
+		if brand != nil && !containsInt(brand.lengths, len(number)) {
+			errs.Add("card_number", "invalid length for "+brand.name)
+		}
+	}
+	data.CardNumber = number
+	data.Brand = ""
+	if brand != nil {
+		data.Brand = brand.name
+	}
+
+	data.ExpiresOn = ""
+	if expiry, err := parseExpiry(data.ExpirationDate); err != nil {
+		errs.Add("expiration_date", err.Error())
+	} else if expiry.After(now.AddDate(maxCardYears, 0, 0)) {
+		errs.Add("expiration_date", "is too far in the future")
+	} else {
+		data.ExpirationDate = expiry.Format("01/06")
+		data.ExpiresOn = expiry.Format(time.DateOnly)
+	}
+
+	if data.CVV != "" {
+		length := 3
+		if brand != nil {
+			length = brand.cvv
+		}
+		if !isDigits(data.CVV) || len(data.CVV) != length {
+			errs.Add("cvv", "must be "+strconv.Itoa(length)+" digits")
+		}
+	}
+
+	if data.PIN != "" && (!isDigits(data.PIN) || len(data.PIN) < minPIN || len(data.PIN) > maxPIN) {
+		errs.Add("pin", "must be "+strconv.Itoa(minPIN)+" to "+strconv.Itoa(maxPIN)+" digits")
+	}
+
+	data.CardType = strings.ToLower(strings.TrimSpace(data.CardType))
+	if data.CardType != "" && data.CardType != model.CardTypeDebit && data.CardType != model.CardTypeCredit {
+		errs.Add("card_type", "must be "+model.CardTypeDebit+" or "+model.CardTypeCredit)
+	}
+
+	data.CardholderName = strings.TrimSpace(data.CardholderName)
+	if len([]rune(data.CardholderName)) > maxCardholderName {
+		errs.Add("cardholder_name", "must be at most "+strconv.Itoa(maxCardholderName)+" characters")
+	}
+
+	data.IssuingBank = strings.TrimSpace(data.IssuingBank)
+	if len([]rune(data.IssuingBank)) > maxIssuingBank {
+		errs.Add("issuing_bank", "must be at most "+strconv.Itoa(maxIssuingBank)+" characters")
+	}
+
+	return errs.OrNil()
+}
+
+// parseExpiry разбирает срок MM/YY или MM/YYYY и возвращает последний день месяца.
+func parseExpiry(value string) (time.Time, error) {
+	month, year, ok := strings.Cut(strings.TrimSpace(value), "/")
+	if value == "" {
+		return time.Time{}, errRequired
+	}
+	if !ok || len(month) != 2 || (len(year) != 2 && len(year) != 4) || !isDigits(month) || !isDigits(year) {
+		return time.Time{}, errExpiryFormat
+	}
+
+	m, _ := strconv.Atoi(month)
+	y, _ := strconv.Atoi(year)
+	if m < 1 || m > 12 {
+		return time.Time{}, errExpiryMonth
+	}
+	if len(year) == 2 {
+		y += 2000
+	}
+
+	// Нулевой день следующего месяца — последний день месяца m.
+	return time.Date(y, time.Month(m)+1, 0, 0, 0, 0, 0, time.UTC), nil
+}
+
+// detectBrand определяет платёжную систему по первым цифрам номера или возвращает nil.
+func detectBrand(number string) *cardBrand {
+	for i := range cardBrands {
+		for _, r := range cardBrands[i].ranges {
+			digits := len(strconv.Itoa(r[0]))
+			prefix, err := strconv.Atoi(number[:digits])
+			if err == nil && prefix >= r[0] && prefix <= r[1] {
+				return &cardBrands[i]
+			}
+		}
+	}
+	return nil
+}
+
+// luhnValid проверяет контрольную цифру номера по алгоритму Луна.
+func luhnValid(number string) bool {
+	sum := 0
+	double := false
+	for i := len(number) - 1; i >= 0; i-- {
+		digit := int(number[i] - '0')
+		if double {
+			digit *= 2
+			if digit > 9 {
+				digit -= 9
+			}
+		}
+		sum += digit
+		double = !double
+	}
+	return sum%10 == 0
+}
+
+func isDigits(value string) bool {
+	if value == "" {
+		return false
+	}
+	for _, c := range value {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+func containsInt(values []int, value int) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
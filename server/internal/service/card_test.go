@@ -0,0 +1,113 @@
+package service
+
+import (
+	"github.com/stretchr/testify/require"
+	"server/internal/model"
+	"testing"
+	"time"
+)
+
+func TestLuhnValid(t *testing.T) {
+	tests := []struct {
+		name   string
+		number string
+		want   bool
+	}{
+		{name: "visa test number", number: "4111111111111111", want: true},
+		{name: "mastercard test number", number: "5555555555554444", want: true},
+		{name: "amex test number", number: "378282246310005", want: true},
+		{name: "mir test number", number: "2200000000000004", want: true},
+		{name: "wrong check digit", number: "4111111111111112", want: false},
+		{name: "doubled digit above nine", number: "18", want: true},
+		{name: "doubled nine", number: "91", want: true},
+		{name: "all zeros", number: "0000000000000000", want: true},
+		{name: "single digit", number: "0", want: true},
+		{name: "transposed digits", number: "4111111111111161", want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.want, luhnValid(tt.number))
+		})
+	}
+}
+
+func TestDetectBrand(t *testing.T) {
+	tests := []struct {
+		name   string
+		number string
+		want   string // пустая строка — платёжная система не определена
+	}{
+		{name: "visa", number: "4111111111111111", want: model.BrandVisa},
+		{name: "mastercard 51", number: "5105105105105100", want: model.BrandMastercard},
+		{name: "mastercard 55", number: "5555555555554444", want: model.BrandMastercard},
+		{name: "mastercard 2-series lower bound", number: "2221000000000009", want: model.BrandMastercard},
+		{name: "mastercard 2-series upper bound", number: "2720990000000000", want: model.BrandMastercard},
+		{name: "above mastercard 2-series", number: "2721000000000000", want: ""},
+		{name: "mir lower bound", number: "2200000000000004", want: model.BrandMir},
+		{name: "mir upper bound", number: "2204000000000000", want: model.BrandMir},
+		{name: "between mir and mastercard", number: "2205000000000000", want: ""},
+		{name: "amex 34", number: "340000000000009", want: model.BrandAmex},
+		{name: "amex 37", number: "378282246310005", want: model.BrandAmex},
+		{name: "diners 300", number: "30000000000004", want: model.BrandDiners},
+		{name: "diners 305", number: "30500000000003", want: model.BrandDiners},
+		{name: "not diners 306", number: "30600000000000", want: ""},
+		{name: "diners 36", number: "36227206271667", want: model.BrandDiners},
+		{name: "discover 6011 before maestro", number: "6011111111111117", want: model.BrandDiscover},
+		{name: "discover 644", number: "6445644564456445", want: model.BrandDiscover},
+		{name: "discover 65", number: "6500000000000002", want: model.BrandDiscover},
+		{name: "jcb", number: "3530111333300000", want: model.BrandJCB},
+		{name: "not jcb 3527", number: "3527000000000000", want: ""},
+		{name: "unionpay before maestro", number: "6200000000000005", want: model.BrandUnionPay},
+		{name: "maestro 50", number: "5018000000000009", want: model.BrandMaestro},
+		{name: "maestro 6", number: "6759649826438453", want: model.BrandMaestro},
+		{name: "unknown prefix", number: "9999999999999995", want: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			brand := detectBrand(tt.number)
+			if tt.want == "" {
+				require.Nil(t, brand)
+				return
+			}
+			require.NotNil(t, brand)
+			require.Equal(t, tt.want, brand.name)
+		})
+	}
+}
+
+func TestParseExpiry(t *testing.T) {
+	tests := []struct {
+		name    string
+		value   string
+		want    string
+		wantErr error
+	}{
+		{name: "two-digit year", value: "12/24", want: "2024-12-31"},
+		{name: "four-digit year", value: "01/2030", want: "2030-01-31"},
+		{name: "leap february", value: "02/28", want: "2028-02-29"},
+		{name: "common february", value: "02/27", want: "2027-02-28"},
+		{name: "surrounding spaces", value: " 04/26 ", want: "2026-04-30"},
+		{name: "empty", value: "", wantErr: errRequired},
+		{name: "month zero", value: "00/26", wantErr: errExpiryMonth},
+		{name: "month thirteen", value: "13/27", wantErr: errExpiryMonth},
+		{name: "no separator", value: "1226", wantErr: errExpiryFormat},
+		{name: "single-digit month", value: "1/26", wantErr: errExpiryFormat},
+		{name: "three-digit year", value: "12/026", wantErr: errExpiryFormat},
+		{name: "letters", value: "ab/cd", wantErr: errExpiryFormat},
+		{name: "dash separator", value: "12-26", wantErr: errExpiryFormat},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			expiry, err := parseExpiry(tt.value)
+			if tt.wantErr != nil {
+				require.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.want, expiry.Format(time.DateOnly))
+		})
+	}
+}
@@ -0,0 +1,176 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/google/uuid"
+	"server/internal/cerrors"
+	"server/internal/model"
+	"time"
+)
+
+// SelectConflicts возвращает неразрешённые конфликты пользователя вместе с текущими версиями записей.
+func (gk *GophKeeper) SelectConflicts(ctx context.Context, privateUserKey uuid.UUID) ([]byte, error) {
+	ctx, span := startSpan(ctx, "SelectConflicts")
+	defer span.End()
+
+	if err := gk.authorizeOwner(ctx, privateUserKey, model.RoleViewer); err != nil {
+		return nil, err
+	}
+
+	conflicts, err := gk.str.SelectConflicts(ctx, privateUserKey)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range conflicts {
+		current, err := gk.currentRecord(ctx, conflicts[i].Type, conflicts[i].RecordKey, privateUserKey)
+		if err != nil && !errors.Is(err, cerrors.ErrNotFound) {
+			return nil, err
+		}
+		conflicts[i].Current = current
+	}
+
+	resultBytes, err := json.Marshal(conflicts)
+	if err != nil {
+		return nil, err
+	}
+	return resultBytes, nil
+}
+
+// ResolveConflict применяет выбор пользователя для конфликта с ключом key
+// и возвращает запись, к которой относился конфликт.
+func (gk *GophKeeper) ResolveConflict(ctx context.Context, key string, body []byte, privateUserKey uuid.UUID) ([]byte, model.ConflictResolutionResponse, error) {
+	ctx, span := startSpan(ctx, "ResolveConflict")
+	defer span.End()
+
+	if err := gk.authorizeOwner(ctx, privateUserKey, model.RoleEditor); err != nil {
+		return nil, model.ConflictResolutionResponse{}, err
+	}
+
+	var resolution model.ConflictResolution
+	err := json.Unmarshal(body, &resolution)
+	if err != nil {
+		return nil, model.ConflictResolutionResponse{}, err
+	}
+
+	resolution.PrivateUserKey = privateUserKey
+	resolution.DataConflictKey, err = uuid.Parse(key)
+	if err != nil {
+		return nil, model.ConflictResolutionResponse{}, err
+	}
+	if resolution.Choice == model.ResolveMerged {
+		if len(resolution.Data) == 0 {
+			return nil, model.ConflictResolutionResponse{}, fmt.Errorf("merged resolution requires data")
+		}
+		conflict, err := gk.str.SelectConflict(ctx, resolution.DataConflictKey, privateUserKey)
+		if err != nil {
+			return nil, model.ConflictResolutionResponse{}, err
+		}
+		resolution.Data, err = gk.validateMerged(ctx, conflict, resolution.Data, privateUserKey)
+		if err != nil {
+			return nil, model.ConflictResolutionResponse{}, err
+		}
+	}
+
+	result, err := gk.str.ResolveConflict(ctx, resolution)
+	if err != nil {
+		return nil, model.ConflictResolutionResponse{}, err
+	}
+	if resolution.Choice != model.ResolveCurrent {
+		gk.publishChange(privateUserKey, result.Type, result.RecordKey, model.ChangeUpdated, result.Revision)
+	}
+
+	resultBytes, err := json.Marshal(result)
+	if err != nil {
+		return nil, model.ConflictResolutionResponse{}, err
+	}
+	return resultBytes, result, nil
+}
+
+// validateMerged проверяет объединённую версию записи так же, как при её изменении,
+// и возвращает её в каноническом виде. Пользовательская запись проверяется по шаблону,
+// с которым она была создана.
+func (gk *GophKeeper) validateMerged(ctx context.Context, conflict model.Conflict, payload json.RawMessage, privateUserKey uuid.UUID) (json.RawMessage, error) {
+	var (
+		data any
+		err  error
+	)
+	switch conflict.Type {
+	case model.RecordCard:
+		var card model.DataCreditCard
+		if err := json.Unmarshal(payload, &card); err != nil {
+			return nil, err
+		}
+		data, err = &card, validateCard(&card, time.Now())
+	case model.RecordTOTP:
+		var totp model.DataTOTP
+		if err := json.Unmarshal(payload, &totp); err != nil {
+			return nil, err
+		}
+		data, err = &totp, validateTOTP(&totp)
+	case model.RecordSSH:
+		var key model.DataSSHKey
+		if err := json.Unmarshal(payload, &key); err != nil {
+			return nil, err
+		}
+		data, err = &key, validateSSHKey(&key)
+	case model.RecordCustom:
+		var custom model.DataCustom
+		if err := json.Unmarshal(payload, &custom); err != nil {
+			return nil, err
+		}
+		current, err := gk.str.SelectDataCustom(ctx, model.DataCustom{DataCustomKey: conflict.RecordKey, PrivateUserKey: privateUserKey})
+		if err != nil {
+			return nil, err
+		}
+		custom.TemplateKey = current.TemplateKey
+
+		template, err := gk.customTemplate(ctx, custom.TemplateKey, privateUserKey)
+		if err != nil {
+			return nil, err
+		}
+		if err := gk.validateCustom(ctx, &custom, template, privateUserKey); err != nil {
+			return nil, err
+		}
+		data = &custom
+	default:
+		return payload, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return json.Marshal(data)
+}
+
+// currentRecord возвращает текущую версию записи в формате ответа API.
+func (gk *GophKeeper) currentRecord(ctx context.Context, recordType string, key, privateUserKey uuid.UUID) (json.RawMessage, error) {
+	var (
+		result any
+		err    error
+	)
+	switch recordType {
+	case model.RecordText:
+		result, err = gk.str.SelectDataText(ctx, model.DataText{DataTextKey: key, PrivateUserKey: privateUserKey})
+	case model.RecordBinary:
+		result, err = gk.str.SelectDataBinary(ctx, model.DataBinary{DataBinaryKey: key, PrivateUserKey: privateUserKey})
+	case model.RecordCard:
+		result, err = gk.str.SelectDataCard(ctx, model.DataCreditCard{DataCreditCardKey: key, PrivateUserKey: privateUserKey})
+	case model.RecordTOTP:
+		result, err = gk.str.SelectDataTOTP(ctx, model.DataTOTP{DataTOTPKey: key, PrivateUserKey: privateUserKey})
+	case model.RecordSSH:
+		result, err = gk.str.SelectDataSSHKey(ctx, model.DataSSHKey{DataSSHKeyKey: key, PrivateUserKey: privateUserKey})
+	case model.RecordCustom:
+		result, err = gk.str.SelectDataCustom(ctx, model.DataCustom{DataCustomKey: key, PrivateUserKey: privateUserKey})
+	default:
+		return nil, fmt.Errorf("unknown record type: %q", recordType)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return json.Marshal(result)
+}
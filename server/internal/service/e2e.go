@@ -0,0 +1,62 @@
+package service
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"github.com/google/uuid"
+	"server/internal/cerrors"
+	"server/internal/model"
+	"strings"
+)
+
+// minE2ESalt — наименьшая длина соли сквозного шифрования в байтах.
+const minE2ESalt = 16
+
+// maxE2EParam ограничивает длину соли и контрольного значения.
+const maxE2EParam = 256
+
+// SelectE2EParams возвращает параметры сквозного шифрования пользователя.
+func (gk *GophKeeper) SelectE2EParams(ctx context.Context, privateUserKey uuid.UUID) ([]byte, error) {
+	ctx, span := startSpan(ctx, "SelectE2EParams")
+	defer span.End()
+
+	result, err := gk.str.SelectE2EParams(ctx, privateUserKey)
+	if err != nil {
+		return nil, err
+	}
+
+	resultBytes, err := json.Marshal(result)
+	if err != nil {
+		return nil, err
+	}
+	return resultBytes, nil
+}
+
+// InsertE2EParams сохраняет параметры сквозного шифрования пользователя. Параметры задаются
+// один раз: соль должна быть случайной строкой base64 не короче minE2ESalt байт,
+// а контрольное значение — шифротекстом клиента.
+func (gk *GophKeeper) InsertE2EParams(ctx context.Context, body []byte, privateUserKey uuid.UUID) error {
+	ctx, span := startSpan(ctx, "InsertE2EParams")
+	defer span.End()
+
+	var params model.E2EParams
+	err := json.Unmarshal(body, &params)
+	if err != nil {
+		return err
+	}
+
+	errs := &cerrors.ValidationError{}
+	salt, err := base64.StdEncoding.DecodeString(params.Salt)
+	if err != nil || len(salt) < minE2ESalt || len(params.Salt) > maxE2EParam {
+		errs.Add("salt", "must be base64 of at least 16 random bytes")
+	}
+	if !strings.HasPrefix(params.Check, e2ePrefix) || len(params.Check) > maxE2EParam {
+		errs.Add("check", "must be a client-side ciphertext")
+	}
+	if err := errs.OrNil(); err != nil {
+		return err
+	}
+
+	return gk.str.InsertE2EParams(ctx, privateUserKey, params)
+}
@@ -0,0 +1,96 @@
+package service
+
+import (
+	"context"
+	"github.com/google/uuid"
+	"server/internal/model"
+	"sync"
+)
+
+// eventBuffer — размер очереди событий одного подписчика.
+// Если клиент не успевает читать поток, лишние события отбрасываются:
+// получив любое record-changed, клиент всё равно запрашивает всю ленту изменений.
+const eventBuffer = 16
+
+// Events рассылает события пользователя всем его открытым потокам /api/events.
+type Events struct {
+	mu          sync.Mutex
+	subscribers map[uuid.UUID]map[chan model.Event]struct{}
+	closed      bool
+}
+
+// NewEvents создает новый объект Events и возвращает указатель на него.
+func NewEvents() *Events {
+	return &Events{
+		subscribers: make(map[uuid.UUID]map[chan model.Event]struct{}),
+	}
+}
+
+// Subscribe открывает поток событий пользователя.
+// Возвращает канал событий и функцию отписки; канал закрывается при отписке или остановке сервера.
+func (e *Events) Subscribe(privateUserKey uuid.UUID) (<-chan model.Event, func()) {
+	ch := make(chan model.Event, eventBuffer)
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.closed {
+		close(ch)
+		return ch, func() {}
+	}
+	if e.subscribers[privateUserKey] == nil {
+		e.subscribers[privateUserKey] = make(map[chan model.Event]struct{})
+	}
+	e.subscribers[privateUserKey][ch] = struct{}{}
+
+	return ch, func() {
+		e.mu.Lock()
+		defer e.mu.Unlock()
+		if _, ok := e.subscribers[privateUserKey][ch]; !ok {
+			return
+		}
+		delete(e.subscribers[privateUserKey], ch)
+		if len(e.subscribers[privateUserKey]) == 0 {
+			delete(e.subscribers, privateUserKey)
+		}
+		close(ch)
+	}
+}
+
+// Publish отправляет событие во все потоки пользователя, не блокируясь на медленных подписчиках.
+func (e *Events) Publish(privateUserKey uuid.UUID, event model.Event) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	for ch := range e.subscribers[privateUserKey] {
+		select {
+		case ch <- event:
+		default:
+		}
+	}
+}
+
+// Close закрывает все потоки. Вызывается при остановке сервера, чтобы долгие соединения
+// не задерживали плавное завершение работы.
+func (e *Events) Close() {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.closed = true
+	for _, channels := range e.subscribers {
+		for ch := range channels {
+			close(ch)
+		}
+	}
+	e.subscribers = make(map[uuid.UUID]map[chan model.Event]struct{})
+}
+
+// SubscribeEvents открывает поток событий владельца ownerKey, проверив, что текущий пользователь может читать его записи.
+func (gk *GophKeeper) SubscribeEvents(ctx context.Context, ownerKey uuid.UUID) (<-chan model.Event, func(), error) {
+	ctx, span := startSpan(ctx, "SubscribeEvents")
+	defer span.End()
+
+	if err := gk.authorizeOwner(ctx, ownerKey, model.RoleViewer); err != nil {
+		return nil, nil, err
+	}
+
+	events, unsubscribe := gk.events.Subscribe(ownerKey)
+	return events, unsubscribe, nil
+}
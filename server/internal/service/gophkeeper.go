@@ -1,37 +1,118 @@
 package service
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"github.com/google/uuid"
 	"server/internal/model"
+	"strconv"
+	"sync/atomic"
+	"time"
 )
 
 type Storage interface {
-	SelectUser(user model.User) (model.UserResponse, error)
-	InsertUser(user model.User) (model.UserResponse, error)
+	SelectUser(ctx context.Context, user model.User) (model.UserResponse, error)
+	InsertUser(ctx context.Context, user model.User) (model.UserResponse, error)
 
-	InsertDataText(data model.DataText) (model.DataTextResponse, error)
-	SelectDataText(data model.DataText) (model.DataTextResponse, error)
-	DeleteDataText(data model.DataText) error
+	InsertDataText(ctx context.Context, data model.DataText) (model.DataTextResponse, error)
+	SelectDataText(ctx context.Context, data model.DataText) (model.DataTextResponse, error)
+	UpdateDataText(ctx context.Context, data model.DataText) (model.DataTextResponse, error)
+	DeleteDataText(ctx context.Context, data model.DataText) error
 
-	InsertDataBinary(data model.DataBinary) (model.DataBinaryResponse, error)
-	SelectDataBinary(data model.DataBinary) (model.DataBinaryResponse, error)
-	DeleteDataBinary(data model.DataBinary) error
+	InsertDataBinary(ctx context.Context, data model.DataBinary) (model.DataBinaryResponse, error)
+	SelectDataBinary(ctx context.Context, data model.DataBinary) (model.DataBinaryResponse, error)
+	UpdateDataBinary(ctx context.Context, data model.DataBinary) (model.DataBinaryResponse, error)
+	DeleteDataBinary(ctx context.Context, data model.DataBinary) error
 
-	InsertDataCard(model.DataCreditCard) (model.DataCreditCardResponse, error)
-	SelectDataCard(model.DataCreditCard) (model.DataCreditCardResponse, error)
-	DeleteDataCard(model.DataCreditCard) error
+	InsertDataCard(ctx context.Context, data model.DataCreditCard) (model.DataCreditCardResponse, error)
+	SelectDataCard(ctx context.Context, data model.DataCreditCard) (model.DataCreditCardResponse, error)
+	UpdateDataCard(ctx context.Context, data model.DataCreditCard) (model.DataCreditCardResponse, error)
+	DeleteDataCard(ctx context.Context, data model.DataCreditCard) error
+
+	InsertDataTOTP(ctx context.Context, data model.DataTOTP) (model.DataTOTPResponse, error)
+	SelectDataTOTP(ctx context.Context, data model.DataTOTP) (model.DataTOTPResponse, error)
+	UpdateDataTOTP(ctx context.Context, data model.DataTOTP) (model.DataTOTPResponse, error)
+	DeleteDataTOTP(ctx context.Context, data model.DataTOTP) error
+
+	InsertDataSSHKey(ctx context.Context, data model.DataSSHKey) (model.DataSSHKeyResponse, error)
+	SelectDataSSHKey(ctx context.Context, data model.DataSSHKey) (model.DataSSHKeyResponse, error)
+	UpdateDataSSHKey(ctx context.Context, data model.DataSSHKey) (model.DataSSHKeyResponse, error)
+	DeleteDataSSHKey(ctx context.Context, data model.DataSSHKey) error
+
+	InsertTemplate(ctx context.Context, template model.RecordTemplate, privateUserKey uuid.UUID) (model.RecordTemplate, error)
+	SelectTemplates(ctx context.Context, privateUserKey uuid.UUID) ([]model.RecordTemplate, error)
+	SelectTemplate(ctx context.Context, templateKey, ownerKey uuid.UUID) (model.RecordTemplate, error)
+	DeleteTemplate(ctx context.Context, templateKey uuid.UUID) error
+
+	InsertDataCustom(ctx context.Context, data model.DataCustom) (model.DataCustomResponse, error)
+	SelectDataCustom(ctx context.Context, data model.DataCustom) (model.DataCustomResponse, error)
+	UpdateDataCustom(ctx context.Context, data model.DataCustom) (model.DataCustomResponse, error)
+	DeleteDataCustom(ctx context.Context, data model.DataCustom) error
+
+	SelectChanges(ctx context.Context, privateUserKey uuid.UUID, since int64) (model.SyncResponse, error)
+
+	SelectConflicts(ctx context.Context, privateUserKey uuid.UUID) ([]model.Conflict, error)
+	SelectConflict(ctx context.Context, key, privateUserKey uuid.UUID) (model.Conflict, error)
+	ResolveConflict(ctx context.Context, resolution model.ConflictResolution) (model.ConflictResolutionResponse, error)
+
+	SelectHistory(ctx context.Context, recordType string, key, privateUserKey uuid.UUID) ([]model.HistoryEntry, error)
+	RestoreRecord(ctx context.Context, recordType string, key, privateUserKey uuid.UUID, revision int64) (int64, error)
+
+	SelectTrash(ctx context.Context, privateUserKey uuid.UUID) ([]model.TrashEntry, error)
+	RestoreTrash(ctx context.Context, recordType string, key, privateUserKey uuid.UUID) (int64, error)
+	EmptyTrash(ctx context.Context, privateUserKey uuid.UUID) (int64, error)
+
+	SelectAccess(ctx context.Context, recordType string, key, privateUserKey uuid.UUID) (uuid.UUID, string, error)
+	InsertShare(ctx context.Context, share model.Share) (model.Share, error)
+	SelectShares(ctx context.Context, recordType string, key, ownerKey uuid.UUID) ([]model.Share, error)
+	DeleteShare(ctx context.Context, recordType string, key, ownerKey uuid.UUID, login string) (uuid.UUID, error)
+	SelectSharedRecords(ctx context.Context, privateUserKey uuid.UUID) ([]model.SharedRecord, error)
+	SelectE2EParams(ctx context.Context, privateUserKey uuid.UUID) (model.E2EParams, error)
+	InsertE2EParams(ctx context.Context, privateUserKey uuid.UUID, params model.E2EParams) error
+
+	InsertOrg(ctx context.Context, name string, privateUserKey uuid.UUID) (model.Org, error)
+	SelectOrgs(ctx context.Context, privateUserKey uuid.UUID) ([]model.Org, error)
+	SelectOrgRole(ctx context.Context, orgKey, privateUserKey uuid.UUID) (string, error)
+	SelectVaultRole(ctx context.Context, vaultKey, privateUserKey uuid.UUID) (string, error)
+	SelectMembers(ctx context.Context, orgKey uuid.UUID) ([]model.OrgMember, error)
+	UpsertMember(ctx context.Context, orgKey uuid.UUID, member model.OrgMember) (model.OrgMember, error)
+	DeleteMember(ctx context.Context, orgKey uuid.UUID, login string) error
+	InsertVault(ctx context.Context, orgKey uuid.UUID, name string) (model.Vault, error)
+	SelectVaults(ctx context.Context, privateUserKey uuid.UUID) ([]model.Vault, error)
+
+	InsertLink(ctx context.Context, link model.Link, privateUserKey uuid.UUID) (model.Link, error)
+	OpenLink(ctx context.Context, key uuid.UUID) (model.Link, error)
+	DeleteLink(ctx context.Context, key, privateUserKey uuid.UUID) error
+
+	InsertRevokedSession(ctx context.Context, sessionKey string, expiresAt time.Time) error
+	SelectSessionRevoked(ctx context.Context, sessionKey string) (bool, time.Time, error)
+
+	InsertAudit(ctx context.Context, entry model.AuditEntry) error
+	SelectAudit(ctx context.Context, ownerKey uuid.UUID, limit int) ([]model.AuditEntry, error)
+
+	SelectExpiring(ctx context.Context, privateUserKey uuid.UUID, days int) ([]model.ExpiringRecord, error)
+
+	Ping(ctx context.Context) error
+	SelectMigrationVersion(ctx context.Context) (int64, bool, error)
+	ExpectedSchemaVersion() int64
 }
 
 type GophKeeper struct {
 	str              Storage
 	srvAuthorization *Authorization
+	events           *Events
+	draining         *atomic.Bool
+	auditFailures    *atomic.Int64
 }
 
 func NewGophKeeper(str Storage) GophKeeper {
 	return GophKeeper{
 		str:              str,
 		srvAuthorization: NewAuthorization(),
+		events:           NewEvents(),
+		draining:         &atomic.Bool{},
+		auditFailures:    &atomic.Int64{},
 	}
 
 }
@@ -40,7 +121,30 @@ func (gk *GophKeeper) GetServiceAuthorization() *Authorization {
 	return gk.srvAuthorization
 }
 
-func (gk *GophKeeper) RegisterUser(body string) ([]byte, string, error) {
+func (gk *GophKeeper) GetServiceEvents() *Events {
+	return gk.events
+}
+
+// SetDraining отмечает, что сервер останавливается: проверка готовности перестаёт проходить.
+func (gk *GophKeeper) SetDraining() {
+	gk.draining.Store(true)
+}
+
+// publishChange уведомляет открытые потоки пользователя об изменении записи.
+func (gk *GophKeeper) publishChange(privateUserKey uuid.UUID, recordType string, key uuid.UUID, action string, revision int64) {
+	gk.events.Publish(privateUserKey, model.Event{
+		Type:       model.EventRecordChanged,
+		RecordType: recordType,
+		RecordKey:  key,
+		Action:     action,
+		Revision:   revision,
+	})
+}
+
+func (gk *GophKeeper) RegisterUser(ctx context.Context, body string) ([]byte, string, error) {
+	ctx, span := startSpan(ctx, "RegisterUser")
+	defer span.End()
+
 	var strUser model.User
 	err := json.Unmarshal([]byte(body), &strUser)
 	if err != nil {
@@ -52,7 +156,7 @@ func (gk *GophKeeper) RegisterUser(body string) ([]byte, string, error) {
 		return nil, "", err
 	}
 
-	result, err := gk.str.InsertUser(strUser)
+	result, err := gk.str.InsertUser(ctx, strUser)
 	if err != nil {
 		return nil, "", err
 	}
@@ -70,14 +174,17 @@ func (gk *GophKeeper) RegisterUser(body string) ([]byte, string, error) {
 	return resultBytes, token, nil
 }
 
-func (gk *GophKeeper) AuthorizationUser(body string) ([]byte, string, error) {
+func (gk *GophKeeper) AuthorizationUser(ctx context.Context, body string) ([]byte, string, error) {
+	ctx, span := startSpan(ctx, "AuthorizationUser")
+	defer span.End()
+
 	var strUser model.User
 	err := json.Unmarshal([]byte(body), &strUser)
 	if err != nil {
 		return nil, "", err
 	}
 
-	result, err := gk.str.SelectUser(strUser)
+	result, err := gk.str.SelectUser(ctx, strUser)
 	if err != nil {
 		return nil, "", err
 	}
@@ -94,23 +201,96 @@ func (gk *GophKeeper) AuthorizationUser(body string) ([]byte, string, error) {
 	return resultBytes, token, nil
 }
 
-func (gk *GophKeeper) LogoutUser() error {
+// LogoutUser отзывает сессию пользователя и сообщает об этом его открытым потокам.
+func (gk *GophKeeper) LogoutUser(ctx context.Context, sessionKey string, privateUserKey uuid.UUID) error {
+	ctx, span := startSpan(ctx, "LogoutUser")
+	defer span.End()
+
+	if sessionKey == "" {
+		return errors.New("session is not specified")
+	}
+
+	// Отзыв сохраняется в базе, чтобы токен не принимался после перезапуска и другими серверами.
+	expiresAt := time.Now().Add(tokenEXP)
+	if err := gk.str.InsertRevokedSession(ctx, sessionKey, expiresAt); err != nil {
+		return err
+	}
+	gk.srvAuthorization.RevokeSession(sessionKey, expiresAt)
+	gk.events.Publish(privateUserKey, model.Event{
+		Type:    model.EventSessionRevoked,
+		Session: sessionKey,
+	})
 	return nil
 }
 
-func (gk *GophKeeper) InsertDataText(body []byte, privateUserKey uuid.UUID) ([]byte, error) {
+// IsSessionRevoked сообщает, завершена ли сессия командой выхода на этом или другом сервере.
+func (gk *GophKeeper) IsSessionRevoked(ctx context.Context, sessionKey string) (bool, error) {
+	if gk.srvAuthorization.IsRevoked(sessionKey) {
+		return true, nil
+	}
+
+	revoked, expiresAt, err := gk.str.SelectSessionRevoked(ctx, sessionKey)
+	if err != nil {
+		return false, err
+	}
+	if revoked {
+		gk.srvAuthorization.markRevoked(sessionKey, expiresAt)
+	}
+	return revoked, nil
+}
+
+func (gk *GophKeeper) InsertDataText(ctx context.Context, body []byte, privateUserKey uuid.UUID) ([]byte, uuid.UUID, error) {
+	ctx, span := startSpan(ctx, "InsertDataText")
+	defer span.End()
+
+	if err := gk.authorizeOwner(ctx, privateUserKey, model.RoleEditor); err != nil {
+		return nil, uuid.Nil, err
+	}
+
 	var data model.DataText
 	err := json.Unmarshal(body, &data)
 	if err != nil {
-		return nil, err
+		return nil, uuid.Nil, err
 	}
 
 	data.PrivateUserKey = privateUserKey
-	result, err := gk.str.InsertDataText(data)
+	result, err := gk.str.InsertDataText(ctx, data)
+	if err != nil {
+		return nil, uuid.Nil, err
+	}
+	gk.publishChange(privateUserKey, model.RecordText, result.DataTextKey, model.ChangeCreated, result.Revision)
+
+	resultBytes, err := json.Marshal(result)
+	if err != nil {
+		return nil, uuid.Nil, err
+	}
+	return resultBytes, result.DataTextKey, nil
+}
+
+func (gk *GophKeeper) SelectDataText(ctx context.Context, key string, privateUserKey uuid.UUID) ([]byte, error) {
+	ctx, span := startSpan(ctx, "SelectDataText")
+	defer span.End()
+
+	if err := gk.authorizeOwner(ctx, privateUserKey, model.RoleViewer); err != nil {
+		return nil, err
+	}
+
+	var err error
+	data := model.DataText{}
+	data.DataTextKey, err = uuid.Parse(key)
 	if err != nil {
 		return nil, err
 	}
 
+	data.PrivateUserKey, err = gk.recordOwner(ctx, model.RecordText, data.DataTextKey, privateUserKey, false)
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := gk.str.SelectDataText(ctx, data)
+	if err != nil {
+		return nil, err
+	}
 	resultBytes, err := json.Marshal(result)
 	if err != nil {
 		return nil, err
@@ -118,19 +298,36 @@ func (gk *GophKeeper) InsertDataText(body []byte, privateUserKey uuid.UUID) ([]b
 	return resultBytes, nil
 }
 
-func (gk *GophKeeper) SelectDataText(key string, privateUserKey uuid.UUID) ([]byte, error) {
-	var err error
-	data := model.DataText{}
-	data.PrivateUserKey = privateUserKey
+func (gk *GophKeeper) UpdateDataText(ctx context.Context, key string, body []byte, privateUserKey uuid.UUID) ([]byte, error) {
+	ctx, span := startSpan(ctx, "UpdateDataText")
+	defer span.End()
+
+	if err := gk.authorizeOwner(ctx, privateUserKey, model.RoleEditor); err != nil {
+		return nil, err
+	}
+
+	var data model.DataText
+	err := json.Unmarshal(body, &data)
+	if err != nil {
+		return nil, err
+	}
+
 	data.DataTextKey, err = uuid.Parse(key)
 	if err != nil {
 		return nil, err
 	}
 
-	result, err := gk.str.SelectDataText(data)
+	data.PrivateUserKey, err = gk.recordOwner(ctx, model.RecordText, data.DataTextKey, privateUserKey, true)
 	if err != nil {
 		return nil, err
 	}
+
+	result, err := gk.str.UpdateDataText(ctx, data)
+	if err != nil {
+		return nil, err
+	}
+	gk.publishChange(data.PrivateUserKey, model.RecordText, result.DataTextKey, model.ChangeUpdated, result.Revision)
+
 	resultBytes, err := json.Marshal(result)
 	if err != nil {
 		return nil, err
@@ -138,7 +335,14 @@ func (gk *GophKeeper) SelectDataText(key string, privateUserKey uuid.UUID) ([]by
 	return resultBytes, nil
 }
 
-func (gk *GophKeeper) DeleteDataText(key string, privateUserKey uuid.UUID) error {
+func (gk *GophKeeper) DeleteDataText(ctx context.Context, key string, privateUserKey uuid.UUID) error {
+	ctx, span := startSpan(ctx, "DeleteDataText")
+	defer span.End()
+
+	if err := gk.authorizeOwner(ctx, privateUserKey, model.RoleEditor); err != nil {
+		return err
+	}
+
 	var err error
 	data := model.DataText{}
 	data.PrivateUserKey = privateUserKey
@@ -147,26 +351,66 @@ func (gk *GophKeeper) DeleteDataText(key string, privateUserKey uuid.UUID) error
 		return err
 	}
 
-	err = gk.str.DeleteDataText(data)
+	err = gk.str.DeleteDataText(ctx, data)
 	if err != nil {
 		return err
 	}
+	gk.publishChange(privateUserKey, model.RecordText, data.DataTextKey, model.ChangeDeleted, 0)
 
 	return nil
 }
 
-func (gk *GophKeeper) InsertDataBinary(body []byte, privateUserKey uuid.UUID) ([]byte, error) {
+func (gk *GophKeeper) InsertDataBinary(ctx context.Context, body []byte, privateUserKey uuid.UUID) ([]byte, uuid.UUID, error) {
+	ctx, span := startSpan(ctx, "InsertDataBinary")
+	defer span.End()
+
+	if err := gk.authorizeOwner(ctx, privateUserKey, model.RoleEditor); err != nil {
+		return nil, uuid.Nil, err
+	}
+
 	var data model.DataBinary
 	err := json.Unmarshal(body, &data)
 	if err != nil {
-		return nil, err
+		return nil, uuid.Nil, err
 	}
 	data.PrivateUserKey = privateUserKey
-	result, err := gk.str.InsertDataBinary(data)
+	result, err := gk.str.InsertDataBinary(ctx, data)
+	if err != nil {
+		return nil, uuid.Nil, err
+	}
+	gk.publishChange(privateUserKey, model.RecordBinary, result.DataBinaryKey, model.ChangeCreated, result.Revision)
+
+	resultBytes, err := json.Marshal(result)
 	if err != nil {
+		return nil, uuid.Nil, err
+	}
+	return resultBytes, result.DataBinaryKey, nil
+}
+
+func (gk *GophKeeper) SelectDataBinary(ctx context.Context, key string, privateUserKey uuid.UUID) ([]byte, error) {
+	ctx, span := startSpan(ctx, "SelectDataBinary")
+	defer span.End()
+
+	if err := gk.authorizeOwner(ctx, privateUserKey, model.RoleViewer); err != nil {
 		return nil, err
 	}
 
+	var err error
+	data := model.DataBinary{}
+	data.DataBinaryKey, err = uuid.Parse(key)
+	if err != nil {
+		return nil, err
+	}
+
+	data.PrivateUserKey, err = gk.recordOwner(ctx, model.RecordBinary, data.DataBinaryKey, privateUserKey, false)
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := gk.str.SelectDataBinary(ctx, data)
+	if err != nil {
+		return nil, err
+	}
 	resultBytes, err := json.Marshal(result)
 	if err != nil {
 		return nil, err
@@ -174,19 +418,36 @@ func (gk *GophKeeper) InsertDataBinary(body []byte, privateUserKey uuid.UUID) ([
 	return resultBytes, nil
 }
 
-func (gk *GophKeeper) SelectDataBinary(key string, privateUserKey uuid.UUID) ([]byte, error) {
-	var err error
-	data := model.DataBinary{}
-	data.PrivateUserKey = privateUserKey
+func (gk *GophKeeper) UpdateDataBinary(ctx context.Context, key string, body []byte, privateUserKey uuid.UUID) ([]byte, error) {
+	ctx, span := startSpan(ctx, "UpdateDataBinary")
+	defer span.End()
+
+	if err := gk.authorizeOwner(ctx, privateUserKey, model.RoleEditor); err != nil {
+		return nil, err
+	}
+
+	var data model.DataBinary
+	err := json.Unmarshal(body, &data)
+	if err != nil {
+		return nil, err
+	}
+
 	data.DataBinaryKey, err = uuid.Parse(key)
 	if err != nil {
 		return nil, err
 	}
 
-	result, err := gk.str.SelectDataBinary(data)
+	data.PrivateUserKey, err = gk.recordOwner(ctx, model.RecordBinary, data.DataBinaryKey, privateUserKey, true)
 	if err != nil {
 		return nil, err
 	}
+
+	result, err := gk.str.UpdateDataBinary(ctx, data)
+	if err != nil {
+		return nil, err
+	}
+	gk.publishChange(data.PrivateUserKey, model.RecordBinary, result.DataBinaryKey, model.ChangeUpdated, result.Revision)
+
 	resultBytes, err := json.Marshal(result)
 	if err != nil {
 		return nil, err
@@ -194,7 +455,14 @@ func (gk *GophKeeper) SelectDataBinary(key string, privateUserKey uuid.UUID) ([]
 	return resultBytes, nil
 }
 
-func (gk *GophKeeper) DeleteDataBinary(key string, privateUserKey uuid.UUID) error {
+func (gk *GophKeeper) DeleteDataBinary(ctx context.Context, key string, privateUserKey uuid.UUID) error {
+	ctx, span := startSpan(ctx, "DeleteDataBinary")
+	defer span.End()
+
+	if err := gk.authorizeOwner(ctx, privateUserKey, model.RoleEditor); err != nil {
+		return err
+	}
+
 	var err error
 	data := model.DataBinary{}
 	data.PrivateUserKey = privateUserKey
@@ -203,26 +471,69 @@ func (gk *GophKeeper) DeleteDataBinary(key string, privateUserKey uuid.UUID) err
 		return err
 	}
 
-	err = gk.str.DeleteDataBinary(data)
+	err = gk.str.DeleteDataBinary(ctx, data)
 	if err != nil {
 		return err
 	}
+	gk.publishChange(privateUserKey, model.RecordBinary, data.DataBinaryKey, model.ChangeDeleted, 0)
 
 	return nil
 }
 
-func (gk *GophKeeper) InsertDataCard(body []byte, privateUserKey uuid.UUID) ([]byte, error) {
+func (gk *GophKeeper) InsertDataCard(ctx context.Context, body []byte, privateUserKey uuid.UUID) ([]byte, uuid.UUID, error) {
+	ctx, span := startSpan(ctx, "InsertDataCard")
+	defer span.End()
+
+	if err := gk.authorizeOwner(ctx, privateUserKey, model.RoleEditor); err != nil {
+		return nil, uuid.Nil, err
+	}
+
 	var data model.DataCreditCard
 	err := json.Unmarshal(body, &data)
 	if err != nil {
-		return nil, err
+		return nil, uuid.Nil, err
+	}
+	if err := validateCard(&data, time.Now()); err != nil {
+		return nil, uuid.Nil, err
 	}
 	data.PrivateUserKey = privateUserKey
-	result, err := gk.str.InsertDataCard(data)
+	result, err := gk.str.InsertDataCard(ctx, data)
+	if err != nil {
+		return nil, uuid.Nil, err
+	}
+	gk.publishChange(privateUserKey, model.RecordCard, result.DataCreditCardKey, model.ChangeCreated, result.Revision)
+
+	resultBytes, err := json.Marshal(result)
 	if err != nil {
+		return nil, uuid.Nil, err
+	}
+	return resultBytes, result.DataCreditCardKey, nil
+}
+
+func (gk *GophKeeper) SelectDataCard(ctx context.Context, key string, privateUserKey uuid.UUID) ([]byte, error) {
+	ctx, span := startSpan(ctx, "SelectDataCard")
+	defer span.End()
+
+	if err := gk.authorizeOwner(ctx, privateUserKey, model.RoleViewer); err != nil {
 		return nil, err
 	}
 
+	var err error
+	data := model.DataCreditCard{}
+	data.DataCreditCardKey, err = uuid.Parse(key)
+	if err != nil {
+		return nil, err
+	}
+
+	data.PrivateUserKey, err = gk.recordOwner(ctx, model.RecordCard, data.DataCreditCardKey, privateUserKey, false)
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := gk.str.SelectDataCard(ctx, data)
+	if err != nil {
+		return nil, err
+	}
 	resultBytes, err := json.Marshal(result)
 	if err != nil {
 		return nil, err
@@ -230,16 +541,122 @@ func (gk *GophKeeper) InsertDataCard(body []byte, privateUserKey uuid.UUID) ([]b
 	return resultBytes, nil
 }
 
-func (gk *GophKeeper) SelectDataCard(key string, privateUserKey uuid.UUID) ([]byte, error) {
+func (gk *GophKeeper) UpdateDataCard(ctx context.Context, key string, body []byte, privateUserKey uuid.UUID) ([]byte, error) {
+	ctx, span := startSpan(ctx, "UpdateDataCard")
+	defer span.End()
+
+	if err := gk.authorizeOwner(ctx, privateUserKey, model.RoleEditor); err != nil {
+		return nil, err
+	}
+
+	var data model.DataCreditCard
+	err := json.Unmarshal(body, &data)
+	if err != nil {
+		return nil, err
+	}
+	if err := validateCard(&data, time.Now()); err != nil {
+		return nil, err
+	}
+
+	data.DataCreditCardKey, err = uuid.Parse(key)
+	if err != nil {
+		return nil, err
+	}
+
+	data.PrivateUserKey, err = gk.recordOwner(ctx, model.RecordCard, data.DataCreditCardKey, privateUserKey, true)
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := gk.str.UpdateDataCard(ctx, data)
+	if err != nil {
+		return nil, err
+	}
+	gk.publishChange(data.PrivateUserKey, model.RecordCard, result.DataCreditCardKey, model.ChangeUpdated, result.Revision)
+
+	resultBytes, err := json.Marshal(result)
+	if err != nil {
+		return nil, err
+	}
+	return resultBytes, nil
+}
+
+func (gk *GophKeeper) DeleteDataCard(ctx context.Context, key string, privateUserKey uuid.UUID) error {
+	ctx, span := startSpan(ctx, "DeleteDataCard")
+	defer span.End()
+
+	if err := gk.authorizeOwner(ctx, privateUserKey, model.RoleEditor); err != nil {
+		return err
+	}
+
 	var err error
 	data := model.DataCreditCard{}
 	data.PrivateUserKey = privateUserKey
 	data.DataCreditCardKey, err = uuid.Parse(key)
+	if err != nil {
+		return err
+	}
+
+	err = gk.str.DeleteDataCard(ctx, data)
+	if err != nil {
+		return err
+	}
+	gk.publishChange(privateUserKey, model.RecordCard, data.DataCreditCardKey, model.ChangeDeleted, 0)
+
+	return nil
+}
+
+func (gk *GophKeeper) InsertDataTOTP(ctx context.Context, body []byte, privateUserKey uuid.UUID) ([]byte, uuid.UUID, error) {
+	ctx, span := startSpan(ctx, "InsertDataTOTP")
+	defer span.End()
+
+	if err := gk.authorizeOwner(ctx, privateUserKey, model.RoleEditor); err != nil {
+		return nil, uuid.Nil, err
+	}
+
+	var data model.DataTOTP
+	err := json.Unmarshal(body, &data)
+	if err != nil {
+		return nil, uuid.Nil, err
+	}
+	if err := validateTOTP(&data); err != nil {
+		return nil, uuid.Nil, err
+	}
+	data.PrivateUserKey = privateUserKey
+	result, err := gk.str.InsertDataTOTP(ctx, data)
+	if err != nil {
+		return nil, uuid.Nil, err
+	}
+	gk.publishChange(privateUserKey, model.RecordTOTP, result.DataTOTPKey, model.ChangeCreated, result.Revision)
+
+	resultBytes, err := json.Marshal(result)
+	if err != nil {
+		return nil, uuid.Nil, err
+	}
+	return resultBytes, result.DataTOTPKey, nil
+}
+
+func (gk *GophKeeper) SelectDataTOTP(ctx context.Context, key string, privateUserKey uuid.UUID) ([]byte, error) {
+	ctx, span := startSpan(ctx, "SelectDataTOTP")
+	defer span.End()
+
+	if err := gk.authorizeOwner(ctx, privateUserKey, model.RoleViewer); err != nil {
+		return nil, err
+	}
+
+	var err error
+	data := model.DataTOTP{}
+	data.DataTOTPKey, err = uuid.Parse(key)
+	if err != nil {
+		return nil, err
+	}
+
+	data.PrivateUserKey, err = gk.recordOwner(ctx, model.RecordTOTP, data.DataTOTPKey, privateUserKey, false)
 	if err != nil {
 		return nil, err
 	}
 
-	result, err := gk.str.SelectDataCard(data)
+	result, err := gk.str.SelectDataTOTP(ctx, data)
 	if err != nil {
 		return nil, err
 	}
@@ -250,19 +667,373 @@ func (gk *GophKeeper) SelectDataCard(key string, privateUserKey uuid.UUID) ([]by
 	return resultBytes, nil
 }
 
-func (gk *GophKeeper) DeleteDataCard(key string, privateUserKey uuid.UUID) error {
+func (gk *GophKeeper) UpdateDataTOTP(ctx context.Context, key string, body []byte, privateUserKey uuid.UUID) ([]byte, error) {
+	ctx, span := startSpan(ctx, "UpdateDataTOTP")
+	defer span.End()
+
+	if err := gk.authorizeOwner(ctx, privateUserKey, model.RoleEditor); err != nil {
+		return nil, err
+	}
+
+	var data model.DataTOTP
+	err := json.Unmarshal(body, &data)
+	if err != nil {
+		return nil, err
+	}
+	if err := validateTOTP(&data); err != nil {
+		return nil, err
+	}
+
+	data.DataTOTPKey, err = uuid.Parse(key)
+	if err != nil {
+		return nil, err
+	}
+
+	data.PrivateUserKey, err = gk.recordOwner(ctx, model.RecordTOTP, data.DataTOTPKey, privateUserKey, true)
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := gk.str.UpdateDataTOTP(ctx, data)
+	if err != nil {
+		return nil, err
+	}
+	gk.publishChange(data.PrivateUserKey, model.RecordTOTP, result.DataTOTPKey, model.ChangeUpdated, result.Revision)
+
+	resultBytes, err := json.Marshal(result)
+	if err != nil {
+		return nil, err
+	}
+	return resultBytes, nil
+}
+
+func (gk *GophKeeper) DeleteDataTOTP(ctx context.Context, key string, privateUserKey uuid.UUID) error {
+	ctx, span := startSpan(ctx, "DeleteDataTOTP")
+	defer span.End()
+
+	if err := gk.authorizeOwner(ctx, privateUserKey, model.RoleEditor); err != nil {
+		return err
+	}
+
 	var err error
-	data := model.DataCreditCard{}
+	data := model.DataTOTP{}
 	data.PrivateUserKey = privateUserKey
-	data.DataCreditCardKey, err = uuid.Parse(key)
+	data.DataTOTPKey, err = uuid.Parse(key)
 	if err != nil {
 		return err
 	}
 
-	err = gk.str.DeleteDataCard(data)
+	err = gk.str.DeleteDataTOTP(ctx, data)
 	if err != nil {
 		return err
 	}
+	gk.publishChange(privateUserKey, model.RecordTOTP, data.DataTOTPKey, model.ChangeDeleted, 0)
 
 	return nil
 }
+
+func (gk *GophKeeper) InsertDataSSHKey(ctx context.Context, body []byte, privateUserKey uuid.UUID) ([]byte, uuid.UUID, error) {
+	ctx, span := startSpan(ctx, "InsertDataSSHKey")
+	defer span.End()
+
+	if err := gk.authorizeOwner(ctx, privateUserKey, model.RoleEditor); err != nil {
+		return nil, uuid.Nil, err
+	}
+
+	var data model.DataSSHKey
+	err := json.Unmarshal(body, &data)
+	if err != nil {
+		return nil, uuid.Nil, err
+	}
+	if err := validateSSHKey(&data); err != nil {
+		return nil, uuid.Nil, err
+	}
+	data.PrivateUserKey = privateUserKey
+	result, err := gk.str.InsertDataSSHKey(ctx, data)
+	if err != nil {
+		return nil, uuid.Nil, err
+	}
+	gk.publishChange(privateUserKey, model.RecordSSH, result.DataSSHKeyKey, model.ChangeCreated, result.Revision)
+
+	resultBytes, err := json.Marshal(result)
+	if err != nil {
+		return nil, uuid.Nil, err
+	}
+	return resultBytes, result.DataSSHKeyKey, nil
+}
+
+func (gk *GophKeeper) SelectDataSSHKey(ctx context.Context, key string, privateUserKey uuid.UUID) ([]byte, error) {
+	ctx, span := startSpan(ctx, "SelectDataSSHKey")
+	defer span.End()
+
+	if err := gk.authorizeOwner(ctx, privateUserKey, model.RoleViewer); err != nil {
+		return nil, err
+	}
+
+	var err error
+	data := model.DataSSHKey{}
+	data.DataSSHKeyKey, err = uuid.Parse(key)
+	if err != nil {
+		return nil, err
+	}
+
+	data.PrivateUserKey, err = gk.recordOwner(ctx, model.RecordSSH, data.DataSSHKeyKey, privateUserKey, false)
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := gk.str.SelectDataSSHKey(ctx, data)
+	if err != nil {
+		return nil, err
+	}
+	resultBytes, err := json.Marshal(result)
+	if err != nil {
+		return nil, err
+	}
+	return resultBytes, nil
+}
+
+func (gk *GophKeeper) UpdateDataSSHKey(ctx context.Context, key string, body []byte, privateUserKey uuid.UUID) ([]byte, error) {
+	ctx, span := startSpan(ctx, "UpdateDataSSHKey")
+	defer span.End()
+
+	if err := gk.authorizeOwner(ctx, privateUserKey, model.RoleEditor); err != nil {
+		return nil, err
+	}
+
+	var data model.DataSSHKey
+	err := json.Unmarshal(body, &data)
+	if err != nil {
+		return nil, err
+	}
+	if err := validateSSHKey(&data); err != nil {
+		return nil, err
+	}
+
+	data.DataSSHKeyKey, err = uuid.Parse(key)
+	if err != nil {
+		return nil, err
+	}
+
+	data.PrivateUserKey, err = gk.recordOwner(ctx, model.RecordSSH, data.DataSSHKeyKey, privateUserKey, true)
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := gk.str.UpdateDataSSHKey(ctx, data)
+	if err != nil {
+		return nil, err
+	}
+	gk.publishChange(data.PrivateUserKey, model.RecordSSH, result.DataSSHKeyKey, model.ChangeUpdated, result.Revision)
+
+	resultBytes, err := json.Marshal(result)
+	if err != nil {
+		return nil, err
+	}
+	return resultBytes, nil
+}
+
+func (gk *GophKeeper) DeleteDataSSHKey(ctx context.Context, key string, privateUserKey uuid.UUID) error {
+	ctx, span := startSpan(ctx, "DeleteDataSSHKey")
+	defer span.End()
+
+	if err := gk.authorizeOwner(ctx, privateUserKey, model.RoleEditor); err != nil {
+		return err
+	}
+
+	var err error
+	data := model.DataSSHKey{}
+	data.PrivateUserKey = privateUserKey
+	data.DataSSHKeyKey, err = uuid.Parse(key)
+	if err != nil {
+		return err
+	}
+
+	err = gk.str.DeleteDataSSHKey(ctx, data)
+	if err != nil {
+		return err
+	}
+	gk.publishChange(privateUserKey, model.RecordSSH, data.DataSSHKeyKey, model.ChangeDeleted, 0)
+
+	return nil
+}
+
+func (gk *GophKeeper) InsertDataCustom(ctx context.Context, body []byte, privateUserKey uuid.UUID) ([]byte, uuid.UUID, error) {
+	ctx, span := startSpan(ctx, "InsertDataCustom")
+	defer span.End()
+
+	if err := gk.authorizeOwner(ctx, privateUserKey, model.RoleEditor); err != nil {
+		return nil, uuid.Nil, err
+	}
+
+	var data model.DataCustom
+	err := json.Unmarshal(body, &data)
+	if err != nil {
+		return nil, uuid.Nil, err
+	}
+	data.PrivateUserKey = privateUserKey
+
+	template, err := gk.customTemplate(ctx, data.TemplateKey, privateUserKey)
+	if err != nil {
+		return nil, uuid.Nil, err
+	}
+	if err := gk.validateCustom(ctx, &data, template, privateUserKey); err != nil {
+		return nil, uuid.Nil, err
+	}
+
+	result, err := gk.str.InsertDataCustom(ctx, data)
+	if err != nil {
+		return nil, uuid.Nil, err
+	}
+	result.TemplateName = template.Name
+	gk.publishChange(privateUserKey, model.RecordCustom, result.DataCustomKey, model.ChangeCreated, result.Revision)
+
+	resultBytes, err := json.Marshal(result)
+	if err != nil {
+		return nil, uuid.Nil, err
+	}
+	return resultBytes, result.DataCustomKey, nil
+}
+
+func (gk *GophKeeper) SelectDataCustom(ctx context.Context, key string, privateUserKey uuid.UUID) ([]byte, error) {
+	ctx, span := startSpan(ctx, "SelectDataCustom")
+	defer span.End()
+
+	if err := gk.authorizeOwner(ctx, privateUserKey, model.RoleViewer); err != nil {
+		return nil, err
+	}
+
+	var err error
+	data := model.DataCustom{}
+	data.DataCustomKey, err = uuid.Parse(key)
+	if err != nil {
+		return nil, err
+	}
+
+	data.PrivateUserKey, err = gk.recordOwner(ctx, model.RecordCustom, data.DataCustomKey, privateUserKey, false)
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := gk.str.SelectDataCustom(ctx, data)
+	if err != nil {
+		return nil, err
+	}
+	resultBytes, err := json.Marshal(result)
+	if err != nil {
+		return nil, err
+	}
+	return resultBytes, nil
+}
+
+// UpdateDataCustom заменяет значения полей записи. Шаблон записи не меняется:
+// значения проверяются по шаблону, с которым запись была создана.
+func (gk *GophKeeper) UpdateDataCustom(ctx context.Context, key string, body []byte, privateUserKey uuid.UUID) ([]byte, error) {
+	ctx, span := startSpan(ctx, "UpdateDataCustom")
+	defer span.End()
+
+	if err := gk.authorizeOwner(ctx, privateUserKey, model.RoleEditor); err != nil {
+		return nil, err
+	}
+
+	var data model.DataCustom
+	err := json.Unmarshal(body, &data)
+	if err != nil {
+		return nil, err
+	}
+
+	data.DataCustomKey, err = uuid.Parse(key)
+	if err != nil {
+		return nil, err
+	}
+
+	data.PrivateUserKey, err = gk.recordOwner(ctx, model.RecordCustom, data.DataCustomKey, privateUserKey, true)
+	if err != nil {
+		return nil, err
+	}
+
+	current, err := gk.str.SelectDataCustom(ctx, model.DataCustom{DataCustomKey: data.DataCustomKey, PrivateUserKey: data.PrivateUserKey})
+	if err != nil {
+		return nil, err
+	}
+	data.TemplateKey = current.TemplateKey
+
+	template, err := gk.customTemplate(ctx, data.TemplateKey, data.PrivateUserKey)
+	if err != nil {
+		return nil, err
+	}
+	if err := gk.validateCustom(ctx, &data, template, privateUserKey); err != nil {
+		return nil, err
+	}
+
+	result, err := gk.str.UpdateDataCustom(ctx, data)
+	if err != nil {
+		return nil, err
+	}
+	result.TemplateName = template.Name
+	gk.publishChange(data.PrivateUserKey, model.RecordCustom, result.DataCustomKey, model.ChangeUpdated, result.Revision)
+
+	resultBytes, err := json.Marshal(result)
+	if err != nil {
+		return nil, err
+	}
+	return resultBytes, nil
+}
+
+func (gk *GophKeeper) DeleteDataCustom(ctx context.Context, key string, privateUserKey uuid.UUID) error {
+	ctx, span := startSpan(ctx, "DeleteDataCustom")
+	defer span.End()
+
+	if err := gk.authorizeOwner(ctx, privateUserKey, model.RoleEditor); err != nil {
+		return err
+	}
+
+	var err error
+	data := model.DataCustom{}
+	data.PrivateUserKey = privateUserKey
+	data.DataCustomKey, err = uuid.Parse(key)
+	if err != nil {
+		return err
+	}
+
+	err = gk.str.DeleteDataCustom(ctx, data)
+	if err != nil {
+		return err
+	}
+	gk.publishChange(privateUserKey, model.RecordCustom, data.DataCustomKey, model.ChangeDeleted, 0)
+
+	return nil
+}
+
+// SelectChanges возвращает ленту изменений пользователя после ревизии since.
+// Пустое значение since означает запрос всех записей.
+func (gk *GophKeeper) SelectChanges(ctx context.Context, since string, privateUserKey uuid.UUID) ([]byte, error) {
+	ctx, span := startSpan(ctx, "SelectChanges")
+	defer span.End()
+
+	if err := gk.authorizeOwner(ctx, privateUserKey, model.RoleViewer); err != nil {
+		return nil, err
+	}
+
+	var (
+		revision int64
+		err      error
+	)
+	if since != "" {
+		revision, err = strconv.ParseInt(since, 10, 64)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	result, err := gk.str.SelectChanges(ctx, privateUserKey, revision)
+	if err != nil {
+		return nil, err
+	}
+
+	resultBytes, err := json.Marshal(result)
+	if err != nil {
+		return nil, err
+	}
+	return resultBytes, nil
+}
@@ -0,0 +1,70 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"server/internal/cerrors"
+	"server/internal/model"
+	"time"
+)
+
+// readinessTimeout ограничивает проверку готовности, чтобы недоступная база данных
+// не задерживала ответ дольше тайм-аута проверяющего.
+const readinessTimeout = 3 * time.Second
+
+// Readiness проверяет соединение с базой данных и версию её схемы.
+// Во время остановки сервера проверка не проходит без обращения к базе данных.
+// Сервер готов, если база данных доступна и применённая миграция не старше ожидаемой и не прервана.
+// Если версия схемы не записана, она не проверяется: миграции могли применяться вручную.
+func (gk *GophKeeper) Readiness(ctx context.Context) (model.Readiness, bool) {
+	ctx, span := startSpan(ctx, "Readiness")
+	defer span.End()
+
+	ctx, cancel := context.WithTimeout(ctx, readinessTimeout)
+	defer cancel()
+
+	if gk.draining.Load() {
+		return model.Readiness{
+			Status:     model.HealthDraining,
+			Database:   model.HealthUnknown,
+			Migrations: model.MigrationStatus{Status: model.HealthUnknown, Expected: gk.str.ExpectedSchemaVersion()},
+		}, false
+	}
+
+	result := model.Readiness{
+		Status:   model.HealthOK,
+		Database: model.HealthOK,
+		Migrations: model.MigrationStatus{
+			Status:   model.HealthOK,
+			Expected: gk.str.ExpectedSchemaVersion(),
+		},
+	}
+
+	if err := gk.str.Ping(ctx); err != nil {
+		result.Status = model.HealthFailed
+		result.Database = model.HealthFailed
+		result.Migrations.Status = model.HealthUnknown
+		return result, false
+	}
+
+	version, dirty, err := gk.str.SelectMigrationVersion(ctx)
+	switch {
+	case errors.Is(err, cerrors.ErrNotFound):
+		result.Migrations.Status = model.HealthUnknown
+		return result, true
+	case err != nil:
+		result.Migrations.Status = model.HealthFailed
+	case dirty:
+		result.Migrations.Status = model.HealthDirty
+	case version < result.Migrations.Expected:
+		result.Migrations.Status = model.HealthOutdated
+	}
+	result.Migrations.Version = version
+	result.Migrations.Dirty = dirty
+
+	if result.Migrations.Status != model.HealthOK {
+		result.Status = model.HealthFailed
+		return result, false
+	}
+	return result, true
+}
@@ -0,0 +1,63 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/google/uuid"
+	"server/internal/model"
+)
+
+// SelectHistory возвращает историю версий записи типа recordType, начиная с последней.
+func (gk *GophKeeper) SelectHistory(ctx context.Context, recordType, key string, privateUserKey uuid.UUID) ([]byte, error) {
+	ctx, span := startSpan(ctx, "SelectHistory")
+	defer span.End()
+
+	if err := gk.authorizeOwner(ctx, privateUserKey, model.RoleViewer); err != nil {
+		return nil, err
+	}
+
+	recordKey, err := uuid.Parse(key)
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := gk.str.SelectHistory(ctx, recordType, recordKey, privateUserKey)
+	if err != nil {
+		return nil, err
+	}
+
+	resultBytes, err := json.Marshal(result)
+	if err != nil {
+		return nil, err
+	}
+	return resultBytes, nil
+}
+
+// RestoreRecord возвращает запись к версии из истории и отдаёт восстановленную запись.
+func (gk *GophKeeper) RestoreRecord(ctx context.Context, recordType, key string, body []byte, privateUserKey uuid.UUID) ([]byte, error) {
+	ctx, span := startSpan(ctx, "RestoreRecord")
+	defer span.End()
+
+	if err := gk.authorizeOwner(ctx, privateUserKey, model.RoleEditor); err != nil {
+		return nil, err
+	}
+
+	var restore model.HistoryRestore
+	err := json.Unmarshal(body, &restore)
+	if err != nil {
+		return nil, err
+	}
+
+	recordKey, err := uuid.Parse(key)
+	if err != nil {
+		return nil, err
+	}
+
+	revision, err := gk.str.RestoreRecord(ctx, recordType, recordKey, privateUserKey, restore.Revision)
+	if err != nil {
+		return nil, err
+	}
+	gk.publishChange(privateUserKey, recordType, recordKey, model.ChangeUpdated, revision)
+
+	return gk.currentRecord(ctx, recordType, recordKey, privateUserKey)
+}
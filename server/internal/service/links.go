@@ -0,0 +1,95 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/google/uuid"
+	"server/internal/model"
+	"time"
+)
+
+const (
+	defaultLinkTTL = 24 * time.Hour      // срок действия ссылки, если он не указан
+	maxLinkTTL     = 30 * 24 * time.Hour // наибольший срок действия ссылки
+	maxLinkViews   = 100                 // наибольшее число просмотров ссылки
+)
+
+// CreateLink сохраняет зашифрованный секрет и возвращает ссылку без данных и её ключ.
+// По умолчанию ссылка открывается один раз и действует defaultLinkTTL.
+func (gk *GophKeeper) CreateLink(ctx context.Context, body []byte, privateUserKey uuid.UUID) ([]byte, uuid.UUID, error) {
+	ctx, span := startSpan(ctx, "CreateLink")
+	defer span.End()
+
+	var link model.Link
+	err := json.Unmarshal(body, &link)
+	if err != nil {
+		return nil, uuid.Nil, err
+	}
+
+	if link.Data == "" {
+		return nil, uuid.Nil, fmt.Errorf("link data is empty")
+	}
+	if link.MaxViews == 0 {
+		link.MaxViews = 1
+	}
+	if link.MaxViews < 0 || link.MaxViews > maxLinkViews {
+		return nil, uuid.Nil, fmt.Errorf("max views must be between 1 and %d", maxLinkViews)
+	}
+
+	now := time.Now()
+	if link.ExpiresAt.IsZero() {
+		link.ExpiresAt = now.Add(defaultLinkTTL)
+	}
+	if !link.ExpiresAt.After(now) || link.ExpiresAt.After(now.Add(maxLinkTTL)) {
+		return nil, uuid.Nil, fmt.Errorf("link expiry must be within %s", maxLinkTTL)
+	}
+
+	result, err := gk.str.InsertLink(ctx, link, privateUserKey)
+	if err != nil {
+		return nil, uuid.Nil, err
+	}
+	result.Data = ""
+
+	resultBytes, err := json.Marshal(result)
+	if err != nil {
+		return nil, uuid.Nil, err
+	}
+	return resultBytes, result.LinkKey, nil
+}
+
+// OpenLink отдаёт шифротекст ссылки, засчитывает просмотр и возвращает владельца ссылки для журнала аудита.
+// Истёкшие и уже открытые максимальное число раз ссылки не находятся.
+func (gk *GophKeeper) OpenLink(ctx context.Context, key string) ([]byte, uuid.UUID, error) {
+	ctx, span := startSpan(ctx, "OpenLink")
+	defer span.End()
+
+	linkKey, err := uuid.Parse(key)
+	if err != nil {
+		return nil, uuid.Nil, err
+	}
+
+	result, err := gk.str.OpenLink(ctx, linkKey)
+	if err != nil {
+		return nil, uuid.Nil, err
+	}
+
+	resultBytes, err := json.Marshal(result)
+	if err != nil {
+		return nil, uuid.Nil, err
+	}
+	return resultBytes, result.OwnerKey, nil
+}
+
+// DeleteLink отзывает ссылку до её открытия.
+func (gk *GophKeeper) DeleteLink(ctx context.Context, key string, privateUserKey uuid.UUID) error {
+	ctx, span := startSpan(ctx, "DeleteLink")
+	defer span.End()
+
+	linkKey, err := uuid.Parse(key)
+	if err != nil {
+		return err
+	}
+
+	return gk.str.DeleteLink(ctx, linkKey, privateUserKey)
+}
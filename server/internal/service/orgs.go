@@ -0,0 +1,276 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/google/uuid"
+	"server/internal/cerrors"
+	"server/internal/model"
+)
+
+// roleRank упорядочивает роли участников организации по объёму прав.
+var roleRank = map[string]int{
+	model.RoleViewer: 1,
+	model.RoleEditor: 2,
+	model.RoleAdmin:  3,
+	model.RoleOwner:  4,
+}
+
+// canManage сообщает, может ли участник с ролью actor назначать и исключать участников с ролью target.
+// Владелец управляет всеми, администратор — редакторами и читателями.
+func canManage(actor, target string) bool {
+	switch actor {
+	case model.RoleOwner:
+		return true
+	case model.RoleAdmin:
+		return roleRank[target] < roleRank[model.RoleAdmin]
+	}
+	return false
+}
+
+// authorizeOwner проверяет права на записи владельца ownerKey. Запросы к хранилищу организации
+// выполняются от имени хранилища, а действует участник организации из контекста запроса:
+// ему нужна роль не ниже minRole. Читают записи все участники (RoleViewer), изменяют —
+// начиная с RoleEditor, а выдают доступ к записям хранилища только администраторы (RoleAdmin).
+// Пользователь, действующий от своего имени, прав на свои записи не проверяет.
+// Запрос без действующего пользователя в контексте отклоняется.
+func (gk *GophKeeper) authorizeOwner(ctx context.Context, ownerKey uuid.UUID, minRole string) error {
+	actor, ok := GetCurrentActor(ctx)
+	if !ok {
+		return cerrors.ErrForbidden
+	}
+	if actor == ownerKey {
+		return nil
+	}
+
+	role, err := gk.str.SelectVaultRole(ctx, ownerKey, actor)
+	if err != nil {
+		return err
+	}
+	if roleRank[role] < roleRank[minRole] {
+		return cerrors.ErrForbidden
+	}
+
+	return nil
+}
+
+// CreateOrg создаёт организацию; пользователь становится её владельцем.
+func (gk *GophKeeper) CreateOrg(ctx context.Context, body []byte, privateUserKey uuid.UUID) ([]byte, error) {
+	ctx, span := startSpan(ctx, "CreateOrg")
+	defer span.End()
+
+	var org model.Org
+	err := json.Unmarshal(body, &org)
+	if err != nil {
+		return nil, err
+	}
+	if org.Name == "" {
+		return nil, fmt.Errorf("organization name is empty")
+	}
+
+	result, err := gk.str.InsertOrg(ctx, org.Name, privateUserKey)
+	if err != nil {
+		return nil, err
+	}
+
+	resultBytes, err := json.Marshal(result)
+	if err != nil {
+		return nil, err
+	}
+	return resultBytes, nil
+}
+
+// SelectOrgs возвращает организации пользователя.
+func (gk *GophKeeper) SelectOrgs(ctx context.Context, privateUserKey uuid.UUID) ([]byte, error) {
+	ctx, span := startSpan(ctx, "SelectOrgs")
+	defer span.End()
+
+	result, err := gk.str.SelectOrgs(ctx, privateUserKey)
+	if err != nil {
+		return nil, err
+	}
+
+	resultBytes, err := json.Marshal(result)
+	if err != nil {
+		return nil, err
+	}
+	return resultBytes, nil
+}
+
+// SelectMembers возвращает участников организации. Доступно любому участнику.
+func (gk *GophKeeper) SelectMembers(ctx context.Context, key string, privateUserKey uuid.UUID) ([]byte, error) {
+	ctx, span := startSpan(ctx, "SelectMembers")
+	defer span.End()
+
+	orgKey, err := uuid.Parse(key)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := gk.str.SelectOrgRole(ctx, orgKey, privateUserKey); err != nil {
+		return nil, err
+	}
+
+	result, err := gk.str.SelectMembers(ctx, orgKey)
+	if err != nil {
+		return nil, err
+	}
+
+	resultBytes, err := json.Marshal(result)
+	if err != nil {
+		return nil, err
+	}
+	return resultBytes, nil
+}
+
+// AddMember приглашает зарегистрированного пользователя в организацию или меняет его роль.
+func (gk *GophKeeper) AddMember(ctx context.Context, key string, body []byte, privateUserKey uuid.UUID) ([]byte, error) {
+	ctx, span := startSpan(ctx, "AddMember")
+	defer span.End()
+
+	var member model.OrgMember
+	err := json.Unmarshal(body, &member)
+	if err != nil {
+		return nil, err
+	}
+	if _, ok := roleRank[member.Role]; !ok {
+		return nil, fmt.Errorf("unknown role: %q", member.Role)
+	}
+
+	orgKey, err := uuid.Parse(key)
+	if err != nil {
+		return nil, err
+	}
+
+	current, err := gk.memberRole(ctx, orgKey, member.Login)
+	if err != nil {
+		return nil, err
+	}
+	if err := gk.checkManage(ctx, orgKey, privateUserKey, member.Role, current); err != nil {
+		return nil, err
+	}
+
+	result, err := gk.str.UpsertMember(ctx, orgKey, member)
+	if err != nil {
+		return nil, err
+	}
+
+	resultBytes, err := json.Marshal(result)
+	if err != nil {
+		return nil, err
+	}
+	return resultBytes, nil
+}
+
+// RemoveMember исключает участника из организации. Последнего владельца исключить нельзя.
+func (gk *GophKeeper) RemoveMember(ctx context.Context, key, login string, privateUserKey uuid.UUID) error {
+	ctx, span := startSpan(ctx, "RemoveMember")
+	defer span.End()
+
+	orgKey, err := uuid.Parse(key)
+	if err != nil {
+		return err
+	}
+
+	current, err := gk.memberRole(ctx, orgKey, login)
+	if err != nil {
+		return err
+	}
+	if current == "" {
+		return cerrors.ErrNotFound
+	}
+	if err := gk.checkManage(ctx, orgKey, privateUserKey, current, ""); err != nil {
+		return err
+	}
+
+	return gk.str.DeleteMember(ctx, orgKey, login)
+}
+
+// CreateVault создаёт хранилище в организации. Доступно администраторам и владельцам.
+func (gk *GophKeeper) CreateVault(ctx context.Context, key string, body []byte, privateUserKey uuid.UUID) ([]byte, error) {
+	ctx, span := startSpan(ctx, "CreateVault")
+	defer span.End()
+
+	var vault model.Vault
+	err := json.Unmarshal(body, &vault)
+	if err != nil {
+		return nil, err
+	}
+	if vault.Name == "" {
+		return nil, fmt.Errorf("vault name is empty")
+	}
+
+	orgKey, err := uuid.Parse(key)
+	if err != nil {
+		return nil, err
+	}
+
+	role, err := gk.str.SelectOrgRole(ctx, orgKey, privateUserKey)
+	if err != nil {
+		return nil, err
+	}
+	if roleRank[role] < roleRank[model.RoleAdmin] {
+		return nil, cerrors.ErrForbidden
+	}
+
+	result, err := gk.str.InsertVault(ctx, orgKey, vault.Name)
+	if err != nil {
+		return nil, err
+	}
+	result.Role = role
+
+	resultBytes, err := json.Marshal(result)
+	if err != nil {
+		return nil, err
+	}
+	return resultBytes, nil
+}
+
+// SelectVaults возвращает хранилища, доступные пользователю.
+func (gk *GophKeeper) SelectVaults(ctx context.Context, privateUserKey uuid.UUID) ([]byte, error) {
+	ctx, span := startSpan(ctx, "SelectVaults")
+	defer span.End()
+
+	result, err := gk.str.SelectVaults(ctx, privateUserKey)
+	if err != nil {
+		return nil, err
+	}
+
+	resultBytes, err := json.Marshal(result)
+	if err != nil {
+		return nil, err
+	}
+	return resultBytes, nil
+}
+
+// memberRole возвращает текущую роль участника с логином login (пустую, если он не участник).
+// Последнего владельца от исключения и смены роли защищает хранилище: количество владельцев
+// проверяется в той же транзакции, что и изменение.
+func (gk *GophKeeper) memberRole(ctx context.Context, orgKey uuid.UUID, login string) (string, error) {
+	members, err := gk.str.SelectMembers(ctx, orgKey)
+	if err != nil {
+		return "", err
+	}
+
+	for _, member := range members {
+		if member.Login == login {
+			return member.Role, nil
+		}
+	}
+	return "", nil
+}
+
+// checkManage проверяет, что пользователь может назначить участнику роль role,
+// если у того уже есть роль current.
+func (gk *GophKeeper) checkManage(ctx context.Context, orgKey, privateUserKey uuid.UUID, role, current string) error {
+	actor, err := gk.str.SelectOrgRole(ctx, orgKey, privateUserKey)
+	if err != nil {
+		return err
+	}
+	if !canManage(actor, role) || (current != "" && !canManage(actor, current)) {
+		return cerrors.ErrForbidden
+	}
+
+	return nil
+}
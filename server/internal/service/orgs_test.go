@@ -0,0 +1,33 @@
+package service
+
+import (
+	"context"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+	"server/internal/cerrors"
+	"server/internal/model"
+	"testing"
+)
+
+// Случаи, в которых роль участника не запрашивается из хранилища.
+func TestAuthorizeOwnerWithoutStorage(t *testing.T) {
+	owner := uuid.New()
+
+	tests := []struct {
+		name    string
+		ctx     context.Context
+		wantErr error
+	}{
+		{name: "no actor", ctx: context.Background(), wantErr: cerrors.ErrForbidden},
+		{name: "owner only as user", ctx: SetCurrentUserID(context.Background(), owner), wantErr: cerrors.ErrForbidden},
+		{name: "owner acts", ctx: SetCurrentActor(context.Background(), owner)},
+	}
+
+	gk := &GophKeeper{}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := gk.authorizeOwner(tt.ctx, owner, model.RoleViewer)
+			require.ErrorIs(t, err, tt.wantErr)
+		})
+	}
+}
@@ -0,0 +1,48 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/google/uuid"
+	"server/internal/model"
+	"strconv"
+)
+
+const (
+	defaultExpiringDays = 30  // за сколько дней показываются истекающие записи, если days не указан
+	maxExpiringDays     = 366 // наибольший горизонт отчёта об истекающих записях
+)
+
+// SelectExpiring возвращает записи владельца, срок действия которых истекает в ближайшие days дней.
+func (gk *GophKeeper) SelectExpiring(ctx context.Context, days string, ownerKey uuid.UUID) ([]byte, error) {
+	ctx, span := startSpan(ctx, "SelectExpiring")
+	defer span.End()
+
+	if err := gk.authorizeOwner(ctx, ownerKey, model.RoleViewer); err != nil {
+		return nil, err
+	}
+
+	count := defaultExpiringDays
+	if days != "" {
+		var err error
+		count, err = strconv.Atoi(days)
+		if err != nil {
+			return nil, err
+		}
+		if count < 0 || count > maxExpiringDays {
+			return nil, fmt.Errorf("days must be between 0 and %d", maxExpiringDays)
+		}
+	}
+
+	result, err := gk.str.SelectExpiring(ctx, ownerKey, count)
+	if err != nil {
+		return nil, err
+	}
+
+	resultBytes, err := json.Marshal(result)
+	if err != nil {
+		return nil, err
+	}
+	return resultBytes, nil
+}
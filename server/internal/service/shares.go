@@ -0,0 +1,131 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/google/uuid"
+	"server/internal/cerrors"
+	"server/internal/model"
+)
+
+// recordOwner возвращает владельца записи, если у пользователя есть к ней доступ.
+// Для изменения записи получателю нужны права PermissionWrite.
+func (gk *GophKeeper) recordOwner(ctx context.Context, recordType string, key, privateUserKey uuid.UUID, write bool) (uuid.UUID, error) {
+	owner, permission, err := gk.str.SelectAccess(ctx, recordType, key, privateUserKey)
+	if err != nil {
+		return uuid.Nil, err
+	}
+	if owner != privateUserKey && write && permission != model.PermissionWrite {
+		return uuid.Nil, cerrors.ErrForbidden
+	}
+
+	return owner, nil
+}
+
+// ShareRecord выдаёт другому пользователю доступ к записи владельца.
+func (gk *GophKeeper) ShareRecord(ctx context.Context, recordType, key string, body []byte, ownerKey uuid.UUID) ([]byte, error) {
+	ctx, span := startSpan(ctx, "ShareRecord")
+	defer span.End()
+
+	if err := gk.authorizeOwner(ctx, ownerKey, model.RoleAdmin); err != nil {
+		return nil, err
+	}
+
+	var share model.Share
+	err := json.Unmarshal(body, &share)
+	if err != nil {
+		return nil, err
+	}
+
+	if share.Permission != model.PermissionRead && share.Permission != model.PermissionWrite {
+		return nil, fmt.Errorf("unknown permission: %q", share.Permission)
+	}
+
+	share.Type, share.OwnerKey = recordType, ownerKey
+	share.RecordKey, err = uuid.Parse(key)
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := gk.str.InsertShare(ctx, share)
+	if err != nil {
+		return nil, err
+	}
+
+	resultBytes, err := json.Marshal(result)
+	if err != nil {
+		return nil, err
+	}
+	return resultBytes, nil
+}
+
+// SelectShares возвращает получателей записи владельца.
+func (gk *GophKeeper) SelectShares(ctx context.Context, recordType, key string, ownerKey uuid.UUID) ([]byte, error) {
+	ctx, span := startSpan(ctx, "SelectShares")
+	defer span.End()
+
+	if err := gk.authorizeOwner(ctx, ownerKey, model.RoleViewer); err != nil {
+		return nil, err
+	}
+
+	recordKey, err := uuid.Parse(key)
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := gk.str.SelectShares(ctx, recordType, recordKey, ownerKey)
+	if err != nil {
+		return nil, err
+	}
+
+	resultBytes, err := json.Marshal(result)
+	if err != nil {
+		return nil, err
+	}
+	return resultBytes, nil
+}
+
+// RevokeShare отзывает доступ получателя. Следующий же запрос получателя к записи будет отклонён.
+func (gk *GophKeeper) RevokeShare(ctx context.Context, recordType, key, login string, ownerKey uuid.UUID) error {
+	ctx, span := startSpan(ctx, "RevokeShare")
+	defer span.End()
+
+	if err := gk.authorizeOwner(ctx, ownerKey, model.RoleAdmin); err != nil {
+		return err
+	}
+
+	recordKey, err := uuid.Parse(key)
+	if err != nil {
+		return err
+	}
+
+	recipient, err := gk.str.DeleteShare(ctx, recordType, recordKey, ownerKey, login)
+	if err != nil {
+		return err
+	}
+	gk.publishChange(recipient, recordType, recordKey, model.ChangeDeleted, 0)
+
+	return nil
+}
+
+// SelectSharedRecords возвращает записи других пользователей, доступные пользователю.
+func (gk *GophKeeper) SelectSharedRecords(ctx context.Context, privateUserKey uuid.UUID) ([]byte, error) {
+	ctx, span := startSpan(ctx, "SelectSharedRecords")
+	defer span.End()
+
+	if err := gk.authorizeOwner(ctx, privateUserKey, model.RoleViewer); err != nil {
+		return nil, err
+	}
+
+	result, err := gk.str.SelectSharedRecords(ctx, privateUserKey)
+	if err != nil {
+		return nil, err
+	}
+
+	resultBytes, err := json.Marshal(result)
+	if err != nil {
+		return nil, err
+	}
+	return resultBytes, nil
+}
@@ -0,0 +1,62 @@
+package service
+
+import (
+	"encoding/base64"
+	"server/internal/cerrors"
+	"server/internal/model"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// maxSSHPrivateKey ограничивает размер закрытого ключа; ключи RSA 16384 бит в PEM занимают около 13 КБ.
+const maxSSHPrivateKey = 16 * 1024
+
+// maxSSHComment — наибольшая длина комментария ключа.
+const maxSSHComment = 200
+
+// validateSSHKey проверяет форму ключа SSH. Разбор ключа и вычисление отпечатка выполняет клиент,
+// поэтому сервер только убеждается, что поля похожи на ключ в формате PEM, строку authorized_keys
+// и отпечаток SHA256. Зашифрованные клиентом значения не проверяются.
+func validateSSHKey(data *model.DataSSHKey) error {
+	errs := &cerrors.ValidationError{}
+
+	switch {
+	case data.PrivateKey == "":
+		errs.Add("private_key", "required")
+	case strings.HasPrefix(data.PrivateKey, e2ePrefix):
+	case len(data.PrivateKey) > maxSSHPrivateKey:
+		errs.Add("private_key", "must be at most "+strconv.Itoa(maxSSHPrivateKey)+" bytes")
+	case !strings.Contains(data.PrivateKey, "-----BEGIN ") || !strings.Contains(data.PrivateKey, "PRIVATE KEY-----"):
+		errs.Add("private_key", "must be a PEM encoded private key")
+	}
+
+	data.PublicKey = strings.TrimSpace(data.PublicKey)
+	if data.PublicKey != "" && !validAuthorizedKey(data.PublicKey) {
+		errs.Add("public_key", "must be in authorized_keys format")
+	}
+
+	data.Fingerprint = strings.TrimSpace(data.Fingerprint)
+	if data.Fingerprint != "" && !strings.HasPrefix(data.Fingerprint, "SHA256:") {
+		errs.Add("fingerprint", "must be a SHA256 fingerprint")
+	}
+
+	data.Comment = strings.TrimSpace(data.Comment)
+	if utf8.RuneCountInString(data.Comment) > maxSSHComment {
+		errs.Add("comment", "must be at most "+strconv.Itoa(maxSSHComment)+" characters")
+	}
+
+	return errs.OrNil()
+}
+
+// validAuthorizedKey проверяет строку вида "ssh-ed25519 AAAA... комментарий".
+func validAuthorizedKey(line string) bool {
+	fields := strings.Fields(line)
+	if len(fields) < 2 {
+		return false
+	}
+	if _, err := base64.StdEncoding.DecodeString(fields[1]); err != nil {
+		return false
+	}
+	return strings.HasPrefix(fields[0], "ssh-") || strings.HasPrefix(fields[0], "ecdsa-") || strings.HasPrefix(fields[0], "sk-")
+}
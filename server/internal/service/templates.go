@@ -0,0 +1,268 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/google/uuid"
+	"math"
+	"net/url"
+	"regexp"
+	"server/internal/cerrors"
+	"server/internal/model"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// maxTemplateName — наибольшая длина названия шаблона и подписи поля.
+const maxTemplateName = 100
+
+// maxTemplateFields ограничивает число полей шаблона.
+const maxTemplateFields = 50
+
+// maxCustomValue — наибольший размер значения поля пользовательской записи в байтах.
+// Файлы хранятся отдельными бинарными записями, поэтому длинные значения не нужны.
+const maxCustomValue = 8 * 1024
+
+// customDate — формат значений полей типа date.
+const customDate = "2006-01-02"
+
+// templateFieldName — допустимое имя поля: его используют в командах клиента как --set name=value.
+var templateFieldName = regexp.MustCompile(`^[a-z][a-z0-9_]{0,63}$`)
+
+// templateFieldTypes — поддерживаемые типы полей шаблона.
+var templateFieldTypes = map[string]bool{
+	model.FieldString: true,
+	model.FieldSecret: true,
+	model.FieldURL:    true,
+	model.FieldDate:   true,
+	model.FieldNumber: true,
+	model.FieldTOTP:   true,
+	model.FieldFile:   true,
+}
+
+// CreateTemplate создаёт шаблон пользователя или, если задан org_key, организации.
+// Шаблоны организации создают участники с ролью admin и выше.
+func (gk *GophKeeper) CreateTemplate(ctx context.Context, body []byte, privateUserKey uuid.UUID) ([]byte, error) {
+	ctx, span := startSpan(ctx, "CreateTemplate")
+	defer span.End()
+
+	var template model.RecordTemplate
+	err := json.Unmarshal(body, &template)
+	if err != nil {
+		return nil, err
+	}
+	if err := validateTemplate(&template); err != nil {
+		return nil, err
+	}
+
+	if template.OrgKey != nil {
+		if err := gk.authorizeOrgTemplate(ctx, *template.OrgKey, privateUserKey); err != nil {
+			return nil, err
+		}
+	}
+
+	result, err := gk.str.InsertTemplate(ctx, template, privateUserKey)
+	if err != nil {
+		return nil, err
+	}
+
+	resultBytes, err := json.Marshal(result)
+	if err != nil {
+		return nil, err
+	}
+	return resultBytes, nil
+}
+
+// SelectTemplates возвращает шаблоны пользователя и его организаций.
+func (gk *GophKeeper) SelectTemplates(ctx context.Context, privateUserKey uuid.UUID) ([]byte, error) {
+	ctx, span := startSpan(ctx, "SelectTemplates")
+	defer span.End()
+
+	result, err := gk.str.SelectTemplates(ctx, privateUserKey)
+	if err != nil {
+		return nil, err
+	}
+
+	resultBytes, err := json.Marshal(result)
+	if err != nil {
+		return nil, err
+	}
+	return resultBytes, nil
+}
+
+// DeleteTemplate удаляет шаблон, по которому не осталось записей.
+// Шаблоны организации удаляют участники с ролью admin и выше.
+func (gk *GophKeeper) DeleteTemplate(ctx context.Context, key string, privateUserKey uuid.UUID) error {
+	ctx, span := startSpan(ctx, "DeleteTemplate")
+	defer span.End()
+
+	templateKey, err := uuid.Parse(key)
+	if err != nil {
+		return err
+	}
+
+	template, err := gk.str.SelectTemplate(ctx, templateKey, privateUserKey)
+	if err != nil {
+		return err
+	}
+	if template.OrgKey != nil {
+		if err := gk.authorizeOrgTemplate(ctx, *template.OrgKey, privateUserKey); err != nil {
+			return err
+		}
+	}
+
+	return gk.str.DeleteTemplate(ctx, templateKey)
+}
+
+// authorizeOrgTemplate проверяет, что пользователь может управлять шаблонами организации.
+func (gk *GophKeeper) authorizeOrgTemplate(ctx context.Context, orgKey, privateUserKey uuid.UUID) error {
+	role, err := gk.str.SelectOrgRole(ctx, orgKey, privateUserKey)
+	if err != nil {
+		return err
+	}
+	if roleRank[role] < roleRank[model.RoleAdmin] {
+		return cerrors.ErrForbidden
+	}
+	return nil
+}
+
+// customTemplate возвращает шаблон записи, доступный владельцу записей ownerKey.
+// Неизвестный или чужой шаблон считается ошибкой в теле запроса.
+func (gk *GophKeeper) customTemplate(ctx context.Context, templateKey, ownerKey uuid.UUID) (model.RecordTemplate, error) {
+	errs := &cerrors.ValidationError{}
+	if templateKey == uuid.Nil {
+		errs.Add("template_key", "required")
+		return model.RecordTemplate{}, errs.OrNil()
+	}
+
+	template, err := gk.str.SelectTemplate(ctx, templateKey, ownerKey)
+	if errors.Is(err, cerrors.ErrNotFound) {
+		errs.Add("template_key", "unknown template")
+		return model.RecordTemplate{}, errs.OrNil()
+	}
+	return template, err
+}
+
+// validateTemplate проверяет шаблон: название, число полей, уникальность и формат имён полей, их типы.
+func validateTemplate(template *model.RecordTemplate) error {
+	errs := &cerrors.ValidationError{}
+
+	template.Name = strings.TrimSpace(template.Name)
+	switch {
+	case template.Name == "":
+		errs.Add("name", "required")
+	case utf8.RuneCountInString(template.Name) > maxTemplateName:
+		errs.Add("name", "must be at most "+strconv.Itoa(maxTemplateName)+" characters")
+	}
+
+	if len(template.Fields) == 0 || len(template.Fields) > maxTemplateFields {
+		errs.Add("fields", "must contain from 1 to "+strconv.Itoa(maxTemplateFields)+" fields")
+	}
+
+	seen := make(map[string]bool, len(template.Fields))
+	for i := range template.Fields {
+		field := &template.Fields[i]
+		prefix := "fields." + strconv.Itoa(i) + "."
+
+		switch {
+		case !templateFieldName.MatchString(field.Name):
+			errs.Add(prefix+"name", "must start with a letter and contain only a-z, 0-9 and _")
+		case seen[field.Name]:
+			errs.Add(prefix+"name", "duplicate field name")
+		}
+		seen[field.Name] = true
+
+		if !templateFieldTypes[field.Type] {
+			errs.Add(prefix+"type", "must be string, secret, url, date, number, totp or file")
+		}
+
+		field.Label = strings.TrimSpace(field.Label)
+		if utf8.RuneCountInString(field.Label) > maxTemplateName {
+			errs.Add(prefix+"label", "must be at most "+strconv.Itoa(maxTemplateName)+" characters")
+		}
+	}
+
+	return errs.OrNil()
+}
+
+// validateCustom проверяет значения полей записи по шаблону: обязательные поля заполнены,
+// неизвестных полей нет, значения соответствуют типам. Пустые значения отбрасываются,
+// секреты TOTP приводятся к каноническому виду. Поля file должны ссылаться на бинарную запись,
+// доступную пользователю. Значения, зашифрованные клиентом, не проверяются.
+func (gk *GophKeeper) validateCustom(ctx context.Context, data *model.DataCustom, template model.RecordTemplate, privateUserKey uuid.UUID) error {
+	errs := &cerrors.ValidationError{}
+
+	types := make(map[string]string, len(template.Fields))
+	for _, field := range template.Fields {
+		types[field.Name] = field.Type
+	}
+	for name := range data.Fields {
+		if _, ok := types[name]; !ok {
+			errs.Add("fields."+name, "unknown field")
+		}
+	}
+
+	for _, field := range template.Fields {
+		name := "fields." + field.Name
+		value := data.Fields[field.Name]
+		if field.Type != model.FieldSecret {
+			value = strings.TrimSpace(value)
+		}
+		if value == "" {
+			delete(data.Fields, field.Name)
+			if field.Required {
+				errs.Add(name, "required")
+			}
+			continue
+		}
+		if strings.HasPrefix(value, e2ePrefix) && (field.Type == model.FieldSecret || field.Type == model.FieldTOTP) {
+			continue
+		}
+		if len(value) > maxCustomValue {
+			errs.Add(name, "must be at most "+strconv.Itoa(maxCustomValue)+" bytes")
+			continue
+		}
+
+		switch field.Type {
+		case model.FieldURL:
+			u, err := url.Parse(value)
+			if err != nil || u.Scheme == "" || u.Host == "" {
+				errs.Add(name, "must be an absolute URL")
+			}
+		case model.FieldDate:
+			if _, err := time.Parse(customDate, value); err != nil {
+				errs.Add(name, "must be a date in YYYY-MM-DD format")
+			}
+		case model.FieldNumber:
+			number, err := strconv.ParseFloat(value, 64)
+			if err != nil || math.IsNaN(number) || math.IsInf(number, 0) {
+				errs.Add(name, "must be a number")
+			}
+		case model.FieldTOTP:
+			secret, err := normalizeTOTPSecret(value)
+			if err != nil {
+				errs.Add(name, err.Error())
+			}
+			value = secret
+		case model.FieldFile:
+			key, err := uuid.Parse(value)
+			if err != nil {
+				errs.Add(name, "must be a binary record key")
+				break
+			}
+			_, err = gk.recordOwner(ctx, model.RecordBinary, key, privateUserKey, false)
+			if errors.Is(err, cerrors.ErrNotFound) || errors.Is(err, cerrors.ErrForbidden) {
+				errs.Add(name, "must reference an existing binary record")
+			} else if err != nil {
+				return err
+			}
+			value = key.String()
+		}
+		data.Fields[field.Name] = value
+	}
+
+	return errs.OrNil()
+}
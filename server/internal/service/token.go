@@ -6,34 +6,60 @@ import (
 	"errors"
 	"fmt"
 	"github.com/golang-jwt/jwt/v4"
+	"sync/atomic"
 	"time"
 )
 
 // Длительность жизни JWT-токена (24 часа).
 const tokenEXP = time.Hour * 24
 
-// Секретный ключ для подписи JWT-токенов.
-const secretKey = "supersecret"
+// errNoSigningKeys возвращается, если ключи подписи JWT не заданы: встроенного ключа нет,
+// потому что ключ из открытого репозитория позволил бы подделывать сессии.
+var errNoSigningKeys = errors.New("JWT signing keys are not configured")
+
+// signingKeys содержит ключи подписи JWT. Список заменяется целиком при перезагрузке настроек.
+var signingKeys atomic.Pointer[[]string]
+
+// SetSigningKeys задаёт ключи подписи JWT: новые токены подписываются первым ключом,
+// а принимаются токены, подписанные любым из них. Пустой список отключает выдачу и проверку
+// токенов; настройки с пустым списком не проходят config.Validate.
+func SetSigningKeys(keys []string) {
+	keys = append([]string(nil), keys...)
+	signingKeys.Store(&keys)
+}
+
+func currentKeys() []string {
+	if keys := signingKeys.Load(); keys != nil {
+		return *keys
+	}
+	return nil
+}
 
 // Token описывает структуру JWT-токена с полем UserID.
+// Идентификатор сессии хранится в стандартном поле ID (jti).
 type Token struct {
 	jwt.RegisteredClaims
 	UserKey       string `json:"user_key"`
 	EncryptionKey string `json:"encryption_key"`
 }
 
-// NewToken создает и возвращает новый JWT-токен с указанным userID.
+// NewToken создает и возвращает новый JWT-токен с указанным userID и идентификатором сессии.
 // Возвращает строку с токеном или ошибку.
-func NewToken(userKey, encryptionKey string) (string, error) {
+func NewToken(userKey, sessionKey, encryptionKey string) (string, error) {
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, Token{
 		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        sessionKey,
 			ExpiresAt: jwt.NewNumericDate(time.Now().Add(tokenEXP)),
 		},
 		UserKey:       userKey,
 		EncryptionKey: encryptionKey,
 	})
 
-	tokenString, err := token.SignedString([]byte(secretKey))
+	keys := currentKeys()
+	if len(keys) == 0 {
+		return "", errNoSigningKeys
+	}
+	tokenString, err := token.SignedString([]byte(keys[0]))
 	if err != nil {
 		return "", err
 	}
@@ -41,23 +67,36 @@ func NewToken(userKey, encryptionKey string) (string, error) {
 	return tokenString, nil
 }
 
-// ReadToken проверяет валидность JWT-токена и возвращает UserID из токена.
-// Возвращает UserID и ошибку, если токен недействителен.
-func ReadToken(cookValue string) (string, error) {
-	token := &Token{}
-
-	res, err := jwt.ParseWithClaims(cookValue, token, func(t *jwt.Token) (interface{}, error) {
-		return []byte(secretKey), nil
-	})
+// ReadToken проверяет валидность JWT-токена и возвращает его содержимое.
+// Подпись проверяется каждым ключом из SetSigningKeys, чтобы смена ключа не завершала сессии.
+// Возвращает ошибку, если токен недействителен.
+func ReadToken(cookValue string) (*Token, error) {
+	var (
+		token *Token
+		res   *jwt.Token
+		err   = errNoSigningKeys
+	)
+	for _, key := range currentKeys() {
+		token = &Token{}
+		res, err = jwt.ParseWithClaims(cookValue, token, func(t *jwt.Token) (interface{}, error) {
+			if _, ok := t.Method.(*jwt.SigningMethodHMAC); !ok {
+				return nil, fmt.Errorf("unexpected signing method: %v", t.Header["alg"])
+			}
+			return []byte(key), nil
+		})
+		if !errors.Is(err, jwt.ErrTokenSignatureInvalid) {
+			break
+		}
+	}
 	if err != nil {
-		return "", err
+		return nil, err
 	}
 
 	if !res.Valid {
-		return "", errors.New("Token is not valid")
+		return nil, errors.New("Token is not valid")
 	}
 
-	return token.UserKey, nil
+	return token, nil
 }
 
 // GenerateEncryptionKey создает криптографически безопасный ключ шифрования.
@@ -0,0 +1,150 @@
+package service
+
+import (
+	"encoding/base32"
+	"errors"
+	"net/url"
+	"server/internal/cerrors"
+	"server/internal/model"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// e2ePrefix отмечает секрет, зашифрованный клиентом: сервер не может его прочитать и не проверяет.
+const e2ePrefix = "e2e:"
+
+// minTOTPSecret — наименьшая длина секрета в байтах (80 бит), которую выдают сервисы с 2FA.
+const minTOTPSecret = 10
+
+// maxTOTPPeriod ограничивает срок действия кода в секундах.
+const maxTOTPPeriod = 300
+
+// maxTOTPLabel — наибольшая длина названия сервиса и имени учётной записи.
+const maxTOTPLabel = 200
+
+// Значения TOTP по умолчанию из RFC 6238 и формата ссылок otpauth://.
+const (
+	defaultTOTPDigits = 6
+	defaultTOTPPeriod = 30
+)
+
+// totpEncoding декодирует секрет base32 без выравнивания "=", как его записывают в otpauth://.
+var totpEncoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// validateTOTP проверяет запись TOTP и приводит её к каноническому виду: параметры из ссылки
+// otpauth:// переносятся в поля, секрет записывается заглавными буквами без пробелов,
+// пустые алгоритм, число цифр и период заменяются значениями по умолчанию.
+// Ссылка после разбора очищается, чтобы секрет не попал в конфликтные копии в открытом виде.
+func validateTOTP(data *model.DataTOTP) error {
+	errs := &cerrors.ValidationError{}
+
+	if data.URI != "" {
+		if err := applyOTPAuthURI(data, data.URI); err != nil {
+			errs.Add("uri", err.Error())
+		}
+		data.URI = ""
+	}
+
+	if !strings.HasPrefix(data.Secret, e2ePrefix) {
+		secret, err := normalizeTOTPSecret(data.Secret)
+		if err != nil {
+			errs.Add("secret", err.Error())
+		}
+		data.Secret = secret
+	}
+
+	data.Algorithm = strings.ToUpper(data.Algorithm)
+	switch data.Algorithm {
+	case "":
+		data.Algorithm = model.TOTPSHA1
+	case model.TOTPSHA1, model.TOTPSHA256, model.TOTPSHA512:
+	default:
+		errs.Add("algorithm", "must be SHA1, SHA256 or SHA512")
+	}
+
+	if data.Digits == 0 {
+		data.Digits = defaultTOTPDigits
+	}
+	if data.Digits < 6 || data.Digits > 8 {
+		errs.Add("digits", "must be between 6 and 8")
+	}
+
+	if data.Period == 0 {
+		data.Period = defaultTOTPPeriod
+	}
+	if data.Period < 1 || data.Period > maxTOTPPeriod {
+		errs.Add("period", "must be between 1 and "+strconv.Itoa(maxTOTPPeriod)+" seconds")
+	}
+
+	data.Issuer = strings.TrimSpace(data.Issuer)
+	if utf8.RuneCountInString(data.Issuer) > maxTOTPLabel {
+		errs.Add("issuer", "must be at most "+strconv.Itoa(maxTOTPLabel)+" characters")
+	}
+	data.Account = strings.TrimSpace(data.Account)
+	if utf8.RuneCountInString(data.Account) > maxTOTPLabel {
+		errs.Add("account", "must be at most "+strconv.Itoa(maxTOTPLabel)+" characters")
+	}
+
+	return errs.OrNil()
+}
+
+// normalizeTOTPSecret записывает секрет base32 заглавными буквами без пробелов, дефисов
+// и выравнивания и проверяет, что он декодируется и не короче 80 бит.
+func normalizeTOTPSecret(raw string) (string, error) {
+	secret := strings.ToUpper(strings.NewReplacer(" ", "", "-", "").Replace(raw))
+	secret = strings.TrimRight(secret, "=")
+	decoded, err := totpEncoding.DecodeString(secret)
+	switch {
+	case secret == "":
+		return secret, errors.New("required")
+	case err != nil:
+		return secret, errors.New("must be base32 encoded")
+	case len(decoded) < minTOTPSecret:
+		return secret, errors.New("must be at least 80 bits long")
+	}
+	return secret, nil
+}
+
+// applyOTPAuthURI переносит в запись параметры ссылки вида
+// otpauth://totp/Issuer:account?secret=...&issuer=...&algorithm=...&digits=...&period=...
+func applyOTPAuthURI(data *model.DataTOTP, raw string) error {
+	u, err := url.Parse(raw)
+	if err != nil || u.Scheme != "otpauth" {
+		return errors.New("must be an otpauth:// URI")
+	}
+	if !strings.EqualFold(u.Host, "totp") {
+		return errors.New("only totp URIs are supported")
+	}
+
+	label := strings.TrimPrefix(u.Path, "/")
+	if issuer, account, ok := strings.Cut(label, ":"); ok {
+		data.Issuer, data.Account = issuer, strings.TrimSpace(account)
+	} else if label != "" {
+		data.Account = label
+	}
+
+	query := u.Query()
+	if secret := query.Get("secret"); secret != "" {
+		data.Secret = secret
+	}
+	// Параметр issuer главнее префикса метки.
+	if issuer := query.Get("issuer"); issuer != "" {
+		data.Issuer = issuer
+	}
+	if algorithm := query.Get("algorithm"); algorithm != "" {
+		data.Algorithm = algorithm
+	}
+	if digits := query.Get("digits"); digits != "" {
+		if data.Digits, err = strconv.Atoi(digits); err != nil {
+			return errors.New("digits must be a number")
+		}
+	}
+	if period := query.Get("period"); period != "" {
+		if data.Period, err = strconv.Atoi(period); err != nil {
+			return errors.New("period must be a number")
+		}
+	}
+
+	return nil
+}
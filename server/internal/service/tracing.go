@@ -0,0 +1,15 @@
+package service
+
+import (
+	"context"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// tracer создаёт спаны методов сервиса. До настройки трассировки спаны не записываются.
+var tracer = otel.Tracer("server/internal/service")
+
+// startSpan открывает спан метода сервиса GophKeeper.
+func startSpan(ctx context.Context, method string) (context.Context, trace.Span) {
+	return tracer.Start(ctx, "GophKeeper."+method)
+}
@@ -0,0 +1,73 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/google/uuid"
+	"server/internal/model"
+)
+
+// SelectTrash возвращает записи из корзины пользователя.
+func (gk *GophKeeper) SelectTrash(ctx context.Context, privateUserKey uuid.UUID) ([]byte, error) {
+	ctx, span := startSpan(ctx, "SelectTrash")
+	defer span.End()
+
+	if err := gk.authorizeOwner(ctx, privateUserKey, model.RoleViewer); err != nil {
+		return nil, err
+	}
+
+	result, err := gk.str.SelectTrash(ctx, privateUserKey)
+	if err != nil {
+		return nil, err
+	}
+
+	resultBytes, err := json.Marshal(result)
+	if err != nil {
+		return nil, err
+	}
+	return resultBytes, nil
+}
+
+// RestoreTrash возвращает запись из корзины и отдаёт восстановленную запись.
+func (gk *GophKeeper) RestoreTrash(ctx context.Context, recordType, key string, privateUserKey uuid.UUID) ([]byte, error) {
+	ctx, span := startSpan(ctx, "RestoreTrash")
+	defer span.End()
+
+	if err := gk.authorizeOwner(ctx, privateUserKey, model.RoleEditor); err != nil {
+		return nil, err
+	}
+
+	recordKey, err := uuid.Parse(key)
+	if err != nil {
+		return nil, err
+	}
+
+	revision, err := gk.str.RestoreTrash(ctx, recordType, recordKey, privateUserKey)
+	if err != nil {
+		return nil, err
+	}
+	gk.publishChange(privateUserKey, recordType, recordKey, model.ChangeUpdated, revision)
+
+	return gk.currentRecord(ctx, recordType, recordKey, privateUserKey)
+}
+
+// EmptyTrash окончательно очищает корзину пользователя и возвращает количество очищенных записей.
+func (gk *GophKeeper) EmptyTrash(ctx context.Context, privateUserKey uuid.UUID) ([]byte, error) {
+	ctx, span := startSpan(ctx, "EmptyTrash")
+	defer span.End()
+
+	if err := gk.authorizeOwner(ctx, privateUserKey, model.RoleAdmin); err != nil {
+		return nil, err
+	}
+
+	count, err := gk.str.EmptyTrash(ctx, privateUserKey)
+	if err != nil {
+		return nil, err
+	}
+
+	resultBytes, err := json.Marshal(model.TrashPurged{Purged: count})
+	if err != nil {
+		return nil, err
+	}
+	return resultBytes, nil
+}
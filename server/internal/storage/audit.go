@@ -0,0 +1,340 @@
+package storage
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/google/uuid"
+	"io"
+	"server/internal/model"
+	"strconv"
+	"sync/atomic"
+	"time"
+)
+
+// Алгоритмы цепочки журнала, см. столбец audit_log.chain.
+const (
+	auditChainSHA256 = "sha256" // общая цепочка без ключа, записи до появления HMAC
+	auditChainHMAC   = "hmac"   // цепочка владельца с HMAC-SHA256
+)
+
+// errNoAuditKey возвращается, если ключ HMAC журнала аудита не задан.
+var errNoAuditKey = errors.New("audit key is not configured")
+
+// auditKey — ключ HMAC цепочек журнала аудита.
+var auditKey atomic.Pointer[[]byte]
+
+// SetAuditKey задаёт ключ HMAC цепочек журнала аудита. Ключ не меняют: записи,
+// подписанные прежним ключом, перестанут проходить проверку при выгрузке.
+func SetAuditKey(key string) {
+	raw := []byte(key)
+	auditKey.Store(&raw)
+}
+
+func currentAuditKey() []byte {
+	if key := auditKey.Load(); key != nil && len(*key) > 0 {
+		return *key
+	}
+	return nil
+}
+
+// InsertAudit добавляет запись в конец цепочки журнала аудита её владельца.
+// Если запись относится к записи хранилища, владельцем становится владелец этой записи,
+// чтобы он видел обращения получателей общего доступа. Блокируется только голова
+// цепочки владельца, поэтому обращения разных пользователей не ждут друг друга.
+func (pstg *PostgreSQL) InsertAudit(ctx context.Context, entry model.AuditEntry) error {
+	key := currentAuditKey()
+	if key == nil {
+		return errNoAuditKey
+	}
+
+	return pstg.inTx(ctx, func(tx *sql.Tx) error {
+		if table, ok := recordTables[entry.RecordType]; ok && entry.RecordKey != nil {
+			query := fmt.Sprintf(`SELECT private_user_key FROM %s WHERE %s = $1`, table.name, table.key)
+			err := tx.QueryRowContext(ctx, query, *entry.RecordKey).Scan(&entry.OwnerKey)
+			if err != nil && !errors.Is(err, sql.ErrNoRows) {
+				return err
+			}
+		}
+
+		_, err := tx.ExecContext(ctx, `INSERT INTO audit_heads (owner_key, audit_id, hash, mac) VALUES ($1, 0, '', '')
+                                       ON CONFLICT (owner_key) DO NOTHING`, entry.OwnerKey)
+		if err != nil {
+			return err
+		}
+
+		var headID int64
+		var headMAC string
+		err = tx.QueryRowContext(ctx, `SELECT audit_id, hash, mac FROM audit_heads WHERE owner_key = $1 FOR UPDATE`,
+			entry.OwnerKey).Scan(&headID, &entry.PrevHash, &headMAC)
+		if err != nil {
+			return err
+		}
+		if headID != 0 && !hmac.Equal([]byte(headMAC), []byte(auditHeadMAC(key, entry.OwnerKey, headID, entry.PrevHash))) {
+			return fmt.Errorf("audit chain head of %s is corrupted", entry.OwnerKey)
+		}
+
+		// Время хранится с точностью до микросекунд, поэтому хеш считается от уже округлённого значения.
+		entry.CreatedAt = time.Now().UTC().Truncate(time.Microsecond)
+		entry.Hash = auditMAC(key, entry)
+
+		query := `INSERT INTO audit_log
+                  (owner_key, actor_key, action, record_type, record_key, ip, user_agent, created_at, prev_hash, hash, chain)
+                  VALUES ($1, $2, $3, NULLIF($4, ''), $5, $6, $7, $8, $9, $10, $11)
+                  RETURNING audit_id`
+
+		err = tx.QueryRowContext(ctx, query,
+			entry.OwnerKey,
+			entry.ActorKey,
+			entry.Action,
+			entry.RecordType,
+			entry.RecordKey,
+			entry.IP,
+			entry.UserAgent,
+			entry.CreatedAt,
+			entry.PrevHash,
+			entry.Hash,
+			auditChainHMAC,
+		).Scan(&entry.AuditID)
+		if err != nil {
+			return err
+		}
+
+		_, err = tx.ExecContext(ctx, `UPDATE audit_heads SET audit_id = $2, hash = $3, mac = $4 WHERE owner_key = $1`,
+			entry.OwnerKey, entry.AuditID, entry.Hash, auditHeadMAC(key, entry.OwnerKey, entry.AuditID, entry.Hash))
+		return err
+	})
+}
+
+// AuditAnchor возвращает якорь журнала: номер последней записи и число записей до него.
+// Якорь периодически пишется в лог сервера, который хранится вне базы данных; по нему
+// выгрузка обнаруживает удаление записей, даже если голову цепочки откатили к старому состоянию.
+func (pstg *PostgreSQL) AuditAnchor(ctx context.Context) (model.AuditAnchor, error) {
+	var anchor model.AuditAnchor
+	err := pstg.db.QueryRowContext(ctx, `SELECT COALESCE(max(audit_id), 0), count(*) FROM audit_log`).Scan(
+		&anchor.AuditID,
+		&anchor.Entries,
+	)
+	return anchor, err
+}
+
+// SelectAudit возвращает последние limit записей журнала владельца, начиная с последних.
+func (pstg *PostgreSQL) SelectAudit(ctx context.Context, ownerKey uuid.UUID, limit int) ([]model.AuditEntry, error) {
+	query := `SELECT a.audit_id, a.owner_key, a.actor_key, COALESCE(u.login, ''), a.action,
+                     COALESCE(a.record_type, ''), a.record_key, a.ip, a.user_agent, a.created_at, a.prev_hash, a.hash
+              FROM audit_log a
+              LEFT JOIN private_user u ON u.private_user_key = a.actor_key
+              WHERE a.owner_key = $1
+              ORDER BY a.audit_id DESC
+              LIMIT $2`
+
+	rows, err := pstg.db.QueryContext(ctx, query, ownerKey, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	entries := []model.AuditEntry{}
+	for rows.Next() {
+		entry, err := scanAudit(rows)
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, entry)
+	}
+
+	return entries, rows.Err()
+}
+
+// ExportAudit выгружает весь журнал в формате JSONL в порядке добавления и проверяет цепочки:
+// связь каждой записи с предыдущей записью владельца, HMAC записей и голов цепочек.
+// Если задан якорь из лога сервера, проверяется, что записи до него не удалены.
+// Выгрузка продолжается после нарушения цепочки; возвращается количество записей
+// и ошибка с номером первой изменённой или пропавшей записи.
+func (pstg *PostgreSQL) ExportAudit(ctx context.Context, w io.Writer, anchor *model.AuditAnchor) (int64, error) {
+	key := currentAuditKey()
+	if key == nil {
+		return 0, errNoAuditKey
+	}
+
+	query := `SELECT a.audit_id, a.owner_key, a.actor_key, COALESCE(u.login, ''), a.action,
+                     COALESCE(a.record_type, ''), a.record_key, a.ip, a.user_agent, a.created_at, a.prev_hash, a.hash,
+                     a.chain
+              FROM audit_log a
+              LEFT JOIN private_user u ON u.private_user_key = a.actor_key
+              ORDER BY a.audit_id`
+
+	rows, err := pstg.db.QueryContext(ctx, query)
+	if err != nil {
+		return 0, err
+	}
+	defer rows.Close()
+
+	var (
+		count    int64
+		anchored int64
+		legacy   string
+		broken   error
+	)
+	last := map[uuid.UUID]model.AuditEntry{}
+	encoder := json.NewEncoder(w)
+	for rows.Next() {
+		var chain string
+		entry, err := scanAudit(rows, &chain)
+		if err != nil {
+			return count, err
+		}
+
+		var valid bool
+		if chain == auditChainSHA256 {
+			valid = entry.PrevHash == legacy && entry.Hash == auditHash(entry)
+			legacy = entry.Hash
+		} else {
+			valid = entry.PrevHash == last[entry.OwnerKey].Hash &&
+				hmac.Equal([]byte(entry.Hash), []byte(auditMAC(key, entry)))
+			last[entry.OwnerKey] = entry
+		}
+		if broken == nil && !valid {
+			broken = fmt.Errorf("audit chain is broken at entry %d", entry.AuditID)
+		}
+		if anchor != nil && entry.AuditID <= anchor.AuditID {
+			anchored++
+		}
+
+		if err := encoder.Encode(entry); err != nil {
+			return count, err
+		}
+		count++
+	}
+	if err := rows.Err(); err != nil {
+		return count, err
+	}
+	rows.Close()
+
+	if broken == nil {
+		broken = pstg.verifyAuditHeads(ctx, key, last)
+	}
+	if broken == nil && anchor != nil && anchored < anchor.Entries {
+		broken = fmt.Errorf("audit log has %d entries up to %d, the anchor recorded %d: entries were deleted",
+			anchored, anchor.AuditID, anchor.Entries)
+	}
+
+	return count, broken
+}
+
+// verifyAuditHeads сверяет головы цепочек с последними записями владельцев. Несовпадение
+// означает, что записи удалены с конца цепочки.
+func (pstg *PostgreSQL) verifyAuditHeads(ctx context.Context, key []byte, last map[uuid.UUID]model.AuditEntry) error {
+	rows, err := pstg.db.QueryContext(ctx, `SELECT owner_key, audit_id, hash, mac FROM audit_heads WHERE audit_id <> 0`)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	heads := 0
+	for rows.Next() {
+		var (
+			owner   uuid.UUID
+			auditID int64
+			hash    string
+			mac     string
+		)
+		if err := rows.Scan(&owner, &auditID, &hash, &mac); err != nil {
+			return err
+		}
+		if !hmac.Equal([]byte(mac), []byte(auditHeadMAC(key, owner, auditID, hash))) {
+			return fmt.Errorf("audit chain head of %s is corrupted", owner)
+		}
+		if entry := last[owner]; entry.AuditID != auditID || entry.Hash != hash {
+			return fmt.Errorf("audit chain of %s ends at entry %d, its head is entry %d: entries were deleted",
+				owner, entry.AuditID, auditID)
+		}
+		heads++
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	if heads != len(last) {
+		return fmt.Errorf("audit log has %d chains but %d chain heads", len(last), heads)
+	}
+	return nil
+}
+
+// scanAudit читает запись журнала; extra принимает дополнительные столбцы после hash.
+func scanAudit(rows *sql.Rows, extra ...any) (model.AuditEntry, error) {
+	var (
+		entry     model.AuditEntry
+		recordKey uuid.NullUUID
+	)
+	dest := []any{
+		&entry.AuditID,
+		&entry.OwnerKey,
+		&entry.ActorKey,
+		&entry.Actor,
+		&entry.Action,
+		&entry.RecordType,
+		&recordKey,
+		&entry.IP,
+		&entry.UserAgent,
+		&entry.CreatedAt,
+		&entry.PrevHash,
+		&entry.Hash,
+	}
+	if err := rows.Scan(append(dest, extra...)...); err != nil {
+		return model.AuditEntry{}, err
+	}
+
+	if recordKey.Valid {
+		entry.RecordKey = &recordKey.UUID
+	}
+	entry.CreatedAt = entry.CreatedAt.UTC()
+	return entry, nil
+}
+
+// auditMAC вычисляет HMAC-SHA256 записи журнала от хеша предыдущей записи владельца и полей самой записи.
+func auditMAC(key []byte, entry model.AuditEntry) string {
+	mac := hmac.New(sha256.New, key)
+	mac.Write(auditFields(entry))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// auditHeadMAC вычисляет HMAC-SHA256 головы цепочки владельца.
+func auditHeadMAC(key []byte, owner uuid.UUID, auditID int64, hash string) string {
+	fields, _ := json.Marshal([]string{owner.String(), strconv.FormatInt(auditID, 10), hash})
+	mac := hmac.New(sha256.New, key)
+	mac.Write(fields)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// auditHash вычисляет хеш записи прежней общей цепочки без ключа.
+func auditHash(entry model.AuditEntry) string {
+	sum := sha256.Sum256(auditFields(entry))
+	return hex.EncodeToString(sum[:])
+}
+
+// auditFields сериализует хеш предыдущей записи и поля записи журнала для вычисления хеша.
+func auditFields(entry model.AuditEntry) []byte {
+	recordKey := ""
+	if entry.RecordKey != nil {
+		recordKey = entry.RecordKey.String()
+	}
+
+	fields, _ := json.Marshal([]string{
+		entry.PrevHash,
+		entry.CreatedAt.UTC().Format(time.RFC3339Nano),
+		entry.OwnerKey.String(),
+		entry.ActorKey.String(),
+		entry.Action,
+		entry.RecordType,
+		recordKey,
+		entry.IP,
+		entry.UserAgent,
+	})
+	return fields
+}
@@ -0,0 +1,150 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"github.com/google/uuid"
+	"strings"
+)
+
+// SealPlaintext шифрует секретные поля, сохранённые в открытом виде до появления шифрования:
+// в таблицах записей, снимках истории и конфликтных копиях. Возвращает число изменённых строк.
+// Зашифрованные значения не затрагиваются, поэтому после первого запуска запросы ничего не находят
+// и повторный вызов при каждом старте сервера обходится дёшево. Ревизии записей не меняются:
+// содержимое записей остаётся прежним.
+func (pstg *PostgreSQL) SealPlaintext(ctx context.Context) (int64, error) {
+	var total int64
+	for recordType, names := range sealedFields {
+		table := recordTables[recordType]
+		count, err := pstg.sealTable(ctx, table, names)
+		if err != nil {
+			return total, fmt.Errorf("seal %s: %w", table.name, err)
+		}
+		total += count
+
+		for _, snapshots := range []struct{ name, key string }{
+			{"data_history", "data_history_key"},
+			{"data_conflicts", "data_conflict_key"},
+		} {
+			count, err := pstg.sealSnapshots(ctx, snapshots.name, snapshots.key, recordType, names)
+			if err != nil {
+				return total, fmt.Errorf("seal %s: %w", snapshots.name, err)
+			}
+			total += count
+		}
+	}
+
+	return total, nil
+}
+
+// sealTable шифрует открытые значения столбцов names; имена столбцов совпадают с полями JSON.
+func (pstg *PostgreSQL) sealTable(ctx context.Context, table recordTable, names []string) (int64, error) {
+	plain := make([]string, 0, len(names))
+	for _, name := range names {
+		plain = append(plain, fmt.Sprintf(`(%[1]s <> '' AND %[1]s NOT LIKE '%[2]s%%')`, name, sealedPrefix))
+	}
+	query := fmt.Sprintf(`SELECT %s, %s FROM %s WHERE %s FOR UPDATE`,
+		table.key, strings.Join(names, ", "), table.name, strings.Join(plain, " OR "))
+
+	var count int64
+	err := pstg.inTx(ctx, func(tx *sql.Tx) error {
+		rows, err := tx.QueryContext(ctx, query)
+		if err != nil {
+			return err
+		}
+		type row struct {
+			key    uuid.UUID
+			values []any
+		}
+		var found []row
+		for rows.Next() {
+			values := make([]string, len(names))
+			dest := []any{new(uuid.UUID)}
+			for i := range values {
+				dest = append(dest, &values[i])
+			}
+			if err := rows.Scan(dest...); err != nil {
+				rows.Close()
+				return err
+			}
+			r := row{key: *dest[0].(*uuid.UUID)}
+			for _, value := range values {
+				sealed, err := sealField(value)
+				if err != nil {
+					rows.Close()
+					return err
+				}
+				r.values = append(r.values, sealed)
+			}
+			found = append(found, r)
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return err
+		}
+
+		assignments := make([]string, 0, len(names))
+		for i, name := range names {
+			assignments = append(assignments, fmt.Sprintf("%s = $%d", name, i+2))
+		}
+		update := fmt.Sprintf(`UPDATE %s SET %s WHERE %s = $1`, table.name, strings.Join(assignments, ", "), table.key)
+		for _, r := range found {
+			if _, err := tx.ExecContext(ctx, update, append([]any{r.key}, r.values...)...); err != nil {
+				return err
+			}
+		}
+		count = int64(len(found))
+		return nil
+	})
+
+	return count, err
+}
+
+// sealSnapshots шифрует открытые секретные поля в JSON-снимках записей типа recordType.
+func (pstg *PostgreSQL) sealSnapshots(ctx context.Context, tableName, key, recordType string, names []string) (int64, error) {
+	plain := make([]string, 0, len(names))
+	for _, name := range names {
+		plain = append(plain, fmt.Sprintf(`(data ->> '%[1]s' <> '' AND data ->> '%[1]s' NOT LIKE '%[2]s%%')`, name, sealedPrefix))
+	}
+	query := fmt.Sprintf(`SELECT %s, data FROM %s WHERE record_type = $1 AND data IS NOT NULL AND (%s) FOR UPDATE`,
+		key, tableName, strings.Join(plain, " OR "))
+	update := fmt.Sprintf(`UPDATE %s SET data = $2 WHERE %s = $1`, tableName, key)
+
+	var count int64
+	err := pstg.inTx(ctx, func(tx *sql.Tx) error {
+		rows, err := tx.QueryContext(ctx, query, recordType)
+		if err != nil {
+			return err
+		}
+		sealed := map[uuid.UUID][]byte{}
+		for rows.Next() {
+			var (
+				rowKey uuid.UUID
+				data   []byte
+			)
+			if err := rows.Scan(&rowKey, &data); err != nil {
+				rows.Close()
+				return err
+			}
+			if sealed[rowKey], err = sealRecord(recordType, data); err != nil {
+				rows.Close()
+				return err
+			}
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return err
+		}
+
+		for rowKey, data := range sealed {
+			if _, err := tx.ExecContext(ctx, update, rowKey, data); err != nil {
+				return err
+			}
+		}
+		count = int64(len(sealed))
+		return nil
+	})
+
+	return count, err
+}
@@ -0,0 +1,235 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"github.com/google/uuid"
+	"server/internal/cerrors"
+	"server/internal/model"
+)
+
+// saveConflict проверяет, что запись не менялась после ревизии base и по ней нет неразрешённых конфликтов.
+// В противном случае изменение сохраняется как конфликтная копия и возвращается true.
+// Изменение без ревизии (base == 0) отклоняется с cerrors.ErrPreconditionRequired.
+func saveConflict(ctx context.Context, tx *sql.Tx, recordType string, key, privateUserKey uuid.UUID, base int64, data any) (bool, error) {
+	if base == 0 {
+		return false, cerrors.ErrPreconditionRequired
+	}
+
+	table := recordTables[recordType]
+	query := fmt.Sprintf(`SELECT revision FROM %s
+              WHERE %s = $1 AND private_user_key = $2 AND deleted_at IS NULL
+              FOR UPDATE`, table.name, table.key)
+
+	var current int64
+	err := tx.QueryRowContext(ctx, query, key, privateUserKey).Scan(&current)
+	if err != nil {
+		return false, notFound(err)
+	}
+
+	query = `SELECT EXISTS (SELECT 1 FROM data_conflicts
+              WHERE record_type = $1 AND record_key = $2 AND resolved_at IS NULL)`
+
+	var unresolved bool
+	err = tx.QueryRowContext(ctx, query, recordType, key).Scan(&unresolved)
+	if err != nil {
+		return false, err
+	}
+
+	if !unresolved && base == current {
+		return false, nil
+	}
+
+	payload, err := json.Marshal(data)
+	if err != nil {
+		return false, err
+	}
+
+	query = `INSERT INTO data_conflicts (private_user_key, record_type, record_key, base_revision, data)
+		VALUES ($1, $2, $3, $4, $5)`
+
+	_, err = tx.ExecContext(ctx, query, privateUserKey, recordType, key, base, string(payload))
+	if err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+// SelectConflicts возвращает неразрешённые конфликты пользователя в порядке их появления.
+func (pstg *PostgreSQL) SelectConflicts(ctx context.Context, privateUserKey uuid.UUID) ([]model.Conflict, error) {
+	query := `SELECT data_conflict_key, record_type, record_key, base_revision, data, created_at
+              FROM data_conflicts
+              WHERE private_user_key = $1 AND resolved_at IS NULL
+              ORDER BY created_at`
+
+	rows, err := pstg.db.QueryContext(ctx, query, privateUserKey)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	conflicts := []model.Conflict{}
+	for rows.Next() {
+		var (
+			conflict model.Conflict
+			data     []byte
+		)
+		err := rows.Scan(
+			&conflict.DataConflictKey,
+			&conflict.Type,
+			&conflict.RecordKey,
+			&conflict.BaseRevision,
+			&data,
+			&conflict.CreatedAt,
+		)
+		if err == nil {
+			data, err = openRecord(conflict.Type, data)
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		conflict.Conflict = data
+		conflicts = append(conflicts, conflict)
+	}
+
+	return conflicts, rows.Err()
+}
+
+// SelectConflict возвращает тип и ключ записи неразрешённого конфликта без конфликтной копии.
+func (pstg *PostgreSQL) SelectConflict(ctx context.Context, key, privateUserKey uuid.UUID) (model.Conflict, error) {
+	query := `SELECT data_conflict_key, record_type, record_key, base_revision, created_at
+              FROM data_conflicts
+              WHERE data_conflict_key = $1 AND private_user_key = $2 AND resolved_at IS NULL`
+
+	var conflict model.Conflict
+	err := pstg.db.QueryRowContext(ctx, query, key, privateUserKey).Scan(
+		&conflict.DataConflictKey,
+		&conflict.Type,
+		&conflict.RecordKey,
+		&conflict.BaseRevision,
+		&conflict.CreatedAt,
+	)
+	if err != nil {
+		return model.Conflict{}, notFound(err)
+	}
+
+	return conflict, nil
+}
+
+// ResolveConflict применяет выбранную пользователем версию записи и помечает конфликт разрешённым.
+func (pstg *PostgreSQL) ResolveConflict(ctx context.Context, resolution model.ConflictResolution) (model.ConflictResolutionResponse, error) {
+	var result model.ConflictResolutionResponse
+	err := pstg.inTx(ctx, func(tx *sql.Tx) error {
+		query := `SELECT record_type, record_key, data
+                  FROM data_conflicts
+                  WHERE data_conflict_key = $1 AND private_user_key = $2 AND resolved_at IS NULL
+                  FOR UPDATE`
+
+		var stored []byte
+		err := tx.QueryRowContext(ctx, query, resolution.DataConflictKey, resolution.PrivateUserKey).Scan(
+			&result.Type,
+			&result.RecordKey,
+			&stored,
+		)
+		if err != nil {
+			return notFound(err)
+		}
+
+		var payload []byte
+		switch resolution.Choice {
+		case model.ResolveCurrent:
+			table := recordTables[result.Type]
+			query = fmt.Sprintf(`SELECT revision FROM %s WHERE %s = $1`, table.name, table.key)
+			err = tx.QueryRowContext(ctx, query, result.RecordKey).Scan(&result.Revision)
+			if err != nil {
+				return notFound(err)
+			}
+		case model.ResolveConflict:
+			payload = stored
+		case model.ResolveMerged:
+			payload = resolution.Data
+		default:
+			return fmt.Errorf("unknown conflict resolution: %q", resolution.Choice)
+		}
+
+		if payload != nil {
+			result.Revision, err = applyRecord(ctx, tx, result.Type, result.RecordKey, resolution.PrivateUserKey, payload, model.ChangeUpdated)
+			if err != nil {
+				return err
+			}
+		}
+
+		query = `UPDATE data_conflicts SET resolved_at = now(), resolution = $2 WHERE data_conflict_key = $1`
+		_, err = tx.ExecContext(ctx, query, resolution.DataConflictKey, resolution.Choice)
+		return err
+	})
+	if err != nil {
+		return model.ConflictResolutionResponse{}, err
+	}
+
+	return result, nil
+}
+
+// applyRecord записывает выбранную версию записи без проверки ревизии
+// и сохраняет её в историю с отметкой action.
+func applyRecord(ctx context.Context, tx *sql.Tx, recordType string, key, privateUserKey uuid.UUID, payload []byte, action string) (int64, error) {
+	var (
+		revision int64
+		err      error
+	)
+	switch recordType {
+	case model.RecordText:
+		var data model.DataText
+		if err := json.Unmarshal(payload, &data); err != nil {
+			return 0, err
+		}
+		data.DataTextKey, data.PrivateUserKey = key, privateUserKey
+		revision, err = updateDataText(ctx, tx, data)
+	case model.RecordBinary:
+		var data model.DataBinary
+		if err := json.Unmarshal(payload, &data); err != nil {
+			return 0, err
+		}
+		data.DataBinaryKey, data.PrivateUserKey = key, privateUserKey
+		revision, err = updateDataBinary(ctx, tx, data)
+	case model.RecordCard:
+		var data model.DataCreditCard
+		if err := json.Unmarshal(payload, &data); err != nil {
+			return 0, err
+		}
+		data.DataCreditCardKey, data.PrivateUserKey = key, privateUserKey
+		revision, err = updateDataCard(ctx, tx, data)
+	case model.RecordTOTP:
+		var data model.DataTOTP
+		if err := json.Unmarshal(payload, &data); err != nil {
+			return 0, err
+		}
+		data.DataTOTPKey, data.PrivateUserKey = key, privateUserKey
+		revision, err = updateDataTOTP(ctx, tx, data)
+	case model.RecordSSH:
+		var data model.DataSSHKey
+		if err := json.Unmarshal(payload, &data); err != nil {
+			return 0, err
+		}
+		data.DataSSHKeyKey, data.PrivateUserKey = key, privateUserKey
+		revision, err = updateDataSSHKey(ctx, tx, data)
+	case model.RecordCustom:
+		var data model.DataCustom
+		if err := json.Unmarshal(payload, &data); err != nil {
+			return 0, err
+		}
+		data.DataCustomKey, data.PrivateUserKey = key, privateUserKey
+		revision, err = updateDataCustom(ctx, tx, data)
+	default:
+		return 0, fmt.Errorf("unknown record type: %q", recordType)
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	return revision, saveHistory(ctx, tx, recordType, key, action)
+}
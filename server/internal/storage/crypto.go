@@ -0,0 +1,270 @@
+package storage
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"server/internal/model"
+	"strings"
+	"sync/atomic"
+)
+
+// errNoEncryptionKeys возвращается, если ключи шифрования полей не заданы: встроенного ключа нет,
+// потому что ключ из открытого репозитория равносилен хранению секретов в открытом виде.
+var errNoEncryptionKeys = errors.New("field encryption keys are not configured")
+
+// sealedPrefix отмечает зашифрованное значение поля: "enc:v1:<id ключа>:<base64(nonce||шифротекст)>".
+const sealedPrefix = "enc:v1:"
+
+// clientSealedPrefix отмечает значение, зашифрованное на клиенте сквозным шифрованием.
+// Такие значения сервер не шифрует повторно: иначе их нельзя отличить от открытых без расшифровки.
+const clientSealedPrefix = "e2e:"
+
+// sealedFields — поля записей, которые хранятся в зашифрованном виде,
+// в том числе в снимках истории и конфликтных копиях.
+var sealedFields = map[string][]string{
+	model.RecordCard: {"cvv", "pin"},
+	model.RecordTOTP: {"secret"},
+	model.RecordSSH:  {"private_key", "passphrase"},
+}
+
+// fieldKey — ключ AES-256-GCM и его идентификатор, записываемый рядом с шифротекстом.
+type fieldKey struct {
+	id   string
+	aead cipher.AEAD
+}
+
+// encryptionKeys содержит ключи шифрования полей. Список заменяется целиком при перезагрузке настроек.
+var encryptionKeys atomic.Pointer[[]fieldKey]
+
+// SetEncryptionKeys задаёт ключи шифрования полей: новые значения шифруются первым ключом,
+// а расшифровываются любым из них. С пустым списком запись секретных полей невозможна;
+// настройки с пустым списком не проходят config.Validate.
+func SetEncryptionKeys(keys []string) {
+	ring := make([]fieldKey, 0, len(keys))
+	for _, key := range keys {
+		ring = append(ring, newFieldKey(key))
+	}
+	encryptionKeys.Store(&ring)
+}
+
+// newFieldKey получает ключ AES-256 из строки настроек хешем SHA-256.
+// Длина ключа всегда подходит для AES, поэтому ошибок создания шифра не бывает.
+func newFieldKey(secret string) fieldKey {
+	sum := sha256.Sum256([]byte(secret))
+	block, _ := aes.NewCipher(sum[:])
+	aead, _ := cipher.NewGCM(block)
+
+	id := sha256.Sum256(sum[:])
+	return fieldKey{id: hex.EncodeToString(id[:4]), aead: aead}
+}
+
+func currentEncryptionKeys() []fieldKey {
+	if keys := encryptionKeys.Load(); keys != nil {
+		return *keys
+	}
+	return nil
+}
+
+// sealField шифрует значение поля текущим ключом. Пустые, уже зашифрованные и зашифрованные
+// на клиенте значения возвращаются без изменений, поэтому снимки из истории и конфликтов
+// можно записывать повторно.
+func sealField(value string) (string, error) {
+	if value == "" || strings.HasPrefix(value, sealedPrefix) || strings.HasPrefix(value, clientSealedPrefix) {
+		return value, nil
+	}
+
+	keys := currentEncryptionKeys()
+	if len(keys) == 0 {
+		return "", errNoEncryptionKeys
+	}
+	key := keys[0]
+	nonce := make([]byte, key.aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+	sealed := key.aead.Seal(nonce, nonce, []byte(value), []byte(key.id))
+
+	return sealedPrefix + key.id + ":" + base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+// openField расшифровывает значение поля. Значения без отметки sealedPrefix
+// сохранены до появления шифрования и возвращаются как есть.
+func openField(value string) (string, error) {
+	rest, ok := strings.CutPrefix(value, sealedPrefix)
+	if !ok {
+		return value, nil
+	}
+
+	id, payload, ok := strings.Cut(rest, ":")
+	if !ok {
+		return "", errors.New("malformed encrypted field")
+	}
+	sealed, err := base64.StdEncoding.DecodeString(payload)
+	if err != nil {
+		return "", fmt.Errorf("malformed encrypted field: %w", err)
+	}
+
+	for _, key := range currentEncryptionKeys() {
+		if key.id != id {
+			continue
+		}
+		size := key.aead.NonceSize()
+		if len(sealed) < size {
+			return "", errors.New("malformed encrypted field")
+		}
+		plain, err := key.aead.Open(nil, sealed[:size], sealed[size:], []byte(key.id))
+		if err != nil {
+			return "", fmt.Errorf("decrypt field: %w", err)
+		}
+		return string(plain), nil
+	}
+
+	return "", fmt.Errorf("encryption key %s is not configured", id)
+}
+
+// sealCard шифрует секретные поля карты перед записью.
+func sealCard(data *model.DataCreditCard) error {
+	var err error
+	if data.CVV, err = sealField(data.CVV); err != nil {
+		return err
+	}
+	data.PIN, err = sealField(data.PIN)
+	return err
+}
+
+// openCard расшифровывает секретные поля прочитанной карты.
+func openCard(data *model.DataCreditCardResponse) error {
+	var err error
+	if data.CVV, err = openField(data.CVV); err != nil {
+		return err
+	}
+	data.PIN, err = openField(data.PIN)
+	return err
+}
+
+// sealTOTP шифрует секрет TOTP перед записью.
+func sealTOTP(data *model.DataTOTP) error {
+	var err error
+	data.Secret, err = sealField(data.Secret)
+	return err
+}
+
+// openTOTP расшифровывает секрет прочитанной записи TOTP.
+func openTOTP(data *model.DataTOTPResponse) error {
+	var err error
+	data.Secret, err = openField(data.Secret)
+	return err
+}
+
+// sealSSHKey шифрует закрытый ключ SSH и пароль к нему перед записью.
+func sealSSHKey(data *model.DataSSHKey) error {
+	var err error
+	if data.PrivateKey, err = sealField(data.PrivateKey); err != nil {
+		return err
+	}
+	data.Passphrase, err = sealField(data.Passphrase)
+	return err
+}
+
+// openSSHKey расшифровывает закрытый ключ SSH и пароль к нему.
+func openSSHKey(data *model.DataSSHKeyResponse) error {
+	var err error
+	if data.PrivateKey, err = openField(data.PrivateKey); err != nil {
+		return err
+	}
+	data.Passphrase, err = openField(data.Passphrase)
+	return err
+}
+
+// sealRecord шифрует секретные поля в JSON-представлении записи, например в снимке истории
+// или конфликтной копии, сохранённых до появления шифрования. Зашифрованные поля не меняются.
+func sealRecord(recordType string, data []byte) ([]byte, error) {
+	names := sealedFields[recordType]
+	if len(names) == 0 || len(data) == 0 {
+		return data, nil
+	}
+
+	var fields map[string]any
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil, err
+	}
+	for _, name := range names {
+		value, ok := fields[name].(string)
+		if !ok {
+			continue
+		}
+		sealed, err := sealField(value)
+		if err != nil {
+			return nil, err
+		}
+		fields[name] = sealed
+	}
+
+	return json.Marshal(fields)
+}
+
+// openRecord расшифровывает секретные поля в JSON-представлении записи:
+// снимке истории, корзины, общей записи или конфликтной копии.
+func openRecord(recordType string, data []byte) ([]byte, error) {
+	if recordType == model.RecordCustom && len(data) > 0 {
+		return openCustomRecord(data)
+	}
+
+	names := sealedFields[recordType]
+	if len(names) == 0 || len(data) == 0 {
+		return data, nil
+	}
+
+	var fields map[string]any
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil, err
+	}
+	for _, name := range names {
+		value, ok := fields[name].(string)
+		if !ok {
+			continue
+		}
+		plain, err := openField(value)
+		if err != nil {
+			return nil, err
+		}
+		fields[name] = plain
+	}
+
+	return json.Marshal(fields)
+}
+
+// openCustomRecord расшифровывает значения полей в JSON-представлении пользовательской записи.
+// Какие поля зашифрованы, определяется по префиксу значения, поэтому шаблон не нужен.
+func openCustomRecord(data []byte) ([]byte, error) {
+	var record map[string]json.RawMessage
+	if err := json.Unmarshal(data, &record); err != nil {
+		return nil, err
+	}
+	raw, ok := record["fields"]
+	if !ok {
+		return data, nil
+	}
+
+	var fields map[string]string
+	if err := json.Unmarshal(raw, &fields); err != nil {
+		return nil, err
+	}
+	if err := openCustom(fields); err != nil {
+		return nil, err
+	}
+	opened, err := json.Marshal(fields)
+	if err != nil {
+		return nil, err
+	}
+	record["fields"] = opened
+
+	return json.Marshal(record)
+}
@@ -0,0 +1,62 @@
+package storage
+
+import (
+	"github.com/stretchr/testify/require"
+	"strings"
+	"testing"
+)
+
+func TestSealField(t *testing.T) {
+	SetEncryptionKeys([]string{"test-key-0123456789abcdef0123456789"})
+	sealed, err := sealField("secret")
+	require.NoError(t, err)
+
+	tests := []struct {
+		name   string
+		value  string
+		sealed bool // true — значение шифруется, иначе возвращается без изменений
+	}{
+		{name: "plain", value: "secret", sealed: true},
+		{name: "empty", value: ""},
+		{name: "already sealed", value: sealed},
+		{name: "client sealed", value: "e2e:v2:c2VjcmV0"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := sealField(tt.value)
+			require.NoError(t, err)
+			if !tt.sealed {
+				require.Equal(t, tt.value, got)
+				return
+			}
+			require.True(t, strings.HasPrefix(got, sealedPrefix), got)
+			plain, err := openField(got)
+			require.NoError(t, err)
+			require.Equal(t, tt.value, plain)
+		})
+	}
+}
+
+func TestHoldsClientSealed(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+		want bool
+	}{
+		{name: "plain", data: `{"secret": "JBSWY3DPEHPK3PXP", "issuer": "Example"}`},
+		{name: "top level", data: `{"secret": "e2e:v2:c2VjcmV0", "issuer": "Example"}`, want: true},
+		{name: "nested", data: `{"name": "Domain", "fields": {"token": "e2e:v2:dG9rZW4="}}`, want: true},
+		{name: "array", data: `{"tags": ["a", "e2e:v2:Yg=="]}`, want: true},
+		{name: "prefix inside value", data: `{"note": "see e2e:v2:"}`},
+		{name: "prefix as key", data: `{"e2e:": "value"}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := holdsClientSealed([]byte(tt.data))
+			require.NoError(t, err)
+			require.Equal(t, tt.want, got)
+		})
+	}
+}
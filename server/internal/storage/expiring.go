@@ -0,0 +1,137 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"github.com/google/uuid"
+	"server/internal/model"
+)
+
+// expiringRecords — записи со сроком действия: карты и пользовательские записи с полями типа date.
+// Каждое заполненное поле date даёт отдельную строку; name и detail — части заголовка записи,
+// которые expiringTitle собирает по её типу.
+var expiringRecords = fmt.Sprintf(`SELECT '%[1]s' AS record_type, data_credit_card_key AS record_key,
+                     brand AS name, card_number AS detail, expires_on, private_user_key, vault_key
+              FROM data_credit_cards
+              WHERE deleted_at IS NULL AND expires_on IS NOT NULL
+              UNION ALL
+              SELECT '%[2]s', c.data_custom_key, t.name, COALESCE(NULLIF(f ->> 'label', ''), f ->> 'name'),
+                     (c.fields ->> (f ->> 'name'))::date, c.private_user_key, c.vault_key
+              FROM data_custom c
+              JOIN record_templates t ON t.template_key = c.template_key
+              CROSS JOIN LATERAL jsonb_array_elements(t.fields) f
+              WHERE c.deleted_at IS NULL AND f ->> 'type' = '%[3]s'
+                AND c.fields ->> (f ->> 'name') ~ '^\d{4}-\d{2}-\d{2}$'`,
+	model.RecordCard, model.RecordCustom, model.FieldDate)
+
+// SelectExpiring возвращает записи пользователя, срок действия которых истекает в ближайшие days дней,
+// начиная с ближайшего срока: карты и пользовательские записи с полями типа date.
+func (pstg *PostgreSQL) SelectExpiring(ctx context.Context, privateUserKey uuid.UUID, days int) ([]model.ExpiringRecord, error) {
+	query := `SELECT r.record_type, r.record_key, r.name, r.detail, to_char(r.expires_on, 'YYYY-MM-DD'),
+                     r.expires_on - current_date
+              FROM (` + expiringRecords + `) r
+              WHERE r.private_user_key = $1
+                AND r.expires_on BETWEEN current_date AND current_date + $2::integer
+              ORDER BY r.expires_on, r.record_key`
+
+	rows, err := pstg.db.QueryContext(ctx, query, privateUserKey, days)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	records := []model.ExpiringRecord{}
+	for rows.Next() {
+		var (
+			record       model.ExpiringRecord
+			name, detail string
+		)
+		err := rows.Scan(&record.Type, &record.Key, &name, &detail, &record.ExpiresOn, &record.DaysLeft)
+		if err != nil {
+			return nil, err
+		}
+
+		record.Title = expiringTitle(record.Type, name, detail)
+		records = append(records, record)
+	}
+
+	return records, rows.Err()
+}
+
+// SelectDueReminders возвращает записи всех пользователей и хранилищ, срок которых истекает
+// в ближайшие days дней и о которых ещё не отправлено напоминание.
+func (pstg *PostgreSQL) SelectDueReminders(ctx context.Context, days int) ([]model.ExpiringRecord, error) {
+	query := `SELECT r.record_type, r.record_key, r.name, r.detail, to_char(r.expires_on, 'YYYY-MM-DD'),
+                     r.expires_on - current_date, r.private_user_key, COALESCE(u.login, v.name, '')
+              FROM (` + expiringRecords + `) r
+              LEFT JOIN private_user u ON u.private_user_key = r.private_user_key
+              LEFT JOIN vaults v ON v.vault_key = r.vault_key
+              WHERE r.expires_on BETWEEN current_date AND current_date + $1::integer
+                AND NOT EXISTS (SELECT 1 FROM expiry_notifications n
+                                WHERE n.record_type = r.record_type AND n.record_key = r.record_key
+                                  AND n.expires_on = r.expires_on)
+              ORDER BY r.expires_on, r.record_key`
+
+	rows, err := pstg.db.QueryContext(ctx, query, days)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var records []model.ExpiringRecord
+	for rows.Next() {
+		var (
+			record       model.ExpiringRecord
+			name, detail string
+			owner        uuid.UUID
+		)
+		err := rows.Scan(&record.Type, &record.Key, &name, &detail, &record.ExpiresOn, &record.DaysLeft, &owner, &record.Owner)
+		if err != nil {
+			return nil, err
+		}
+
+		record.Title, record.OwnerKey = expiringTitle(record.Type, name, detail), &owner
+		records = append(records, record)
+	}
+
+	return records, rows.Err()
+}
+
+// MarkReminded отмечает, что напоминания о записях доставлены.
+func (pstg *PostgreSQL) MarkReminded(ctx context.Context, records []model.ExpiringRecord) error {
+	query := `INSERT INTO expiry_notifications (record_type, record_key, expires_on)
+              VALUES ($1, $2, $3::date)
+              ON CONFLICT DO NOTHING`
+
+	return pstg.inTx(ctx, func(tx *sql.Tx) error {
+		for _, record := range records {
+			if _, err := tx.ExecContext(ctx, query, record.Type, record.Key, record.ExpiresOn); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// expiringTitle возвращает заголовок истекающей записи: для карты — платёжную систему и последние
+// цифры номера, для пользовательской записи — название шаблона и подпись поля с датой.
+func expiringTitle(recordType, name, detail string) string {
+	if recordType == model.RecordCard {
+		return cardTitle(name, detail)
+	}
+	return name + ": " + detail
+}
+
+// cardTitle описывает карту платёжной системой и последними цифрами номера,
+// не раскрывая номер целиком.
+func cardTitle(brand, number string) string {
+	if len(number) > 4 {
+		number = number[len(number)-4:]
+	}
+	title := "**** " + number
+	if brand != "" {
+		title = brand + " " + title
+	}
+	return title
+}
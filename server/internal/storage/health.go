@@ -0,0 +1,48 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"github.com/jackc/pgx/v5/pgconn"
+	"server/internal/cerrors"
+)
+
+// SchemaVersion — номер последней миграции из migration/postgresql, на которую рассчитан код.
+// Увеличивается вместе с добавлением миграции.
+const SchemaVersion = 20
+
+// undefinedTable — код ошибки PostgreSQL для несуществующей таблицы.
+const undefinedTable = "42P01"
+
+// Ping проверяет соединение с базой данных.
+func (pstg *PostgreSQL) Ping(ctx context.Context) error {
+	return pstg.db.PingContext(ctx)
+}
+
+// SelectMigrationVersion возвращает номер применённой миграции и признак незавершённой миграции
+// из таблицы schema_migrations, которую ведёт golang-migrate.
+// Если миграции применялись без него и таблицы нет, возвращает cerrors.ErrNotFound.
+func (pstg *PostgreSQL) SelectMigrationVersion(ctx context.Context) (int64, bool, error) {
+	query := `SELECT version, dirty FROM schema_migrations LIMIT 1`
+
+	var (
+		version int64
+		dirty   bool
+	)
+	err := pstg.db.QueryRowContext(ctx, query).Scan(&version, &dirty)
+	var pgErr *pgconn.PgError
+	if errors.Is(err, sql.ErrNoRows) || errors.As(err, &pgErr) && pgErr.Code == undefinedTable {
+		return 0, false, cerrors.ErrNotFound
+	}
+	if err != nil {
+		return 0, false, err
+	}
+
+	return version, dirty, nil
+}
+
+// ExpectedSchemaVersion возвращает номер миграции, на которую рассчитан код.
+func (pstg *PostgreSQL) ExpectedSchemaVersion() int64 {
+	return SchemaVersion
+}
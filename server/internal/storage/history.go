@@ -0,0 +1,125 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"github.com/google/uuid"
+	"server/internal/config"
+	"server/internal/model"
+)
+
+// saveHistory добавляет в историю версию записи, только что записанную в транзакции tx.
+// Для удалений сохраняется только отметка без данных.
+func saveHistory(ctx context.Context, tx *sql.Tx, recordType string, key uuid.UUID, action string) error {
+	table := recordTables[recordType]
+	snapshot := table.snapshot
+	if action == model.ChangeDeleted {
+		snapshot = "NULL"
+	}
+
+	query := fmt.Sprintf(`INSERT INTO data_history (private_user_key, record_type, record_key, revision, action, data)
+              SELECT private_user_key, $1, %[2]s, revision, $2, %[3]s
+              FROM %[1]s
+              WHERE %[2]s = $3`, table.name, table.key, snapshot)
+
+	return execAffected(ctx, tx, query, recordType, action, key)
+}
+
+// SelectHistory возвращает версии записи пользователя, начиная с последней.
+func (pstg *PostgreSQL) SelectHistory(ctx context.Context, recordType string, key, privateUserKey uuid.UUID) ([]model.HistoryEntry, error) {
+	query := `SELECT revision, action, data, created_at
+              FROM data_history
+              WHERE record_type = $1 AND record_key = $2 AND private_user_key = $3
+              ORDER BY revision DESC`
+
+	rows, err := pstg.db.QueryContext(ctx, query, recordType, key, privateUserKey)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	history := []model.HistoryEntry{}
+	for rows.Next() {
+		var (
+			entry model.HistoryEntry
+			data  []byte
+		)
+		if err := rows.Scan(&entry.Revision, &entry.Action, &data, &entry.CreatedAt); err != nil {
+			return nil, err
+		}
+		if data, err = openRecord(recordType, data); err != nil {
+			return nil, err
+		}
+
+		entry.Data = data
+		history = append(history, entry)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	if len(history) == 0 {
+		return nil, notFound(sql.ErrNoRows)
+	}
+
+	return history, nil
+}
+
+// RestoreRecord делает версию записи с ревизией revision текущей, в том числе для удалённой записи.
+// Возвращает новую ревизию записи.
+func (pstg *PostgreSQL) RestoreRecord(ctx context.Context, recordType string, key, privateUserKey uuid.UUID, revision int64) (int64, error) {
+	table, ok := recordTables[recordType]
+	if !ok {
+		return 0, fmt.Errorf("unknown record type: %q", recordType)
+	}
+
+	var result int64
+	err := pstg.inTx(ctx, func(tx *sql.Tx) error {
+		query := `SELECT data FROM data_history
+                  WHERE record_type = $1 AND record_key = $2 AND private_user_key = $3
+                    AND revision = $4 AND data IS NOT NULL`
+
+		var payload []byte
+		err := tx.QueryRowContext(ctx, query, recordType, key, privateUserKey, revision).Scan(&payload)
+		if err != nil {
+			return notFound(err)
+		}
+
+		query = fmt.Sprintf(`UPDATE %s SET deleted_at = NULL
+                  WHERE %s = $1 AND private_user_key = $2 AND purged_at IS NULL`, table.name, table.key)
+		if err := execAffected(ctx, tx, query, key, privateUserKey); err != nil {
+			return err
+		}
+
+		result, err = applyRecord(ctx, tx, recordType, key, privateUserKey, payload, model.HistoryRestored)
+		return err
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return result, nil
+}
+
+// PruneHistory удаляет версии, вышедшие за пределы настроек хранения истории.
+// Последняя версия каждой записи не удаляется.
+func (pstg *PostgreSQL) PruneHistory(ctx context.Context, settings config.HistorySettings) (int64, error) {
+	if settings.Limit <= 0 && settings.MaxAge <= 0 {
+		return 0, nil
+	}
+
+	query := `DELETE FROM data_history h
+              USING (SELECT data_history_key, created_at,
+                            row_number() OVER (PARTITION BY record_type, record_key ORDER BY revision DESC) AS n
+                     FROM data_history) v
+              WHERE h.data_history_key = v.data_history_key AND v.n > 1
+                AND (($1::bigint > 0 AND v.n > $1::bigint)
+                  OR ($2::bigint > 0 AND v.created_at < now() - $2::bigint * interval '1 second'))`
+
+	res, err := pstg.db.ExecContext(ctx, query, settings.Limit, int64(settings.MaxAge.Seconds()))
+	if err != nil {
+		return 0, err
+	}
+
+	return res.RowsAffected()
+}
@@ -0,0 +1,80 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"github.com/google/uuid"
+	"server/internal/model"
+)
+
+// InsertLink сохраняет зашифрованные данные ссылки.
+func (pstg *PostgreSQL) InsertLink(ctx context.Context, link model.Link, privateUserKey uuid.UUID) (model.Link, error) {
+	query := `INSERT INTO share_links (private_user_key, data, max_views, expires_at)
+              VALUES ($1, $2, $3, $4)
+              RETURNING link_key, created_at`
+
+	err := pstg.db.QueryRowContext(ctx, query, privateUserKey, link.Data, link.MaxViews, link.ExpiresAt).Scan(
+		&link.LinkKey,
+		&link.CreatedAt,
+	)
+	if err != nil {
+		return model.Link{}, err
+	}
+
+	return link, nil
+}
+
+// OpenLink возвращает данные действующей ссылки и засчитывает просмотр.
+// После последнего разрешённого просмотра ссылка удаляется.
+func (pstg *PostgreSQL) OpenLink(ctx context.Context, key uuid.UUID) (model.Link, error) {
+	link := model.Link{LinkKey: key}
+	err := pstg.inTx(ctx, func(tx *sql.Tx) error {
+		query := `SELECT private_user_key, data, max_views, views, expires_at, created_at
+                  FROM share_links
+                  WHERE link_key = $1 AND expires_at > now()
+                  FOR UPDATE`
+
+		err := tx.QueryRowContext(ctx, query, key).Scan(
+			&link.OwnerKey,
+			&link.Data,
+			&link.MaxViews,
+			&link.Views,
+			&link.ExpiresAt,
+			&link.CreatedAt,
+		)
+		if err != nil {
+			return notFound(err)
+		}
+
+		link.Views++
+		if link.Views >= link.MaxViews {
+			_, err = tx.ExecContext(ctx, `DELETE FROM share_links WHERE link_key = $1`, key)
+			return err
+		}
+
+		_, err = tx.ExecContext(ctx, `UPDATE share_links SET views = $2 WHERE link_key = $1`, key, link.Views)
+		return err
+	})
+	if err != nil {
+		return model.Link{}, err
+	}
+
+	return link, nil
+}
+
+// DeleteLink отзывает ссылку, созданную пользователем.
+func (pstg *PostgreSQL) DeleteLink(ctx context.Context, key, privateUserKey uuid.UUID) error {
+	return pstg.inTx(ctx, func(tx *sql.Tx) error {
+		return execAffected(ctx, tx, `DELETE FROM share_links WHERE link_key = $1 AND private_user_key = $2`, key, privateUserKey)
+	})
+}
+
+// PurgeLinks удаляет истёкшие ссылки и возвращает их количество.
+func (pstg *PostgreSQL) PurgeLinks(ctx context.Context) (int64, error) {
+	res, err := pstg.db.ExecContext(ctx, `DELETE FROM share_links WHERE expires_at <= now()`)
+	if err != nil {
+		return 0, err
+	}
+
+	return res.RowsAffected()
+}
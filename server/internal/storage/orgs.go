@@ -0,0 +1,238 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"github.com/google/uuid"
+	"server/internal/model"
+)
+
+// InsertOrg создаёт организацию, владельцем которой становится пользователь.
+func (pstg *PostgreSQL) InsertOrg(ctx context.Context, name string, privateUserKey uuid.UUID) (model.Org, error) {
+	result := model.Org{Name: name, Role: model.RoleOwner}
+	err := pstg.inTx(ctx, func(tx *sql.Tx) error {
+		query := `INSERT INTO orgs (name) VALUES ($1) RETURNING org_key, created_at`
+		err := tx.QueryRowContext(ctx, query, name).Scan(&result.OrgKey, &result.CreatedAt)
+		if err != nil {
+			return err
+		}
+
+		query = `INSERT INTO org_members (org_key, private_user_key, role) VALUES ($1, $2, $3)`
+		_, err = tx.ExecContext(ctx, query, result.OrgKey, privateUserKey, model.RoleOwner)
+		return err
+	})
+	if err != nil {
+		return model.Org{}, err
+	}
+
+	return result, nil
+}
+
+// SelectOrgs возвращает организации, в которых состоит пользователь.
+func (pstg *PostgreSQL) SelectOrgs(ctx context.Context, privateUserKey uuid.UUID) ([]model.Org, error) {
+	query := `SELECT o.org_key, o.name, m.role, o.created_at
+              FROM orgs o
+              JOIN org_members m ON m.org_key = o.org_key
+              WHERE m.private_user_key = $1
+              ORDER BY o.name`
+
+	rows, err := pstg.db.QueryContext(ctx, query, privateUserKey)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	orgs := []model.Org{}
+	for rows.Next() {
+		var org model.Org
+		if err := rows.Scan(&org.OrgKey, &org.Name, &org.Role, &org.CreatedAt); err != nil {
+			return nil, err
+		}
+		orgs = append(orgs, org)
+	}
+
+	return orgs, rows.Err()
+}
+
+// SelectOrgRole возвращает роль пользователя в организации.
+// Если пользователь в ней не состоит, возвращается cerrors.ErrNotFound.
+func (pstg *PostgreSQL) SelectOrgRole(ctx context.Context, orgKey, privateUserKey uuid.UUID) (string, error) {
+	query := `SELECT role FROM org_members WHERE org_key = $1 AND private_user_key = $2`
+
+	var role string
+	err := pstg.db.QueryRowContext(ctx, query, orgKey, privateUserKey).Scan(&role)
+	if err != nil {
+		return "", notFound(err)
+	}
+
+	return role, nil
+}
+
+// SelectVaultRole возвращает роль пользователя в организации, которой принадлежит хранилище.
+// Если хранилища нет или пользователь не состоит в организации, возвращается cerrors.ErrNotFound.
+func (pstg *PostgreSQL) SelectVaultRole(ctx context.Context, vaultKey, privateUserKey uuid.UUID) (string, error) {
+	query := `SELECT m.role
+              FROM vaults v
+              JOIN org_members m ON m.org_key = v.org_key
+              WHERE v.vault_key = $1 AND m.private_user_key = $2`
+
+	var role string
+	err := pstg.db.QueryRowContext(ctx, query, vaultKey, privateUserKey).Scan(&role)
+	if err != nil {
+		return "", notFound(err)
+	}
+
+	return role, nil
+}
+
+// SelectMembers возвращает участников организации.
+func (pstg *PostgreSQL) SelectMembers(ctx context.Context, orgKey uuid.UUID) ([]model.OrgMember, error) {
+	query := `SELECT u.login, m.role, m.created_at
+              FROM org_members m
+              JOIN private_user u ON u.private_user_key = m.private_user_key
+              WHERE m.org_key = $1
+              ORDER BY u.login`
+
+	rows, err := pstg.db.QueryContext(ctx, query, orgKey)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	members := []model.OrgMember{}
+	for rows.Next() {
+		var member model.OrgMember
+		if err := rows.Scan(&member.Login, &member.Role, &member.CreatedAt); err != nil {
+			return nil, err
+		}
+		members = append(members, member)
+	}
+
+	return members, rows.Err()
+}
+
+// errLastOwner возвращается при попытке исключить последнего владельца организации или сменить его роль.
+var errLastOwner = errors.New("organization must keep at least one owner")
+
+// lockMembers блокирует строки участников организации до конца транзакции и возвращает
+// роль участника с логином login (пустую, если он не участник) и количество владельцев.
+// Одновременные изменения состава ждут друг друга, поэтому не могут вместе оставить
+// организацию без владельца.
+func lockMembers(ctx context.Context, tx *sql.Tx, orgKey uuid.UUID, login string) (string, int, error) {
+	query := `SELECT u.login, m.role
+              FROM org_members m
+              JOIN private_user u ON u.private_user_key = m.private_user_key
+              WHERE m.org_key = $1
+              ORDER BY m.private_user_key
+              FOR UPDATE OF m`
+
+	rows, err := tx.QueryContext(ctx, query, orgKey)
+	if err != nil {
+		return "", 0, err
+	}
+	defer rows.Close()
+
+	role, owners := "", 0
+	for rows.Next() {
+		var memberLogin, memberRole string
+		if err := rows.Scan(&memberLogin, &memberRole); err != nil {
+			return "", 0, err
+		}
+		if memberLogin == login {
+			role = memberRole
+		}
+		if memberRole == model.RoleOwner {
+			owners++
+		}
+	}
+
+	return role, owners, rows.Err()
+}
+
+// UpsertMember добавляет пользователя с логином member.Login в организацию или меняет его роль.
+// Роль последнего владельца не меняется.
+func (pstg *PostgreSQL) UpsertMember(ctx context.Context, orgKey uuid.UUID, member model.OrgMember) (model.OrgMember, error) {
+	query := `INSERT INTO org_members (org_key, private_user_key, role)
+              SELECT $1, private_user_key, $3 FROM private_user WHERE login = $2
+              ON CONFLICT (org_key, private_user_key) DO UPDATE SET role = EXCLUDED.role
+              RETURNING created_at`
+
+	err := pstg.inTx(ctx, func(tx *sql.Tx) error {
+		current, owners, err := lockMembers(ctx, tx, orgKey, member.Login)
+		if err != nil {
+			return err
+		}
+		if current == model.RoleOwner && member.Role != model.RoleOwner && owners == 1 {
+			return errLastOwner
+		}
+
+		err = tx.QueryRowContext(ctx, query, orgKey, member.Login, member.Role).Scan(&member.CreatedAt)
+		return notFound(err)
+	})
+	if err != nil {
+		return model.OrgMember{}, err
+	}
+
+	return member, nil
+}
+
+// DeleteMember исключает пользователя с логином login из организации. Последнего владельца исключить нельзя.
+func (pstg *PostgreSQL) DeleteMember(ctx context.Context, orgKey uuid.UUID, login string) error {
+	query := `DELETE FROM org_members m
+              USING private_user u
+              WHERE u.private_user_key = m.private_user_key AND m.org_key = $1 AND u.login = $2`
+
+	return pstg.inTx(ctx, func(tx *sql.Tx) error {
+		current, owners, err := lockMembers(ctx, tx, orgKey, login)
+		if err != nil {
+			return err
+		}
+		if current == model.RoleOwner && owners == 1 {
+			return errLastOwner
+		}
+
+		return execAffected(ctx, tx, query, orgKey, login)
+	})
+}
+
+// InsertVault создаёт хранилище в организации.
+func (pstg *PostgreSQL) InsertVault(ctx context.Context, orgKey uuid.UUID, name string) (model.Vault, error) {
+	query := `INSERT INTO vaults (org_key, name) VALUES ($1, $2) RETURNING vault_key, created_at`
+
+	result := model.Vault{OrgKey: orgKey, Name: name}
+	err := pstg.db.QueryRowContext(ctx, query, orgKey, name).Scan(&result.VaultKey, &result.CreatedAt)
+	if err != nil {
+		return model.Vault{}, err
+	}
+
+	return result, nil
+}
+
+// SelectVaults возвращает хранилища организаций, в которых состоит пользователь.
+func (pstg *PostgreSQL) SelectVaults(ctx context.Context, privateUserKey uuid.UUID) ([]model.Vault, error) {
+	query := `SELECT v.vault_key, v.org_key, o.name, v.name, m.role, v.created_at
+              FROM vaults v
+              JOIN orgs o ON o.org_key = v.org_key
+              JOIN org_members m ON m.org_key = v.org_key
+              WHERE m.private_user_key = $1
+              ORDER BY o.name, v.name`
+
+	rows, err := pstg.db.QueryContext(ctx, query, privateUserKey)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	vaults := []model.Vault{}
+	for rows.Next() {
+		var vault model.Vault
+		err := rows.Scan(&vault.VaultKey, &vault.OrgKey, &vault.OrgName, &vault.Name, &vault.Role, &vault.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+		vaults = append(vaults, vault)
+	}
+
+	return vaults, rows.Err()
+}
@@ -1,13 +1,17 @@
 package storage
 
 import (
+	"context"
 	"database/sql"
+	"encoding/json"
 	"fmt"
 	"github.com/google/uuid"
-	_ "github.com/jackc/pgx/v5/stdlib"
-	"log"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/stdlib"
+	"server/internal/cerrors"
 	"server/internal/config"
 	"server/internal/model"
+	"strconv"
 )
 
 type PostgreSQL struct {
@@ -35,32 +39,44 @@ func (pstg *PostgreSQL) Connect() error {
 		pstg.config.Postgres.Database,
 	)
 
-	var err error
-	pstg.db, err = sql.Open("pgx", dsn)
+	if pstg.config.Postgres.DSN != "" {
+		dsn = pstg.config.Postgres.DSN
+	}
+
+	connConfig, err := pgx.ParseConfig(dsn)
 	if err != nil {
-		log.Println("Ошибка при подключении к PostgreSQL:", err)
-		return err
+		return fmt.Errorf("parse PostgreSQL config: %w", err)
+	}
+	connConfig.Tracer = queryTracer{}
+	// Тайм-аут действует на каждый запрос на стороне сервера, в том числе внутри транзакций;
+	// отмена контекста запроса прерывает запрос независимо от него.
+	if timeout := pstg.config.Postgres.QueryTimeout; timeout > 0 {
+		connConfig.RuntimeParams["statement_timeout"] = strconv.FormatInt(timeout.Milliseconds(), 10)
 	}
+	pstg.db = stdlib.OpenDB(*connConfig)
 
 	err = pstg.db.Ping()
 	if err != nil {
-		log.Println("Ошибка при проверке подключения к PostgreSQL:", err)
-		return err
+		return fmt.Errorf("ping PostgreSQL: %w", err)
 	}
 
-	log.Println("Успешное подключение к PostgreSQL")
 	return nil
 }
 
+// DB возвращает пул соединений, например для сбора его статистики.
+func (pstg *PostgreSQL) DB() *sql.DB {
+	return pstg.db
+}
+
 func (pstg *PostgreSQL) Close() error {
 	return pstg.db.Close()
 }
 
-func (pstg *PostgreSQL) SelectUser(user model.User) (model.UserResponse, error) {
+func (pstg *PostgreSQL) SelectUser(ctx context.Context, user model.User) (model.UserResponse, error) {
 	query := `SELECT private_user_key FROM private_user WHERE login = $1 and password_hash = $2 `
 
 	var PrivateUserKey uuid.UUID
-	err := pstg.db.QueryRow(query, user.Login, user.PasswordHash).Scan(&PrivateUserKey)
+	err := pstg.db.QueryRowContext(ctx, query, user.Login, user.PasswordHash).Scan(&PrivateUserKey)
 	if err != nil {
 		return model.UserResponse{}, err
 	}
@@ -68,11 +84,11 @@ func (pstg *PostgreSQL) SelectUser(user model.User) (model.UserResponse, error)
 	return model.UserResponse{PrivateUserKey: PrivateUserKey}, nil
 }
 
-func (pstg *PostgreSQL) InsertUser(user model.User) (model.UserResponse, error) {
+func (pstg *PostgreSQL) InsertUser(ctx context.Context, user model.User) (model.UserResponse, error) {
 	query := `INSERT INTO private_user (login, password_hash, encryption_key) VALUES ($1, $2, $3) RETURNING private_user_key`
 
 	var PrivateUserKey uuid.UUID
-	err := pstg.db.QueryRow(query, user.Login, user.PasswordHash, user.EncryptionKey).Scan(&PrivateUserKey)
+	err := pstg.db.QueryRowContext(ctx, query, user.Login, user.PasswordHash, user.EncryptionKey).Scan(&PrivateUserKey)
 	if err != nil {
 		return model.UserResponse{}, err
 	}
@@ -80,158 +96,884 @@ func (pstg *PostgreSQL) InsertUser(user model.User) (model.UserResponse, error)
 	return model.UserResponse{PrivateUserKey: PrivateUserKey}, nil
 }
 
-func (pstg *PostgreSQL) InsertDataText(data model.DataText) (model.DataTextResponse, error) {
-	query := `INSERT INTO data_text (private_user_key, data)
-		VALUES ($1, $2) RETURNING data_text_key`
-
-	var insertedUUID uuid.UUID
-	err := pstg.db.QueryRow(query, data.PrivateUserKey, data.Data).Scan(&insertedUUID)
+func (pstg *PostgreSQL) InsertDataText(ctx context.Context, data model.DataText) (model.DataTextResponse, error) {
+	query := `INSERT INTO data_text (private_user_key, data, revision, vault_key)
+		VALUES ($1, $2, $3, (SELECT vault_key FROM vaults WHERE vault_key = $1)) RETURNING data_text_key`
+
+	var result model.DataTextResponse
+	err := pstg.inTx(ctx, func(tx *sql.Tx) error {
+		var err error
+		result.Revision, err = nextRevision(ctx, tx, data.PrivateUserKey)
+		if err != nil {
+			return err
+		}
+		err = tx.QueryRowContext(ctx, query, data.PrivateUserKey, data.Data, result.Revision).Scan(&result.DataTextKey)
+		if err != nil {
+			return err
+		}
+		return saveHistory(ctx, tx, model.RecordText, result.DataTextKey, model.ChangeCreated)
+	})
 	if err != nil {
 		return model.DataTextResponse{}, err
 	}
 
-	return model.DataTextResponse{DataTextKey: insertedUUID}, nil
+	return result, nil
 }
 
-func (pstg *PostgreSQL) SelectDataText(data model.DataText) (model.DataTextResponse, error) {
-	query := `SELECT data_text_key, data
+func (pstg *PostgreSQL) SelectDataText(ctx context.Context, data model.DataText) (model.DataTextResponse, error) {
+	query := `SELECT data_text_key, data, revision
               FROM data_text
-              WHERE data_text_key = $1 AND private_user_key = $2`
+              WHERE data_text_key = $1 AND private_user_key = $2 AND deleted_at IS NULL`
 
 	var dataText model.DataTextResponse
-	err := pstg.db.QueryRow(query, data.DataTextKey, data.PrivateUserKey).Scan(
+	err := pstg.db.QueryRowContext(ctx, query, data.DataTextKey, data.PrivateUserKey).Scan(
 		&dataText.DataTextKey,
 		&dataText.Data,
+		&dataText.Revision,
 	)
 
 	if err != nil {
-		return model.DataTextResponse{}, err
+		return model.DataTextResponse{}, notFound(err)
 	}
 
 	return dataText, nil
 }
 
-func (pstg *PostgreSQL) DeleteDataText(data model.DataText) error {
-	query := `DELETE FROM data_text
-              WHERE data_text_key = $1 AND private_user_key = $2`
+func (pstg *PostgreSQL) UpdateDataText(ctx context.Context, data model.DataText) (model.DataTextResponse, error) {
+	result := model.DataTextResponse{DataTextKey: data.DataTextKey}
+	conflict := false
+	err := pstg.inTx(ctx, func(tx *sql.Tx) error {
+		var err error
+		conflict, err = saveConflict(ctx, tx, model.RecordText, data.DataTextKey, data.PrivateUserKey, data.Revision, data)
+		if err != nil || conflict {
+			return err
+		}
+
+		result.Revision, err = updateDataText(ctx, tx, data)
+		if err != nil {
+			return err
+		}
+		return saveHistory(ctx, tx, model.RecordText, data.DataTextKey, model.ChangeUpdated)
+	})
+	if err != nil {
+		return model.DataTextResponse{}, err
+	}
+	if conflict {
+		return model.DataTextResponse{}, cerrors.ErrConflict
+	}
 
-	_, err := pstg.db.Exec(query, data.DataTextKey, data.PrivateUserKey)
+	return result, nil
+}
+
+// updateDataText изменяет текстовую запись в рамках транзакции и возвращает её новую ревизию.
+func updateDataText(ctx context.Context, tx *sql.Tx, data model.DataText) (int64, error) {
+	query := `UPDATE data_text SET data = $3, revision = $4, updated_at = now()
+              WHERE data_text_key = $1 AND private_user_key = $2 AND deleted_at IS NULL`
+
+	revision, err := nextRevision(ctx, tx, data.PrivateUserKey)
 	if err != nil {
-		return err
+		return 0, err
 	}
 
-	return nil
+	return revision, execAffected(ctx, tx, query, data.DataTextKey, data.PrivateUserKey, data.Data, revision)
 }
 
-func (pstg *PostgreSQL) InsertDataBinary(data model.DataBinary) (model.DataBinaryResponse, error) {
-	query := `INSERT INTO data_binary (private_user_key, filename, data)
-		VALUES ($1, $2, $3) RETURNING data_binary_key`
+func (pstg *PostgreSQL) DeleteDataText(ctx context.Context, data model.DataText) error {
+	query := `UPDATE data_text SET deleted_at = now(), updated_at = now(), revision = $3
+              WHERE data_text_key = $1 AND private_user_key = $2 AND deleted_at IS NULL`
+
+	return pstg.inTx(ctx, func(tx *sql.Tx) error {
+		revision, err := nextRevision(ctx, tx, data.PrivateUserKey)
+		if err != nil {
+			return err
+		}
+		err = execAffected(ctx, tx, query, data.DataTextKey, data.PrivateUserKey, revision)
+		if err != nil {
+			return err
+		}
+		return saveHistory(ctx, tx, model.RecordText, data.DataTextKey, model.ChangeDeleted)
+	})
+}
+
+func (pstg *PostgreSQL) InsertDataBinary(ctx context.Context, data model.DataBinary) (model.DataBinaryResponse, error) {
+	query := `INSERT INTO data_binary (private_user_key, filename, data, revision, vault_key)
+		VALUES ($1, $2, $3, $4, (SELECT vault_key FROM vaults WHERE vault_key = $1)) RETURNING data_binary_key`
 
-	var insertedUUID uuid.UUID
+	var result model.DataBinaryResponse
 	binaryData := []byte(data.Data)
-	err := pstg.db.QueryRow(query, data.PrivateUserKey, data.FileName, binaryData).Scan(&insertedUUID)
+	err := pstg.inTx(ctx, func(tx *sql.Tx) error {
+		var err error
+		result.Revision, err = nextRevision(ctx, tx, data.PrivateUserKey)
+		if err != nil {
+			return err
+		}
+		err = tx.QueryRowContext(ctx, query, data.PrivateUserKey, data.FileName, binaryData, result.Revision).Scan(&result.DataBinaryKey)
+		if err != nil {
+			return err
+		}
+		return saveHistory(ctx, tx, model.RecordBinary, result.DataBinaryKey, model.ChangeCreated)
+	})
 	if err != nil {
 		return model.DataBinaryResponse{}, err
 	}
 
-	return model.DataBinaryResponse{DataBinaryKey: insertedUUID}, nil
+	return result, nil
 }
 
-func (pstg *PostgreSQL) SelectDataBinary(data model.DataBinary) (model.DataBinaryResponse, error) {
-	query := `SELECT data_binary_key, filename, data
+func (pstg *PostgreSQL) SelectDataBinary(ctx context.Context, data model.DataBinary) (model.DataBinaryResponse, error) {
+	query := `SELECT data_binary_key, filename, data, revision
               FROM data_binary
-              WHERE data_binary_key = $1 AND private_user_key = $2`
+              WHERE data_binary_key = $1 AND private_user_key = $2 AND deleted_at IS NULL`
 
 	var dataBinary model.DataBinaryResponse
-	err := pstg.db.QueryRow(query, data.DataBinaryKey, data.PrivateUserKey).Scan(
+	err := pstg.db.QueryRowContext(ctx, query, data.DataBinaryKey, data.PrivateUserKey).Scan(
 		&dataBinary.DataBinaryKey,
 		&dataBinary.FileName,
 		&dataBinary.Data,
+		&dataBinary.Revision,
 	)
 
 	if err != nil {
-		return model.DataBinaryResponse{}, err
+		return model.DataBinaryResponse{}, notFound(err)
 	}
 
 	return dataBinary, nil
 }
 
-func (pstg *PostgreSQL) DeleteDataBinary(data model.DataBinary) error {
-	query := `DELETE FROM data_binary
-              WHERE data_binary_key = $1 AND private_user_key = $2`
+func (pstg *PostgreSQL) UpdateDataBinary(ctx context.Context, data model.DataBinary) (model.DataBinaryResponse, error) {
+	result := model.DataBinaryResponse{DataBinaryKey: data.DataBinaryKey}
+	conflict := false
+	err := pstg.inTx(ctx, func(tx *sql.Tx) error {
+		var err error
+		conflict, err = saveConflict(ctx, tx, model.RecordBinary, data.DataBinaryKey, data.PrivateUserKey, data.Revision, data)
+		if err != nil || conflict {
+			return err
+		}
+
+		result.Revision, err = updateDataBinary(ctx, tx, data)
+		if err != nil {
+			return err
+		}
+		return saveHistory(ctx, tx, model.RecordBinary, data.DataBinaryKey, model.ChangeUpdated)
+	})
+	if err != nil {
+		return model.DataBinaryResponse{}, err
+	}
+	if conflict {
+		return model.DataBinaryResponse{}, cerrors.ErrConflict
+	}
 
-	_, err := pstg.db.Exec(query, data.DataBinaryKey, data.PrivateUserKey)
+	return result, nil
+}
+
+// updateDataBinary изменяет бинарную запись в рамках транзакции и возвращает её новую ревизию.
+func updateDataBinary(ctx context.Context, tx *sql.Tx, data model.DataBinary) (int64, error) {
+	query := `UPDATE data_binary SET filename = $3, data = $4, revision = $5, updated_at = now()
+              WHERE data_binary_key = $1 AND private_user_key = $2 AND deleted_at IS NULL`
+
+	revision, err := nextRevision(ctx, tx, data.PrivateUserKey)
 	if err != nil {
-		return err
+		return 0, err
 	}
 
-	return nil
+	binaryData := []byte(data.Data)
+	return revision, execAffected(ctx, tx, query, data.DataBinaryKey, data.PrivateUserKey, data.FileName, binaryData, revision)
+}
+
+func (pstg *PostgreSQL) DeleteDataBinary(ctx context.Context, data model.DataBinary) error {
+	query := `UPDATE data_binary SET deleted_at = now(), updated_at = now(), revision = $3
+              WHERE data_binary_key = $1 AND private_user_key = $2 AND deleted_at IS NULL`
+
+	return pstg.inTx(ctx, func(tx *sql.Tx) error {
+		revision, err := nextRevision(ctx, tx, data.PrivateUserKey)
+		if err != nil {
+			return err
+		}
+		err = execAffected(ctx, tx, query, data.DataBinaryKey, data.PrivateUserKey, revision)
+		if err != nil {
+			return err
+		}
+		return saveHistory(ctx, tx, model.RecordBinary, data.DataBinaryKey, model.ChangeDeleted)
+	})
 }
 
-func (pstg *PostgreSQL) InsertDataCard(data model.DataCreditCard) (model.DataCreditCardResponse, error) {
+func (pstg *PostgreSQL) InsertDataCard(ctx context.Context, data model.DataCreditCard) (model.DataCreditCardResponse, error) {
 	query := `INSERT INTO public.data_credit_cards (
                                       card_number, 
                                       cardholder_name, 
                                       expiration_date, 
-                                      cvv_hash, 
-                                      private_user_key) 
-		VALUES ($1, $2, $3, $4, $5) RETURNING data_credit_card_key`
-
-	var insertedUUID uuid.UUID
+                                      cvv, 
+                                      pin,
+                                      brand,
+                                      expires_on,
+                                      card_type,
+                                      issuing_bank,
+                                      billing_address,
+                                      notes,
+                                      private_user_key,
+                                      revision,
+                                      vault_key) 
+		VALUES ($1, $2, $3, $4, $5, $6, NULLIF($7, '')::date, $8, $9, $10, $11, $12, $13,
+		        (SELECT vault_key FROM vaults WHERE vault_key = $12)) RETURNING data_credit_card_key`
+
+	if err := sealCard(&data); err != nil {
+		return model.DataCreditCardResponse{}, err
+	}
 
-	err := pstg.db.QueryRow(
-		query,
-		data.CardNumber,
-		data.CardholderName,
-		data.ExpirationDate,
-		data.CVVHash,
-		data.PrivateUserKey,
-	).Scan(&insertedUUID)
+	result := cardResult(data)
+	err := pstg.inTx(ctx, func(tx *sql.Tx) error {
+		var err error
+		result.Revision, err = nextRevision(ctx, tx, data.PrivateUserKey)
+		if err != nil {
+			return err
+		}
+		err = tx.QueryRowContext(ctx,
+			query,
+			data.CardNumber,
+			data.CardholderName,
+			data.ExpirationDate,
+			data.CVV,
+			data.PIN,
+			data.Brand,
+			data.ExpiresOn,
+			data.CardType,
+			data.IssuingBank,
+			data.BillingAddress,
+			data.Notes,
+			data.PrivateUserKey,
+			result.Revision,
+		).Scan(&result.DataCreditCardKey)
+		if err != nil {
+			return err
+		}
+		return saveHistory(ctx, tx, model.RecordCard, result.DataCreditCardKey, model.ChangeCreated)
+	})
 	if err != nil {
 		return model.DataCreditCardResponse{}, err
 	}
 
-	return model.DataCreditCardResponse{
-		DataCreditCardKey: insertedUUID,
-	}, nil
+	return result, nil
 }
 
-func (pstg *PostgreSQL) SelectDataCard(data model.DataCreditCard) (model.DataCreditCardResponse, error) {
+func (pstg *PostgreSQL) SelectDataCard(ctx context.Context, data model.DataCreditCard) (model.DataCreditCardResponse, error) {
 	query := `SELECT 
     	data_credit_card_key, 
        	card_number, 
        	cardholder_name,
        	expiration_date,
-       	cvv_hash,
-       	created_at
+       	cvv,
+       	pin,
+       	brand,
+       	COALESCE(to_char(expires_on, 'YYYY-MM-DD'), ''),
+       	card_type,
+       	issuing_bank,
+       	billing_address,
+       	notes,
+       	created_at,
+       	revision
               FROM data_credit_cards
-              WHERE data_credit_card_key = $1 AND private_user_key = $2`
+              WHERE data_credit_card_key = $1 AND private_user_key = $2 AND deleted_at IS NULL`
 
 	var dataCreditCard model.DataCreditCardResponse
-	err := pstg.db.QueryRow(query, data.DataCreditCardKey, data.PrivateUserKey).Scan(
+	err := pstg.db.QueryRowContext(ctx, query, data.DataCreditCardKey, data.PrivateUserKey).Scan(
 		&dataCreditCard.DataCreditCardKey,
 		&dataCreditCard.CardNumber,
 		&dataCreditCard.CardholderName,
 		&dataCreditCard.ExpirationDate,
-		&dataCreditCard.CVVHash,
+		&dataCreditCard.CVV,
+		&dataCreditCard.PIN,
+		&dataCreditCard.Brand,
+		&dataCreditCard.ExpiresOn,
+		&dataCreditCard.CardType,
+		&dataCreditCard.IssuingBank,
+		&dataCreditCard.BillingAddress,
+		&dataCreditCard.Notes,
 		&dataCreditCard.CreatedAt,
+		&dataCreditCard.Revision,
 	)
 
 	if err != nil {
+		return model.DataCreditCardResponse{}, notFound(err)
+	}
+	if err := openCard(&dataCreditCard); err != nil {
 		return model.DataCreditCardResponse{}, err
 	}
 
 	return dataCreditCard, nil
 }
 
-func (pstg *PostgreSQL) DeleteDataCard(data model.DataCreditCard) error {
-	query := `DELETE FROM data_credit_cards
-              WHERE data_credit_card_key = $1 AND private_user_key = $2`
+func (pstg *PostgreSQL) UpdateDataCard(ctx context.Context, data model.DataCreditCard) (model.DataCreditCardResponse, error) {
+	// Конфликтная копия тоже сохраняется с зашифрованными полями.
+	if err := sealCard(&data); err != nil {
+		return model.DataCreditCardResponse{}, err
+	}
 
-	_, err := pstg.db.Exec(query, data.DataCreditCardKey, data.PrivateUserKey)
+	result := cardResult(data)
+	conflict := false
+	err := pstg.inTx(ctx, func(tx *sql.Tx) error {
+		var err error
+		conflict, err = saveConflict(ctx, tx, model.RecordCard, data.DataCreditCardKey, data.PrivateUserKey, data.Revision, data)
+		if err != nil || conflict {
+			return err
+		}
+
+		result.Revision, err = updateDataCard(ctx, tx, data)
+		if err != nil {
+			return err
+		}
+		return saveHistory(ctx, tx, model.RecordCard, data.DataCreditCardKey, model.ChangeUpdated)
+	})
 	if err != nil {
-		return err
+		return model.DataCreditCardResponse{}, err
+	}
+	if conflict {
+		return model.DataCreditCardResponse{}, cerrors.ErrConflict
 	}
 
-	return nil
+	return result, nil
+}
+
+// cardResult возвращает ответ на сохранение карты с полями, которые сервер вычислил
+// или привёл к каноническому виду, чтобы клиент мог обновить свою копию.
+func cardResult(data model.DataCreditCard) model.DataCreditCardResponse {
+	return model.DataCreditCardResponse{
+		DataCreditCardKey: data.DataCreditCardKey,
+		CardNumber:        data.CardNumber,
+		ExpirationDate:    data.ExpirationDate,
+		Brand:             data.Brand,
+		ExpiresOn:         data.ExpiresOn,
+	}
+}
+
+// updateDataCard изменяет карту в рамках транзакции и возвращает её новую ревизию.
+// Секретные поля шифруются, если ещё не зашифрованы, например при восстановлении старой версии.
+func updateDataCard(ctx context.Context, tx *sql.Tx, data model.DataCreditCard) (int64, error) {
+	query := `UPDATE data_credit_cards SET
+                             card_number = $3,
+                             cardholder_name = $4,
+                             expiration_date = $5,
+                             cvv = $6,
+                             pin = $7,
+                             brand = $8,
+                             expires_on = NULLIF($9, '')::date,
+                             card_type = $10,
+                             issuing_bank = $11,
+                             billing_address = $12,
+                             notes = $13,
+                             revision = $14,
+                             updated_at = now()
+              WHERE data_credit_card_key = $1 AND private_user_key = $2 AND deleted_at IS NULL`
+
+	if err := sealCard(&data); err != nil {
+		return 0, err
+	}
+
+	revision, err := nextRevision(ctx, tx, data.PrivateUserKey)
+	if err != nil {
+		return 0, err
+	}
+
+	return revision, execAffected(
+		ctx,
+		tx,
+		query,
+		data.DataCreditCardKey,
+		data.PrivateUserKey,
+		data.CardNumber,
+		data.CardholderName,
+		data.ExpirationDate,
+		data.CVV,
+		data.PIN,
+		data.Brand,
+		data.ExpiresOn,
+		data.CardType,
+		data.IssuingBank,
+		data.BillingAddress,
+		data.Notes,
+		revision,
+	)
+}
+
+func (pstg *PostgreSQL) DeleteDataCard(ctx context.Context, data model.DataCreditCard) error {
+	query := `UPDATE data_credit_cards SET deleted_at = now(), updated_at = now(), revision = $3
+              WHERE data_credit_card_key = $1 AND private_user_key = $2 AND deleted_at IS NULL`
+
+	return pstg.inTx(ctx, func(tx *sql.Tx) error {
+		revision, err := nextRevision(ctx, tx, data.PrivateUserKey)
+		if err != nil {
+			return err
+		}
+		err = execAffected(ctx, tx, query, data.DataCreditCardKey, data.PrivateUserKey, revision)
+		if err != nil {
+			return err
+		}
+		return saveHistory(ctx, tx, model.RecordCard, data.DataCreditCardKey, model.ChangeDeleted)
+	})
+}
+
+func (pstg *PostgreSQL) InsertDataTOTP(ctx context.Context, data model.DataTOTP) (model.DataTOTPResponse, error) {
+	query := `INSERT INTO public.data_totp (private_user_key, secret, issuer, account, algorithm, digits, period, revision, vault_key)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, (SELECT vault_key FROM vaults WHERE vault_key = $1)) RETURNING data_totp_key`
+
+	result := totpResult(data)
+	if err := sealTOTP(&data); err != nil {
+		return model.DataTOTPResponse{}, err
+	}
+
+	err := pstg.inTx(ctx, func(tx *sql.Tx) error {
+		var err error
+		result.Revision, err = nextRevision(ctx, tx, data.PrivateUserKey)
+		if err != nil {
+			return err
+		}
+		err = tx.QueryRowContext(ctx,
+			query,
+			data.PrivateUserKey,
+			data.Secret,
+			data.Issuer,
+			data.Account,
+			data.Algorithm,
+			data.Digits,
+			data.Period,
+			result.Revision,
+		).Scan(&result.DataTOTPKey)
+		if err != nil {
+			return err
+		}
+		return saveHistory(ctx, tx, model.RecordTOTP, result.DataTOTPKey, model.ChangeCreated)
+	})
+	if err != nil {
+		return model.DataTOTPResponse{}, err
+	}
+
+	return result, nil
+}
+
+func (pstg *PostgreSQL) SelectDataTOTP(ctx context.Context, data model.DataTOTP) (model.DataTOTPResponse, error) {
+	query := `SELECT data_totp_key, secret, issuer, account, algorithm, digits, period, created_at, revision
+              FROM data_totp
+              WHERE data_totp_key = $1 AND private_user_key = $2 AND deleted_at IS NULL`
+
+	var dataTOTP model.DataTOTPResponse
+	err := pstg.db.QueryRowContext(ctx, query, data.DataTOTPKey, data.PrivateUserKey).Scan(
+		&dataTOTP.DataTOTPKey,
+		&dataTOTP.Secret,
+		&dataTOTP.Issuer,
+		&dataTOTP.Account,
+		&dataTOTP.Algorithm,
+		&dataTOTP.Digits,
+		&dataTOTP.Period,
+		&dataTOTP.CreatedAt,
+		&dataTOTP.Revision,
+	)
+
+	if err != nil {
+		return model.DataTOTPResponse{}, notFound(err)
+	}
+	if err := openTOTP(&dataTOTP); err != nil {
+		return model.DataTOTPResponse{}, err
+	}
+
+	return dataTOTP, nil
+}
+
+func (pstg *PostgreSQL) UpdateDataTOTP(ctx context.Context, data model.DataTOTP) (model.DataTOTPResponse, error) {
+	result := totpResult(data)
+	// Конфликтная копия тоже сохраняется с зашифрованным секретом.
+	if err := sealTOTP(&data); err != nil {
+		return model.DataTOTPResponse{}, err
+	}
+
+	conflict := false
+	err := pstg.inTx(ctx, func(tx *sql.Tx) error {
+		var err error
+		conflict, err = saveConflict(ctx, tx, model.RecordTOTP, data.DataTOTPKey, data.PrivateUserKey, data.Revision, data)
+		if err != nil || conflict {
+			return err
+		}
+
+		result.Revision, err = updateDataTOTP(ctx, tx, data)
+		if err != nil {
+			return err
+		}
+		return saveHistory(ctx, tx, model.RecordTOTP, data.DataTOTPKey, model.ChangeUpdated)
+	})
+	if err != nil {
+		return model.DataTOTPResponse{}, err
+	}
+	if conflict {
+		return model.DataTOTPResponse{}, cerrors.ErrConflict
+	}
+
+	return result, nil
+}
+
+// totpResult возвращает ответ на сохранение записи TOTP с параметрами, которые сервер
+// взял из ссылки otpauth:// или заполнил значениями по умолчанию. Секрет в ответ не попадает.
+func totpResult(data model.DataTOTP) model.DataTOTPResponse {
+	return model.DataTOTPResponse{
+		DataTOTPKey: data.DataTOTPKey,
+		Issuer:      data.Issuer,
+		Account:     data.Account,
+		Algorithm:   data.Algorithm,
+		Digits:      data.Digits,
+		Period:      data.Period,
+	}
+}
+
+// updateDataTOTP изменяет запись TOTP в рамках транзакции и возвращает её новую ревизию.
+// Секрет шифруется, если ещё не зашифрован, например при восстановлении старой версии.
+func updateDataTOTP(ctx context.Context, tx *sql.Tx, data model.DataTOTP) (int64, error) {
+	query := `UPDATE data_totp SET secret = $3, issuer = $4, account = $5, algorithm = $6, digits = $7, period = $8,
+                             revision = $9, updated_at = now()
+              WHERE data_totp_key = $1 AND private_user_key = $2 AND deleted_at IS NULL`
+
+	if err := sealTOTP(&data); err != nil {
+		return 0, err
+	}
+
+	revision, err := nextRevision(ctx, tx, data.PrivateUserKey)
+	if err != nil {
+		return 0, err
+	}
+
+	return revision, execAffected(
+		ctx,
+		tx,
+		query,
+		data.DataTOTPKey,
+		data.PrivateUserKey,
+		data.Secret,
+		data.Issuer,
+		data.Account,
+		data.Algorithm,
+		data.Digits,
+		data.Period,
+		revision,
+	)
+}
+
+func (pstg *PostgreSQL) DeleteDataTOTP(ctx context.Context, data model.DataTOTP) error {
+	query := `UPDATE data_totp SET deleted_at = now(), updated_at = now(), revision = $3
+              WHERE data_totp_key = $1 AND private_user_key = $2 AND deleted_at IS NULL`
+
+	return pstg.inTx(ctx, func(tx *sql.Tx) error {
+		revision, err := nextRevision(ctx, tx, data.PrivateUserKey)
+		if err != nil {
+			return err
+		}
+		err = execAffected(ctx, tx, query, data.DataTOTPKey, data.PrivateUserKey, revision)
+		if err != nil {
+			return err
+		}
+		return saveHistory(ctx, tx, model.RecordTOTP, data.DataTOTPKey, model.ChangeDeleted)
+	})
+}
+
+func (pstg *PostgreSQL) InsertDataSSHKey(ctx context.Context, data model.DataSSHKey) (model.DataSSHKeyResponse, error) {
+	query := `INSERT INTO public.data_ssh_keys (private_user_key, private_key, public_key, comment, passphrase, fingerprint, revision, vault_key)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, (SELECT vault_key FROM vaults WHERE vault_key = $1)) RETURNING data_ssh_key_key`
+
+	result := sshKeyResult(data)
+	if err := sealSSHKey(&data); err != nil {
+		return model.DataSSHKeyResponse{}, err
+	}
+
+	err := pstg.inTx(ctx, func(tx *sql.Tx) error {
+		var err error
+		result.Revision, err = nextRevision(ctx, tx, data.PrivateUserKey)
+		if err != nil {
+			return err
+		}
+		err = tx.QueryRowContext(ctx,
+			query,
+			data.PrivateUserKey,
+			data.PrivateKey,
+			data.PublicKey,
+			data.Comment,
+			data.Passphrase,
+			data.Fingerprint,
+			result.Revision,
+		).Scan(&result.DataSSHKeyKey)
+		if err != nil {
+			return err
+		}
+		return saveHistory(ctx, tx, model.RecordSSH, result.DataSSHKeyKey, model.ChangeCreated)
+	})
+	if err != nil {
+		return model.DataSSHKeyResponse{}, err
+	}
+
+	return result, nil
+}
+
+func (pstg *PostgreSQL) SelectDataSSHKey(ctx context.Context, data model.DataSSHKey) (model.DataSSHKeyResponse, error) {
+	query := `SELECT data_ssh_key_key, private_key, public_key, comment, passphrase, fingerprint, created_at, revision
+              FROM data_ssh_keys
+              WHERE data_ssh_key_key = $1 AND private_user_key = $2 AND deleted_at IS NULL`
+
+	var dataSSHKey model.DataSSHKeyResponse
+	err := pstg.db.QueryRowContext(ctx, query, data.DataSSHKeyKey, data.PrivateUserKey).Scan(
+		&dataSSHKey.DataSSHKeyKey,
+		&dataSSHKey.PrivateKey,
+		&dataSSHKey.PublicKey,
+		&dataSSHKey.Comment,
+		&dataSSHKey.Passphrase,
+		&dataSSHKey.Fingerprint,
+		&dataSSHKey.CreatedAt,
+		&dataSSHKey.Revision,
+	)
+
+	if err != nil {
+		return model.DataSSHKeyResponse{}, notFound(err)
+	}
+	if err := openSSHKey(&dataSSHKey); err != nil {
+		return model.DataSSHKeyResponse{}, err
+	}
+
+	return dataSSHKey, nil
+}
+
+func (pstg *PostgreSQL) UpdateDataSSHKey(ctx context.Context, data model.DataSSHKey) (model.DataSSHKeyResponse, error) {
+	result := sshKeyResult(data)
+	// Конфликтная копия тоже сохраняется с зашифрованными полями.
+	if err := sealSSHKey(&data); err != nil {
+		return model.DataSSHKeyResponse{}, err
+	}
+
+	conflict := false
+	err := pstg.inTx(ctx, func(tx *sql.Tx) error {
+		var err error
+		conflict, err = saveConflict(ctx, tx, model.RecordSSH, data.DataSSHKeyKey, data.PrivateUserKey, data.Revision, data)
+		if err != nil || conflict {
+			return err
+		}
+
+		result.Revision, err = updateDataSSHKey(ctx, tx, data)
+		if err != nil {
+			return err
+		}
+		return saveHistory(ctx, tx, model.RecordSSH, data.DataSSHKeyKey, model.ChangeUpdated)
+	})
+	if err != nil {
+		return model.DataSSHKeyResponse{}, err
+	}
+	if conflict {
+		return model.DataSSHKeyResponse{}, cerrors.ErrConflict
+	}
+
+	return result, nil
+}
+
+// sshKeyResult возвращает ответ на сохранение ключа SSH без закрытого ключа и пароля.
+func sshKeyResult(data model.DataSSHKey) model.DataSSHKeyResponse {
+	return model.DataSSHKeyResponse{
+		DataSSHKeyKey: data.DataSSHKeyKey,
+		PublicKey:     data.PublicKey,
+		Comment:       data.Comment,
+		Fingerprint:   data.Fingerprint,
+	}
+}
+
+// updateDataSSHKey изменяет ключ SSH в рамках транзакции и возвращает его новую ревизию.
+// Секретные поля шифруются, если ещё не зашифрованы, например при восстановлении старой версии.
+func updateDataSSHKey(ctx context.Context, tx *sql.Tx, data model.DataSSHKey) (int64, error) {
+	query := `UPDATE data_ssh_keys SET private_key = $3, public_key = $4, comment = $5, passphrase = $6, fingerprint = $7,
+                             revision = $8, updated_at = now()
+              WHERE data_ssh_key_key = $1 AND private_user_key = $2 AND deleted_at IS NULL`
+
+	if err := sealSSHKey(&data); err != nil {
+		return 0, err
+	}
+
+	revision, err := nextRevision(ctx, tx, data.PrivateUserKey)
+	if err != nil {
+		return 0, err
+	}
+
+	return revision, execAffected(
+		ctx,
+		tx,
+		query,
+		data.DataSSHKeyKey,
+		data.PrivateUserKey,
+		data.PrivateKey,
+		data.PublicKey,
+		data.Comment,
+		data.Passphrase,
+		data.Fingerprint,
+		revision,
+	)
+}
+
+func (pstg *PostgreSQL) DeleteDataSSHKey(ctx context.Context, data model.DataSSHKey) error {
+	query := `UPDATE data_ssh_keys SET deleted_at = now(), updated_at = now(), revision = $3
+              WHERE data_ssh_key_key = $1 AND private_user_key = $2 AND deleted_at IS NULL`
+
+	return pstg.inTx(ctx, func(tx *sql.Tx) error {
+		revision, err := nextRevision(ctx, tx, data.PrivateUserKey)
+		if err != nil {
+			return err
+		}
+		err = execAffected(ctx, tx, query, data.DataSSHKeyKey, data.PrivateUserKey, revision)
+		if err != nil {
+			return err
+		}
+		return saveHistory(ctx, tx, model.RecordSSH, data.DataSSHKeyKey, model.ChangeDeleted)
+	})
+}
+
+func (pstg *PostgreSQL) InsertDataCustom(ctx context.Context, data model.DataCustom) (model.DataCustomResponse, error) {
+	query := `INSERT INTO public.data_custom (private_user_key, template_key, fields, revision, vault_key)
+		VALUES ($1, $2, $3, $4, (SELECT vault_key FROM vaults WHERE vault_key = $1)) RETURNING data_custom_key`
+
+	result := customResult(data)
+	err := pstg.inTx(ctx, func(tx *sql.Tx) error {
+		if err := sealCustom(ctx, tx, &data); err != nil {
+			return err
+		}
+		fields, err := json.Marshal(data.Fields)
+		if err != nil {
+			return err
+		}
+
+		result.Revision, err = nextRevision(ctx, tx, data.PrivateUserKey)
+		if err != nil {
+			return err
+		}
+		err = tx.QueryRowContext(ctx,
+			query,
+			data.PrivateUserKey,
+			data.TemplateKey,
+			fields,
+			result.Revision,
+		).Scan(&result.DataCustomKey)
+		if err != nil {
+			return err
+		}
+		return saveHistory(ctx, tx, model.RecordCustom, result.DataCustomKey, model.ChangeCreated)
+	})
+	if err != nil {
+		return model.DataCustomResponse{}, err
+	}
+
+	return result, nil
+}
+
+func (pstg *PostgreSQL) SelectDataCustom(ctx context.Context, data model.DataCustom) (model.DataCustomResponse, error) {
+	query := `SELECT c.data_custom_key, c.template_key, t.name, c.fields, c.created_at, c.revision
+              FROM data_custom c
+              JOIN record_templates t ON t.template_key = c.template_key
+              WHERE c.data_custom_key = $1 AND c.private_user_key = $2 AND c.deleted_at IS NULL`
+
+	var (
+		dataCustom model.DataCustomResponse
+		fields     []byte
+	)
+	err := pstg.db.QueryRowContext(ctx, query, data.DataCustomKey, data.PrivateUserKey).Scan(
+		&dataCustom.DataCustomKey,
+		&dataCustom.TemplateKey,
+		&dataCustom.TemplateName,
+		&fields,
+		&dataCustom.CreatedAt,
+		&dataCustom.Revision,
+	)
+
+	if err != nil {
+		return model.DataCustomResponse{}, notFound(err)
+	}
+	if err := json.Unmarshal(fields, &dataCustom.Fields); err != nil {
+		return model.DataCustomResponse{}, err
+	}
+	if err := openCustom(dataCustom.Fields); err != nil {
+		return model.DataCustomResponse{}, err
+	}
+
+	return dataCustom, nil
+}
+
+func (pstg *PostgreSQL) UpdateDataCustom(ctx context.Context, data model.DataCustom) (model.DataCustomResponse, error) {
+	result := customResult(data)
+
+	conflict := false
+	err := pstg.inTx(ctx, func(tx *sql.Tx) error {
+		// Конфликтная копия тоже сохраняется с зашифрованными полями.
+		if err := sealCustom(ctx, tx, &data); err != nil {
+			return err
+		}
+
+		var err error
+		conflict, err = saveConflict(ctx, tx, model.RecordCustom, data.DataCustomKey, data.PrivateUserKey, data.Revision, data)
+		if err != nil || conflict {
+			return err
+		}
+
+		result.Revision, err = updateDataCustom(ctx, tx, data)
+		if err != nil {
+			return err
+		}
+		return saveHistory(ctx, tx, model.RecordCustom, data.DataCustomKey, model.ChangeUpdated)
+	})
+	if err != nil {
+		return model.DataCustomResponse{}, err
+	}
+	if conflict {
+		return model.DataCustomResponse{}, cerrors.ErrConflict
+	}
+
+	return result, nil
+}
+
+// customResult возвращает ответ на сохранение пользовательской записи без значений полей:
+// среди них могут быть секретные.
+func customResult(data model.DataCustom) model.DataCustomResponse {
+	return model.DataCustomResponse{
+		DataCustomKey: data.DataCustomKey,
+		TemplateKey:   data.TemplateKey,
+	}
+}
+
+// updateDataCustom изменяет пользовательскую запись в рамках транзакции и возвращает её новую ревизию.
+// Шаблон записи не меняется; секретные поля шифруются, если ещё не зашифрованы.
+func updateDataCustom(ctx context.Context, tx *sql.Tx, data model.DataCustom) (int64, error) {
+	query := `UPDATE data_custom SET fields = $3, revision = $4, updated_at = now()
+              WHERE data_custom_key = $1 AND private_user_key = $2 AND deleted_at IS NULL`
+
+	if data.TemplateKey == uuid.Nil {
+		query := `SELECT template_key FROM data_custom WHERE data_custom_key = $1 AND private_user_key = $2`
+		if err := tx.QueryRowContext(ctx, query, data.DataCustomKey, data.PrivateUserKey).Scan(&data.TemplateKey); err != nil {
+			return 0, notFound(err)
+		}
+	}
+	if err := sealCustom(ctx, tx, &data); err != nil {
+		return 0, err
+	}
+	fields, err := json.Marshal(data.Fields)
+	if err != nil {
+		return 0, err
+	}
+
+	revision, err := nextRevision(ctx, tx, data.PrivateUserKey)
+	if err != nil {
+		return 0, err
+	}
+
+	return revision, execAffected(ctx, tx, query, data.DataCustomKey, data.PrivateUserKey, fields, revision)
+}
+
+func (pstg *PostgreSQL) DeleteDataCustom(ctx context.Context, data model.DataCustom) error {
+	query := `UPDATE data_custom SET deleted_at = now(), updated_at = now(), revision = $3
+              WHERE data_custom_key = $1 AND private_user_key = $2 AND deleted_at IS NULL`
+
+	return pstg.inTx(ctx, func(tx *sql.Tx) error {
+		revision, err := nextRevision(ctx, tx, data.PrivateUserKey)
+		if err != nil {
+			return err
+		}
+		err = execAffected(ctx, tx, query, data.DataCustomKey, data.PrivateUserKey, revision)
+		if err != nil {
+			return err
+		}
+		return saveHistory(ctx, tx, model.RecordCustom, data.DataCustomKey, model.ChangeDeleted)
+	})
 }
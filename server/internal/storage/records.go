@@ -0,0 +1,130 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"github.com/google/uuid"
+	"server/internal/cerrors"
+	"server/internal/model"
+)
+
+// recordTable описывает таблицу, в которой хранятся записи одного типа.
+type recordTable struct {
+	name     string // имя таблицы
+	key      string // столбец первичного ключа
+	snapshot string // выражение jsonb со строкой в формате ответа API, сохраняемое в историю
+	purge    string // присваивания SET, стирающие данные записи при очистке корзины
+}
+
+// recordTables сопоставляет типы записей их таблицам.
+var recordTables = map[string]recordTable{
+	model.RecordText: {
+		name:     "data_text",
+		key:      "data_text_key",
+		snapshot: `jsonb_build_object('data_text_key', data_text_key, 'data', data, 'revision', revision)`,
+		purge:    `data = ''`,
+	},
+	model.RecordBinary: {
+		name: "data_binary",
+		key:  "data_binary_key",
+		snapshot: `jsonb_build_object('data_binary_key', data_binary_key, 'filename', filename,
+                                      'data', convert_from(data, 'UTF8'), 'revision', revision)`,
+		purge: `filename = '', data = ''::bytea`,
+	},
+	model.RecordCard: {
+		name: "data_credit_cards",
+		key:  "data_credit_card_key",
+		snapshot: `jsonb_build_object('data_credit_card_key', data_credit_card_key, 'card_number', card_number,
+                                      'cardholder_name', cardholder_name, 'expiration_date', expiration_date,
+                                      'cvv', cvv, 'pin', pin, 'brand', brand, 'expires_on', expires_on,
+                                      'card_type', card_type, 'issuing_bank', issuing_bank,
+                                      'billing_address', billing_address, 'notes', notes,
+                                      'created_at', created_at, 'revision', revision)`,
+		purge: `card_number = '', cardholder_name = '', expiration_date = '', cvv = '', pin = '', brand = '', expires_on = NULL,
+                card_type = '', issuing_bank = '', billing_address = '', notes = ''`,
+	},
+	model.RecordTOTP: {
+		name: "data_totp",
+		key:  "data_totp_key",
+		snapshot: `jsonb_build_object('data_totp_key', data_totp_key, 'secret', secret, 'issuer', issuer,
+                                      'account', account, 'algorithm', algorithm, 'digits', digits,
+                                      'period', period, 'created_at', created_at, 'revision', revision)`,
+		purge: `secret = '', issuer = '', account = ''`,
+	},
+	model.RecordSSH: {
+		name: "data_ssh_keys",
+		key:  "data_ssh_key_key",
+		snapshot: `jsonb_build_object('data_ssh_key_key', data_ssh_key_key, 'private_key', private_key,
+                                      'public_key', public_key, 'comment', comment, 'passphrase', passphrase,
+                                      'fingerprint', fingerprint, 'created_at', created_at, 'revision', revision)`,
+		purge: `private_key = '', public_key = '', comment = '', passphrase = '', fingerprint = ''`,
+	},
+	model.RecordCustom: {
+		name: "data_custom",
+		key:  "data_custom_key",
+		snapshot: `jsonb_build_object('data_custom_key', data_custom_key, 'template_key', template_key,
+                                      'fields', fields, 'created_at', created_at, 'revision', revision)`,
+		purge: `fields = '{}'::jsonb`,
+	},
+}
+
+// inTx выполняет fn в транзакции и откатывает её, если fn вернула ошибку.
+func (pstg *PostgreSQL) inTx(ctx context.Context, fn func(tx *sql.Tx) error) error {
+	tx, err := pstg.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	if err := fn(tx); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// nextRevision увеличивает ревизию владельца записей — пользователя или хранилища организации —
+// и возвращает новое значение. Строка владельца блокируется до конца транзакции, поэтому ревизии
+// выдаются строго по возрастанию в порядке фиксации изменений.
+func nextRevision(ctx context.Context, tx *sql.Tx, privateUserKey uuid.UUID) (int64, error) {
+	query := `WITH u AS (UPDATE private_user SET revision = revision + 1
+                         WHERE private_user_key = $1 RETURNING revision),
+                   v AS (UPDATE vaults SET revision = revision + 1
+                         WHERE vault_key = $1 RETURNING revision)
+              SELECT revision FROM u UNION ALL SELECT revision FROM v`
+
+	var revision int64
+	err := tx.QueryRowContext(ctx, query, privateUserKey).Scan(&revision)
+	if err != nil {
+		return 0, err
+	}
+
+	return revision, nil
+}
+
+// execAffected выполняет запрос и возвращает cerrors.ErrNotFound, если ни одна строка не изменилась.
+func execAffected(ctx context.Context, tx *sql.Tx, query string, args ...any) error {
+	res, err := tx.ExecContext(ctx, query, args...)
+	if err != nil {
+		return err
+	}
+
+	count, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if count == 0 {
+		return cerrors.ErrNotFound
+	}
+
+	return nil
+}
+
+// notFound заменяет sql.ErrNoRows на cerrors.ErrNotFound.
+func notFound(err error) error {
+	if errors.Is(err, sql.ErrNoRows) {
+		return cerrors.ErrNotFound
+	}
+	return err
+}
@@ -0,0 +1,44 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+)
+
+// InsertRevokedSession сохраняет отзыв сессии до момента expiresAt, когда истекает её токен.
+// Повторный отзыв той же сессии не считается ошибкой.
+func (pstg *PostgreSQL) InsertRevokedSession(ctx context.Context, sessionKey string, expiresAt time.Time) error {
+	query := `INSERT INTO revoked_sessions (session_key, expires_at) VALUES ($1, $2)
+              ON CONFLICT (session_key) DO NOTHING`
+
+	_, err := pstg.db.ExecContext(ctx, query, sessionKey, expiresAt)
+	return err
+}
+
+// SelectSessionRevoked сообщает, отозвана ли сессия, и возвращает срок хранения отзыва.
+func (pstg *PostgreSQL) SelectSessionRevoked(ctx context.Context, sessionKey string) (bool, time.Time, error) {
+	query := `SELECT expires_at FROM revoked_sessions WHERE session_key = $1`
+
+	var expiresAt time.Time
+	err := pstg.db.QueryRowContext(ctx, query, sessionKey).Scan(&expiresAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return false, time.Time{}, nil
+	}
+	if err != nil {
+		return false, time.Time{}, err
+	}
+
+	return true, expiresAt, nil
+}
+
+// PurgeRevokedSessions удаляет отзывы сессий, токены которых уже истекли, и возвращает их количество.
+func (pstg *PostgreSQL) PurgeRevokedSessions(ctx context.Context) (int64, error) {
+	res, err := pstg.db.ExecContext(ctx, `DELETE FROM revoked_sessions WHERE expires_at <= now()`)
+	if err != nil {
+		return 0, err
+	}
+
+	return res.RowsAffected()
+}
@@ -0,0 +1,258 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"github.com/google/uuid"
+	"server/internal/cerrors"
+	"server/internal/model"
+	"sort"
+	"strings"
+)
+
+// SelectAccess возвращает владельца записи и права пользователя на неё.
+// Для владельца права пустые; если доступа нет, возвращается cerrors.ErrNotFound.
+func (pstg *PostgreSQL) SelectAccess(ctx context.Context, recordType string, key, privateUserKey uuid.UUID) (uuid.UUID, string, error) {
+	table, ok := recordTables[recordType]
+	if !ok {
+		return uuid.Nil, "", fmt.Errorf("unknown record type: %q", recordType)
+	}
+
+	query := fmt.Sprintf(`SELECT t.private_user_key, COALESCE(s.permission, '')
+              FROM %[1]s t
+              LEFT JOIN data_shares s
+                ON s.record_type = $3 AND s.record_key = t.%[2]s AND s.recipient_user_key = $2
+              WHERE t.%[2]s = $1 AND t.deleted_at IS NULL
+                AND (t.private_user_key = $2 OR s.permission IS NOT NULL)`, table.name, table.key)
+
+	var (
+		owner      uuid.UUID
+		permission string
+	)
+	err := pstg.db.QueryRowContext(ctx, query, key, privateUserKey, recordType).Scan(&owner, &permission)
+	if err != nil {
+		return uuid.Nil, "", notFound(err)
+	}
+
+	return owner, permission, nil
+}
+
+// InsertShare выдаёт получателю с логином share.Login доступ к записи владельца.
+// Повторная выдача заменяет права. Записи со значениями, зашифрованными на клиенте,
+// не передаются: клиенты не обмениваются ключами сквозного шифрования,
+// и получатель не смог бы их расшифровать.
+func (pstg *PostgreSQL) InsertShare(ctx context.Context, share model.Share) (model.Share, error) {
+	table, ok := recordTables[share.Type]
+	if !ok {
+		return model.Share{}, fmt.Errorf("unknown record type: %q", share.Type)
+	}
+
+	err := pstg.inTx(ctx, func(tx *sql.Tx) error {
+		query := fmt.Sprintf(`SELECT %s FROM %s
+              WHERE %s = $1 AND private_user_key = $2 AND deleted_at IS NULL FOR SHARE`,
+			table.snapshot, table.name, table.key)
+
+		var data []byte
+		err := tx.QueryRowContext(ctx, query, share.RecordKey, share.OwnerKey).Scan(&data)
+		if err != nil {
+			return notFound(err)
+		}
+		// Записи, сохранённые до появления clientSealedPrefix в sealField, хранят значения
+		// клиента зашифрованными на сервере, поэтому проверяются расшифрованные поля.
+		if data, err = openRecord(share.Type, data); err != nil {
+			return err
+		}
+		encrypted, err := holdsClientSealed(data)
+		if err != nil {
+			return err
+		}
+		if encrypted {
+			errs := &cerrors.ValidationError{}
+			errs.Add("record", "holds client-side encrypted values that the recipient can not decrypt")
+			return errs
+		}
+
+		var recipient uuid.UUID
+		err = tx.QueryRowContext(ctx, `SELECT private_user_key FROM private_user WHERE login = $1`, share.Login).Scan(&recipient)
+		if err != nil {
+			return notFound(err)
+		}
+		if recipient == share.OwnerKey {
+			return fmt.Errorf("record can not be shared with its owner")
+		}
+
+		query = `INSERT INTO data_shares (owner_user_key, recipient_user_key, record_type, record_key, permission)
+                 VALUES ($1, $2, $3, $4, $5)
+                 ON CONFLICT (record_type, record_key, recipient_user_key)
+                 DO UPDATE SET permission = EXCLUDED.permission
+                 RETURNING created_at`
+
+		return tx.QueryRowContext(ctx,
+			query,
+			share.OwnerKey,
+			recipient,
+			share.Type,
+			share.RecordKey,
+			share.Permission,
+		).Scan(&share.CreatedAt)
+	})
+	if err != nil {
+		return model.Share{}, err
+	}
+
+	return share, nil
+}
+
+// holdsClientSealed сообщает, есть ли в JSON-представлении записи строки с префиксом clientSealedPrefix.
+func holdsClientSealed(data []byte) (bool, error) {
+	var value any
+	if err := json.Unmarshal(data, &value); err != nil {
+		return false, err
+	}
+
+	pending := []any{value}
+	for len(pending) > 0 {
+		value, pending = pending[len(pending)-1], pending[:len(pending)-1]
+		switch v := value.(type) {
+		case string:
+			if strings.HasPrefix(v, clientSealedPrefix) {
+				return true, nil
+			}
+		case []any:
+			pending = append(pending, v...)
+		case map[string]any:
+			for _, item := range v {
+				pending = append(pending, item)
+			}
+		}
+	}
+
+	return false, nil
+}
+
+// SelectShares возвращает получателей записи владельца.
+func (pstg *PostgreSQL) SelectShares(ctx context.Context, recordType string, key, ownerKey uuid.UUID) ([]model.Share, error) {
+	query := `SELECT u.login, s.permission, s.created_at
+              FROM data_shares s
+              JOIN private_user u ON u.private_user_key = s.recipient_user_key
+              WHERE s.record_type = $1 AND s.record_key = $2 AND s.owner_user_key = $3
+              ORDER BY u.login`
+
+	rows, err := pstg.db.QueryContext(ctx, query, recordType, key, ownerKey)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	shares := []model.Share{}
+	for rows.Next() {
+		share := model.Share{Type: recordType, RecordKey: key, OwnerKey: ownerKey}
+		if err := rows.Scan(&share.Login, &share.Permission, &share.CreatedAt); err != nil {
+			return nil, err
+		}
+		shares = append(shares, share)
+	}
+
+	return shares, rows.Err()
+}
+
+// DeleteShare отзывает доступ получателя с логином login и возвращает его ключ.
+func (pstg *PostgreSQL) DeleteShare(ctx context.Context, recordType string, key, ownerKey uuid.UUID, login string) (uuid.UUID, error) {
+	query := `DELETE FROM data_shares s
+              USING private_user u
+              WHERE u.private_user_key = s.recipient_user_key AND u.login = $4
+                AND s.record_type = $1 AND s.record_key = $2 AND s.owner_user_key = $3
+              RETURNING s.recipient_user_key`
+
+	var recipient uuid.UUID
+	err := pstg.db.QueryRowContext(ctx, query, recordType, key, ownerKey, login).Scan(&recipient)
+	if err != nil {
+		return uuid.Nil, notFound(err)
+	}
+
+	return recipient, nil
+}
+
+// SelectSharedRecords возвращает записи других пользователей, доступные пользователю.
+func (pstg *PostgreSQL) SelectSharedRecords(ctx context.Context, privateUserKey uuid.UUID) ([]model.SharedRecord, error) {
+	records := []model.SharedRecord{}
+	for recordType, table := range recordTables {
+		query := fmt.Sprintf(`SELECT t.%[2]s, COALESCE(u.login, v.name), s.permission, %[3]s
+              FROM data_shares s
+              JOIN %[1]s t ON t.%[2]s = s.record_key
+              LEFT JOIN private_user u ON u.private_user_key = t.private_user_key
+              LEFT JOIN vaults v ON v.vault_key = t.vault_key
+              WHERE s.recipient_user_key = $1 AND s.record_type = $2 AND t.deleted_at IS NULL`,
+			table.name, table.key, table.snapshot)
+
+		rows, err := pstg.db.QueryContext(ctx, query, privateUserKey, recordType)
+		if err != nil {
+			return nil, err
+		}
+
+		for rows.Next() {
+			var (
+				record model.SharedRecord
+				data   []byte
+			)
+			err := rows.Scan(&record.Key, &record.Owner, &record.Permission, &data)
+			if err == nil {
+				data, err = openRecord(recordType, data)
+			}
+			if err != nil {
+				rows.Close()
+				return nil, err
+			}
+
+			record.Type, record.Data = recordType, data
+			records = append(records, record)
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return nil, err
+		}
+	}
+
+	sort.Slice(records, func(i, j int) bool {
+		if records[i].Owner != records[j].Owner {
+			return records[i].Owner < records[j].Owner
+		}
+		return records[i].Key.String() < records[j].Key.String()
+	})
+	return records, nil
+}
+
+// SelectE2EParams возвращает параметры сквозного шифрования пользователя или ErrNotFound,
+// если они ещё не заданы.
+func (pstg *PostgreSQL) SelectE2EParams(ctx context.Context, privateUserKey uuid.UUID) (model.E2EParams, error) {
+	query := `SELECT e2e_salt, e2e_check FROM private_user WHERE private_user_key = $1 AND e2e_salt IS NOT NULL`
+
+	var result model.E2EParams
+	err := pstg.db.QueryRowContext(ctx, query, privateUserKey).Scan(&result.Salt, &result.Check)
+	if err != nil {
+		return model.E2EParams{}, notFound(err)
+	}
+
+	return result, nil
+}
+
+// InsertE2EParams сохраняет параметры сквозного шифрования. Заданные параметры не заменяются:
+// иначе записи, зашифрованные прежним ключом, станут недоступны. Повторная попытка,
+// например с другого устройства, возвращает ErrConflict.
+func (pstg *PostgreSQL) InsertE2EParams(ctx context.Context, privateUserKey uuid.UUID, params model.E2EParams) error {
+	return pstg.inTx(ctx, func(tx *sql.Tx) error {
+		var salt sql.NullString
+		err := tx.QueryRowContext(ctx, `SELECT e2e_salt FROM private_user WHERE private_user_key = $1 FOR UPDATE`, privateUserKey).Scan(&salt)
+		if err != nil {
+			return notFound(err)
+		}
+		if salt.Valid {
+			return cerrors.ErrConflict
+		}
+
+		return execAffected(ctx, tx, `UPDATE private_user SET e2e_salt = $2, e2e_check = $3 WHERE private_user_key = $1`,
+			privateUserKey, params.Salt, params.Check)
+	})
+}
@@ -0,0 +1,354 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"github.com/google/uuid"
+	"server/internal/model"
+	"sort"
+	"time"
+)
+
+// SelectChanges возвращает записи пользователя, изменённые после ревизии since,
+// включая удалённые записи. Все запросы выполняются в одном снимке базы данных.
+func (pstg *PostgreSQL) SelectChanges(ctx context.Context, privateUserKey uuid.UUID, since int64) (model.SyncResponse, error) {
+	tx, err := pstg.db.BeginTx(ctx, &sql.TxOptions{
+		Isolation: sql.LevelRepeatableRead,
+		ReadOnly:  true,
+	})
+	if err != nil {
+		return model.SyncResponse{}, err
+	}
+	defer tx.Rollback()
+
+	result := model.SyncResponse{Changes: []model.SyncChange{}}
+	query := `SELECT revision FROM private_user WHERE private_user_key = $1
+              UNION ALL
+              SELECT revision FROM vaults WHERE vault_key = $1`
+	err = tx.QueryRowContext(ctx, query, privateUserKey).Scan(&result.Revision)
+	if err != nil {
+		return model.SyncResponse{}, err
+	}
+
+	for _, selectChanges := range []func(context.Context, *sql.Tx, uuid.UUID, int64) ([]model.SyncChange, error){
+		selectTextChanges,
+		selectBinaryChanges,
+		selectCardChanges,
+		selectTOTPChanges,
+		selectSSHKeyChanges,
+		selectCustomChanges,
+	} {
+		changes, err := selectChanges(ctx, tx, privateUserKey, since)
+		if err != nil {
+			return model.SyncResponse{}, err
+		}
+		result.Changes = append(result.Changes, changes...)
+	}
+
+	sort.Slice(result.Changes, func(i, j int) bool {
+		return result.Changes[i].Revision < result.Changes[j].Revision
+	})
+
+	return result, nil
+}
+
+func selectTextChanges(ctx context.Context, tx *sql.Tx, privateUserKey uuid.UUID, since int64) ([]model.SyncChange, error) {
+	query := `SELECT data_text_key, data, revision, created_at, updated_at, deleted_at
+              FROM data_text
+              WHERE private_user_key = $1 AND revision > $2`
+
+	rows, err := tx.QueryContext(ctx, query, privateUserKey, since)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var changes []model.SyncChange
+	for rows.Next() {
+		var (
+			data      model.DataTextResponse
+			createdAt time.Time
+			updatedAt time.Time
+			deletedAt sql.NullTime
+		)
+		err := rows.Scan(&data.DataTextKey, &data.Data, &data.Revision, &createdAt, &updatedAt, &deletedAt)
+		if err != nil {
+			return nil, err
+		}
+
+		change, err := newSyncChange(model.RecordText, data.DataTextKey, data.Revision, createdAt, updatedAt, deletedAt, data)
+		if err != nil {
+			return nil, err
+		}
+		changes = append(changes, change)
+	}
+
+	return changes, rows.Err()
+}
+
+func selectBinaryChanges(ctx context.Context, tx *sql.Tx, privateUserKey uuid.UUID, since int64) ([]model.SyncChange, error) {
+	query := `SELECT data_binary_key, filename, data, revision, created_at, updated_at, deleted_at
+              FROM data_binary
+              WHERE private_user_key = $1 AND revision > $2`
+
+	rows, err := tx.QueryContext(ctx, query, privateUserKey, since)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var changes []model.SyncChange
+	for rows.Next() {
+		var (
+			data      model.DataBinaryResponse
+			createdAt time.Time
+			updatedAt time.Time
+			deletedAt sql.NullTime
+		)
+		err := rows.Scan(&data.DataBinaryKey, &data.FileName, &data.Data, &data.Revision, &createdAt, &updatedAt, &deletedAt)
+		if err != nil {
+			return nil, err
+		}
+
+		change, err := newSyncChange(model.RecordBinary, data.DataBinaryKey, data.Revision, createdAt, updatedAt, deletedAt, data)
+		if err != nil {
+			return nil, err
+		}
+		changes = append(changes, change)
+	}
+
+	return changes, rows.Err()
+}
+
+func selectCardChanges(ctx context.Context, tx *sql.Tx, privateUserKey uuid.UUID, since int64) ([]model.SyncChange, error) {
+	query := `SELECT data_credit_card_key, card_number, cardholder_name, expiration_date, cvv, pin,
+                     brand, COALESCE(to_char(expires_on, 'YYYY-MM-DD'), ''), card_type, issuing_bank,
+                     billing_address, notes, revision, created_at, updated_at, deleted_at
+              FROM data_credit_cards
+              WHERE private_user_key = $1 AND revision > $2`
+
+	rows, err := tx.QueryContext(ctx, query, privateUserKey, since)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var changes []model.SyncChange
+	for rows.Next() {
+		var (
+			data      model.DataCreditCardResponse
+			updatedAt time.Time
+			deletedAt sql.NullTime
+		)
+		err := rows.Scan(
+			&data.DataCreditCardKey,
+			&data.CardNumber,
+			&data.CardholderName,
+			&data.ExpirationDate,
+			&data.CVV,
+			&data.PIN,
+			&data.Brand,
+			&data.ExpiresOn,
+			&data.CardType,
+			&data.IssuingBank,
+			&data.BillingAddress,
+			&data.Notes,
+			&data.Revision,
+			&data.CreatedAt,
+			&updatedAt,
+			&deletedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		if err := openCard(&data); err != nil {
+			return nil, err
+		}
+
+		change, err := newSyncChange(model.RecordCard, data.DataCreditCardKey, data.Revision, data.CreatedAt, updatedAt, deletedAt, data)
+		if err != nil {
+			return nil, err
+		}
+		changes = append(changes, change)
+	}
+
+	return changes, rows.Err()
+}
+
+func selectTOTPChanges(ctx context.Context, tx *sql.Tx, privateUserKey uuid.UUID, since int64) ([]model.SyncChange, error) {
+	query := `SELECT data_totp_key, secret, issuer, account, algorithm, digits, period,
+                     revision, created_at, updated_at, deleted_at
+              FROM data_totp
+              WHERE private_user_key = $1 AND revision > $2`
+
+	rows, err := tx.QueryContext(ctx, query, privateUserKey, since)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var changes []model.SyncChange
+	for rows.Next() {
+		var (
+			data      model.DataTOTPResponse
+			updatedAt time.Time
+			deletedAt sql.NullTime
+		)
+		err := rows.Scan(
+			&data.DataTOTPKey,
+			&data.Secret,
+			&data.Issuer,
+			&data.Account,
+			&data.Algorithm,
+			&data.Digits,
+			&data.Period,
+			&data.Revision,
+			&data.CreatedAt,
+			&updatedAt,
+			&deletedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		if err := openTOTP(&data); err != nil {
+			return nil, err
+		}
+
+		change, err := newSyncChange(model.RecordTOTP, data.DataTOTPKey, data.Revision, data.CreatedAt, updatedAt, deletedAt, data)
+		if err != nil {
+			return nil, err
+		}
+		changes = append(changes, change)
+	}
+
+	return changes, rows.Err()
+}
+
+func selectSSHKeyChanges(ctx context.Context, tx *sql.Tx, privateUserKey uuid.UUID, since int64) ([]model.SyncChange, error) {
+	query := `SELECT data_ssh_key_key, private_key, public_key, comment, passphrase, fingerprint,
+                     revision, created_at, updated_at, deleted_at
+              FROM data_ssh_keys
+              WHERE private_user_key = $1 AND revision > $2`
+
+	rows, err := tx.QueryContext(ctx, query, privateUserKey, since)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var changes []model.SyncChange
+	for rows.Next() {
+		var (
+			data      model.DataSSHKeyResponse
+			updatedAt time.Time
+			deletedAt sql.NullTime
+		)
+		err := rows.Scan(
+			&data.DataSSHKeyKey,
+			&data.PrivateKey,
+			&data.PublicKey,
+			&data.Comment,
+			&data.Passphrase,
+			&data.Fingerprint,
+			&data.Revision,
+			&data.CreatedAt,
+			&updatedAt,
+			&deletedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		if err := openSSHKey(&data); err != nil {
+			return nil, err
+		}
+
+		change, err := newSyncChange(model.RecordSSH, data.DataSSHKeyKey, data.Revision, data.CreatedAt, updatedAt, deletedAt, data)
+		if err != nil {
+			return nil, err
+		}
+		changes = append(changes, change)
+	}
+
+	return changes, rows.Err()
+}
+
+func selectCustomChanges(ctx context.Context, tx *sql.Tx, privateUserKey uuid.UUID, since int64) ([]model.SyncChange, error) {
+	query := `SELECT c.data_custom_key, c.template_key, COALESCE(t.name, ''), c.fields,
+                     c.revision, c.created_at, c.updated_at, c.deleted_at
+              FROM data_custom c
+              LEFT JOIN record_templates t ON t.template_key = c.template_key
+              WHERE c.private_user_key = $1 AND c.revision > $2`
+
+	rows, err := tx.QueryContext(ctx, query, privateUserKey, since)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var changes []model.SyncChange
+	for rows.Next() {
+		var (
+			data      model.DataCustomResponse
+			fields    []byte
+			updatedAt time.Time
+			deletedAt sql.NullTime
+		)
+		err := rows.Scan(
+			&data.DataCustomKey,
+			&data.TemplateKey,
+			&data.TemplateName,
+			&fields,
+			&data.Revision,
+			&data.CreatedAt,
+			&updatedAt,
+			&deletedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal(fields, &data.Fields); err != nil {
+			return nil, err
+		}
+		if err := openCustom(data.Fields); err != nil {
+			return nil, err
+		}
+
+		change, err := newSyncChange(model.RecordCustom, data.DataCustomKey, data.Revision, data.CreatedAt, updatedAt, deletedAt, data)
+		if err != nil {
+			return nil, err
+		}
+		changes = append(changes, change)
+	}
+
+	return changes, rows.Err()
+}
+
+// newSyncChange формирует элемент ленты изменений. Для удалённых записей данные не передаются.
+func newSyncChange(recordType string, key uuid.UUID, revision int64, createdAt, updatedAt time.Time, deletedAt sql.NullTime, data any) (model.SyncChange, error) {
+	change := model.SyncChange{
+		Type:      recordType,
+		Key:       key,
+		Revision:  revision,
+		UpdatedAt: updatedAt,
+	}
+
+	switch {
+	case deletedAt.Valid:
+		change.Action = model.ChangeDeleted
+		change.UpdatedAt = deletedAt.Time
+		return change, nil
+	case createdAt.Equal(updatedAt):
+		change.Action = model.ChangeCreated
+	default:
+		change.Action = model.ChangeUpdated
+	}
+
+	var err error
+	change.Data, err = json.Marshal(data)
+	if err != nil {
+		return model.SyncChange{}, err
+	}
+
+	return change, nil
+}
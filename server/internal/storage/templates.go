@@ -0,0 +1,187 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgconn"
+	"server/internal/cerrors"
+	"server/internal/model"
+)
+
+// uniqueViolation — код ошибки PostgreSQL при нарушении ограничения уникальности.
+const uniqueViolation = "23505"
+
+// templateAccess — условие доступа к шаблону для владельца записей $2: свои шаблоны пользователя,
+// шаблоны его организаций, а для хранилища — шаблоны организации, которой оно принадлежит.
+const templateAccess = `(t.owner_key = $2
+                 OR t.owner_key IN (SELECT org_key FROM org_members WHERE private_user_key = $2)
+                 OR t.owner_key IN (SELECT org_key FROM vaults WHERE vault_key = $2))`
+
+// InsertTemplate сохраняет шаблон пользователя или, если задан OrgKey, организации.
+// Шаблон с тем же именем у того же владельца возвращает cerrors.ErrConflict.
+func (pstg *PostgreSQL) InsertTemplate(ctx context.Context, template model.RecordTemplate, privateUserKey uuid.UUID) (model.RecordTemplate, error) {
+	query := `INSERT INTO record_templates (owner_key, is_org, name, fields) VALUES ($1, $2, $3, $4)
+              RETURNING template_key, created_at`
+
+	ownerKey := privateUserKey
+	if template.OrgKey != nil {
+		ownerKey = *template.OrgKey
+	}
+	fields, err := json.Marshal(template.Fields)
+	if err != nil {
+		return model.RecordTemplate{}, err
+	}
+
+	err = pstg.db.QueryRowContext(ctx, query, ownerKey, template.OrgKey != nil, template.Name, fields).
+		Scan(&template.TemplateKey, &template.CreatedAt)
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == uniqueViolation {
+		return model.RecordTemplate{}, cerrors.ErrConflict
+	}
+	if err != nil {
+		return model.RecordTemplate{}, err
+	}
+
+	return template, nil
+}
+
+// SelectTemplates возвращает шаблоны, доступные пользователю: его собственные и шаблоны его организаций.
+func (pstg *PostgreSQL) SelectTemplates(ctx context.Context, privateUserKey uuid.UUID) ([]model.RecordTemplate, error) {
+	query := `SELECT t.template_key, t.owner_key, t.is_org, t.name, t.fields, t.created_at
+              FROM record_templates t
+              WHERE t.owner_key = $1
+                 OR t.owner_key IN (SELECT org_key FROM org_members WHERE private_user_key = $1)
+              ORDER BY t.name`
+
+	rows, err := pstg.db.QueryContext(ctx, query, privateUserKey)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	templates := []model.RecordTemplate{}
+	for rows.Next() {
+		template, err := scanTemplate(rows)
+		if err != nil {
+			return nil, err
+		}
+		templates = append(templates, template)
+	}
+
+	return templates, rows.Err()
+}
+
+// SelectTemplate возвращает шаблон, если его могут использовать записи владельца ownerKey —
+// пользователя или хранилища организации. Иначе возвращается cerrors.ErrNotFound.
+func (pstg *PostgreSQL) SelectTemplate(ctx context.Context, templateKey, ownerKey uuid.UUID) (model.RecordTemplate, error) {
+	query := `SELECT t.template_key, t.owner_key, t.is_org, t.name, t.fields, t.created_at
+              FROM record_templates t
+              WHERE t.template_key = $1 AND ` + templateAccess
+
+	template, err := scanTemplate(pstg.db.QueryRowContext(ctx, query, templateKey, ownerKey))
+	if err != nil {
+		return model.RecordTemplate{}, notFound(err)
+	}
+
+	return template, nil
+}
+
+// DeleteTemplate удаляет шаблон. Пока по шаблону есть записи, в том числе в корзине,
+// возвращается cerrors.ErrConflict. Очищенные записи остаются отметками удаления
+// для синхронизации, но шаблон им уже не нужен.
+// Строка шаблона блокируется до проверки: запись, которая сохраняется по шаблону одновременно
+// с удалением, держит на нём блокировку FOR SHARE (см. sealCustom), поэтому проверка дожидается
+// её фиксации и видит запись, а новые записи ждут окончания удаления.
+func (pstg *PostgreSQL) DeleteTemplate(ctx context.Context, templateKey uuid.UUID) error {
+	return pstg.inTx(ctx, func(tx *sql.Tx) error {
+		query := `SELECT 1 FROM record_templates WHERE template_key = $1 FOR UPDATE`
+		var locked int
+		if err := tx.QueryRowContext(ctx, query, templateKey).Scan(&locked); err != nil {
+			return notFound(err)
+		}
+
+		var used bool
+		query = `SELECT EXISTS (SELECT 1 FROM data_custom WHERE template_key = $1 AND purged_at IS NULL)`
+		if err := tx.QueryRowContext(ctx, query, templateKey).Scan(&used); err != nil {
+			return err
+		}
+		if used {
+			return cerrors.ErrConflict
+		}
+
+		return execAffected(ctx, tx, `DELETE FROM record_templates WHERE template_key = $1`, templateKey)
+	})
+}
+
+// scanTemplate читает строку шаблона в порядке столбцов запросов SelectTemplates и SelectTemplate.
+func scanTemplate(row interface{ Scan(...any) error }) (model.RecordTemplate, error) {
+	var (
+		template model.RecordTemplate
+		ownerKey uuid.UUID
+		isOrg    bool
+		fields   []byte
+	)
+	err := row.Scan(&template.TemplateKey, &ownerKey, &isOrg, &template.Name, &fields, &template.CreatedAt)
+	if err != nil {
+		return model.RecordTemplate{}, err
+	}
+	if isOrg {
+		template.OrgKey = &ownerKey
+	}
+	if err := json.Unmarshal(fields, &template.Fields); err != nil {
+		return model.RecordTemplate{}, err
+	}
+
+	return template, nil
+}
+
+// sealCustom шифрует значения полей secret и totp записи. Типы полей берутся из шаблона,
+// поэтому значения шифруются и при восстановлении версии или разрешении конфликта.
+// Шаблон блокируется FOR SHARE до конца транзакции, чтобы его не удалили, пока запись сохраняется.
+func sealCustom(ctx context.Context, tx *sql.Tx, data *model.DataCustom) error {
+	var fields []byte
+	query := `SELECT fields FROM record_templates WHERE template_key = $1 FOR SHARE`
+	if err := tx.QueryRowContext(ctx, query, data.TemplateKey).Scan(&fields); err != nil {
+		return notFound(err)
+	}
+	var templateFields []model.TemplateField
+	if err := json.Unmarshal(fields, &templateFields); err != nil {
+		return err
+	}
+
+	sealed := make(map[string]string, len(data.Fields))
+	for name, value := range data.Fields {
+		sealed[name] = value
+	}
+	for _, field := range templateFields {
+		if field.Type != model.FieldSecret && field.Type != model.FieldTOTP {
+			continue
+		}
+		value, err := sealField(sealed[field.Name])
+		if err != nil {
+			return err
+		}
+		if value != "" {
+			sealed[field.Name] = value
+		}
+	}
+	data.Fields = sealed
+
+	return nil
+}
+
+// openCustom расшифровывает значения полей записи. Шаблон не нужен: незашифрованные значения
+// возвращаются как есть.
+func openCustom(fields map[string]string) error {
+	for name, value := range fields {
+		plain, err := openField(value)
+		if err != nil {
+			return err
+		}
+		fields[name] = plain
+	}
+	return nil
+}
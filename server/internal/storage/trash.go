@@ -0,0 +1,150 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"github.com/google/uuid"
+	"server/internal/model"
+	"sort"
+	"time"
+)
+
+// SelectTrash возвращает удалённые, но ещё не очищенные записи пользователя, начиная с последних.
+func (pstg *PostgreSQL) SelectTrash(ctx context.Context, privateUserKey uuid.UUID) ([]model.TrashEntry, error) {
+	trash := []model.TrashEntry{}
+	for recordType, table := range recordTables {
+		query := fmt.Sprintf(`SELECT %s, deleted_at, %s
+              FROM %s
+              WHERE private_user_key = $1 AND deleted_at IS NOT NULL AND purged_at IS NULL`,
+			table.key, table.snapshot, table.name)
+
+		rows, err := pstg.db.QueryContext(ctx, query, privateUserKey)
+		if err != nil {
+			return nil, err
+		}
+
+		for rows.Next() {
+			var (
+				entry model.TrashEntry
+				data  []byte
+			)
+			if err := rows.Scan(&entry.Key, &entry.DeletedAt, &data); err != nil {
+				rows.Close()
+				return nil, err
+			}
+			if data, err = openRecord(recordType, data); err != nil {
+				rows.Close()
+				return nil, err
+			}
+
+			entry.Type, entry.Data = recordType, data
+			trash = append(trash, entry)
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return nil, err
+		}
+	}
+
+	sort.Slice(trash, func(i, j int) bool {
+		return trash[i].DeletedAt.After(trash[j].DeletedAt)
+	})
+	return trash, nil
+}
+
+// RestoreTrash возвращает запись из корзины и возвращает её новую ревизию.
+func (pstg *PostgreSQL) RestoreTrash(ctx context.Context, recordType string, key, privateUserKey uuid.UUID) (int64, error) {
+	table, ok := recordTables[recordType]
+	if !ok {
+		return 0, fmt.Errorf("unknown record type: %q", recordType)
+	}
+
+	query := fmt.Sprintf(`UPDATE %s SET deleted_at = NULL, updated_at = now(), revision = $3
+              WHERE %s = $1 AND private_user_key = $2 AND deleted_at IS NOT NULL AND purged_at IS NULL`,
+		table.name, table.key)
+
+	var revision int64
+	err := pstg.inTx(ctx, func(tx *sql.Tx) error {
+		var err error
+		revision, err = nextRevision(ctx, tx, privateUserKey)
+		if err != nil {
+			return err
+		}
+		if err := execAffected(ctx, tx, query, key, privateUserKey, revision); err != nil {
+			return err
+		}
+		return saveHistory(ctx, tx, recordType, key, model.HistoryRestored)
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return revision, nil
+}
+
+// EmptyTrash окончательно очищает все записи из корзины пользователя.
+// Возвращает количество очищенных записей.
+func (pstg *PostgreSQL) EmptyTrash(ctx context.Context, privateUserKey uuid.UUID) (int64, error) {
+	return pstg.purge(ctx, `private_user_key = $1`, privateUserKey)
+}
+
+// PurgeTrash окончательно очищает записи, пролежавшие в корзине дольше retention.
+// Возвращает количество очищенных записей.
+func (pstg *PostgreSQL) PurgeTrash(ctx context.Context, retention time.Duration) (int64, error) {
+	return pstg.purge(ctx, `deleted_at < now() - $1::bigint * interval '1 second'`, int64(retention.Seconds()))
+}
+
+// purge стирает данные записей из корзины, отобранных условием where, вместе с их историей,
+// конфликтами и выданным доступом. Строки остаются отметками удаления для ленты синхронизации.
+func (pstg *PostgreSQL) purge(ctx context.Context, where string, args ...any) (int64, error) {
+	var total int64
+	err := pstg.inTx(ctx, func(tx *sql.Tx) error {
+		for recordType, table := range recordTables {
+			query := fmt.Sprintf(`UPDATE %[1]s SET %[3]s, purged_at = now()
+                  WHERE deleted_at IS NOT NULL AND purged_at IS NULL AND %[4]s
+                  RETURNING %[2]s`, table.name, table.key, table.purge, where)
+
+			rows, err := tx.QueryContext(ctx, query, args...)
+			if err != nil {
+				return err
+			}
+
+			var keys []uuid.UUID
+			for rows.Next() {
+				var key uuid.UUID
+				if err := rows.Scan(&key); err != nil {
+					rows.Close()
+					return err
+				}
+				keys = append(keys, key)
+			}
+			rows.Close()
+			if err := rows.Err(); err != nil {
+				return err
+			}
+
+			for _, key := range keys {
+				_, err := tx.ExecContext(ctx, `DELETE FROM data_history WHERE record_type = $1 AND record_key = $2`, recordType, key)
+				if err != nil {
+					return err
+				}
+				_, err = tx.ExecContext(ctx, `DELETE FROM data_conflicts WHERE record_type = $1 AND record_key = $2`, recordType, key)
+				if err != nil {
+					return err
+				}
+				_, err = tx.ExecContext(ctx, `DELETE FROM data_shares WHERE record_type = $1 AND record_key = $2`, recordType, key)
+				if err != nil {
+					return err
+				}
+			}
+			total += int64(len(keys))
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return total, nil
+}
@@ -0,0 +1,9 @@
+-- Платёжная система и последний день срока действия карты, которые сервер вычисляет при сохранении.
+-- У карт, сохранённых до проверки номера и срока, они остаются пустыми до следующего изменения.
+
+ALTER TABLE public.data_credit_cards
+    ADD COLUMN IF NOT EXISTS brand      text DEFAULT '' NOT NULL,
+    ADD COLUMN IF NOT EXISTS expires_on date;
+
+COMMENT ON COLUMN public.data_credit_cards.brand IS 'Платёжная система, определённая по номеру карты';
+COMMENT ON COLUMN public.data_credit_cards.expires_on IS 'Последний день срока действия карты';
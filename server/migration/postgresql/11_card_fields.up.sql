@@ -0,0 +1,25 @@
+-- CVV хранится для ввода при оплате, а не как хеш: поле переименовано и вместе с PIN-кодом
+-- шифруется сервером. Значения, сохранённые до шифрования, в том числе в истории
+-- и конфликтных копиях, шифрует сервер при запуске (storage.SealPlaintext).
+
+ALTER TABLE public.data_credit_cards RENAME COLUMN cvv_hash TO cvv;
+
+ALTER TABLE public.data_credit_cards
+    ADD COLUMN IF NOT EXISTS pin             text DEFAULT '' NOT NULL,
+    ADD COLUMN IF NOT EXISTS card_type       text DEFAULT '' NOT NULL CHECK (card_type IN ('', 'debit', 'credit')),
+    ADD COLUMN IF NOT EXISTS issuing_bank    text DEFAULT '' NOT NULL,
+    ADD COLUMN IF NOT EXISTS billing_address text DEFAULT '' NOT NULL,
+    ADD COLUMN IF NOT EXISTS notes           text DEFAULT '' NOT NULL;
+
+COMMENT ON COLUMN public.data_credit_cards.cvv IS 'CVV, зашифрованный ключом сервера';
+COMMENT ON COLUMN public.data_credit_cards.pin IS 'PIN-код, зашифрованный ключом сервера';
+COMMENT ON COLUMN public.data_credit_cards.card_type IS 'Тип карты: debit или credit';
+
+-- Сохранённые версии и конфликтные копии переводятся на новое имя поля.
+UPDATE public.data_history
+SET data = (data - 'cvv_hash') || jsonb_build_object('cvv', data -> 'cvv_hash')
+WHERE record_type = 'card' AND data ? 'cvv_hash';
+
+UPDATE public.data_conflicts
+SET data = (data - 'cvv_hash') || jsonb_build_object('cvv', data -> 'cvv_hash')
+WHERE record_type = 'card' AND data ? 'cvv_hash';
@@ -0,0 +1,19 @@
+-- Отправленные напоминания об истечении срока действия записей.
+-- Напоминание отправляется один раз для каждого срока: после продления карты срок меняется,
+-- и о новом сроке напоминание придёт снова.
+
+CREATE TABLE IF NOT EXISTS public.expiry_notifications
+(
+    record_type text                    NOT NULL,
+    record_key  uuid                    NOT NULL,
+    expires_on  date                    NOT NULL,
+    notified_at timestamp DEFAULT now() NOT NULL,
+    CONSTRAINT expiry_notifications_pk
+        PRIMARY KEY (record_type, record_key, expires_on)
+);
+
+COMMENT ON TABLE public.expiry_notifications IS 'Напоминания об истечении срока, уже доставленные уведомителем';
+
+CREATE INDEX IF NOT EXISTS data_credit_cards_expires_idx
+    ON public.data_credit_cards (expires_on)
+    WHERE deleted_at IS NULL;
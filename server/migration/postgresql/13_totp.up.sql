@@ -0,0 +1,30 @@
+-- Секреты двухфакторной аутентификации (TOTP, RFC 6238).
+-- Секрет шифруется сервером; при сквозном шифровании клиент присылает уже зашифрованное значение.
+
+CREATE TABLE IF NOT EXISTS public.data_totp
+(
+    data_totp_key    uuid      DEFAULT uuid_generate_v4() NOT NULL
+        CONSTRAINT data_totp_pk
+            PRIMARY KEY,
+    private_user_key uuid                                 NOT NULL,
+    secret           text                                 NOT NULL,
+    issuer           text      DEFAULT ''                 NOT NULL,
+    account          text      DEFAULT ''                 NOT NULL,
+    algorithm        text      DEFAULT 'SHA1'             NOT NULL
+        CHECK (algorithm IN ('SHA1', 'SHA256', 'SHA512')),
+    digits           integer   DEFAULT 6                  NOT NULL,
+    period           integer   DEFAULT 30                 NOT NULL,
+    revision         bigint    DEFAULT 0                  NOT NULL,
+    created_at       timestamp DEFAULT now()              NOT NULL,
+    updated_at       timestamp DEFAULT now()              NOT NULL,
+    deleted_at       timestamp,
+    purged_at        timestamp
+);
+
+COMMENT ON TABLE public.data_totp IS 'Секреты для одноразовых кодов двухфакторной аутентификации';
+COMMENT ON COLUMN public.data_totp.secret IS 'Секрет в base32, зашифрованный сервером или клиентом';
+COMMENT ON COLUMN public.data_totp.period IS 'Срок действия кода в секундах';
+
+CREATE INDEX IF NOT EXISTS data_totp_revision_idx ON public.data_totp (private_user_key, revision);
+CREATE INDEX IF NOT EXISTS data_totp_trash_idx ON public.data_totp (deleted_at)
+    WHERE deleted_at IS NOT NULL AND purged_at IS NULL;
@@ -0,0 +1,29 @@
+-- Ключи SSH. Открытый ключ и отпечаток вычисляет клиент; закрытый ключ и пароль к нему
+-- шифруются сервером, а при сквозном шифровании приходят уже зашифрованными клиентом.
+
+CREATE TABLE IF NOT EXISTS public.data_ssh_keys
+(
+    data_ssh_key_key uuid      DEFAULT uuid_generate_v4() NOT NULL
+        CONSTRAINT data_ssh_keys_pk
+            PRIMARY KEY,
+    private_user_key uuid                                 NOT NULL,
+    private_key      text                                 NOT NULL,
+    public_key       text      DEFAULT ''                 NOT NULL,
+    comment          text      DEFAULT ''                 NOT NULL,
+    passphrase       text      DEFAULT ''                 NOT NULL,
+    fingerprint      text      DEFAULT ''                 NOT NULL,
+    revision         bigint    DEFAULT 0                  NOT NULL,
+    created_at       timestamp DEFAULT now()              NOT NULL,
+    updated_at       timestamp DEFAULT now()              NOT NULL,
+    deleted_at       timestamp,
+    purged_at        timestamp
+);
+
+COMMENT ON TABLE public.data_ssh_keys IS 'Ключи SSH';
+COMMENT ON COLUMN public.data_ssh_keys.private_key IS 'Закрытый ключ, зашифрованный сервером или клиентом';
+COMMENT ON COLUMN public.data_ssh_keys.passphrase IS 'Пароль закрытого ключа, зашифрованный сервером или клиентом';
+COMMENT ON COLUMN public.data_ssh_keys.fingerprint IS 'Отпечаток открытого ключа SHA256, вычисленный клиентом';
+
+CREATE INDEX IF NOT EXISTS data_ssh_keys_revision_idx ON public.data_ssh_keys (private_user_key, revision);
+CREATE INDEX IF NOT EXISTS data_ssh_keys_trash_idx ON public.data_ssh_keys (deleted_at)
+    WHERE deleted_at IS NOT NULL AND purged_at IS NULL;
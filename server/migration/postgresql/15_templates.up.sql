@@ -0,0 +1,44 @@
+-- Пользовательские типы записей: шаблоны с типизированными полями и записи по ним.
+-- Шаблон принадлежит пользователю или организации; в хранилищах организации используются её шаблоны.
+
+CREATE TABLE IF NOT EXISTS public.record_templates
+(
+    template_key uuid      DEFAULT uuid_generate_v4() NOT NULL
+        CONSTRAINT record_templates_pk
+            PRIMARY KEY,
+    owner_key    uuid                                 NOT NULL,
+    is_org       boolean   DEFAULT false              NOT NULL,
+    name         text                                 NOT NULL,
+    fields       jsonb                                NOT NULL,
+    created_at   timestamp DEFAULT now()              NOT NULL,
+    CONSTRAINT record_templates_name_uq
+        UNIQUE (owner_key, name)
+);
+
+COMMENT ON TABLE public.record_templates IS 'Шаблоны пользовательских типов записей';
+COMMENT ON COLUMN public.record_templates.owner_key IS 'Владелец шаблона: пользователь или организация';
+COMMENT ON COLUMN public.record_templates.fields IS 'Поля шаблона: имя, подпись, тип и обязательность';
+
+CREATE TABLE IF NOT EXISTS public.data_custom
+(
+    data_custom_key  uuid      DEFAULT uuid_generate_v4() NOT NULL
+        CONSTRAINT data_custom_pk
+            PRIMARY KEY,
+    private_user_key uuid                                 NOT NULL,
+    template_key     uuid                                 NOT NULL,
+    fields           jsonb     DEFAULT '{}'::jsonb        NOT NULL,
+    revision         bigint    DEFAULT 0                  NOT NULL,
+    created_at       timestamp DEFAULT now()              NOT NULL,
+    updated_at       timestamp DEFAULT now()              NOT NULL,
+    deleted_at       timestamp,
+    purged_at        timestamp
+);
+
+COMMENT ON TABLE public.data_custom IS 'Записи пользовательских типов';
+COMMENT ON COLUMN public.data_custom.template_key IS 'Шаблон записи; после очистки корзины шаблон можно удалить';
+COMMENT ON COLUMN public.data_custom.fields IS 'Значения полей по именам; поля secret и totp зашифрованы';
+
+CREATE INDEX IF NOT EXISTS data_custom_revision_idx ON public.data_custom (private_user_key, revision);
+CREATE INDEX IF NOT EXISTS data_custom_trash_idx ON public.data_custom (deleted_at)
+    WHERE deleted_at IS NOT NULL AND purged_at IS NULL;
+CREATE INDEX IF NOT EXISTS data_custom_template_idx ON public.data_custom (template_key);
@@ -0,0 +1,10 @@
+-- Параметры сквозного шифрования. Ключ выводится на клиенте из отдельной ключевой фразы,
+-- которую сервер не получает; сервер хранит только случайную соль и контрольный шифротекст,
+-- чтобы все устройства пользователя выводили один ключ и сразу проверяли фразу.
+
+ALTER TABLE public.private_user
+    ADD COLUMN IF NOT EXISTS e2e_salt  text,
+    ADD COLUMN IF NOT EXISTS e2e_check text;
+
+COMMENT ON COLUMN public.private_user.e2e_salt IS 'Случайная соль Argon2id для ключа сквозного шифрования, base64';
+COMMENT ON COLUMN public.private_user.e2e_check IS 'Контрольное значение, зашифрованное ключом сквозного шифрования';
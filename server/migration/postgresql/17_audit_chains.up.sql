@@ -0,0 +1,23 @@
+-- Цепочки журнала аудита по владельцам. Записи каждого владельца связаны своей цепочкой,
+-- поэтому записи разных владельцев добавляются параллельно, без общей блокировки.
+-- hash новых записей — HMAC-SHA256 с ключом из настроек сервера: без ключа запись
+-- нельзя изменить, пересчитав цепочку. Записи, добавленные раньше, проверяются
+-- по прежней общей цепочке SHA-256 (chain = 'sha256').
+
+ALTER TABLE public.audit_log
+    ADD COLUMN IF NOT EXISTS chain text DEFAULT 'sha256' NOT NULL;
+
+COMMENT ON COLUMN public.audit_log.chain IS 'Алгоритм цепочки: sha256 — общая цепочка без ключа, hmac — цепочка владельца с HMAC';
+
+CREATE TABLE IF NOT EXISTS public.audit_heads
+(
+    owner_key uuid   NOT NULL
+        CONSTRAINT audit_heads_pk
+            PRIMARY KEY,
+    audit_id  bigint NOT NULL,
+    hash      text   NOT NULL,
+    mac       text   NOT NULL
+);
+
+COMMENT ON TABLE public.audit_heads IS 'Последние записи цепочек журнала аудита; по ним обнаруживается удаление записей с конца цепочки';
+COMMENT ON COLUMN public.audit_heads.mac IS 'HMAC-SHA256 владельца, номера и хеша последней записи';
@@ -0,0 +1,42 @@
+-- Явная связь записей с хранилищами организаций. Раньше запись хранилища отличалась от личной
+-- только тем, что private_user_key совпадал с vault_key; теперь хранилище записи указано
+-- в vault_key со ссылкой на vaults, а для личных записей vault_key пуст.
+
+ALTER TABLE public.data_text
+    ADD COLUMN IF NOT EXISTS vault_key uuid
+        CONSTRAINT data_text_vault_fk REFERENCES public.vaults (vault_key),
+    ADD CONSTRAINT data_text_vault_owner_ck CHECK (vault_key IS NULL OR vault_key = private_user_key);
+ALTER TABLE public.data_binary
+    ADD COLUMN IF NOT EXISTS vault_key uuid
+        CONSTRAINT data_binary_vault_fk REFERENCES public.vaults (vault_key),
+    ADD CONSTRAINT data_binary_vault_owner_ck CHECK (vault_key IS NULL OR vault_key = private_user_key);
+ALTER TABLE public.data_credit_cards
+    ADD COLUMN IF NOT EXISTS vault_key uuid
+        CONSTRAINT data_credit_cards_vault_fk REFERENCES public.vaults (vault_key),
+    ADD CONSTRAINT data_credit_cards_vault_owner_ck CHECK (vault_key IS NULL OR vault_key = private_user_key);
+ALTER TABLE public.data_totp
+    ADD COLUMN IF NOT EXISTS vault_key uuid
+        CONSTRAINT data_totp_vault_fk REFERENCES public.vaults (vault_key),
+    ADD CONSTRAINT data_totp_vault_owner_ck CHECK (vault_key IS NULL OR vault_key = private_user_key);
+ALTER TABLE public.data_ssh_keys
+    ADD COLUMN IF NOT EXISTS vault_key uuid
+        CONSTRAINT data_ssh_keys_vault_fk REFERENCES public.vaults (vault_key),
+    ADD CONSTRAINT data_ssh_keys_vault_owner_ck CHECK (vault_key IS NULL OR vault_key = private_user_key);
+ALTER TABLE public.data_custom
+    ADD COLUMN IF NOT EXISTS vault_key uuid
+        CONSTRAINT data_custom_vault_fk REFERENCES public.vaults (vault_key),
+    ADD CONSTRAINT data_custom_vault_owner_ck CHECK (vault_key IS NULL OR vault_key = private_user_key);
+
+UPDATE public.data_text t SET vault_key = v.vault_key FROM public.vaults v WHERE v.vault_key = t.private_user_key;
+UPDATE public.data_binary t SET vault_key = v.vault_key FROM public.vaults v WHERE v.vault_key = t.private_user_key;
+UPDATE public.data_credit_cards t SET vault_key = v.vault_key FROM public.vaults v WHERE v.vault_key = t.private_user_key;
+UPDATE public.data_totp t SET vault_key = v.vault_key FROM public.vaults v WHERE v.vault_key = t.private_user_key;
+UPDATE public.data_ssh_keys t SET vault_key = v.vault_key FROM public.vaults v WHERE v.vault_key = t.private_user_key;
+UPDATE public.data_custom t SET vault_key = v.vault_key FROM public.vaults v WHERE v.vault_key = t.private_user_key;
+
+COMMENT ON COLUMN public.data_text.vault_key IS 'Хранилище организации, которому принадлежит запись; пусто для личных записей';
+COMMENT ON COLUMN public.data_binary.vault_key IS 'Хранилище организации, которому принадлежит запись; пусто для личных записей';
+COMMENT ON COLUMN public.data_credit_cards.vault_key IS 'Хранилище организации, которому принадлежит запись; пусто для личных записей';
+COMMENT ON COLUMN public.data_totp.vault_key IS 'Хранилище организации, которому принадлежит запись; пусто для личных записей';
+COMMENT ON COLUMN public.data_ssh_keys.vault_key IS 'Хранилище организации, которому принадлежит запись; пусто для личных записей';
+COMMENT ON COLUMN public.data_custom.vault_key IS 'Хранилище организации, которому принадлежит запись; пусто для личных записей';
@@ -0,0 +1,18 @@
+-- Отозванные сессии. Токен остаётся действительным по подписи до истечения срока,
+-- поэтому отзыв хранится в базе: он переживает перезапуск сервера и виден всем его экземплярам.
+-- Записи удаляются после истечения срока действия токена.
+
+CREATE TABLE IF NOT EXISTS public.revoked_sessions
+(
+    session_key text                    NOT NULL
+        CONSTRAINT revoked_sessions_pk
+            PRIMARY KEY,
+    expires_at  timestamp               NOT NULL,
+    revoked_at  timestamp DEFAULT now() NOT NULL
+);
+
+COMMENT ON TABLE public.revoked_sessions IS 'Сессии, завершённые командой выхода, до истечения срока их токенов';
+COMMENT ON COLUMN public.revoked_sessions.session_key IS 'Идентификатор сессии (jti токена)';
+
+CREATE INDEX IF NOT EXISTS revoked_sessions_expires_idx
+    ON public.revoked_sessions (expires_at);
@@ -0,0 +1,8 @@
+-- Передача ключей записей получателям не используется: записи со значениями,
+-- зашифрованными на клиенте, не передаются, а остальные сервер отдаёт расшифрованными.
+
+ALTER TABLE public.data_shares
+    DROP COLUMN IF EXISTS wrapped_key;
+
+ALTER TABLE public.private_user
+    DROP COLUMN IF EXISTS public_key;
@@ -0,0 +1,33 @@
+-- Ревизии пользователя и отслеживание изменений для синхронизации нескольких устройств.
+
+ALTER TABLE public.private_user
+    ADD COLUMN IF NOT EXISTS revision bigint DEFAULT 0 NOT NULL;
+
+COMMENT ON COLUMN public.private_user.revision IS 'Последняя выданная ревизия изменений пользователя';
+
+ALTER TABLE public.data_text
+    ADD COLUMN IF NOT EXISTS revision   bigint    DEFAULT 0     NOT NULL,
+    ADD COLUMN IF NOT EXISTS created_at timestamp DEFAULT now() NOT NULL,
+    ADD COLUMN IF NOT EXISTS updated_at timestamp DEFAULT now() NOT NULL,
+    ADD COLUMN IF NOT EXISTS deleted_at timestamp;
+
+ALTER TABLE public.data_binary
+    ADD COLUMN IF NOT EXISTS revision   bigint    DEFAULT 0     NOT NULL,
+    ADD COLUMN IF NOT EXISTS created_at timestamp DEFAULT now() NOT NULL,
+    ADD COLUMN IF NOT EXISTS updated_at timestamp DEFAULT now() NOT NULL,
+    ADD COLUMN IF NOT EXISTS deleted_at timestamp;
+
+ALTER TABLE public.data_credit_cards
+    ADD COLUMN IF NOT EXISTS revision   bigint    DEFAULT 0     NOT NULL,
+    ADD COLUMN IF NOT EXISTS updated_at timestamp DEFAULT now() NOT NULL,
+    ADD COLUMN IF NOT EXISTS deleted_at timestamp;
+
+CREATE INDEX IF NOT EXISTS data_text_revision_idx ON public.data_text (private_user_key, revision);
+CREATE INDEX IF NOT EXISTS data_binary_revision_idx ON public.data_binary (private_user_key, revision);
+CREATE INDEX IF NOT EXISTS data_credit_cards_revision_idx ON public.data_credit_cards (private_user_key, revision);
+
+-- Существующие записи попадают в первую ревизию, чтобы их вернул запрос since=0.
+UPDATE public.private_user SET revision = 1 WHERE revision = 0;
+UPDATE public.data_text SET revision = 1 WHERE revision = 0;
+UPDATE public.data_binary SET revision = 1 WHERE revision = 0;
+UPDATE public.data_credit_cards SET revision = 1 WHERE revision = 0;
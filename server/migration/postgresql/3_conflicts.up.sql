@@ -0,0 +1,22 @@
+-- Копии конфликтующих изменений записей, ожидающие явного выбора пользователем.
+
+CREATE TABLE IF NOT EXISTS public.data_conflicts
+(
+    data_conflict_key uuid      DEFAULT uuid_generate_v4() NOT NULL
+        CONSTRAINT data_conflicts_pk
+            PRIMARY KEY,
+    private_user_key  uuid                                 NOT NULL,
+    record_type       text                                 NOT NULL,
+    record_key        uuid                                 NOT NULL,
+    base_revision     bigint                               NOT NULL,
+    data              jsonb                                NOT NULL,
+    created_at        timestamp DEFAULT now()              NOT NULL,
+    resolved_at       timestamp,
+    resolution        text
+);
+
+COMMENT ON TABLE public.data_conflicts IS 'Конфликтующие версии записей';
+
+CREATE INDEX IF NOT EXISTS data_conflicts_record_idx
+    ON public.data_conflicts (record_type, record_key)
+    WHERE resolved_at IS NULL;
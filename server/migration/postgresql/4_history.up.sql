@@ -0,0 +1,42 @@
+-- Неизменяемая история версий записей для восстановления на момент времени.
+
+CREATE TABLE IF NOT EXISTS public.data_history
+(
+    data_history_key uuid      DEFAULT uuid_generate_v4() NOT NULL
+        CONSTRAINT data_history_pk
+            PRIMARY KEY,
+    private_user_key uuid                                 NOT NULL,
+    record_type      text                                 NOT NULL,
+    record_key       uuid                                 NOT NULL,
+    revision         bigint                               NOT NULL,
+    action           text                                 NOT NULL,
+    data             jsonb,
+    created_at       timestamp DEFAULT now()              NOT NULL
+);
+
+COMMENT ON TABLE public.data_history IS 'Версии записей; у удалений data не заполняется';
+
+CREATE INDEX IF NOT EXISTS data_history_record_idx
+    ON public.data_history (record_type, record_key, revision);
+
+-- Текущие версии существующих записей становятся первыми версиями истории.
+INSERT INTO public.data_history (private_user_key, record_type, record_key, revision, action, data)
+SELECT private_user_key, 'text', data_text_key, revision, 'created',
+       jsonb_build_object('data_text_key', data_text_key, 'data', data, 'revision', revision)
+FROM public.data_text
+WHERE deleted_at IS NULL;
+
+INSERT INTO public.data_history (private_user_key, record_type, record_key, revision, action, data)
+SELECT private_user_key, 'binary', data_binary_key, revision, 'created',
+       jsonb_build_object('data_binary_key', data_binary_key, 'filename', filename,
+                          'data', convert_from(data, 'UTF8'), 'revision', revision)
+FROM public.data_binary
+WHERE deleted_at IS NULL;
+
+INSERT INTO public.data_history (private_user_key, record_type, record_key, revision, action, data)
+SELECT private_user_key, 'card', data_credit_card_key, revision, 'created',
+       jsonb_build_object('data_credit_card_key', data_credit_card_key, 'card_number', card_number,
+                          'cardholder_name', cardholder_name, 'expiration_date', expiration_date,
+                          'cvv_hash', cvv_hash, 'created_at', created_at, 'revision', revision)
+FROM public.data_credit_cards
+WHERE deleted_at IS NULL;
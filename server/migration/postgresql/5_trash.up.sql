@@ -0,0 +1,18 @@
+-- Корзина: удалённые записи хранятся до окончательной очистки.
+-- После очистки данные стираются, а строка остаётся отметкой удаления для синхронизации.
+
+ALTER TABLE public.data_text
+    ADD COLUMN IF NOT EXISTS purged_at timestamp;
+
+ALTER TABLE public.data_binary
+    ADD COLUMN IF NOT EXISTS purged_at timestamp;
+
+ALTER TABLE public.data_credit_cards
+    ADD COLUMN IF NOT EXISTS purged_at timestamp;
+
+CREATE INDEX IF NOT EXISTS data_text_trash_idx ON public.data_text (deleted_at)
+    WHERE deleted_at IS NOT NULL AND purged_at IS NULL;
+CREATE INDEX IF NOT EXISTS data_binary_trash_idx ON public.data_binary (deleted_at)
+    WHERE deleted_at IS NOT NULL AND purged_at IS NULL;
+CREATE INDEX IF NOT EXISTS data_credit_cards_trash_idx ON public.data_credit_cards (deleted_at)
+    WHERE deleted_at IS NOT NULL AND purged_at IS NULL;
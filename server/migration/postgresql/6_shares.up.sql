@@ -0,0 +1,28 @@
+-- Доступ к отдельным записям для других пользователей.
+
+ALTER TABLE public.private_user
+    ADD COLUMN IF NOT EXISTS public_key text;
+
+COMMENT ON COLUMN public.private_user.public_key IS 'Открытый ключ для передачи ключей записей при сквозном шифровании';
+
+CREATE TABLE IF NOT EXISTS public.data_shares
+(
+    data_share_key     uuid      DEFAULT uuid_generate_v4() NOT NULL
+        CONSTRAINT data_shares_pk
+            PRIMARY KEY,
+    owner_user_key     uuid                                 NOT NULL,
+    recipient_user_key uuid                                 NOT NULL,
+    record_type        text                                 NOT NULL,
+    record_key         uuid                                 NOT NULL,
+    permission         text                                 NOT NULL,
+    wrapped_key        text,
+    created_at         timestamp DEFAULT now()              NOT NULL,
+    CONSTRAINT data_shares_recipient_uq
+        UNIQUE (record_type, record_key, recipient_user_key)
+);
+
+COMMENT ON TABLE public.data_shares IS 'Доступ получателей к записям: read или write';
+COMMENT ON COLUMN public.data_shares.wrapped_key IS 'Ключ записи, зашифрованный открытым ключом получателя; сервер его не читает';
+
+CREATE INDEX IF NOT EXISTS data_shares_recipient_idx
+    ON public.data_shares (recipient_user_key);
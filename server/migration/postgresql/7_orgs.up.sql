@@ -0,0 +1,49 @@
+-- Организации с общими хранилищами и ролями участников.
+-- Записи хранилища команды хранятся с private_user_key, равным vault_key хранилища:
+-- у хранилища своя ревизия, лента синхронизации, история и корзина.
+
+CREATE TABLE IF NOT EXISTS public.orgs
+(
+    org_key    uuid      DEFAULT uuid_generate_v4() NOT NULL
+        CONSTRAINT orgs_pk
+            PRIMARY KEY,
+    name       text                                 NOT NULL,
+    created_at timestamp DEFAULT now()              NOT NULL
+);
+
+COMMENT ON TABLE public.orgs IS 'Организации';
+
+CREATE TABLE IF NOT EXISTS public.org_members
+(
+    org_key          uuid                    NOT NULL,
+    private_user_key uuid                    NOT NULL,
+    role             text                    NOT NULL,
+    created_at       timestamp DEFAULT now() NOT NULL,
+    CONSTRAINT org_members_pk
+        PRIMARY KEY (org_key, private_user_key)
+);
+
+COMMENT ON TABLE public.org_members IS 'Участники организаций: owner, admin, editor или viewer';
+
+CREATE INDEX IF NOT EXISTS org_members_user_idx
+    ON public.org_members (private_user_key);
+
+CREATE TABLE IF NOT EXISTS public.vaults
+(
+    vault_key  uuid      DEFAULT uuid_generate_v4() NOT NULL
+        CONSTRAINT vaults_pk
+            PRIMARY KEY,
+    org_key    uuid                                 NOT NULL,
+    name       text                                 NOT NULL,
+    revision   bigint    DEFAULT 0                  NOT NULL,
+    created_at timestamp DEFAULT now()              NOT NULL,
+    CONSTRAINT vaults_name_uq
+        UNIQUE (org_key, name)
+);
+
+COMMENT ON TABLE public.vaults IS 'Именованные хранилища организаций';
+COMMENT ON COLUMN public.vaults.revision IS 'Последняя выданная ревизия изменений хранилища';
+
+COMMENT ON COLUMN public.data_text.private_user_key IS 'Владелец записи: пользователь или хранилище организации';
+COMMENT ON COLUMN public.data_binary.private_user_key IS 'Владелец записи: пользователь или хранилище организации';
+COMMENT ON COLUMN public.data_credit_cards.private_user_key IS 'Владелец записи: пользователь или хранилище организации';
@@ -0,0 +1,21 @@
+-- Одноразовые ссылки на секреты.
+-- Данные шифруются клиентом ключом, который передаётся только во фрагменте URL и не попадает на сервер.
+
+CREATE TABLE IF NOT EXISTS public.share_links
+(
+    link_key         uuid      DEFAULT uuid_generate_v4() NOT NULL
+        CONSTRAINT share_links_pk
+            PRIMARY KEY,
+    private_user_key uuid                                 NOT NULL,
+    data             text                                 NOT NULL,
+    max_views        integer                              NOT NULL,
+    views            integer   DEFAULT 0                  NOT NULL,
+    expires_at       timestamp                            NOT NULL,
+    created_at       timestamp DEFAULT now()              NOT NULL
+);
+
+COMMENT ON TABLE public.share_links IS 'Ссылки на секреты, удаляемые после max_views просмотров или по истечении expires_at';
+COMMENT ON COLUMN public.share_links.data IS 'Шифротекст; ключ известен только получателю ссылки';
+
+CREATE INDEX IF NOT EXISTS share_links_expires_idx
+    ON public.share_links (expires_at);
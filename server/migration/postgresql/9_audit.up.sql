@@ -0,0 +1,27 @@
+-- Журнал аудита доступа к хранилищу.
+-- Записи связаны в цепочку: hash каждой записи вычисляется от prev_hash и её полей,
+-- поэтому изменение или удаление любой записи обнаруживается при проверке цепочки.
+
+CREATE TABLE IF NOT EXISTS public.audit_log
+(
+    audit_id    bigserial
+        CONSTRAINT audit_log_pk
+            PRIMARY KEY,
+    owner_key   uuid      NOT NULL,
+    actor_key   uuid      NOT NULL,
+    action      text      NOT NULL,
+    record_type text,
+    record_key  uuid,
+    ip          text      NOT NULL,
+    user_agent  text      NOT NULL,
+    created_at  timestamp NOT NULL,
+    prev_hash   text      NOT NULL,
+    hash        text      NOT NULL
+);
+
+COMMENT ON TABLE public.audit_log IS 'Журнал входов, чтений, изменений и выдачи доступа, связанный цепочкой хешей';
+COMMENT ON COLUMN public.audit_log.owner_key IS 'Владелец записи (пользователь или хранилище организации), которому доступна запись журнала';
+COMMENT ON COLUMN public.audit_log.actor_key IS 'Пользователь, выполнивший действие';
+
+CREATE INDEX IF NOT EXISTS audit_log_owner_idx
+    ON public.audit_log (owner_key, audit_id);